@@ -4,82 +4,203 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"sync"
+	"syscall"
 	"time"
 	"time-tracker/internal/config"
 	"time-tracker/internal/modules/dashboard"
+	"time-tracker/internal/modules/health"
 	"time-tracker/internal/modules/pages"
 	"time-tracker/internal/modules/users"
+	"time-tracker/internal/modules/webhooks"
 	"time-tracker/internal/utils"
 	"time-tracker/internal/utils/mailgun"
+	"time-tracker/internal/utils/metrics"
+	"time-tracker/internal/utils/migrate"
+	"time-tracker/internal/utils/oauth"
+	"time-tracker/internal/utils/sqlite"
+	"time-tracker/internal/utils/tracing"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 func main() {
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		slog.Error("Config failed", "err", err)
+		os.Exit(1)
+	}
 	setLogger(cfg.AppEnv)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:], os.Stdout))
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("Config invalid", "err", err)
+		os.Exit(1)
+	}
+	utils.SetWebDir(cfg.WebDir)
 	slog.Info("======================================== Server start ========================================", "Config", cfg)
+	if err := utils.LoadTemplates(); err != nil {
+		slog.Error("Templates failed", "err", err)
+		os.Exit(1)
+	}
+
+	// SIGTERM from ECS or docker stop, SIGINT from Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	db, err := connectToDatabase(cfg)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		slog.Error("Database connection failed", "err", err)
+		slog.Error("Tracing failed", "err", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Tracing shutdown", "err", err)
+		}
+	}()
 
-	redisClient, err := connectToRedis(cfg)
+	repos, err := openRepositories(cfg)
 	if err != nil {
-		slog.Error("Redis connection failed", "err", err)
+		slog.Error("Storage failed", "storage", cfg.Storage, "err", err)
 		os.Exit(1)
 	}
+	defer repos.close()
 
 	// mailService, err := ses.NewMailService(cfg.EmailFrom)
 	// if err != nil {
 	// 	slog.Error("NewMailService failed", "err", err)
 	// 	os.Exit(1)
 	// }
-	mailService := mailgun.NewMailService(
-		cfg.MailgunDomain,
-		cfg.MailgunApiKey,
-		cfg.EmailFrom,
-	)
-	if err := mailService.Ping(); err != nil {
-		slog.Error("NewMailService failed Ping", "err", err)
-		os.Exit(1)
+	var mailService users.MailService
+	// Check of /readyz, nil without the mail
+	var mailCheck health.Check
+	if cfg.MailEnabled() {
+		mailgunService := mailgun.NewMailService(
+			cfg.MailgunDomain,
+			cfg.MailgunApiKey,
+			cfg.EmailFrom,
+		)
+		if err := mailgunService.Ping(); err != nil {
+			slog.Error("NewMailService failed Ping", "err", err)
+			os.Exit(1)
+		}
+		slog.Info("Successfully ping to the email service")
+		mailService = mailgunService
+		mailCheck = func(ctx context.Context) error { return mailgunService.Ping() }
+	} else {
+		slog.Warn("The mail is not configured, the links of the emails are written to the log")
+		mailService = users.NewLogMailService()
 	}
-	slog.Info("Successfully ping to the email service")
 
-	// usersRepo := users.NewUsersRepositoryMem()
-	usersRepo := users.NewUsersRepositoryPostgres(db)
-	// sessionsRepo := users.NewSessionsRepositoryMem()
-	sessionsRepo := users.NewSessionsRepositoryRedis(redisClient)
-	usersService := users.NewUsersService(usersRepo, sessionsRepo, mailService, cfg.SiteUrl)
-	usersHandlers := users.NewUsersHandlers(usersService)
+	usersRepo := repos.users
+	sessionsRepo := repos.sessions
+	// Validate checked the format
+	trustedProxies, _ := cfg.GetTrustedProxies()
+	rateLimiter := users.NewRateLimiter(repos.rateLimit, trustedProxies)
+	usersService := users.NewUsersService(usersRepo, sessionsRepo, repos.personalTokens, users.NewInstrumentedMailService(mailService), cfg.SiteUrl)
+	oauthProviders, err := newOAuthProviders(cfg)
+	if err != nil {
+		slog.Error("OAuth providers failed", "err", err)
+		os.Exit(1)
+	}
+	usersHandlers := users.NewUsersHandlers(usersService, oauthProviders...)
+	webhooksService := webhooks.NewWebhooksService(repos.webhooks, usersRepo, cfg.WebhooksAllowPrivateNetworks)
+	// The changes of the tasks and the records by the handlers and the jobs are sent to the webhooks
+	// and to the open dashboards of the user
+	dashboardRepo := dashboard.NewDashboardRepositoryEvents(repos.dashboard, dashboard.EventPublishers{webhooksService, repos.events})
+	recurringRecordsService := dashboard.NewRecurringRecordsService(dashboardRepo, usersRepo)
+	var jobs sync.WaitGroup
+	jobs.Add(4)
+	go func() {
+		defer jobs.Done()
+		usersService.RunAccountDeletionJob(ctx, time.Hour)
+	}()
+	go func() {
+		defer jobs.Done()
+		// The records are created within minutes after their end
+		recurringRecordsService.RunRecurringRecordsJob(ctx, 5*time.Minute)
+	}()
+	go func() {
+		defer jobs.Done()
+		// The new events wake the job, the interval is for the retries
+		webhooksService.RunDeliveryJob(ctx, 30*time.Second)
+	}()
+	go func() {
+		defer jobs.Done()
+		// Closes the streams of /events when ctx is done, before the shutdown waits for the requests
+		repos.events.Run(ctx)
+	}()
 
-	dashboardRepo := dashboard.NewDashboardRepositoryPostgres(db)
 	dashboardHandler := dashboard.NewDashboardHandler(dashboardRepo)
+	calendarHandlers := dashboard.NewCalendarHandlers(dashboardRepo, usersRepo)
+	eventsHandlers := dashboard.NewEventsHandlers(repos.events)
+	webhooksHandlers := webhooks.NewWebhooksHandlers(webhooksService)
+	personalTokensHandlers := users.NewPersonalTokensHandlers(repos.personalTokens)
+
+	healthHandlers := health.NewHealthHandlers(readyCheckTimeout)
+	for name, check := range repos.checks {
+		healthHandlers.AddCheck(name, check)
+	}
+	if mailCheck != nil {
+		healthHandlers.AddCheck("mail", mailCheck)
+	}
+
+	metrics.Registry.MustRegister(repos.collectors...)
+	metrics.Registry.MustRegister(metrics.NewCountFunc("records_in_progress",
+		"Records in progress of all users, i.e. the running timers.", readyCheckTimeout, repos.dashboard.CountRecordsInProgress))
 
 	mux := http.NewServeMux()
 
-	fsPublic := http.FileServer(http.Dir("./web/public"))
+	mux.HandleFunc("GET /healthz", healthHandlers.HandleHealthz)
+	mux.HandleFunc("GET /readyz", healthHandlers.HandleReadyz)
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	fsPublic := http.FileServer(http.FS(utils.PublicFS()))
 	mux.Handle("/img/", fsPublic)
 	mux.Handle("/css/", fsPublic)
 	mux.Handle("/js/", fsPublic)
 	mux.Handle("/favicon.ico", fsPublic)
 
 	mux.HandleFunc("/{$}", pages.IndexHandler)
-	mux.HandleFunc("/signup", usersHandlers.HandleSignup)
+	mux.Handle("/signup", users.RateLimitMiddleware(http.HandlerFunc(usersHandlers.HandleSignup), rateLimiter, "signup"))
 	mux.HandleFunc("/signup-success", usersHandlers.HandleSignupSuccess)
 	mux.HandleFunc("/activation", usersHandlers.HandleActivation)
-	mux.HandleFunc("/login", usersHandlers.HandleLogin)
+	mux.Handle("/login", users.RateLimitMiddleware(http.HandlerFunc(usersHandlers.HandleLogin), rateLimiter, "login"))
 	mux.HandleFunc("/login-with-token", usersHandlers.HandleLoginWithToken)
-	mux.HandleFunc("/forgot-password", usersHandlers.HandleForgotPassword)
+	mux.Handle("/forgot-password", users.RateLimitMiddleware(http.HandlerFunc(usersHandlers.HandleForgotPassword), rateLimiter, "forgot-password"))
 	mux.HandleFunc("POST /logout", usersHandlers.HandleLogout)
 	mux.HandleFunc("/settings", usersHandlers.HandleSettings)
+	mux.HandleFunc("POST /settings/email", usersHandlers.HandleEmailChange)
+	mux.HandleFunc("GET /confirm-email", usersHandlers.HandleConfirmEmail)
+	mux.HandleFunc("POST /settings/delete", usersHandlers.HandleDeleteAccount)
+	mux.HandleFunc("POST /settings/delete/cancel", usersHandlers.HandleCancelAccountDeletion)
+	mux.HandleFunc("POST /settings/calendar", usersHandlers.HandleCalendarTokenRotate)
+	mux.HandleFunc("POST /settings/calendar/disable", usersHandlers.HandleCalendarFeedDisable)
+	mux.HandleFunc("GET /settings/webhooks", webhooksHandlers.HandleWebhooks)
+	mux.HandleFunc("POST /settings/webhooks", webhooksHandlers.HandleWebhooksCreate)
+	mux.HandleFunc("POST /settings/webhooks/{id}/delete", webhooksHandlers.HandleWebhooksDelete)
+	mux.HandleFunc("GET /settings/tokens", personalTokensHandlers.HandlePersonalTokens)
+	mux.HandleFunc("POST /settings/tokens", personalTokensHandlers.HandlePersonalTokensCreate)
+	mux.HandleFunc("POST /settings/tokens/{id}/delete", personalTokensHandlers.HandlePersonalTokensDelete)
+	mux.HandleFunc("GET /oauth/{provider}", usersHandlers.HandleOAuthLogin)
+	mux.HandleFunc("GET /oauth/{provider}/callback", usersHandlers.HandleOAuthCallback)
 
 	mux.HandleFunc("/dashboard", dashboardHandler.HandleDashboard)
 	mux.HandleFunc("GET /tasks/new", dashboardHandler.HandleTasksNew)
@@ -90,6 +211,9 @@ func main() {
 	mux.HandleFunc("GET /tasks", dashboardHandler.HandleTaskList)
 	mux.HandleFunc("POST /tasks/update-sort-order", dashboardHandler.HandleUpdateSortOrder)
 	mux.HandleFunc("/reports", dashboardHandler.HandleReports)
+	mux.HandleFunc("GET /settings/export", dashboardHandler.HandleExport)
+	mux.HandleFunc("GET /calendar.ics", calendarHandlers.HandleCalendarFeed)
+	mux.HandleFunc("GET /events", eventsHandlers.HandleEvents)
 
 	mux.HandleFunc("GET /records/new", dashboardHandler.HandleRecordsNew)
 	mux.HandleFunc("POST /records", dashboardHandler.HandleRecordsCreate)
@@ -97,6 +221,26 @@ func main() {
 	mux.HandleFunc("POST /records/{id}", dashboardHandler.HandleRecordsUpdate)
 	mux.HandleFunc("DELETE /records/{id}", dashboardHandler.HandleRecordsDelete)
 	mux.HandleFunc("GET /records", dashboardHandler.HandleRecordsList)
+	mux.HandleFunc("GET /records/import", dashboardHandler.HandleRecordsImport)
+	mux.HandleFunc("POST /records/import/preview", dashboardHandler.HandleRecordsImportPreview)
+	mux.HandleFunc("POST /records/import", dashboardHandler.HandleRecordsImportCreate)
+
+	mux.HandleFunc("GET /recurring-records", dashboardHandler.HandleRecurringRecords)
+	mux.HandleFunc("GET /recurring-records/new", dashboardHandler.HandleRecurringRecordsNew)
+	mux.HandleFunc("POST /recurring-records", dashboardHandler.HandleRecurringRecordsCreate)
+	mux.HandleFunc("GET /recurring-records/{id}", dashboardHandler.HandleRecurringRecordsEdit)
+	mux.HandleFunc("POST /recurring-records/{id}", dashboardHandler.HandleRecurringRecordsUpdate)
+	mux.HandleFunc("DELETE /recurring-records/{id}", dashboardHandler.HandleRecurringRecordsDelete)
+	// The API of the tt command-line client, authenticated by the personal tokens instead of the session
+	apiMux := http.NewServeMux()
+	apiMux.HandleFunc("GET /api/v1/tasks", dashboardHandler.HandleAPITasks)
+	apiMux.HandleFunc("GET /api/v1/status", dashboardHandler.HandleAPIStatus)
+	apiMux.HandleFunc("POST /api/v1/records/start", dashboardHandler.HandleAPIRecordsStart)
+	apiMux.HandleFunc("POST /api/v1/records/stop", dashboardHandler.HandleAPIRecordsStop)
+	apiMux.HandleFunc("GET /api/v1/log", dashboardHandler.HandleAPILog)
+	apiMux.HandleFunc("GET /api/v1/report", dashboardHandler.HandleAPIReport)
+	mux.Handle("/api/", users.PersonalTokenMiddleware(apiMux, repos.personalTokens, usersRepo))
+
 	// mux.HandleFunc("/projects", handler)
 	// http.HandleFunc("/projects/{project_id}", handler)
 	// mux.HandleFunc("/reports", pages.IndexHandler)
@@ -106,15 +250,163 @@ func main() {
 		http.NotFound(w, r)
 	})
 
-	muxSession := users.SessionMiddleware(mux, sessionsRepo, usersRepo)
+	// See CSRFMiddleware for the rules of exempt routes
+	muxCSRF := users.CSRFMiddleware(mux, sessionsRepo, "POST /tasks/update-sort-order")
+	muxSession := users.SessionMiddleware(muxCSRF, sessionsRepo, usersRepo)
 	muxRecovery := recoveryMiddleware(muxSession)
+	muxRequestLog := utils.RequestLogMiddleware(muxRecovery, mux)
+	// Outside of the request log, so the logs have the trace ID, and the duration includes the middlewares
+	// and a recovered panic is counted with its status
+	muxMetrics := metrics.Middleware(muxRequestLog, mux)
+	muxTracing := tracing.Middleware(muxMetrics, mux)
 
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: muxRecovery,
+		Addr:         cfg.ServerAddr,
+		Handler:      muxTracing,
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
+		IdleTimeout:  cfg.ServerIdleTimeout,
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		slog.Error("Server listen", "addr", server.Addr, "err", err)
+		os.Exit(1)
+	}
+	err = serve(ctx, server, listener, cfg.ServerShutdownTimeout, jobs.Wait, usersService.Wait)
+	if err != nil {
+		slog.Error("Server stop", "err", err)
+		return
+	}
+	slog.Info("Server stop")
+}
+
+// Time to send the remaining spans on exit
+const tracingShutdownTimeout = 5 * time.Second
+
+// Maximum duration of the checks of /readyz, shorter than the timeout of the health check of the load balancer
+const readyCheckTimeout = 3 * time.Second
+
+// serve handles requests until ctx is done, then stops accepting new connections, waits for the requests
+// in progress and then for the background work, e.g. the jobs and the emails. All of it is limited by shutdownTimeout.
+func serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration, waits ...func()) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("Server shutdown started", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for _, wait := range waits {
+			wait()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-shutdownCtx.Done():
+		return fmt.Errorf("waiting for the background work: %w", shutdownCtx.Err())
+	}
+}
+
+// Repositories of the storage chosen by cfg.Storage
+type repositories struct {
+	users     users.UsersRepository
+	sessions  users.SessionsRepository
+	rateLimit users.RateLimitRepository
+	dashboard dashboard.DashboardRepository
+	webhooks  webhooks.WebhooksRepository
+	// The tokens of the API
+	personalTokens users.PersonalTokensRepository
+	// The live updates of the dashboards
+	events dashboard.EventsBroker
+	// Checks of /readyz by the name of the storage
+	checks map[string]health.Check
+	// Metrics of the connection pools for /metrics
+	collectors []prometheus.Collector
+	// Closes the connections of the storage
+	close func()
+}
+
+// For tests without a database use users.NewUsersRepositoryMem, users.NewSessionsRepositoryMem,
+// users.NewRateLimitRepositoryMem, users.NewPersonalTokensRepositoryMem, dashboard.NewDashboardRepositoryMem,
+// dashboard.NewEventsBrokerMem and webhooks.NewWebhooksRepositoryMem
+func openRepositories(cfg *config.Config) (*repositories, error) {
+	switch cfg.Storage {
+	case config.StorageSQLite:
+		db, err := sqlite.Open(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Successfully opened the SQLite database", "path", cfg.SQLitePath)
+		return &repositories{
+			users:    users.NewUsersRepositorySQLite(db, cfg.DBQueryTimeout),
+			sessions: users.NewSessionsRepositorySQLite(db),
+			// One process serves all requests, the limits do not have to be shared
+			rateLimit:      users.NewRateLimitRepositoryMem(),
+			dashboard:      dashboard.NewDashboardRepositorySQLite(db, cfg.DBQueryTimeout),
+			webhooks:       webhooks.NewWebhooksRepositorySQLite(db, cfg.DBQueryTimeout),
+			personalTokens: users.NewPersonalTokensRepositorySQLite(db, cfg.DBQueryTimeout),
+			events:         dashboard.NewEventsBrokerMem(),
+			checks:         map[string]health.Check{"sqlite": db.PingContext},
+			collectors:     []prometheus.Collector{collectors.NewDBStatsCollector(db, "sqlite")},
+			close:          func() { db.Close() },
+		}, nil
+
+	case config.StoragePostgres:
+		if cfg.DBAutoMigrate {
+			if err := migrateDatabase(context.Background(), cfg, migrate.CommandUp, os.Stdout); err != nil {
+				return nil, err
+			}
+		}
+		db, err := connectToDatabase(cfg)
+		if err != nil {
+			return nil, err
+		}
+		redisClient, err := connectToRedis(cfg)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return &repositories{
+			users:          users.NewUsersRepositoryPostgres(db, cfg.DBQueryTimeout),
+			sessions:       users.NewSessionsRepositoryRedis(redisClient),
+			rateLimit:      users.NewRateLimitRepositoryRedis(redisClient),
+			dashboard:      dashboard.NewDashboardRepositoryPostgres(db, cfg.DBQueryTimeout),
+			webhooks:       webhooks.NewWebhooksRepositoryPostgres(db, cfg.DBQueryTimeout),
+			personalTokens: users.NewPersonalTokensRepositoryPostgres(db, cfg.DBQueryTimeout),
+			// Every instance gets the events of all instances
+			events: dashboard.NewEventsBrokerRedis(redisClient),
+			checks: map[string]health.Check{
+				"postgres": db.Ping,
+				"redis":    func(ctx context.Context) error { return redisClient.Ping(ctx).Err() },
+			},
+			collectors: []prometheus.Collector{
+				metrics.NewPgxPoolCollector(db),
+				metrics.NewRedisPoolCollector(redisClient),
+			},
+			close: func() {
+				redisClient.Close()
+				db.Close()
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage %q, expected %q or %q", cfg.Storage, config.StoragePostgres, config.StorageSQLite)
 	}
-	listenError := server.ListenAndServe()
-	slog.Error("Server stop", "listenError", listenError)
 }
 
 func connectToDatabase(cfg *config.Config) (*pgxpool.Pool, error) {
@@ -123,8 +415,10 @@ func connectToDatabase(cfg *config.Config) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("error parsing database config: %w", err)
 	}
 
-	config.MaxConns = 10
-	config.MaxConnLifetime = time.Hour
+	config.MaxConns = int32(cfg.DBMaxConns)
+	config.MaxConnLifetime = cfg.DBMaxConnLifetime
+	// Spans of the queries with the SQL, without the arguments
+	config.ConnConfig.Tracer = otelpgx.NewTracer()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -145,9 +439,14 @@ func connectToDatabase(cfg *config.Config) (*pgxpool.Pool, error) {
 func connectToRedis(cfg *config.Config) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisAddr,
-		Password: "",
-		DB:       0,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
 	})
+	// The commands are not in the spans, the keys are session IDs
+	if err := redisotel.InstrumentTracing(client, redisotel.WithDBStatement(false)); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
 	ctx := context.Background()
 	_, err := client.Ping(ctx).Result()
 	if err != nil {
@@ -157,6 +456,38 @@ func connectToRedis(cfg *config.Config) (*redis.Client, error) {
 	return client, nil
 }
 
+func newOAuthProviders(cfg *config.Config) (providers []users.OAuthProvider, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if cfg.GoogleClientID != "" {
+		google, err := oauth.NewGoogleProvider(ctx, cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GetOAuthRedirectURL("google"))
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, google)
+	}
+	if cfg.GitHubClientID != "" {
+		providers = append(providers, oauth.NewGitHubProvider(cfg.GitHubClientID, cfg.GitHubClientSecret, cfg.GetOAuthRedirectURL("github")))
+	}
+	if cfg.OIDCClientID != "" {
+		name := cfg.OIDCName
+		if name == "" {
+			name = "oidc"
+		}
+		title := cfg.OIDCTitle
+		if title == "" {
+			title = "SSO"
+		}
+		oidcProvider, err := oauth.NewOIDCProvider(ctx, name, title, cfg.OIDCIssuer, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.GetOAuthRedirectURL(name))
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, oidcProvider)
+	}
+	return providers, nil
+}
+
 func setLogger(env string) {
 	var handler slog.Handler
 	if env == "development" {
@@ -172,7 +503,7 @@ func recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				slog.Error("Panic occurred", "error", err, "stack", string(debug.Stack()))
+				utils.Logger(r.Context()).Error("Panic occurred", "error", err, "stack", string(debug.Stack()))
 				http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
 			}
 		}()
@@ -4,13 +4,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 	"time-tracker/internal/config"
+	"time-tracker/internal/modules/dashboard"
+	"time-tracker/internal/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,6 +78,119 @@ func TestMain_ConnectToRedis(t *testing.T) {
 	})
 }
 
+func TestMain_OpenRepositories(t *testing.T) {
+	t.Run("TestOpenRepositoriesSQLite", func(t *testing.T) {
+		cfg := &config.Config{
+			Storage:    config.StorageSQLite,
+			SQLitePath: filepath.Join(t.TempDir(), "test.db"),
+		}
+		repos, err := openRepositories(cfg)
+		require.NoError(t, err)
+		defer repos.close()
+		assert.NotNil(t, repos.users)
+		assert.NotNil(t, repos.sessions)
+		assert.NotNil(t, repos.rateLimit)
+		assert.NotNil(t, repos.dashboard)
+		assert.NotNil(t, repos.webhooks)
+		assert.NotNil(t, repos.personalTokens)
+		assert.IsType(t, &dashboard.EventsBrokerMem{}, repos.events)
+		require.Contains(t, repos.checks, "sqlite")
+		assert.NoError(t, repos.checks["sqlite"](context.Background()))
+		assert.Len(t, repos.collectors, 1)
+	})
+
+	t.Run("TestOpenRepositoriesUnknownStorage", func(t *testing.T) {
+		cfg := &config.Config{Storage: "mysql"}
+		repos, err := openRepositories(cfg)
+		assert.Error(t, err)
+		assert.Nil(t, repos)
+	})
+}
+
+func TestMain_RunMigrate(t *testing.T) {
+	t.Run("TestRunMigrateUsage", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		code := runMigrate(&config.Config{Storage: config.StoragePostgres}, []string{}, out)
+		assert.Equal(t, 2, code)
+		assert.Contains(t, out.String(), "Usage: server migrate")
+	})
+
+	t.Run("TestRunMigrateUnknownCommand", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		code := runMigrate(&config.Config{Storage: config.StoragePostgres}, []string{"sideways"}, out)
+		assert.Equal(t, 2, code)
+		assert.Contains(t, out.String(), "Usage: server migrate")
+	})
+
+	t.Run("TestRunMigrateSQLite", func(t *testing.T) {
+		code := runMigrate(&config.Config{Storage: config.StorageSQLite}, []string{"up"}, &bytes.Buffer{})
+		assert.Equal(t, 1, code)
+	})
+}
+
+func TestMain_Serve(t *testing.T) {
+	t.Run("TestServeDrainsRequests", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.Write([]byte("done"))
+		})}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		jobDone := false
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- serve(ctx, server, listener, 5*time.Second, func() { jobDone = true })
+		}()
+
+		type result struct {
+			body string
+			err  error
+		}
+		response := make(chan result, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String())
+			if err != nil {
+				response <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			response <- result{body: string(body), err: err}
+		}()
+		<-started
+		// SIGTERM during the request
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+
+		res := <-response
+		require.NoError(t, res.err)
+		assert.Equal(t, "done", res.body)
+		require.NoError(t, <-serveErr)
+		assert.True(t, jobDone)
+
+		_, err = http.Get("http://" + listener.Addr().String())
+		assert.Error(t, err, "expected new connections to be refused after the shutdown")
+	})
+
+	t.Run("TestServeShutdownTimeout", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		release := make(chan struct{})
+		defer close(release)
+		err = serve(ctx, &http.Server{Handler: http.NotFoundHandler()}, listener, 50*time.Millisecond, func() { <-release })
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
 func TestMain_RecoveryMiddleware(t *testing.T) {
 	t.Run("TestRecoveryMiddlewareNoPanic", func(t *testing.T) {
 		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -101,6 +223,28 @@ func TestMain_RecoveryMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusBadGateway, rr.Code)
 		assert.Contains(t, rr.Body.String(), http.StatusText(http.StatusBadGateway))
 	})
+
+	t.Run("TestRecoveryMiddlewareLogsRequestID", func(t *testing.T) {
+		var buf bytes.Buffer
+		prev := slog.Default()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+		defer slog.SetDefault(prev)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+			panic("Something went wrong")
+		})
+		handler := utils.RequestLogMiddleware(recoveryMiddleware(mux), mux)
+		req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+		req.Header.Set(utils.RequestIDHeader, "req-1")
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		panicLog, _, _ := strings.Cut(buf.String(), "\n")
+		assert.Contains(t, panicLog, `"msg":"Panic occurred"`)
+		assert.Contains(t, panicLog, `"request_id":"req-1"`)
+		assert.Contains(t, panicLog, `"route":"GET /tasks/{id}"`)
+	})
 }
 
 func TestMain_SetLogger(t *testing.T) {
@@ -124,7 +268,8 @@ func TestMain_SetLogger(t *testing.T) {
 func TestMain_MainHandler(t *testing.T) {
 	t.Run("TestMainHandler", func(t *testing.T) {
 		// Simulate application startup
-		cfg := config.LoadConfig()
+		cfg, err := config.LoadConfig()
+		require.NoError(t, err)
 		assert.NotNil(t, cfg)
 
 		// Test handlers can be attached and serve basic HTTP requests
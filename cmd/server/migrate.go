@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time-tracker/db/migrations"
+	"time-tracker/internal/config"
+	"time-tracker/internal/utils/migrate"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = "Usage: server migrate up|down|status|redo"
+
+// runMigrate runs "server migrate <command>" and returns the exit code
+func runMigrate(cfg *config.Config, args []string, out io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(out, migrateUsage)
+		return 2
+	}
+	err := migrateDatabase(context.Background(), cfg, args[0], out)
+	if errors.Is(err, migrate.ErrUnknownCommand) {
+		fmt.Fprintln(out, migrateUsage)
+		return 2
+	}
+	if err != nil {
+		slog.Error("Migrate failed", "command", args[0], "err", err)
+		return 1
+	}
+	return 0
+}
+
+// migrateDatabase runs the migrations of db/migrations embedded into the binary.
+// The migrations are for Postgres, sqlite.Open applies the SQLite schema itself.
+func migrateDatabase(ctx context.Context, cfg *config.Config, command string, out io.Writer) error {
+	if cfg.Storage != config.StoragePostgres {
+		return fmt.Errorf("migrations are only for the storage %q, the schema of %q is applied on start", config.StoragePostgres, cfg.Storage)
+	}
+	// A separate connection of database/sql, goose does not work with pgxpool
+	db, err := sql.Open("pgx", cfg.GetPostgresDSN())
+	if err != nil {
+		return fmt.Errorf("migrate open database: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, goose.DialectPostgres, migrations.FS)
+	if err != nil {
+		return err
+	}
+	return migrator.Run(ctx, command, out)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// The types of the JSON API of the server, see dashboard_handlers_api.go.
+// The times have the offset of the time zone of the user, the durations are in seconds.
+
+type task struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	IsCompleted bool   `json:"is_completed"`
+}
+
+type record struct {
+	ID        int        `json:"id"`
+	Task      task       `json:"task"`
+	TimeStart time.Time  `json:"time_start"`
+	TimeEnd   *time.Time `json:"time_end"`
+	Comment   string     `json:"comment"`
+	Duration  int64      `json:"duration"`
+}
+
+type status struct {
+	Record *record `json:"record"`
+}
+
+type weekLog struct {
+	Week string `json:"week"`
+	Days []struct {
+		Date     string   `json:"date"`
+		Records  []record `json:"records"`
+		Duration int64    `json:"duration"`
+	} `json:"days"`
+	Duration int64 `json:"duration"`
+}
+
+type report struct {
+	Month string `json:"month"`
+	Tasks []struct {
+		Task     task  `json:"task"`
+		Duration int64 `json:"duration"`
+	} `json:"tasks"`
+	Duration int64 `json:"duration"`
+}
+
+// apiError is a response of the API with a 4xx or 5xx status: {"error": "<message>"}
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+var errNotConfigured = errors.New(`the server and the token are not configured, run "tt config --server <URL> --token <token>"`)
+
+type apiClient struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func newAPIClient(cfg *clientConfig) (*apiClient, error) {
+	if cfg.Server == "" || cfg.Token == "" {
+		return nil, errNotConfigured
+	}
+	return &apiClient{server: cfg.Server, token: cfg.Token, http: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (c *apiClient) Tasks(ctx context.Context, all bool) (tasks []task, err error) {
+	query := url.Values{}
+	if all {
+		query.Set("completed", "all")
+	}
+	err = c.do(ctx, http.MethodGet, "/api/v1/tasks", query, nil, &tasks)
+	return tasks, err
+}
+
+// nil if no record is in progress
+func (c *apiClient) Status(ctx context.Context) (*record, error) {
+	var result status
+	err := c.do(ctx, http.MethodGet, "/api/v1/status", nil, nil, &result)
+	return result.Record, err
+}
+
+func (c *apiClient) Start(ctx context.Context, taskID int, comment string) (*record, error) {
+	var result record
+	body := map[string]any{"task_id": taskID, "comment": comment}
+	err := c.do(ctx, http.MethodPost, "/api/v1/records/start", nil, body, &result)
+	return &result, err
+}
+
+func (c *apiClient) Stop(ctx context.Context) (*record, error) {
+	var result record
+	err := c.do(ctx, http.MethodPost, "/api/v1/records/stop", nil, nil, &result)
+	return &result, err
+}
+
+// The current week if week is empty, e.g. "2024-W49"
+func (c *apiClient) Log(ctx context.Context, week string) (*weekLog, error) {
+	query := url.Values{}
+	if week != "" {
+		query.Set("week", week)
+	}
+	var result weekLog
+	err := c.do(ctx, http.MethodGet, "/api/v1/log", query, nil, &result)
+	return &result, err
+}
+
+// The current month if month is empty, e.g. "2024-12"
+func (c *apiClient) Report(ctx context.Context, month string) (*report, error) {
+	query := url.Values{}
+	if month != "" {
+		query.Set("month", month)
+	}
+	var result report
+	err := c.do(ctx, http.MethodGet, "/api/v1/report", query, nil, &result)
+	return &result, err
+}
+
+func (c *apiClient) do(ctx context.Context, method string, path string, query url.Values, body any, result any) error {
+	target := c.server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &errorResponse) != nil || errorResponse.Error == "" {
+			// Not the API, e.g. a proxy or a wrong URL of the server
+			errorResponse.Error = fmt.Sprintf("%s %s: response status %s", method, path, resp.Status)
+		}
+		return &apiError{Status: resp.StatusCode, Message: errorResponse.Error}
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("%s %s: the response is not JSON of the API, check the server URL: %w", method, path, err)
+	}
+	return nil
+}
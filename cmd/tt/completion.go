@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// The task titles are completed by "tt tasks -q"
+
+const bashCompletion = `# bash completion of tt, add to ~/.bashrc:
+#   source <(tt completion bash)
+_tt() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    if [[ ${COMP_CWORD} -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "start stop status log report tasks config completion help" -- "${cur}"))
+        return
+    fi
+    case "${COMP_WORDS[1]}" in
+        start)
+            local IFS=$'\n'
+            COMPREPLY=($(compgen -W "$(tt tasks -q 2>/dev/null)" -- "${cur}"))
+            ;;
+        log) COMPREPLY=($(compgen -W "--week" -- "${cur}")) ;;
+        report) COMPREPLY=($(compgen -W "--month" -- "${cur}")) ;;
+        tasks) COMPREPLY=($(compgen -W "--all -q" -- "${cur}")) ;;
+        config) COMPREPLY=($(compgen -W "--server --token" -- "${cur}")) ;;
+        completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "${cur}")) ;;
+    esac
+}
+complete -F _tt tt
+`
+
+const zshCompletion = `#compdef tt
+# zsh completion of tt, add to ~/.zshrc:
+#   source <(tt completion zsh)
+_tt() {
+    local -a commands
+    commands=(
+        'start:Start a record of the task'
+        'stop:Stop the current record'
+        'status:Show the current record'
+        'log:Show the records of the week'
+        'report:Show the time of the tasks in the month'
+        'tasks:List the tasks'
+        'config:Save the server and the token'
+        'completion:Print the shell completion script'
+        'help:Show the help'
+    )
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+    case "${words[2]}" in
+        start)
+            local -a tasks
+            tasks=("${(@f)$(tt tasks -q 2>/dev/null)}")
+            compadd -a tasks
+            ;;
+        log) _arguments '--week[ISO week, e.g. 2024-W49]:week:' ;;
+        report) _arguments '--month[month, e.g. 2024-12]' ;;
+        tasks) _arguments '--all[with the completed tasks]' '-q[only the titles]' ;;
+        config) _arguments '--server[URL of the time tracker]:url:' '--token[personal token]:token:' ;;
+        completion) compadd bash zsh fish ;;
+    esac
+}
+if [[ "$funcstack[1]" = "_tt" ]]; then
+    _tt "$@"
+else
+    compdef _tt tt
+fi
+`
+
+const fishCompletion = `# fish completion of tt, save to ~/.config/fish/completions/tt.fish:
+#   tt completion fish > ~/.config/fish/completions/tt.fish
+complete -c tt -f
+complete -c tt -n __fish_use_subcommand -a start -d 'Start a record of the task'
+complete -c tt -n __fish_use_subcommand -a stop -d 'Stop the current record'
+complete -c tt -n __fish_use_subcommand -a status -d 'Show the current record'
+complete -c tt -n __fish_use_subcommand -a log -d 'Show the records of the week'
+complete -c tt -n __fish_use_subcommand -a report -d 'Show the time of the tasks in the month'
+complete -c tt -n __fish_use_subcommand -a tasks -d 'List the tasks'
+complete -c tt -n __fish_use_subcommand -a config -d 'Save the server and the token'
+complete -c tt -n __fish_use_subcommand -a completion -d 'Print the shell completion script'
+complete -c tt -n __fish_use_subcommand -a help -d 'Show the help'
+complete -c tt -n '__fish_seen_subcommand_from start' -a '(tt tasks -q 2>/dev/null)'
+complete -c tt -n '__fish_seen_subcommand_from log' -l week -r -d 'ISO week, e.g. 2024-W49'
+complete -c tt -n '__fish_seen_subcommand_from report' -l month -d 'month, e.g. 2024-12'
+complete -c tt -n '__fish_seen_subcommand_from tasks' -l all -d 'with the completed tasks'
+complete -c tt -n '__fish_seen_subcommand_from tasks' -s q -d 'only the titles'
+complete -c tt -n '__fish_seen_subcommand_from config' -l server -r -d 'URL of the time tracker'
+complete -c tt -n '__fish_seen_subcommand_from config' -l token -r -d 'personal token'
+complete -c tt -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'
+`
+
+// tt completion bash|zsh|fish
+func runCompletion(args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return usageError("completion needs a shell: tt completion bash|zsh|fish")
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Fprint(stdout, bashCompletion)
+	case "zsh":
+		fmt.Fprint(stdout, zshCompletion)
+	case "fish":
+		fmt.Fprint(stdout, fishCompletion)
+	default:
+		return usageError(fmt.Sprintf("unknown shell %q, expected bash, zsh or fish", args[0]))
+	}
+	return nil
+}
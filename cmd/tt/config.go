@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The config of the client in $XDG_CONFIG_HOME/tt/config.yaml, ~/.config/tt/config.yaml by default.
+// TT_SERVER and TT_TOKEN override the file, e.g. in CI.
+type clientConfig struct {
+	// The URL of the time tracker, e.g. "https://tt.example.com"
+	Server string `yaml:"server"`
+	// A personal token of Settings → API Tokens
+	Token string `yaml:"token"`
+}
+
+func configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("config path: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "tt", "config.yaml"), nil
+}
+
+// A missing file is an empty config
+func loadConfig() (*clientConfig, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &clientConfig{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if server := os.Getenv("TT_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	if token := os.Getenv("TT_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	cfg.Server = strings.TrimRight(cfg.Server, "/")
+	return cfg, nil
+}
+
+// The file has the token, only the user can read it
+func saveConfig(cfg *clientConfig) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("save config: %w", err)
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("save config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("save config: %w", err)
+	}
+	return path, nil
+}
+
+// "tt_1234…cdef" for the output of "tt config"
+func maskToken(token string) string {
+	if len(token) <= 12 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:7] + "…" + token[len(token)-4:]
+}
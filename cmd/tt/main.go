@@ -0,0 +1,441 @@
+// tt is the command-line client of the time tracker. It works with the API of the server by a personal token,
+// see "tt help" and the "Command-line client" section of the README.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+const usage = `tt is the command-line client of the time tracker.
+
+Usage:
+  tt start [-c comment] <task>   Start a record of the task by its ID or title, stops the current one
+  tt stop                        Stop the current record
+  tt status                      Show the current record
+  tt log [--week 2024-W49]       Show the records of the week, the current one by default
+  tt report [--month 2024-12]    Show the time of the tasks in the month, the current one by default
+  tt tasks [--all] [-q]          List the active tasks, --all with the completed ones
+  tt config [--server URL] [--token TOKEN]
+                                 Save the server and the token, without flags show them
+  tt completion bash|zsh|fish    Print the shell completion script
+
+Create a token in Settings → API Tokens of the web app.
+The config is in ~/.config/tt/config.yaml, TT_SERVER and TT_TOKEN override it.
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command of args and returns the exit code: 0, 1 on errors, 2 on wrong usage
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command, args := args[0], args[1:]
+
+	var err error
+	switch command {
+	case "start":
+		err = runStart(ctx, args, stdout)
+	case "stop":
+		err = runStop(ctx, args, stdout)
+	case "status":
+		err = runStatus(ctx, args, stdout)
+	case "log":
+		err = runLog(ctx, args, stdout)
+	case "report":
+		err = runReport(ctx, args, stdout)
+	case "tasks":
+		err = runTasks(ctx, args, stdout)
+	case "config":
+		err = runConfig(args, stdout)
+	case "completion":
+		err = runCompletion(args, stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		err = usageError(fmt.Sprintf("unknown command %q", command))
+	}
+
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "tt: %s\n\n%s", usageErr, usage)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "tt: %s\n", errorMessage(err))
+		return 1
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// The hints for the errors of the API
+func errorMessage(err error) string {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+		return apiErr.Message + `, create a token in Settings → API Tokens and run "tt config --token <token>"`
+	}
+	return err.Error()
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("tt "+name, flag.ContinueOnError)
+	// The errors are printed by run with the usage
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError(err.Error())
+	}
+	return err
+}
+
+func newClient() (*apiClient, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newAPIClient(cfg)
+}
+
+// tt start [-c comment] <task>
+func runStart(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("start")
+	comment := flags.String("c", "", "comment of the record")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		return usageError("start needs a task: tt start <task>")
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	tasks, err := client.Tasks(ctx, false)
+	if err != nil {
+		return err
+	}
+	task, err := findTask(tasks, query)
+	if err != nil {
+		return err
+	}
+
+	current, err := client.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		if current.Task.ID == task.ID && *comment == "" {
+			fmt.Fprintf(stdout, "Already doing %s\n", formatRecord(current))
+			return nil
+		}
+		stopped, err := client.Stop(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Stopped %s\n", formatRecord(stopped))
+	}
+
+	started, err := client.Start(ctx, task.ID, *comment)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Started %s\n", formatRecord(started))
+	return nil
+}
+
+// tt stop
+func runStop(ctx context.Context, args []string, stdout io.Writer) error {
+	if err := parseFlags(newFlagSet("stop"), args); err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	stopped, err := client.Stop(ctx)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		fmt.Fprintln(stdout, "No record in progress")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Stopped %s\n", formatRecord(stopped))
+	return nil
+}
+
+// tt status
+func runStatus(ctx context.Context, args []string, stdout io.Writer) error {
+	if err := parseFlags(newFlagSet("status"), args); err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	current, err := client.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		fmt.Fprintln(stdout, "No record in progress")
+		return nil
+	}
+	fmt.Fprintf(stdout, "Doing %s\n", formatRecord(current))
+	return nil
+}
+
+// tt log [--week 2024-W49]
+func runLog(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("log")
+	week := flags.String("week", "", "ISO week, e.g. 2024-W49")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	log, err := client.Log(ctx, *week)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Week %s\n\n", log.Week)
+	// One table without empty lines, tabwriter aligns the columns of consecutive lines only
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, day := range log.Days {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return fmt.Errorf("log date %q: %w", day.Date, err)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", date.Format("Mon 2006-01-02"), formatDuration(day.Duration))
+		for _, record := range day.Records {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n",
+				formatTimeRange(record), formatDuration(record.Duration), record.Task.Title, record.Comment)
+		}
+	}
+	fmt.Fprintf(w, "Total\t%s\n", formatDuration(log.Duration))
+	return w.Flush()
+}
+
+// tt report [--month 2024-12]
+func runReport(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("report")
+	month := &monthFlag{}
+	flags.Var(month, "month", "month, e.g. 2024-12")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	// "--month 2024-12", the flag does not need a value
+	if flags.NArg() > 0 {
+		if err := month.Set(flags.Arg(0)); err != nil {
+			return usageError(err.Error())
+		}
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	report, err := client.Report(ctx, month.value)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Month %s\n\n", report.Month)
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, row := range report.Tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.Task.Title, formatDuration(row.Duration), formatPercent(row.Duration, report.Duration))
+	}
+	fmt.Fprintf(w, "Total\t%s\n", formatDuration(report.Duration))
+	return w.Flush()
+}
+
+// tt tasks [--all] [-q]
+func runTasks(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := newFlagSet("tasks")
+	all := flags.Bool("all", false, "with the completed tasks")
+	quiet := flags.Bool("q", false, "only the titles, for the shell completion")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	tasks, err := client.Tasks(ctx, *all)
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		for _, task := range tasks {
+			fmt.Fprintln(stdout, task.Title)
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, task := range tasks {
+		completed := ""
+		if task.IsCompleted {
+			completed = "completed"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", task.ID, task.Title, completed)
+	}
+	return w.Flush()
+}
+
+// tt config [--server URL] [--token TOKEN]
+func runConfig(args []string, stdout io.Writer) error {
+	flags := newFlagSet("config")
+	server := flags.String("server", "", "URL of the time tracker")
+	token := flags.String("token", "", "personal token")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if *server == "" && *token == "" {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "config: %s\nserver: %s\ntoken:  %s\n", path, cfg.Server, maskToken(cfg.Token))
+		return nil
+	}
+	if *server != "" {
+		if !strings.HasPrefix(*server, "http://") && !strings.HasPrefix(*server, "https://") {
+			return usageError("the server must be an http or https URL")
+		}
+		cfg.Server = strings.TrimRight(*server, "/")
+	}
+	if *token != "" {
+		cfg.Token = strings.TrimSpace(*token)
+	}
+	path, err := saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Saved %s\n", path)
+	return nil
+}
+
+// findTask finds the task by the ID, the title or the start of the title, ignoring the case
+func findTask(tasks []task, query string) (*task, error) {
+	if id, err := strconv.Atoi(query); err == nil {
+		for i := range tasks {
+			if tasks[i].ID == id {
+				return &tasks[i], nil
+			}
+		}
+	}
+	var matches []*task
+	for i := range tasks {
+		if strings.EqualFold(tasks[i].Title, query) {
+			return &tasks[i], nil
+		}
+		if strings.HasPrefix(strings.ToLower(tasks[i].Title), strings.ToLower(query)) {
+			matches = append(matches, &tasks[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no active task %q, see \"tt tasks\"", query)
+	case 1:
+		return matches[0], nil
+	default:
+		titles := make([]string, 0, len(matches))
+		for _, match := range matches {
+			titles = append(titles, strconv.Quote(match.Title))
+		}
+		return nil, fmt.Errorf("%q matches several tasks: %s", query, strings.Join(titles, ", "))
+	}
+}
+
+// monthFlag is --month with or without the value
+type monthFlag struct {
+	value string
+}
+
+func (f *monthFlag) String() string {
+	return f.value
+}
+
+func (f *monthFlag) Set(value string) error {
+	// "--month" without the value is the current month
+	if value == "true" {
+		f.value = ""
+		return nil
+	}
+	if _, err := time.Parse("2006-01", value); err != nil {
+		return fmt.Errorf("invalid month %q, expected YYYY-MM", value)
+	}
+	f.value = value
+	return nil
+}
+
+func (f *monthFlag) IsBoolFlag() bool {
+	return true
+}
+
+// "Coding 10:00-11:30 (1h 30m) comment", the times are of the time zone of the user
+func formatRecord(record *record) string {
+	text := fmt.Sprintf("%s %s (%s)", record.Task.Title, formatTimeRange(*record), formatDuration(record.Duration))
+	if record.Comment != "" {
+		text += " " + record.Comment
+	}
+	return text
+}
+
+func formatTimeRange(record record) string {
+	if record.TimeEnd == nil {
+		return "since " + record.TimeStart.Format("15:04")
+	}
+	return record.TimeStart.Format("15:04") + "-" + record.TimeEnd.Format("15:04")
+}
+
+// Like the dashboard: "1h 30m"
+func formatDuration(seconds int64) string {
+	return utils.FormatDuration(time.Duration(seconds) * time.Second)
+}
+
+func formatPercent(part int64, total int64) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d%%", part*100/total)
+}
@@ -0,0 +1,276 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./cmd/tt --tags=unit -cover -run TestTT.*
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/dashboard"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "tt_0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// The API of the server with the repositories in memory, like in cmd/server
+func newTestServer(t *testing.T) (*httptest.Server, *dashboard.DashboardRepositoryMem) {
+	ctx := context.Background()
+	usersRepo := users.NewUsersRepositoryMem()
+	user := &users.User{Name: "User", Email: "user@example.com", TimeZone: "UTC", IsWeekStartMonday: true, IsActive: true}
+	require.NoError(t, usersRepo.Create(ctx, user))
+	tokensRepo := users.NewPersonalTokensRepositoryMem()
+	// The hash of the token like users.hashPersonalToken
+	sum := sha256.Sum256([]byte(testToken))
+	_, err := tokensRepo.CreatePersonalToken(ctx, &users.PersonalToken{UserID: user.ID, Name: "Test", TokenHash: hex.EncodeToString(sum[:])})
+	require.NoError(t, err)
+
+	repo := dashboard.NewDashboardRepositoryMem()
+	for _, title := range []string{"Coding", "Code review", "Meetings"} {
+		_, err := repo.CreateTask(ctx, &dashboard.Task{UserID: user.ID, Title: title})
+		require.NoError(t, err)
+	}
+	_, err = repo.CreateTask(ctx, &dashboard.Task{UserID: user.ID, Title: "Archive", IsCompleted: true})
+	require.NoError(t, err)
+
+	handler := dashboard.NewDashboardHandler(repo)
+	apiMux := http.NewServeMux()
+	apiMux.HandleFunc("GET /api/v1/tasks", handler.HandleAPITasks)
+	apiMux.HandleFunc("GET /api/v1/status", handler.HandleAPIStatus)
+	apiMux.HandleFunc("POST /api/v1/records/start", handler.HandleAPIRecordsStart)
+	apiMux.HandleFunc("POST /api/v1/records/stop", handler.HandleAPIRecordsStop)
+	apiMux.HandleFunc("GET /api/v1/log", handler.HandleAPILog)
+	apiMux.HandleFunc("GET /api/v1/report", handler.HandleAPIReport)
+	server := httptest.NewServer(users.PersonalTokenMiddleware(apiMux, tokensRepo, usersRepo))
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+func runTT(args ...string) (code int, stdout string, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func setTestEnv(t *testing.T, server string, token string) string {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("TT_SERVER", server)
+	t.Setenv("TT_TOKEN", token)
+	return configHome
+}
+
+func TestTT_Config(t *testing.T) {
+	configHome := setTestEnv(t, "", "")
+
+	code, _, stderr := runTT("status")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "tt config --server <URL> --token <token>")
+
+	code, stdout, _ := runTT("config", "--server", "https://tt.example.com/", "--token", testToken)
+	require.Equal(t, 0, code)
+	path := filepath.Join(configHome, "tt", "config.yaml")
+	assert.Equal(t, "Saved "+path+"\n", stdout)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "server: https://tt.example.com\ntoken: "+testToken+"\n", string(data))
+
+	code, stdout, _ = runTT("config")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "server: https://tt.example.com\n")
+	assert.Contains(t, stdout, "token:  tt_0123…cdef\n")
+	assert.NotContains(t, stdout, testToken)
+
+	// The environment overrides the file
+	t.Setenv("TT_SERVER", "http://localhost:8080")
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080", cfg.Server)
+	assert.Equal(t, testToken, cfg.Token)
+
+	code, _, stderr = runTT("config", "--server", "tt.example.com")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "the server must be an http or https URL")
+}
+
+func TestTT_Tasks(t *testing.T) {
+	server, _ := newTestServer(t)
+	setTestEnv(t, server.URL, testToken)
+
+	code, stdout, _ := runTT("tasks")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "1  Coding       \n2  Code review  \n3  Meetings     \n", stdout)
+
+	code, stdout, _ = runTT("tasks", "--all", "-q")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "Coding\nCode review\nMeetings\nArchive\n", stdout)
+}
+
+func TestTT_StartStopStatus(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newTestServer(t)
+	setTestEnv(t, server.URL, testToken)
+
+	code, stdout, _ := runTT("status")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "No record in progress\n", stdout)
+	code, stdout, _ = runTT("stop")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "No record in progress\n", stdout)
+
+	// Started an hour ago on the dashboard
+	now, _ := utils.NowWithTimezone("UTC")
+	timeStart := now.Add(-time.Hour).Truncate(time.Minute)
+	_, err := repo.CreateRecord(ctx, &dashboard.Record{TaskID: 3, TimeStart: timeStart})
+	require.NoError(t, err)
+
+	code, stdout, _ = runTT("status")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "Doing Meetings since "+timeStart.Format("15:04"), strings.Split(stdout, " (")[0])
+
+	// Stops the current record
+	code, stdout, stderr := runTT("start", "-c", "PR 42", "coding")
+	require.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "Stopped Meetings "+timeStart.Format("15:04")+"-"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "Started Coding since "), lines[1])
+	assert.True(t, strings.HasSuffix(lines[1], " PR 42"), lines[1])
+
+	code, stdout, _ = runTT("start", "Coding")
+	require.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(stdout, "Already doing Coding since "), stdout)
+
+	// Started less than a minute ago
+	code, _, stderr = runTT("stop")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "The record started less than a minute ago")
+
+	code, _, stderr = runTT("start", "Cod")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `"Cod" matches several tasks: "Coding", "Code review"`)
+
+	code, _, stderr = runTT("start")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "start needs a task")
+}
+
+func TestTT_LogAndReport(t *testing.T) {
+	ctx := context.Background()
+	server, repo := newTestServer(t)
+	setTestEnv(t, server.URL, testToken)
+	timeEnd := time.Date(2024, 12, 3, 11, 30, 0, 0, time.UTC)
+	_, err := repo.CreateRecord(ctx, &dashboard.Record{TaskID: 1, TimeStart: time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC), TimeEnd: &timeEnd, Comment: "PR 42"})
+	require.NoError(t, err)
+	timeEnd = time.Date(2024, 12, 4, 10, 30, 0, 0, time.UTC)
+	_, err = repo.CreateRecord(ctx, &dashboard.Record{TaskID: 3, TimeStart: time.Date(2024, 12, 4, 10, 0, 0, 0, time.UTC), TimeEnd: &timeEnd})
+	require.NoError(t, err)
+
+	code, stdout, stderr := runTT("log", "--week", "2024-W49")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Week 2024-W49\n")
+	assert.Contains(t, stdout, "Mon 2024-12-02")
+	assert.Regexp(t, `Tue 2024-12-03 +1h 30m`, stdout)
+	assert.Regexp(t, `  10:00-11:30 +1h 30m +Coding +PR 42`, stdout)
+	assert.Regexp(t, `  10:00-10:30 +30m +Meetings`, stdout)
+	assert.Regexp(t, `Total +2h\s+$`, stdout)
+
+	code, stdout, stderr = runTT("report", "--month", "2024-12")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Month 2024-12\n")
+	assert.Regexp(t, `Coding +1h 30m +75%`, stdout)
+	assert.Regexp(t, `Meetings +30m +25%`, stdout)
+
+	code, stdout, _ = runTT("report", "--month=2024-11")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Month 2024-11\n")
+	assert.Regexp(t, `Total +-`, stdout)
+
+	code, stdout, _ = runTT("report", "--month")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Month "+time.Now().UTC().Format("2006-01"))
+
+	code, _, stderr = runTT("report", "--month", "December")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `invalid month "December"`)
+}
+
+func TestTT_Errors(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	setTestEnv(t, server.URL, "tt_wrong")
+	code, _, stderr := runTT("status")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "tt: Invalid API token, create a token in Settings → API Tokens and run \"tt config --token <token>\"\n", stderr)
+
+	// Not the API
+	setTestEnv(t, server.URL+"/other", testToken)
+	code, _, stderr = runTT("status")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "response status 404 Not Found")
+
+	code, _, stderr = runTT("archive")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "archive"`)
+	assert.Contains(t, stderr, "Usage:")
+
+	code, _, _ = runTT()
+	assert.Equal(t, 2, code)
+	code, stdout, _ := runTT("help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "tt start")
+}
+
+func TestTT_Completion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		code, stdout, _ := runTT("completion", shell)
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "tt tasks -q")
+	}
+	code, _, stderr := runTT("completion", "powershell")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown shell "powershell"`)
+}
+
+func TestTT_FindTask(t *testing.T) {
+	tasks := []task{{ID: 1, Title: "Coding"}, {ID: 2, Title: "Code review"}, {ID: 12, Title: "2024 planning"}}
+	tests := []struct {
+		query string
+		id    int
+		err   string
+	}{
+		{"1", 1, ""},
+		{"coding", 1, ""},
+		{"code r", 2, ""},
+		{"2024", 12, ""},
+		{"12", 12, ""},
+		{"Cod", 0, `"Cod" matches several tasks`},
+		{"Meetings", 0, `no active task "Meetings"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			found, err := findTask(tasks, tt.query)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.id, found.ID)
+		})
+	}
+}
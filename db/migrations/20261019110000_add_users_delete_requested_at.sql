@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN delete_requested_at TIMESTAMP;
+CREATE INDEX idx_users_delete_requested_at ON users (delete_requested_at) WHERE delete_requested_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN delete_requested_at;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Overlapping records created before this migration must be fixed first, otherwise the constraint cannot be added.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- records.user_id duplicates tasks.user_id so that constraints can work per user without a join.
+-- The composite foreign key keeps it equal to the user of the task.
+ALTER TABLE tasks ADD CONSTRAINT tasks_id_user_id_key UNIQUE (id, user_id);
+
+ALTER TABLE records ADD COLUMN user_id INT;
+UPDATE records r SET user_id = t.user_id FROM tasks t WHERE r.task_id = t.id;
+ALTER TABLE records ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE records ADD CONSTRAINT records_task_id_user_id_fkey
+    FOREIGN KEY (task_id, user_id) REFERENCES tasks (id, user_id) ON DELETE CASCADE;
+
+-- Times are stored as the wall clock time of the user, AT TIME ZONE 'UTC' only makes tstzrange immutable.
+-- A record in progress ends "now", which cannot be used in a constraint,
+-- so it occupies only its start, like validateIntersectingRecords does for records in the future.
+-- Completed records are [time_start, time_end): a record may start when the previous one ends.
+ALTER TABLE records ADD CONSTRAINT records_no_overlap EXCLUDE USING gist (
+    user_id WITH =,
+    tstzrange(
+        time_start AT TIME ZONE 'UTC',
+        COALESCE(time_end, time_start) AT TIME ZONE 'UTC',
+        CASE WHEN time_end IS NULL THEN '[]' ELSE '[)' END
+    ) WITH &&
+);
+
+-- Only one record in progress per user
+CREATE UNIQUE INDEX records_one_in_progress_per_user ON records (user_id) WHERE time_end IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX records_one_in_progress_per_user;
+ALTER TABLE records DROP CONSTRAINT records_no_overlap;
+ALTER TABLE records DROP CONSTRAINT records_task_id_user_id_fkey;
+ALTER TABLE records DROP COLUMN user_id;
+ALTER TABLE tasks DROP CONSTRAINT tasks_id_user_id_key;
+-- +goose StatementEnd
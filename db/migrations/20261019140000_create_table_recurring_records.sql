@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Templates of the records that repeat on a schedule, RecurringRecordsService creates the records.
+-- The start is the wall clock time of the user, like the times of the records.
+CREATE TABLE recurring_records (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    user_id INT NOT NULL,
+    start_minute INT NOT NULL CHECK (start_minute >= 0 AND start_minute < 1440),
+    duration_minutes INT NOT NULL CHECK (duration_minutes > 0),
+    rule VARCHAR(255) NOT NULL,
+    date_start DATE NOT NULL,
+    -- The last day processed by the job, the occurrences up to it are created or skipped
+    materialized_until DATE,
+    comment TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (task_id, user_id) REFERENCES tasks (id, user_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_recurring_records_user_id ON recurring_records (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE recurring_records;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN calendar_token VARCHAR(64) NOT NULL DEFAULT '';
+-- Empty while the calendar feed is disabled
+CREATE UNIQUE INDEX users_calendar_token_key ON users (calendar_token) WHERE calendar_token <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_calendar_token_key;
+ALTER TABLE users DROP COLUMN calendar_token;
+-- +goose StatementEnd
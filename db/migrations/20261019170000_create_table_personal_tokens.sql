@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The tokens of the API, e.g. for the tt command-line client. Only the SHA-256 of a token is stored.
+CREATE TABLE personal_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP
+);
+CREATE INDEX idx_personal_tokens_user_id ON personal_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE personal_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The emails that differ only in the case are one account. The duplicates that were never activated
+-- are deleted: the active account, or the first one if none is active, is kept.
+DELETE FROM users u
+WHERE NOT u.is_active
+  AND EXISTS (
+    SELECT 1 FROM users o
+    WHERE lower(o.email) = lower(u.email) AND o.id <> u.id AND (o.is_active OR o.id < u.id)
+  );
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- The active duplicates are merged or renamed by hand, the migration stops with their emails
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(email, ', ') INTO duplicates
+    FROM (SELECT lower(email) AS email FROM users GROUP BY lower(email) HAVING count(*) > 1) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'active users with the same email in another case: %', duplicates;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- GetByEmail ignores the case of the email, and so does the uniqueness
+CREATE UNIQUE INDEX idx_users_email_lower ON users (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_users_email_lower;
+-- +goose StatementEnd
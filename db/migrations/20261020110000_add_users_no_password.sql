@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN no_password BOOLEAN NOT NULL DEFAULT FALSE;
+-- The users created by OAuth: the identity was added with the user
+UPDATE users u SET no_password = TRUE
+FROM user_identities i
+WHERE i.user_id = u.id AND i.date_add - u.date_add < INTERVAL '1 minute';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN no_password;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+// Package migrations embeds the goose migrations into the server binary, see "server migrate"
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -1,44 +1,376 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/netip"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultDBQueryTimeout = 5 * time.Second
+
+// Defaults of http.Server. ECS waits 30s after SIGTERM, the shutdown ends before it.
+const (
+	defaultServerAddr            = ":8080"
+	defaultServerReadTimeout     = 15 * time.Second
+	defaultServerWriteTimeout    = 30 * time.Second
+	defaultServerIdleTimeout     = 120 * time.Second
+	defaultServerShutdownTimeout = 25 * time.Second
+)
+
+// Defaults of the Postgres connection pool
+const (
+	defaultDBMaxConns        = 10
+	defaultDBMaxConnLifetime = time.Hour
+)
+
+// Values of Config.Storage
+const (
+	// Postgres for the data, Redis for the sessions, the rate limits and the live updates
+	StoragePostgres = "postgres"
+	// One SQLite file for the data and the sessions, the rate limits and the live updates are in memory. For single-user self-hosting.
+	StorageSQLite = "sqlite"
 )
 
+const defaultSQLitePath = "data/time-tracker.db"
+
+// ConfigFileEnv is the environment variable with the path of the YAML config file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Config of the server. Every field is read from the environment variable of its env tag
+// or from the YAML file with the same key in lower case, e.g. DB_HOST or db_host.
+// The environment overrides the file, the file overrides Default.
+// Fields with the secret tag are redacted in the logs, see LogValue.
 type Config struct {
-	AppEnv        string
-	SiteUrl       string
-	EmailFrom     string
-	DBHost        string
-	DBPort        string
-	DBUser        string
-	DBPassword    string
-	DBName        string
-	DBSSLMode     string
-	RedisAddr     string
-	MailgunDomain string
-	MailgunApiKey string
-}
-
-// LoadConfig загружает конфигурацию из переменных окружения
-func LoadConfig() *Config {
+	AppEnv    string `env:"APP_ENV"`
+	SiteUrl   string `env:"SITE_URL"`
+	EmailFrom string `env:"EMAIL_FROM"`
+	// Address of http.Server, e.g. ":8080"
+	ServerAddr string `env:"SERVER_ADDR"`
+	// Timeouts of http.Server, 0 disables a timeout
+	ServerReadTimeout  time.Duration `env:"SERVER_READ_TIMEOUT"`
+	ServerWriteTimeout time.Duration `env:"SERVER_WRITE_TIMEOUT"`
+	ServerIdleTimeout  time.Duration `env:"SERVER_IDLE_TIMEOUT"`
+	// Time for the requests in progress and the background jobs to finish after SIGTERM
+	ServerShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// Directory with templates and public, e.g. "web", to read them from the disk in development.
+	// Empty uses the files embedded into the binary.
+	WebDir string `env:"WEB_DIR"`
+	// StoragePostgres or StorageSQLite
+	Storage    string `env:"STORAGE"`
+	SQLitePath string `env:"SQLITE_PATH"`
+	DBHost     string `env:"DB_HOST"`
+	DBPort     string `env:"DB_PORT"`
+	DBUser     string `env:"DB_USER"`
+	DBPassword string `env:"DB_PASSWORD" secret:"true"`
+	DBName     string `env:"DB_NAME"`
+	DBSSLMode  string `env:"DB_SSLMODE"`
+	// Maximum duration of one database query, 0 disables the limit
+	DBQueryTimeout time.Duration `env:"DB_QUERY_TIMEOUT"`
+	// Size of the Postgres connection pool
+	DBMaxConns int `env:"DB_MAX_CONNS"`
+	// A connection is closed after this time, e.g. to follow a failover of the database
+	DBMaxConnLifetime time.Duration `env:"DB_MAX_CONN_LIFETIME"`
+	// Apply pending migrations on start, see "server migrate"
+	DBAutoMigrate bool   `env:"DB_AUTO_MIGRATE"`
+	RedisAddr     string `env:"REDIS_ADDR"`
+	RedisPassword string `env:"REDIS_PASSWORD" secret:"true"`
+	RedisDB       int    `env:"REDIS_DB"`
+	MailgunDomain string `env:"MAILGUN_DOMAIN"`
+	MailgunApiKey string `env:"MAILGUN_API_KEY" secret:"true"`
+	// Tracing: "" (disabled), "otlp" or "stdout", see tracing.Setup
+	TracingExporter string `env:"TRACING_EXPORTER"`
+	// URL of the OTLP HTTP receiver, e.g. "http://otel-collector:4318"
+	TracingEndpoint string `env:"TRACING_OTLP_ENDPOINT"`
+	// Part of the traces that are sampled, from 0 to 1
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO"`
+	// Allows the webhooks to localhost and the private networks, e.g. for self-hosting next to the receivers
+	WebhooksAllowPrivateNetworks bool `env:"WEBHOOKS_ALLOW_PRIVATE_NETWORKS"`
+	// Comma-separated addresses or CIDRs of the load balancers and proxies, e.g. "10.0.0.0/16".
+	// Their X-Forwarded-For gives the client IP of the rate limits. Empty trusts no one.
+	TrustedProxies string `env:"TRUSTED_PROXIES"`
+
+	// OAuth / OpenID Connect providers. A provider is enabled if its client ID is set.
+	GoogleClientID     string `env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET" secret:"true"`
+	GitHubClientID     string `env:"GITHUB_CLIENT_ID"`
+	GitHubClientSecret string `env:"GITHUB_CLIENT_SECRET" secret:"true"`
+	// Generic OpenID Connect provider, e.g. Keycloak or a local mock issuer
+	OIDCName         string `env:"OIDC_NAME"`
+	OIDCTitle        string `env:"OIDC_TITLE"`
+	OIDCIssuer       string `env:"OIDC_ISSUER"`
+	OIDCClientID     string `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET" secret:"true"`
+}
+
+// Default is the config without the file and the environment
+func Default() *Config {
 	return &Config{
-		AppEnv:        os.Getenv("APP_ENV"),
-		SiteUrl:       os.Getenv("SITE_URL"),
-		EmailFrom:     os.Getenv("EMAIL_FROM"),
-		DBHost:        os.Getenv("DB_HOST"),
-		DBPort:        os.Getenv("DB_PORT"),
-		DBUser:        os.Getenv("DB_USER"),
-		DBPassword:    os.Getenv("DB_PASSWORD"),
-		DBName:        os.Getenv("DB_NAME"),
-		DBSSLMode:     os.Getenv("DB_SSLMODE"),
-		RedisAddr:     os.Getenv("REDIS_ADDR"),
-		MailgunDomain: os.Getenv("MAILGUN_DOMAIN"),
-		MailgunApiKey: os.Getenv("MAILGUN_API_KEY"),
+		ServerAddr:            defaultServerAddr,
+		ServerReadTimeout:     defaultServerReadTimeout,
+		ServerWriteTimeout:    defaultServerWriteTimeout,
+		ServerIdleTimeout:     defaultServerIdleTimeout,
+		ServerShutdownTimeout: defaultServerShutdownTimeout,
+		Storage:               StoragePostgres,
+		SQLitePath:            defaultSQLitePath,
+		DBQueryTimeout:        defaultDBQueryTimeout,
+		DBMaxConns:            defaultDBMaxConns,
+		DBMaxConnLifetime:     defaultDBMaxConnLifetime,
+		TracingSampleRatio:    1,
+	}
+}
+
+// LoadConfig reads Default, the YAML file of CONFIG_FILE if it is set and the environment.
+// Only YAML is supported for the file. An empty environment variable or an empty value of the file
+// is ignored and keeps the default, so docker compose can pass "X=${X}" for an unset X.
+// A value that cannot be parsed is an error, call Validate for the required fields.
+func LoadConfig() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	var errs []error
+	fields := cfg.fields()
+	for key, value := range values {
+		field, ok := fields[strings.ToUpper(key)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown key %q", key))
+			continue
+		}
+		if value == nil || value == "" {
+			continue
+		}
+		if err := setField(field, fmt.Sprint(value)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (cfg *Config) loadEnv() error {
+	var errs []error
+	for env, field := range cfg.fields() {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("config env: %w", err)
+	}
+	return nil
+}
+
+// fields returns the settable fields of cfg by the name of the environment variable
+func (cfg *Config) fields() map[string]reflect.Value {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	fields := make(map[string]reflect.Value, t.NumField())
+	for i := range t.NumField() {
+		if env := t.Field(i).Tag.Get("env"); env != "" {
+			fields[env] = v.Field(i)
+		}
+	}
+	return fields
+}
+
+// setField parses value by the type of the field, e.g. "5s" for time.Duration or "true" for bool
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", value)
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected e.g. 5s or 500ms", value)
+		}
+		field.SetInt(int64(d))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate checks the fields required by the storage and the enabled features and the ranges of the values.
+// main calls it on start, so a missing variable stops the server instead of failing the first request.
+func (cfg *Config) Validate() error {
+	var errs []error
+	required := func(value, env string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", env))
+		}
+	}
+
+	required(cfg.SiteUrl, "SITE_URL")
+	required(cfg.ServerAddr, "SERVER_ADDR")
+	if cfg.MailEnabled() {
+		required(cfg.EmailFrom, "EMAIL_FROM")
+		required(cfg.MailgunDomain, "MAILGUN_DOMAIN")
+		required(cfg.MailgunApiKey, "MAILGUN_API_KEY")
+	}
+
+	switch cfg.Storage {
+	case StoragePostgres:
+		required(cfg.DBHost, "DB_HOST")
+		required(cfg.DBPort, "DB_PORT")
+		required(cfg.DBUser, "DB_USER")
+		required(cfg.DBName, "DB_NAME")
+		required(cfg.RedisAddr, "REDIS_ADDR")
+		if cfg.DBMaxConns < 1 {
+			errs = append(errs, fmt.Errorf("DB_MAX_CONNS must be at least 1, got %d", cfg.DBMaxConns))
+		}
+	case StorageSQLite:
+		required(cfg.SQLitePath, "SQLITE_PATH")
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE must be %q or %q, got %q", StoragePostgres, StorageSQLite, cfg.Storage))
+	}
+
+	for env, d := range map[string]time.Duration{
+		"SERVER_READ_TIMEOUT":     cfg.ServerReadTimeout,
+		"SERVER_WRITE_TIMEOUT":    cfg.ServerWriteTimeout,
+		"SERVER_IDLE_TIMEOUT":     cfg.ServerIdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT": cfg.ServerShutdownTimeout,
+		"DB_QUERY_TIMEOUT":        cfg.DBQueryTimeout,
+		"DB_MAX_CONN_LIFETIME":    cfg.DBMaxConnLifetime,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", env, d))
+		}
+	}
+	if cfg.RedisDB < 0 {
+		errs = append(errs, fmt.Errorf("REDIS_DB must not be negative, got %d", cfg.RedisDB))
+	}
+
+	switch cfg.TracingExporter {
+	case "", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf(`TRACING_EXPORTER must be empty, "otlp" or "stdout", got %q`, cfg.TracingExporter))
+	}
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be from 0 to 1, got %g", cfg.TracingSampleRatio))
+	}
+
+	if _, err := cfg.GetTrustedProxies(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if cfg.GoogleClientID != "" {
+		required(cfg.GoogleClientSecret, "GOOGLE_CLIENT_SECRET")
+	}
+	if cfg.GitHubClientID != "" {
+		required(cfg.GitHubClientSecret, "GITHUB_CLIENT_SECRET")
+	}
+	if cfg.OIDCClientID != "" {
+		required(cfg.OIDCIssuer, "OIDC_ISSUER")
+		required(cfg.OIDCName, "OIDC_NAME")
+	}
+
+	return errors.Join(errs...)
+}
+
+// MailEnabled reports whether the emails are sent by Mailgun. The mail is optional with StorageSQLite:
+// without MAILGUN_DOMAIN and MAILGUN_API_KEY the links of the emails are written to the log.
+func (cfg *Config) MailEnabled() bool {
+	return cfg.Storage != StorageSQLite || cfg.MailgunDomain != "" || cfg.MailgunApiKey != ""
+}
+
+// LogValue implements slog.LogValuer: the secrets are replaced by "[REDACTED]", an empty secret stays empty
+// to show that it is not set.
+func (cfg *Config) LogValue() slog.Value {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		value := v.Field(i).Interface()
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = "[REDACTED]"
+		}
+		attrs = append(attrs, slog.Any(field.Name, value))
 	}
+	return slog.GroupValue(attrs...)
 }
 
 func (cfg *Config) GetPostgresDSN() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBSSLMode)
 }
+
+// TrustedProxies parsed, a single address is the prefix of one address
+func (cfg *Config) GetTrustedProxies() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, value := range strings.Split(cfg.TrustedProxies, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: invalid address %q", value)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: invalid CIDR %q", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Callback URL registered in the OAuth application of the provider
+func (cfg *Config) GetOAuthRedirectURL(provider string) string {
+	return fmt.Sprintf("%s/oauth/%s/callback", cfg.SiteUrl, provider)
+}
@@ -0,0 +1,169 @@
+package dashboard
+
+import (
+	"context"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/metrics"
+)
+
+// The types of Event
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskDeleted   = "task.deleted"
+	EventRecordStarted = "record.started"
+	EventRecordStopped = "record.stopped"
+	EventRecordUpdated = "record.updated"
+	EventRecordDeleted = "record.deleted"
+)
+
+var EventTypes = []string{
+	EventTaskCreated, EventTaskUpdated, EventTaskDeleted,
+	EventRecordStarted, EventRecordStopped, EventRecordUpdated, EventRecordDeleted,
+}
+
+// Event is a change of a task or a record, published by DashboardRepositoryEvents
+type Event struct {
+	Type   string
+	UserID int
+	// The task of the task events and the task of the record of the record events.
+	// For the deleted ones, as before the deletion.
+	Task *Task
+	// Nil for the task events
+	Record     *Record
+	OccurredAt time.Time
+}
+
+// EventPublisher gets the events after the changes are saved. The errors are logged by the publisher,
+// a change is not rolled back if its event is lost.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// DashboardRepositoryEvents wraps a repository and publishes the events of the tasks and the records
+// changed through it: by the handlers, the import and the recurring records job.
+// It also counts the created records in metrics.RecordsCreated.
+//
+// A record created with the end, e.g. by the form, is record.started and record.stopped.
+// An update that sets the end of a record in progress is record.stopped, other updates are record.updated.
+// The records deleted with their task have no events, only task.deleted.
+type DashboardRepositoryEvents struct {
+	DashboardRepository
+	publisher EventPublisher
+}
+
+func NewDashboardRepositoryEvents(repo DashboardRepository, publisher EventPublisher) *DashboardRepositoryEvents {
+	return &DashboardRepositoryEvents{DashboardRepository: repo, publisher: publisher}
+}
+
+func (r *DashboardRepositoryEvents) CreateTask(ctx context.Context, task *Task) (int, error) {
+	id, err := r.DashboardRepository.CreateTask(ctx, task)
+	if err != nil {
+		return id, err
+	}
+	r.publishTask(ctx, EventTaskCreated, id)
+	return id, nil
+}
+
+func (r *DashboardRepositoryEvents) UpdateTask(ctx context.Context, task *Task) error {
+	if err := r.DashboardRepository.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+	r.publishTask(ctx, EventTaskUpdated, task.ID)
+	return nil
+}
+
+func (r *DashboardRepositoryEvents) DeleteTask(ctx context.Context, id int) error {
+	task, taskErr := r.DashboardRepository.TaskByID(ctx, id)
+	if err := r.DashboardRepository.DeleteTask(ctx, id); err != nil {
+		return err
+	}
+	if taskErr != nil {
+		utils.Logger(ctx).Warn("DashboardRepositoryEvents DeleteTask TaskByID", "taskID", id, "err", taskErr)
+		return nil
+	}
+	r.publish(ctx, Event{Type: EventTaskDeleted, UserID: task.UserID, Task: task})
+	return nil
+}
+
+func (r *DashboardRepositoryEvents) CreateRecord(ctx context.Context, record *Record) (int, error) {
+	id, err := r.DashboardRepository.CreateRecord(ctx, record)
+	if err != nil {
+		return id, err
+	}
+	metrics.RecordsCreated.Inc()
+	created := r.recordByID(ctx, id)
+	if created == nil {
+		return id, nil
+	}
+	r.publish(ctx, Event{Type: EventRecordStarted, UserID: created.Task.UserID, Task: created.Task, Record: created})
+	if created.TimeEnd != nil {
+		r.publish(ctx, Event{Type: EventRecordStopped, UserID: created.Task.UserID, Task: created.Task, Record: created})
+	}
+	return id, nil
+}
+
+func (r *DashboardRepositoryEvents) UpdateRecord(ctx context.Context, record *Record) error {
+	before, beforeErr := r.DashboardRepository.RecordByIDWithTask(ctx, record.ID)
+	if err := r.DashboardRepository.UpdateRecord(ctx, record); err != nil {
+		return err
+	}
+	updated := r.recordByID(ctx, record.ID)
+	if updated == nil {
+		return nil
+	}
+	eventType := EventRecordUpdated
+	if beforeErr == nil && before.TimeEnd == nil && updated.TimeEnd != nil {
+		eventType = EventRecordStopped
+	}
+	r.publish(ctx, Event{Type: eventType, UserID: updated.Task.UserID, Task: updated.Task, Record: updated})
+	return nil
+}
+
+func (r *DashboardRepositoryEvents) DeleteRecord(ctx context.Context, recordID int) error {
+	record, recordErr := r.DashboardRepository.RecordByIDWithTask(ctx, recordID)
+	if err := r.DashboardRepository.DeleteRecord(ctx, recordID); err != nil {
+		return err
+	}
+	if recordErr != nil {
+		utils.Logger(ctx).Warn("DashboardRepositoryEvents DeleteRecord RecordByIDWithTask", "recordID", recordID, "err", recordErr)
+		return nil
+	}
+	r.publish(ctx, Event{Type: EventRecordDeleted, UserID: record.Task.UserID, Task: record.Task, Record: record})
+	return nil
+}
+
+// The saved task with all fields, the handlers pass only the changed ones
+func (r *DashboardRepositoryEvents) publishTask(ctx context.Context, eventType string, id int) {
+	task, err := r.DashboardRepository.TaskByID(ctx, id)
+	if err != nil {
+		utils.Logger(ctx).Warn("DashboardRepositoryEvents TaskByID", "type", eventType, "taskID", id, "err", err)
+		return
+	}
+	r.publish(ctx, Event{Type: eventType, UserID: task.UserID, Task: task})
+}
+
+func (r *DashboardRepositoryEvents) recordByID(ctx context.Context, id int) *Record {
+	record, err := r.DashboardRepository.RecordByIDWithTask(ctx, id)
+	if err != nil {
+		utils.Logger(ctx).Warn("DashboardRepositoryEvents RecordByIDWithTask", "recordID", id, "err", err)
+		return nil
+	}
+	return record
+}
+
+// The change is saved, so the event is published even if the client of the request has gone
+func (r *DashboardRepositoryEvents) publish(ctx context.Context, event Event) {
+	event.OccurredAt = time.Now().UTC()
+	r.publisher.Publish(context.WithoutCancel(ctx), event)
+}
+
+// EventPublishers publishes every event to all of the publishers, e.g. the webhooks and the live updates
+type EventPublishers []EventPublisher
+
+func (p EventPublishers) Publish(ctx context.Context, event Event) {
+	for _, publisher := range p {
+		publisher.Publish(ctx, event)
+	}
+}
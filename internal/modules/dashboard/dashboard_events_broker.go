@@ -0,0 +1,53 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// The events of the live updates, the names match the HX-Trigger events load-records and load-tasks
+const (
+	LiveEventRecords = "records"
+	LiveEventTasks   = "tasks"
+)
+
+var ErrEventsBrokerClosed = errors.New("events broker is closed")
+
+// LiveEvent is an Event sent to the open dashboards of the user, only the IDs, the dashboard reloads the lists
+type LiveEvent struct {
+	Type     string `json:"type"`
+	TaskID   int    `json:"task_id,omitempty"`
+	RecordID int    `json:"record_id,omitempty"`
+}
+
+// Name is the event of Server-Sent Events, "records" or "tasks"
+func (e LiveEvent) Name() string {
+	if strings.HasPrefix(e.Type, "task.") {
+		return LiveEventTasks
+	}
+	return LiveEventRecords
+}
+
+func newLiveEvent(event Event) LiveEvent {
+	liveEvent := LiveEvent{Type: event.Type}
+	if event.Task != nil {
+		liveEvent.TaskID = event.Task.ID
+	}
+	if event.Record != nil {
+		liveEvent.RecordID = event.Record.ID
+	}
+	return liveEvent
+}
+
+// EventsBroker delivers the events of DashboardRepositoryEvents to the subscriptions of the same user,
+// i.e. GET /events of the dashboards in other tabs and on other devices.
+// The events are not stored, a subscriber gets only the events published after Subscribe.
+type EventsBroker interface {
+	EventPublisher
+	// Subscribe returns the events of the user, the channel is closed when ctx is done or the broker stops.
+	// ErrEventsBrokerClosed after the broker stopped.
+	Subscribe(ctx context.Context, userID int) (<-chan LiveEvent, error)
+	// Run receives the events until ctx is done, then closes all subscriptions
+	Run(ctx context.Context)
+}
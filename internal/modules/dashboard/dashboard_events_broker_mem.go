@@ -0,0 +1,88 @@
+package dashboard
+
+import (
+	"context"
+	"sync"
+	"time-tracker/internal/utils"
+)
+
+// The events of a slow subscriber over the buffer are dropped, a reload of the lists catches up anyway
+const liveEventsBuffer = 16
+
+// EventsBrokerMem delivers the events within the process, enough for a single instance, e.g. with SQLite.
+// EventsBrokerRedis uses it for the subscriptions of its instance.
+type EventsBrokerMem struct {
+	mu            sync.Mutex
+	subscriptions map[int]map[chan LiveEvent]struct{}
+	closed        bool
+}
+
+func NewEventsBrokerMem() *EventsBrokerMem {
+	return &EventsBrokerMem{subscriptions: make(map[int]map[chan LiveEvent]struct{})}
+}
+
+func (b *EventsBrokerMem) Publish(ctx context.Context, event Event) {
+	b.deliver(ctx, event.UserID, newLiveEvent(event))
+}
+
+func (b *EventsBrokerMem) Subscribe(ctx context.Context, userID int) (<-chan LiveEvent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrEventsBrokerClosed
+	}
+	ch := make(chan LiveEvent, liveEventsBuffer)
+	if b.subscriptions[userID] == nil {
+		b.subscriptions[userID] = make(map[chan LiveEvent]struct{})
+	}
+	b.subscriptions[userID][ch] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(userID, ch)
+	}()
+	return ch, nil
+}
+
+// Nothing to receive within the process
+func (b *EventsBrokerMem) Run(ctx context.Context) {
+	<-ctx.Done()
+	b.close()
+}
+
+func (b *EventsBrokerMem) deliver(ctx context.Context, userID int, event LiveEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscriptions[userID] {
+		select {
+		case ch <- event:
+		default:
+			utils.Logger(ctx).Warn("EventsBrokerMem subscription is full, the event is dropped", "userID", userID, "type", event.Type)
+		}
+	}
+}
+
+func (b *EventsBrokerMem) unsubscribe(userID int, ch chan LiveEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscriptions[userID][ch]; !ok {
+		// Closed by close
+		return
+	}
+	delete(b.subscriptions[userID], ch)
+	if len(b.subscriptions[userID]) == 0 {
+		delete(b.subscriptions, userID)
+	}
+	close(ch)
+}
+
+func (b *EventsBrokerMem) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for userID, chs := range b.subscriptions {
+		for ch := range chs {
+			close(ch)
+		}
+		delete(b.subscriptions, userID)
+	}
+}
@@ -0,0 +1,75 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time-tracker/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const liveEventsChannelPrefix = "events:user:"
+
+// EventsBrokerRedis delivers the events to the subscriptions on all instances of the app through Redis pub/sub.
+// Every event is published to the channel of its user, e.g. "events:user:42". Each instance has
+// one pattern subscription to all of the channels and delivers the events to its own subscriptions.
+type EventsBrokerRedis struct {
+	client *redis.Client
+	local  *EventsBrokerMem
+}
+
+func NewEventsBrokerRedis(client *redis.Client) *EventsBrokerRedis {
+	return &EventsBrokerRedis{client: client, local: NewEventsBrokerMem()}
+}
+
+func (b *EventsBrokerRedis) Publish(ctx context.Context, event Event) {
+	data, err := json.Marshal(newLiveEvent(event))
+	if err != nil {
+		utils.Logger(ctx).Error("EventsBrokerRedis Publish json.Marshal", "type", event.Type, "err", err)
+		return
+	}
+	channel := liveEventsChannelPrefix + strconv.Itoa(event.UserID)
+	if err := b.client.Publish(ctx, channel, data).Err(); err != nil {
+		utils.Logger(ctx).Error("EventsBrokerRedis Publish", "type", event.Type, "userID", event.UserID, "err", err)
+	}
+}
+
+func (b *EventsBrokerRedis) Subscribe(ctx context.Context, userID int) (<-chan LiveEvent, error) {
+	return b.local.Subscribe(ctx, userID)
+}
+
+// Run keeps the pattern subscription, go-redis reconnects it after the errors of the connection.
+// The events published during a reconnection are lost.
+func (b *EventsBrokerRedis) Run(ctx context.Context) {
+	defer b.local.close()
+	pubsub := b.client.PSubscribe(ctx, liveEventsChannelPrefix+"*")
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			b.receive(ctx, message)
+		}
+	}
+}
+
+func (b *EventsBrokerRedis) receive(ctx context.Context, message *redis.Message) {
+	userID, err := strconv.Atoi(strings.TrimPrefix(message.Channel, liveEventsChannelPrefix))
+	if err != nil {
+		utils.Logger(ctx).Warn("EventsBrokerRedis unknown channel", "channel", message.Channel)
+		return
+	}
+	var event LiveEvent
+	if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+		utils.Logger(ctx).Warn("EventsBrokerRedis json.Unmarshal", "channel", message.Channel, "err", err)
+		return
+	}
+	b.local.deliver(ctx, userID, event)
+}
@@ -0,0 +1,56 @@
+//go:build integration
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=integration -run TestEventsBrokerRedis_Integration
+package dashboard
+
+import (
+	"context"
+	"testing"
+	"time"
+	"time-tracker/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Two brokers on the Redis of the config, like two instances of the app
+func TestEventsBrokerRedis_Integration(t *testing.T) {
+	TShort(t)
+	ctx := context.Background()
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, Password: cfg.RedisPassword, DB: cfg.RedisDB})
+	t.Cleanup(func() { client.Close() })
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis is not available: %v", err)
+	}
+
+	publisher := NewEventsBrokerRedis(client)
+	subscriber := NewEventsBrokerRedis(client)
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		subscriber.Run(runCtx)
+		close(done)
+	}()
+	userID := int(time.Now().UnixNano() % 1_000_000_000)
+	events, err := subscriber.Subscribe(ctx, userID)
+	require.NoError(t, err)
+
+	// The pattern subscription of Run is asynchronous, the events before it are lost
+	require.Eventually(t, func() bool {
+		publisher.Publish(ctx, Event{Type: EventTaskCreated, UserID: userID, Task: &Task{ID: 3}})
+		select {
+		case event := <-events:
+			return assert.Equal(t, LiveEvent{Type: EventTaskCreated, TaskID: 3}, event)
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stop()
+	<-done
+	_, ok := <-events
+	assert.False(t, ok)
+}
@@ -0,0 +1,147 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestEventsBroker.*
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveLiveEvent(t *testing.T, events <-chan LiveEvent) LiveEvent {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "the subscription is closed")
+		return event
+	case <-time.After(time.Second):
+		require.Fail(t, "no event")
+		return LiveEvent{}
+	}
+}
+
+func assertClosed(t *testing.T, events <-chan LiveEvent) {
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok, "the subscription is open")
+	case <-time.After(time.Second):
+		assert.Fail(t, "the subscription is open")
+	}
+}
+
+func TestEventsBrokerMem(t *testing.T) {
+	ctx := context.Background()
+	task := &Task{ID: 3, UserID: 7}
+
+	t.Run("Publish", func(t *testing.T) {
+		broker := NewEventsBrokerMem()
+		first, err := broker.Subscribe(ctx, 7)
+		require.NoError(t, err)
+		second, err := broker.Subscribe(ctx, 7)
+		require.NoError(t, err)
+		other, err := broker.Subscribe(ctx, 8)
+		require.NoError(t, err)
+
+		broker.Publish(ctx, Event{Type: EventRecordStarted, UserID: 7, Task: task, Record: &Record{ID: 5}})
+
+		want := LiveEvent{Type: EventRecordStarted, TaskID: 3, RecordID: 5}
+		assert.Equal(t, want, receiveLiveEvent(t, first))
+		assert.Equal(t, want, receiveLiveEvent(t, second))
+		assert.Empty(t, other)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		broker := NewEventsBrokerMem()
+		subscriptionCtx, cancel := context.WithCancel(ctx)
+		events, err := broker.Subscribe(subscriptionCtx, 7)
+		require.NoError(t, err)
+
+		cancel()
+
+		assertClosed(t, events)
+		broker.Publish(ctx, Event{Type: EventTaskCreated, UserID: 7, Task: task})
+		broker.mu.Lock()
+		assert.Empty(t, broker.subscriptions)
+		broker.mu.Unlock()
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		broker := NewEventsBrokerMem()
+		events, err := broker.Subscribe(ctx, 7)
+		require.NoError(t, err)
+
+		// Does not block on the full buffer
+		for i := 0; i < liveEventsBuffer+5; i++ {
+			broker.Publish(ctx, Event{Type: EventTaskUpdated, UserID: 7, Task: task})
+		}
+
+		assert.Len(t, events, liveEventsBuffer)
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		broker := NewEventsBrokerMem()
+		events, err := broker.Subscribe(ctx, 7)
+		require.NoError(t, err)
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			broker.Run(runCtx)
+			close(done)
+		}()
+
+		cancel()
+		<-done
+
+		assertClosed(t, events)
+		_, err = broker.Subscribe(ctx, 7)
+		assert.ErrorIs(t, err, ErrEventsBrokerClosed)
+	})
+}
+
+func TestLiveEvent_Name(t *testing.T) {
+	for _, eventType := range EventTypes {
+		name := LiveEvent{Type: eventType}.Name()
+		if eventType == EventTaskCreated || eventType == EventTaskUpdated || eventType == EventTaskDeleted {
+			assert.Equal(t, LiveEventTasks, name, eventType)
+		} else {
+			assert.Equal(t, LiveEventRecords, name, eventType)
+		}
+	}
+}
+
+func TestEventsBrokerRedis(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Publish", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		broker := NewEventsBrokerRedis(client)
+		data, err := json.Marshal(LiveEvent{Type: EventRecordStopped, TaskID: 3, RecordID: 5})
+		require.NoError(t, err)
+		mock.ExpectPublish("events:user:7", data).SetVal(1)
+
+		broker.Publish(ctx, Event{Type: EventRecordStopped, UserID: 7, Task: &Task{ID: 3}, Record: &Record{ID: 5}})
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Receive", func(t *testing.T) {
+		client, _ := redismock.NewClientMock()
+		broker := NewEventsBrokerRedis(client)
+		events, err := broker.Subscribe(ctx, 7)
+		require.NoError(t, err)
+
+		broker.receive(ctx, &redis.Message{Channel: "events:user:8", Payload: `{"type":"task.created","task_id":1}`})
+		broker.receive(ctx, &redis.Message{Channel: "events:user:x", Payload: `{"type":"task.created","task_id":2}`})
+		broker.receive(ctx, &redis.Message{Channel: "events:user:7", Payload: `not json`})
+		broker.receive(ctx, &redis.Message{Channel: "events:user:7", Payload: `{"type":"task.created","task_id":3}`})
+
+		assert.Equal(t, LiveEvent{Type: EventTaskCreated, TaskID: 3}, receiveLiveEvent(t, events))
+		assert.Empty(t, events)
+	})
+}
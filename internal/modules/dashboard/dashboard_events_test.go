@@ -0,0 +1,120 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardRepositoryEvents.*
+package dashboard
+
+import (
+	"context"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	events []Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event Event) {
+	p.events = append(p.events, event)
+}
+
+func (p *recordingPublisher) types() (types []string) {
+	for _, event := range p.events {
+		types = append(types, event.Type)
+	}
+	return
+}
+
+func TestDashboardRepositoryEvents(t *testing.T) {
+	ctx := context.Background()
+	at := func(hour int) *time.Time {
+		tm := time.Date(2024, 12, 2, hour, 0, 0, 0, time.UTC)
+		return &tm
+	}
+	newRepo := func() (*DashboardRepositoryEvents, *recordingPublisher) {
+		publisher := &recordingPublisher{}
+		return NewDashboardRepositoryEvents(NewDashboardRepositoryMem(), publisher), publisher
+	}
+
+	t.Run("Tasks", func(t *testing.T) {
+		repo, publisher := newRepo()
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: 7, Title: "Coding", Color: "#FF0000"})
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateTask(ctx, &Task{ID: taskID, Title: "Code review", Color: "#00FF00"}))
+		require.NoError(t, repo.DeleteTask(ctx, taskID))
+
+		assert.Equal(t, []string{EventTaskCreated, EventTaskUpdated, EventTaskDeleted}, publisher.types())
+		for _, event := range publisher.events {
+			assert.Equal(t, 7, event.UserID)
+			assert.Equal(t, taskID, event.Task.ID)
+			assert.Nil(t, event.Record)
+			assert.False(t, event.OccurredAt.IsZero())
+		}
+		// The saved task, not the argument of UpdateTask
+		assert.Equal(t, 7, publisher.events[1].Task.UserID)
+		assert.Equal(t, "Code review", publisher.events[2].Task.Title)
+	})
+
+	t.Run("Records", func(t *testing.T) {
+		repo, publisher := newRepo()
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: 7, Title: "Coding"})
+		require.NoError(t, err)
+		publisher.events = nil
+
+		// Timer started and stopped
+		recordsCreated := testutil.ToFloat64(metrics.RecordsCreated)
+		inProgressID, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: *at(9)})
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateRecord(ctx, &Record{ID: inProgressID, TaskID: taskID, TimeStart: *at(9), TimeEnd: at(10)}))
+		require.NoError(t, repo.UpdateRecord(ctx, &Record{ID: inProgressID, TaskID: taskID, TimeStart: *at(9), TimeEnd: at(11), Comment: "longer"}))
+		// A finished record of the form
+		finishedID, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: *at(12), TimeEnd: at(13)})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteRecord(ctx, finishedID))
+
+		assert.Equal(t, []string{
+			EventRecordStarted, EventRecordStopped, EventRecordUpdated,
+			EventRecordStarted, EventRecordStopped, EventRecordDeleted,
+		}, publisher.types())
+		for _, event := range publisher.events {
+			assert.Equal(t, 7, event.UserID)
+			assert.Equal(t, "Coding", event.Task.Title)
+		}
+		assert.Nil(t, publisher.events[0].Record.TimeEnd)
+		assert.Equal(t, at(10), publisher.events[1].Record.TimeEnd)
+		assert.Equal(t, "longer", publisher.events[2].Record.Comment)
+		assert.Equal(t, finishedID, publisher.events[5].Record.ID)
+		assert.Equal(t, recordsCreated+2, testutil.ToFloat64(metrics.RecordsCreated))
+	})
+
+	t.Run("ErrorsHaveNoEvents", func(t *testing.T) {
+		repo, publisher := newRepo()
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: 7, Title: "Coding"})
+		require.NoError(t, err)
+		_, err = repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: *at(9), TimeEnd: at(11)})
+		require.NoError(t, err)
+		publisher.events = nil
+		recordsCreated := testutil.ToFloat64(metrics.RecordsCreated)
+
+		_, err = repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: *at(10), TimeEnd: at(12)})
+		assert.ErrorIs(t, err, ErrRecordsOverlap)
+		assert.Equal(t, recordsCreated, testutil.ToFloat64(metrics.RecordsCreated))
+		assert.ErrorIs(t, repo.DeleteRecord(ctx, 1000), utils.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteTask(ctx, 1000), utils.ErrNotFound)
+		assert.Empty(t, publisher.events)
+	})
+}
+
+func TestEventPublishers(t *testing.T) {
+	first, second := &recordingPublisher{}, &recordingPublisher{}
+
+	EventPublishers{first, second}.Publish(context.Background(), Event{Type: EventTaskCreated, UserID: 7})
+
+	assert.Equal(t, []string{EventTaskCreated}, first.types())
+	assert.Equal(t, []string{EventTaskCreated}, second.types())
+}
@@ -35,13 +35,21 @@ func (h *DashboardHandlers) HandleDashboard(w http.ResponseWriter, r *http.Reque
 		EndInterval:   endInterval,
 	}
 
-	dailyRecords := h.repo.DailyRecords(filterRecords, nowWithTimezone)
+	dailyRecords, err := h.dailyRecordsWithPending(r.Context(), filterRecords, nowWithTimezone)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 
-	tasks := h.repo.Tasks(user.ID, "")
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 
 	previousWeek := utils.FormatISOWeek(startInterval.AddDate(0, 0, -7), user.IsWeekStartMonday)
 	nextWeek := utils.FormatISOWeek(endInterval.AddDate(0, 0, 7), user.IsWeekStartMonday)
-	utils.RenderTemplate(w, []string{"dashboard/dashboard", "dashboard/task_list", "dashboard/record_list", "dashboard/record_list_navigation"}, utils.TplData{
+	utils.RenderTemplate(w, "dashboard/dashboard", utils.TplData{
 		"Title":           "Tasks & Records Dashboard",
 		"Tasks":           tasks,
 		"DailyRecords":    dailyRecords,
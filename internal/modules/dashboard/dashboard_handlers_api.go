@@ -0,0 +1,330 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+// The JSON API of the tt command-line client, under /api/v1/.
+// The user is authenticated by users.PersonalTokenMiddleware.
+// The times are in the time zone of the user with the offset, e.g. "2024-12-02T10:00:00+03:00",
+// the durations are in seconds.
+
+type apiTask struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	IsCompleted bool   `json:"is_completed"`
+}
+
+type apiRecord struct {
+	ID        int        `json:"id"`
+	Task      apiTask    `json:"task"`
+	TimeStart time.Time  `json:"time_start"`
+	TimeEnd   *time.Time `json:"time_end"`
+	Comment   string     `json:"comment"`
+	// Until now for the record in progress. In the log, the part of the day.
+	Duration int64 `json:"duration"`
+}
+
+type apiStatus struct {
+	Record *apiRecord `json:"record"`
+}
+
+type apiDay struct {
+	Date     string      `json:"date"`
+	Records  []apiRecord `json:"records"`
+	Duration int64       `json:"duration"`
+}
+
+type apiLog struct {
+	Week     string   `json:"week"`
+	Days     []apiDay `json:"days"`
+	Duration int64    `json:"duration"`
+}
+
+type apiReportRow struct {
+	Task     apiTask `json:"task"`
+	Duration int64   `json:"duration"`
+}
+
+type apiReport struct {
+	Month    string         `json:"month"`
+	Tasks    []apiReportRow `json:"tasks"`
+	Duration int64          `json:"duration"`
+}
+
+type apiStartRequest struct {
+	TaskID  int    `json:"task_id"`
+	Comment string `json:"comment"`
+}
+
+// GET /api/v1/tasks?completed=all|completed
+// The active tasks by default, in the order of the dashboard.
+func (h *DashboardHandlers) HandleAPITasks(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, r.URL.Query().Get("completed"))
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	apiTasks := make([]apiTask, 0, len(tasks))
+	for _, task := range tasks {
+		apiTasks = append(apiTasks, newAPITask(task))
+	}
+	utils.RenderJSON(w, http.StatusOK, apiTasks)
+}
+
+// GET /api/v1/status
+// The record in progress or {"record": null}
+func (h *DashboardHandlers) HandleAPIStatus(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	record, err := h.recordInProgress(r, user)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	status := apiStatus{}
+	if record != nil {
+		nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+		apiRecord := newAPIRecord(record, user.TimeZone, nowWithTimezone)
+		status.Record = &apiRecord
+	}
+	utils.RenderJSON(w, http.StatusOK, status)
+}
+
+// POST /api/v1/records/start {"task_id": 1, "comment": ""}
+// Starts a record now. 409 if another record is in progress, the client stops it first.
+func (h *DashboardHandlers) HandleAPIRecordsStart(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	var request apiStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.TaskID == 0 {
+		renderAPIError(w, http.StatusBadRequest, "The request must be JSON with task_id")
+		return
+	}
+	if len(request.Comment) > 10000 {
+		renderAPIError(w, http.StatusBadRequest, "The comment must be at most 10000 characters")
+		return
+	}
+
+	task, err := h.repo.TaskByID(r.Context(), request.TaskID)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	if task.UserID != user.ID {
+		// Like a task that does not exist
+		utils.RenderErrorJSON(w, r, fmt.Errorf("task %d: %w", task.ID, utils.ErrNotFound))
+		return
+	}
+
+	running, err := h.recordInProgress(r, user)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	if running != nil {
+		renderAPIError(w, http.StatusConflict, "You are already doing task: "+recordToText(running, user))
+		return
+	}
+
+	// Minutes, like the form of the dashboard
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	timeStart := nowWithTimezone.Truncate(time.Minute)
+	overlapping, err := h.repo.RecordsWithTasks(r.Context(), FilterRecords{
+		UserID:        user.ID,
+		StartInterval: timeStart,
+		EndInterval:   nowWithTimezone,
+	})
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	if len(overlapping) > 0 {
+		renderAPIError(w, http.StatusConflict, "The time overlaps with the record: "+recordToText(overlapping[0], user))
+		return
+	}
+
+	id, err := h.repo.CreateRecord(r.Context(), &Record{
+		TaskID:    task.ID,
+		TimeStart: timeStart,
+		Comment:   request.Comment,
+	})
+	if errors.Is(err, ErrRecordInProgressExists) || errors.Is(err, ErrRecordsOverlap) {
+		// A concurrent request started a record after the checks
+		renderAPIError(w, http.StatusConflict, "You are already doing another task")
+		return
+	}
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+
+	record, err := h.repo.RecordByIDWithTask(r.Context(), id)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusCreated, newAPIRecord(record, user.TimeZone, nowWithTimezone))
+}
+
+// POST /api/v1/records/stop
+// Stops the record in progress now. 404 if there is none.
+func (h *DashboardHandlers) HandleAPIRecordsStop(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	record, err := h.recordInProgress(r, user)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	if record == nil {
+		renderAPIError(w, http.StatusNotFound, "No record in progress")
+		return
+	}
+
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	timeEnd := nowWithTimezone.Truncate(time.Minute)
+	if !timeEnd.After(record.TimeStart) {
+		renderAPIError(w, http.StatusConflict, "The record started less than a minute ago, delete it on the dashboard instead")
+		return
+	}
+	record.TimeEnd = &timeEnd
+	err = h.repo.UpdateRecord(r.Context(), record)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, newAPIRecord(record, user.TimeZone, nowWithTimezone))
+}
+
+// GET /api/v1/log?week=2024-W49
+// The records of the week by day, the current week by default, like the dashboard.
+func (h *DashboardHandlers) HandleAPILog(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	startInterval, endInterval := getWeekInterval(r.URL.Query().Get("week"), nowWithTimezone, user.IsWeekStartMonday)
+	dailyRecords, err := h.repo.DailyRecords(r.Context(), FilterRecords{
+		UserID:        user.ID,
+		StartInterval: startInterval,
+		EndInterval:   endInterval,
+	}, nowWithTimezone)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+
+	log := apiLog{
+		Week: utils.FormatISOWeek(startInterval, user.IsWeekStartMonday),
+		Days: make([]apiDay, 0, len(dailyRecords)),
+	}
+	for _, daily := range dailyRecords {
+		day := apiDay{Date: daily.Day.Format("2006-01-02"), Records: make([]apiRecord, 0, len(daily.Records))}
+		for _, record := range daily.Records {
+			apiRecord := newAPIRecord(&record, user.TimeZone, nowWithTimezone)
+			apiRecord.Duration = int64(record.Duration.Seconds())
+			day.Records = append(day.Records, apiRecord)
+			day.Duration += apiRecord.Duration
+		}
+		log.Days = append(log.Days, day)
+		log.Duration += day.Duration
+	}
+	utils.RenderJSON(w, http.StatusOK, log)
+}
+
+// GET /api/v1/report?month=2024-12
+// The durations of the tasks in the month, the current month by default, like the reports page.
+func (h *DashboardHandlers) HandleAPIReport(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	startInterval, endInterval := getMonthInterval(r.URL.Query().Get("month"), nowWithTimezone)
+	reportData, err := h.repo.Reports(r.Context(), user.ID, startInterval, endInterval, nowWithTimezone)
+	if err != nil {
+		utils.RenderErrorJSON(w, r, err)
+		return
+	}
+
+	report := apiReport{
+		Month:    startInterval.Format("2006-01"),
+		Tasks:    make([]apiReportRow, 0, len(reportData.ReportRows)),
+		Duration: int64(reportData.TotalDuration.Seconds()),
+	}
+	for _, row := range reportData.ReportRows {
+		report.Tasks = append(report.Tasks, apiReportRow{
+			Task:     newAPITask(row.Task),
+			Duration: int64(row.TotalDuration.Seconds()),
+		})
+	}
+	utils.RenderJSON(w, http.StatusOK, report)
+}
+
+// nil if the user does not do any task now
+func (h *DashboardHandlers) recordInProgress(r *http.Request, user *users.User) (*Record, error) {
+	records, err := h.repo.RecordsWithTasks(r.Context(), FilterRecords{
+		UserID:     user.ID,
+		InProgress: true,
+	})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return records[0], nil
+}
+
+func renderAPIError(w http.ResponseWriter, status int, message string) {
+	utils.RenderJSON(w, status, map[string]string{"error": message})
+}
+
+func newAPITask(task *Task) apiTask {
+	if task == nil {
+		return apiTask{}
+	}
+	return apiTask{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Color:       task.Color,
+		IsCompleted: task.IsCompleted,
+	}
+}
+
+// The records are in the wall clock time of the user, nowWithTimezone too
+func newAPIRecord(record *Record, timezone string, nowWithTimezone time.Time) apiRecord {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	inZone := func(t time.Time) time.Time {
+		moment, _ := utils.FromWallClock(t, loc.String())
+		return moment.In(loc)
+	}
+	timeEnd := nowWithTimezone
+	if record.TimeEnd != nil {
+		timeEnd = *record.TimeEnd
+	}
+	apiRecord := apiRecord{
+		ID:        record.ID,
+		Task:      newAPITask(record.Task),
+		TimeStart: inZone(record.TimeStart),
+		Comment:   record.Comment,
+		Duration:  int64(timeEnd.Sub(record.TimeStart).Seconds()),
+	}
+	if record.TimeEnd != nil {
+		end := inZone(*record.TimeEnd)
+		apiRecord.TimeEnd = &end
+	}
+	return apiRecord
+}
+
+// recordToString without the link for the messages of the API
+func recordToText(record *Record, user *users.User) string {
+	text := record.Task.Title + " " + utils.FormatTimeRange(record.TimeStart, record.TimeEnd, user.TimeZone)
+	if record.Comment != "" {
+		text += " " + record.Comment
+	}
+	return text
+}
@@ -0,0 +1,222 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_HandleAPI.*
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPITestHandler(t *testing.T) (*DashboardHandlers, *DashboardRepositoryMem, *users.User, int) {
+	ctx := context.Background()
+	user := &users.User{ID: 1, TimeZone: "Europe/Moscow", IsWeekStartMonday: true}
+	repo := NewDashboardRepositoryMem()
+	taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: "Coding", Color: "#FF0000"})
+	require.NoError(t, err)
+	_, err = repo.CreateTask(ctx, &Task{UserID: user.ID, Title: "Old", IsCompleted: true})
+	require.NoError(t, err)
+	_, err = repo.CreateTask(ctx, &Task{UserID: 2, Title: "Other"})
+	require.NoError(t, err)
+	return NewDashboardHandler(repo), repo, user, taskID
+}
+
+func newAPIRequest(method string, target string, body string, user *users.User) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(context.WithValue(req.Context(), users.ContextUserKey, user))
+}
+
+func decodeAPIResponse(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), v))
+}
+
+func TestDashboardHandlers_HandleAPITasks(t *testing.T) {
+	handler, _, user, _ := newAPITestHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.HandleAPITasks(w, newAPIRequest(http.MethodGet, "/api/v1/tasks", "", user))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tasks []apiTask
+	decodeAPIResponse(t, w, &tasks)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Coding", tasks[0].Title)
+	assert.Equal(t, "#FF0000", tasks[0].Color)
+
+	w = httptest.NewRecorder()
+	handler.HandleAPITasks(w, newAPIRequest(http.MethodGet, "/api/v1/tasks?completed=all", "", user))
+
+	decodeAPIResponse(t, w, &tasks)
+	assert.Len(t, tasks, 2)
+}
+
+func TestDashboardHandlers_HandleAPIRecords(t *testing.T) {
+	ctx := context.Background()
+	handler, repo, user, taskID := newAPITestHandler(t)
+	status := func(t *testing.T) apiStatus {
+		w := httptest.NewRecorder()
+		handler.HandleAPIStatus(w, newAPIRequest(http.MethodGet, "/api/v1/status", "", user))
+		require.Equal(t, http.StatusOK, w.Code)
+		var status apiStatus
+		decodeAPIResponse(t, w, &status)
+		return status
+	}
+
+	t.Run("NothingInProgress", func(t *testing.T) {
+		assert.Nil(t, status(t).Record)
+
+		w := httptest.NewRecorder()
+		handler.HandleAPIRecordsStop(w, newAPIRequest(http.MethodPost, "/api/v1/records/stop", "", user))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"No record in progress"}`, w.Body.String())
+	})
+
+	t.Run("StartInvalid", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			status int
+		}{
+			{"NotJSON", "task_id=1", http.StatusBadRequest},
+			{"NoTask", `{"comment":"x"}`, http.StatusBadRequest},
+			{"UnknownTask", `{"task_id":100}`, http.StatusNotFound},
+			{"OtherUserTask", `{"task_id":3}`, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				handler.HandleAPIRecordsStart(w, newAPIRequest(http.MethodPost, "/api/v1/records/start", tt.body, user))
+				assert.Equal(t, tt.status, w.Code)
+				var response map[string]string
+				decodeAPIResponse(t, w, &response)
+				assert.NotEmpty(t, response["error"])
+			})
+		}
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleAPIRecordsStart(w, newAPIRequest(http.MethodPost, "/api/v1/records/start", `{"task_id":1,"comment":"review"}`, user))
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		var record apiRecord
+		decodeAPIResponse(t, w, &record)
+		assert.Equal(t, taskID, record.Task.ID)
+		assert.Equal(t, "review", record.Comment)
+		assert.Nil(t, record.TimeEnd)
+		// The moment of now in the time zone of the user
+		assert.WithinDuration(t, time.Now(), record.TimeStart, time.Minute)
+		_, offset := record.TimeStart.Zone()
+		assert.Equal(t, 3*60*60, offset)
+
+		current := status(t).Record
+		require.NotNil(t, current)
+		assert.Equal(t, record.ID, current.ID)
+
+		// Another record is in progress
+		w = httptest.NewRecorder()
+		handler.HandleAPIRecordsStart(w, newAPIRequest(http.MethodPost, "/api/v1/records/start", `{"task_id":1}`, user))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "You are already doing task: Coding")
+
+		// Started less than a minute ago
+		w = httptest.NewRecorder()
+		handler.HandleAPIRecordsStop(w, newAPIRequest(http.MethodPost, "/api/v1/records/stop", "", user))
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		require.NoError(t, repo.DeleteRecord(ctx, record.ID))
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+		id, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: nowWithTimezone.Add(-time.Hour).Truncate(time.Minute)})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		handler.HandleAPIRecordsStop(w, newAPIRequest(http.MethodPost, "/api/v1/records/stop", "", user))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var record apiRecord
+		decodeAPIResponse(t, w, &record)
+		assert.Equal(t, id, record.ID)
+		require.NotNil(t, record.TimeEnd)
+		assert.InDelta(t, time.Hour.Seconds(), float64(record.Duration), 60)
+		assert.Nil(t, status(t).Record)
+		saved, err := repo.RecordByIDWithTask(ctx, id)
+		require.NoError(t, err)
+		assert.NotNil(t, saved.TimeEnd)
+	})
+}
+
+func TestDashboardHandlers_HandleAPILog(t *testing.T) {
+	ctx := context.Background()
+	handler, repo, user, taskID := newAPITestHandler(t)
+	// 2024-12-03 10:00-11:30 of the wall clock of Moscow
+	timeEnd := time.Date(2024, 12, 3, 11, 30, 0, 0, time.UTC)
+	_, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC), TimeEnd: &timeEnd, Comment: "review"})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.HandleAPILog(w, newAPIRequest(http.MethodGet, "/api/v1/log?week=2024-W49", "", user))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var log map[string]any
+	decodeAPIResponse(t, w, &log)
+	assert.Equal(t, "2024-W49", log["week"])
+	assert.Equal(t, 5400.0, log["duration"])
+	days := log["days"].([]any)
+	require.Len(t, days, 7)
+	assert.Equal(t, "2024-12-02", days[0].(map[string]any)["date"])
+	assert.Empty(t, days[0].(map[string]any)["records"])
+	day := days[1].(map[string]any)
+	assert.Equal(t, "2024-12-03", day["date"])
+	assert.Equal(t, 5400.0, day["duration"])
+	assert.Equal(t, []any{map[string]any{
+		"id":         1.0,
+		"task":       map[string]any{"id": 1.0, "title": "Coding", "description": "", "color": "#FF0000", "is_completed": false},
+		"time_start": "2024-12-03T10:00:00+03:00",
+		"time_end":   "2024-12-03T11:30:00+03:00",
+		"comment":    "review",
+		"duration":   5400.0,
+	}}, day["records"])
+}
+
+func TestDashboardHandlers_HandleAPIReport(t *testing.T) {
+	ctx := context.Background()
+	handler, repo, user, taskID := newAPITestHandler(t)
+	timeEnd := time.Date(2024, 12, 3, 12, 0, 0, 0, time.UTC)
+	_, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC), TimeEnd: &timeEnd})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.HandleAPIReport(w, newAPIRequest(http.MethodGet, "/api/v1/report?month=2024-12", "", user))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var report apiReport
+	decodeAPIResponse(t, w, &report)
+	assert.Equal(t, "2024-12", report.Month)
+	assert.Equal(t, int64(7200), report.Duration)
+	require.Len(t, report.Tasks, 1)
+	assert.Equal(t, "Coding", report.Tasks[0].Task.Title)
+	assert.Equal(t, int64(7200), report.Tasks[0].Duration)
+
+	// Another month
+	w = httptest.NewRecorder()
+	handler.HandleAPIReport(w, newAPIRequest(http.MethodGet, "/api/v1/report?month=2024-11", "", user))
+
+	decodeAPIResponse(t, w, &report)
+	assert.Empty(t, report.Tasks)
+	assert.Zero(t, report.Duration)
+}
@@ -0,0 +1,84 @@
+//go:build unit
+
+// Compares the templates parsed once at startup with the parsing on every render, as before the registry:
+// docker exec -it tt-app-1 go test ./internal/modules/dashboard --tags=unit -run ^$ -bench BenchmarkDashboardHandlers_ -benchmem
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+// A week with 5 tasks and 4 records a day
+func benchmarkDashboardHandler(b *testing.B) (*DashboardHandlers, *users.User) {
+	ctx := context.Background()
+	repo := NewDashboardRepositoryMem()
+	user := &users.User{ID: 1, TimeZone: "UTC", IsWeekStartMonday: true}
+
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	startInterval, _ := utils.GetWeekIntervalByDate(nowWithTimezone, user.IsWeekStartMonday)
+	for i := 1; i <= 5; i++ {
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: fmt.Sprintf("Task %d", i), Color: "#FF5733", SortOrder: i})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for day := 0; day < 7; day++ {
+			timeStart := startInterval.AddDate(0, 0, day).Add(time.Duration(8+2*i) * time.Hour)
+			timeEnd := timeStart.Add(90 * time.Minute)
+			_, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: timeStart, TimeEnd: &timeEnd, Comment: "Comment"})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return NewDashboardHandler(repo), user
+}
+
+func benchmarkHandler(b *testing.B, handler http.HandlerFunc, user *users.User, url string) {
+	modes := []struct {
+		name   string
+		webDir string
+	}{
+		{"Registry", ""},
+		{"ParseOnEveryRender", "web"},
+	}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			utils.SetWebDir(mode.webDir)
+			defer utils.SetWebDir("")
+			if err := utils.LoadTemplates(); err != nil {
+				b.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodGet, url, nil)
+			r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				handler(w, r)
+				if w.Code != http.StatusOK {
+					b.Fatalf("expected status 200, got %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDashboardHandlers_Dashboard(b *testing.B) {
+	SetAppDir()
+	handler, user := benchmarkDashboardHandler(b)
+	benchmarkHandler(b, handler.HandleDashboard, user, "/dashboard")
+}
+
+func BenchmarkDashboardHandlers_Records(b *testing.B) {
+	SetAppDir()
+	handler, user := benchmarkDashboardHandler(b)
+	benchmarkHandler(b, handler.HandleRecordsList, user, "/records")
+}
@@ -0,0 +1,84 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/ical"
+)
+
+// The range of the calendar feed: the last days including today
+const (
+	CalendarFeedDefaultDays = 90
+	CalendarFeedMaxDays     = 365
+)
+
+// CalendarHandlers serve the calendar feed. The calendar apps have no session, the user is found by the secret token of the URL.
+type CalendarHandlers struct {
+	repo      DashboardRepository
+	usersRepo users.UsersRepository
+}
+
+func NewCalendarHandlers(repo DashboardRepository, usersRepo users.UsersRepository) *CalendarHandlers {
+	return &CalendarHandlers{repo: repo, usersRepo: usersRepo}
+}
+
+// GET /calendar.ics?token=...&days=90
+// The records of the last days as VEVENTs: the task title is the summary, the comment is the description,
+// the task color is the category. The records in progress are added when they are stopped.
+func (h *CalendarHandlers) HandleCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	user, err := h.usersRepo.GetByCalendarToken(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		utils.RenderError(w, r, fmt.Errorf("calendar feed: %w", err))
+		return
+	}
+
+	days := CalendarFeedDefaultDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 {
+			utils.RenderError(w, r, fmt.Errorf("calendar feed days %q: %w", daysStr, utils.ErrInvalidInput))
+			return
+		}
+		days = min(days, CalendarFeedMaxDays)
+	}
+
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	records, err := h.repo.RecordsWithTasks(r.Context(), FilterRecords{
+		UserID:        user.ID,
+		StartInterval: nowWithTimezone.Truncate(24*time.Hour).AddDate(0, 0, 1-days),
+		EndInterval:   nowWithTimezone,
+	})
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+
+	calendar := ical.Calendar{Name: "Time Tracker"}
+	for _, record := range records {
+		if record.TimeEnd == nil {
+			continue
+		}
+		// The records are in the wall clock time of the user, the calendar apps get UTC
+		start, _ := utils.FromWallClock(record.TimeStart, user.TimeZone)
+		end, _ := utils.FromWallClock(*record.TimeEnd, user.TimeZone)
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         fmt.Sprintf("record-%d@time-tracker", record.ID),
+			Start:       start,
+			End:         end,
+			Summary:     record.Task.Title,
+			Description: record.Comment,
+			Categories:  []string{record.Task.Color},
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="time-tracker.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if err := ical.Write(w, calendar, time.Now()); err != nil {
+		utils.Logger(r.Context()).Error("HandleCalendarFeed ical.Write", "err", err)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestCalendarHandlers.*
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarHandlers_HandleCalendarFeed(t *testing.T) {
+	SetAppDir()
+	ctx := context.Background()
+	usersRepo := users.NewUsersRepositoryMem()
+	user := &users.User{Name: "John", Email: "john@example.com", TimeZone: "Europe/Moscow", CalendarToken: strings.Repeat("a", 64)}
+	require.NoError(t, usersRepo.Create(ctx, user))
+	require.NoError(t, usersRepo.Create(ctx, &users.User{Name: "Other", Email: "other@example.com", TimeZone: "UTC"}))
+
+	repo := NewDashboardRepositoryMem()
+	taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: "Meeting, weekly", Color: "#FF5733"})
+	require.NoError(t, err)
+	otherTaskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID + 1, Title: "Other task"})
+	require.NoError(t, err)
+
+	now, _ := utils.NowWithTimezone(user.TimeZone)
+	today := now.Truncate(24 * time.Hour)
+	at := func(daysAgo int, hour int) *time.Time {
+		tm := today.AddDate(0, 0, -daysAgo).Add(time.Duration(hour) * time.Hour)
+		return &tm
+	}
+	createRecord := func(taskID int, timeStart, timeEnd *time.Time, comment string) int {
+		id, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: *timeStart, TimeEnd: timeEnd, Comment: comment})
+		require.NoError(t, err)
+		return id
+	}
+	// 10:00-11:00 Moscow is 07:00-08:00 UTC
+	recentID := createRecord(taskID, at(2, 10), at(2, 11), "Planning\nnotes")
+	oldID := createRecord(taskID, at(100, 10), at(100, 11), "")
+	createRecord(otherTaskID, at(1, 10), at(1, 11), "")
+	// In progress since yesterday
+	createRecord(taskID, at(0, -1), nil, "")
+
+	handler := NewCalendarHandlers(repo, usersRepo)
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleCalendarFeed(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	token := user.CalendarToken
+
+	t.Run("Default", func(t *testing.T) {
+		w := get("/calendar.ics?token=" + token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"))
+		assert.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"))
+		assert.Contains(t, body, "UID:record-"+strconv.Itoa(recentID)+"@time-tracker\r\n")
+		assert.Contains(t, body, "DTSTART:"+at(2, 7).Format("20060102T150405Z")+"\r\n")
+		assert.Contains(t, body, "DTEND:"+at(2, 8).Format("20060102T150405Z")+"\r\n")
+		assert.Contains(t, body, `SUMMARY:Meeting\, weekly`+"\r\n")
+		assert.Contains(t, body, `DESCRIPTION:Planning\nnotes`+"\r\n")
+		assert.Contains(t, body, "CATEGORIES:#FF5733\r\n")
+		assert.NotContains(t, body, "Other task")
+	})
+
+	t.Run("Days", func(t *testing.T) {
+		w := get("/calendar.ics?days=101&token=" + token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 2, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+		assert.Contains(t, w.Body.String(), "UID:record-"+strconv.Itoa(oldID)+"@time-tracker\r\n")
+
+		// Limited by CalendarFeedMaxDays
+		w = get("/calendar.ics?days=100000&token=" + token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 2, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+
+		for _, days := range []string{"0", "-1", "week"} {
+			w = get("/calendar.ics?days=" + days + "&token=" + token)
+			assert.Equal(t, http.StatusBadRequest, w.Code, days)
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		for _, target := range []string{"/calendar.ics", "/calendar.ics?token=", "/calendar.ics?token=" + strings.Repeat("b", 64)} {
+			w := get(target)
+			assert.Equal(t, http.StatusNotFound, w.Code, target)
+			assert.NotContains(t, w.Body.String(), "BEGIN:VCALENDAR")
+		}
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		mockRepo := new(MockDashboardRepository)
+		mockRepo.On("RecordsWithTasks", mock.Anything).Return(nil, errors.New("connection refused"))
+
+		w := httptest.NewRecorder()
+		NewCalendarHandlers(mockRepo, usersRepo).HandleCalendarFeed(w, httptest.NewRequest(http.MethodGet, "/calendar.ics?token="+token, nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "connection refused")
+	})
+}
@@ -0,0 +1,87 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+// A comment every keepaliveInterval keeps the proxies from closing an idle stream
+const keepaliveInterval = 25 * time.Second
+
+// The delay of the reconnection of EventSource after the stream is closed, e.g. by a restart
+const eventsRetry = 5 * time.Second
+
+type EventsHandlers struct {
+	broker            EventsBroker
+	keepaliveInterval time.Duration
+}
+
+func NewEventsHandlers(broker EventsBroker) *EventsHandlers {
+	return &EventsHandlers{broker: broker, keepaliveInterval: keepaliveInterval}
+}
+
+// GET /events
+// The stream of Server-Sent Events of the changes of the tasks and the records of the user, by any tab,
+// device, the API or the jobs. The event is "records" or "tasks" with the LiveEvent in the data.
+// The dashboard reloads the lists on them, see dashboard.html.
+func (h *EventsHandlers) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		// EventSource does not follow the login, it stops on the error
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	events, err := h.broker.Subscribe(r.Context(), user.ID)
+	if err != nil {
+		utils.Logger(r.Context()).Warn("HandleEvents Subscribe", "err", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream is longer than the write timeout of the server, not supported by httptest.ResponseRecorder
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Nginx buffers the responses by default
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds()); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		utils.Logger(r.Context()).Error("HandleEvents Flush", "err", err)
+		return
+	}
+
+	keepalive := time.NewTicker(h.keepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// The request is done or the server stops, EventSource reconnects after the retry
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				utils.Logger(r.Context()).Error("HandleEvents json.Marshal", "type", event.Type, "err", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name(), data); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestEventsHandlers.*
+package dashboard
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The server of HandleEvents, the requests with ?user are of the user 7
+func newEventsTestServer(t *testing.T, keepaliveInterval time.Duration) (*httptest.Server, *EventsBrokerMem) {
+	broker := NewEventsBrokerMem()
+	handlers := NewEventsHandlers(broker)
+	handlers.keepaliveInterval = keepaliveInterval
+	user := &users.User{ID: 7}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("user") {
+			r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+		}
+		handlers.HandleEvents(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, broker
+}
+
+// Reads the messages of the stream, the first one is the retry
+func openEventsStream(t *testing.T, url string) func() string {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	stream := bufio.NewReader(resp.Body)
+	return func() string {
+		var message strings.Builder
+		for {
+			line, err := stream.ReadString('\n')
+			if err != nil {
+				return message.String() + err.Error()
+			}
+			if line == "\n" {
+				return message.String()
+			}
+			message.WriteString(line)
+		}
+	}
+}
+
+func TestEventsHandlers_HandleEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Stream", func(t *testing.T) {
+		server, broker := newEventsTestServer(t, time.Hour)
+		readMessage := openEventsStream(t, server.URL+"/events?user")
+		assert.Equal(t, "retry: 5000\n", readMessage())
+
+		// The handler subscribed before the first message
+		broker.Publish(ctx, Event{Type: EventTaskDeleted, UserID: 8, Task: &Task{ID: 1}})
+		broker.Publish(ctx, Event{Type: EventRecordStarted, UserID: 7, Task: &Task{ID: 3}, Record: &Record{ID: 5}})
+		broker.Publish(ctx, Event{Type: EventTaskUpdated, UserID: 7, Task: &Task{ID: 3}})
+
+		assert.Equal(t, "event: records\ndata: {\"type\":\"record.started\",\"task_id\":3,\"record_id\":5}\n", readMessage())
+		assert.Equal(t, "event: tasks\ndata: {\"type\":\"task.updated\",\"task_id\":3}\n", readMessage())
+
+		// The stream ends when the broker stops
+		runCtx, stop := context.WithCancel(ctx)
+		stop()
+		broker.Run(runCtx)
+		assert.Equal(t, "EOF", readMessage())
+	})
+
+	t.Run("Keepalive", func(t *testing.T) {
+		server, _ := newEventsTestServer(t, 10*time.Millisecond)
+		readMessage := openEventsStream(t, server.URL+"/events?user")
+		assert.Equal(t, "retry: 5000\n", readMessage())
+
+		assert.Equal(t, ": keepalive\n", readMessage())
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		server, _ := newEventsTestServer(t, time.Hour)
+		resp, err := http.Get(server.URL + "/events")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("BrokerClosed", func(t *testing.T) {
+		server, broker := newEventsTestServer(t, time.Hour)
+		runCtx, stop := context.WithCancel(ctx)
+		stop()
+		broker.Run(runCtx)
+
+		resp, err := http.Get(server.URL + "/events?user")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+}
@@ -0,0 +1,168 @@
+package dashboard
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+// Records are stored in the user's timezone
+const exportTimeLayout = "2006-01-02T15:04:05"
+
+type exportProfile struct {
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	TimeZone          string     `json:"timezone"`
+	IsWeekStartMonday bool       `json:"is_week_start_monday"`
+	DateAdd           time.Time  `json:"date_add"`
+	DeleteRequestedAt *time.Time `json:"delete_requested_at"`
+}
+
+type exportTask struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	SortOrder   int    `json:"sort_order"`
+	IsCompleted bool   `json:"is_completed"`
+}
+
+type exportRecord struct {
+	ID        int    `json:"id"`
+	TaskID    int    `json:"task_id"`
+	TaskTitle string `json:"task_title"`
+	TimeStart string `json:"time_start"`
+	TimeEnd   string `json:"time_end"` // Empty if the record is in progress
+	Comment   string `json:"comment"`
+}
+
+// GET /settings/export
+// "Download my data": ZIP of the profile, tasks and records as JSON and CSV.
+func (h *DashboardHandlers) HandleExport(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	profile := exportProfile{
+		Name:              user.Name,
+		Email:             user.Email,
+		TimeZone:          user.TimeZone,
+		IsWeekStartMonday: user.IsWeekStartMonday,
+		DateAdd:           user.DateAdd,
+		DeleteRequestedAt: user.DeleteRequestedAt,
+	}
+
+	userTasks, err := h.repo.Tasks(r.Context(), user.ID, "all")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	userRecords, err := h.repo.RecordsWithTasks(r.Context(), FilterRecords{UserID: user.ID})
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+
+	tasks := []exportTask{}
+	for _, task := range userTasks {
+		tasks = append(tasks, exportTask{
+			ID:          task.ID,
+			Title:       task.Title,
+			Description: task.Description,
+			Color:       task.Color,
+			SortOrder:   task.SortOrder,
+			IsCompleted: task.IsCompleted,
+		})
+	}
+
+	records := []exportRecord{}
+	for _, record := range userRecords {
+		exported := exportRecord{
+			ID:        record.ID,
+			TaskID:    record.TaskID,
+			TimeStart: record.TimeStart.Format(exportTimeLayout),
+			Comment:   record.Comment,
+		}
+		if record.Task != nil {
+			exported.TaskTitle = record.Task.Title
+		}
+		if record.TimeEnd != nil {
+			exported.TimeEnd = record.TimeEnd.Format(exportTimeLayout)
+		}
+		records = append(records, exported)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="time-tracker-%s.zip"`, time.Now().Format("2006-01-02")))
+	err = writeExportZip(w, profile, tasks, records)
+	if err != nil {
+		// Headers are already sent
+		utils.Logger(r.Context()).Error("HandleExport writeExportZip", "userID", user.ID, "err", err)
+	}
+}
+
+func writeExportZip(w io.Writer, profile exportProfile, tasks []exportTask, records []exportRecord) error {
+	zw := zip.NewWriter(w)
+
+	taskRows := [][]string{{"id", "title", "description", "color", "sort_order", "is_completed"}}
+	for _, t := range tasks {
+		taskRows = append(taskRows, []string{
+			strconv.Itoa(t.ID), t.Title, t.Description, t.Color, strconv.Itoa(t.SortOrder), strconv.FormatBool(t.IsCompleted),
+		})
+	}
+
+	recordRows := [][]string{{"id", "task_id", "task_title", "time_start", "time_end", "comment"}}
+	for _, rec := range records {
+		recordRows = append(recordRows, []string{
+			strconv.Itoa(rec.ID), strconv.Itoa(rec.TaskID), rec.TaskTitle, rec.TimeStart, rec.TimeEnd, rec.Comment,
+		})
+	}
+
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"profile.json", jsonWriter(profile)},
+		{"tasks.json", jsonWriter(tasks)},
+		{"tasks.csv", csvWriter(taskRows)},
+		{"records.json", jsonWriter(records)},
+		{"records.csv", csvWriter(recordRows)},
+	}
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", file.name, err)
+		}
+		if err := file.write(fw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+func jsonWriter(v interface{}) func(io.Writer) error {
+	return func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+}
+
+func csvWriter(rows [][]string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		cw := csv.NewWriter(w)
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	}
+}
@@ -0,0 +1,101 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_HandleExport
+package dashboard
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardHandlers_HandleExport(t *testing.T) {
+	SetAppDir()
+
+	t.Run("RedirectToLoginWhenUserIsNotAuthenticated", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/settings/export", nil)
+
+		handler.HandleExport(w, r)
+
+		assert.Equal(t, http.StatusSeeOther, w.Result().StatusCode)
+		assert.Contains(t, w.Result().Header.Get("Location"), "/login")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, Name: "John Doe", Email: "john@example.com", Password: "secret-hash", TimeZone: "UTC"}
+		task := &Task{ID: 2, UserID: 1, Title: "Task, with comma", Color: "#FF5733"}
+		timeStart := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		timeEnd := timeStart.Add(time.Hour)
+		records := []*Record{
+			{ID: 3, TaskID: 2, TimeStart: timeStart, TimeEnd: &timeEnd, Comment: "Done", Task: task},
+			{ID: 4, TaskID: 2, TimeStart: timeEnd, Task: task},
+		}
+		repo.On("Tasks", user.ID, "all").Return([]*Task{task}, nil)
+		repo.On("RecordsWithTasks", FilterRecords{UserID: user.ID}).Return(records, nil)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/settings/export", nil)
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleExport(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+		body := w.Body.Bytes()
+		zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		require.NoError(t, err)
+		files := map[string][]byte{}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			files[f.Name], err = io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+		}
+		require.Len(t, files, 5)
+
+		var profile map[string]interface{}
+		require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+		assert.Equal(t, "john@example.com", profile["email"])
+		assert.NotContains(t, string(files["profile.json"]), "secret-hash")
+
+		var exportedRecords []exportRecord
+		require.NoError(t, json.Unmarshal(files["records.json"], &exportedRecords))
+		require.Len(t, exportedRecords, 2)
+		assert.Equal(t, "2024-01-15T10:00:00", exportedRecords[0].TimeStart)
+		assert.Equal(t, "2024-01-15T11:00:00", exportedRecords[0].TimeEnd)
+		assert.Equal(t, "", exportedRecords[1].TimeEnd)
+
+		taskRows, err := csv.NewReader(bytes.NewReader(files["tasks.csv"])).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, taskRows, 2)
+		assert.Equal(t, "Task, with comma", taskRows[1][1])
+
+		recordRows, err := csv.NewReader(bytes.NewReader(files["records.csv"])).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, recordRows, 3)
+		assert.Equal(t, []string{"3", "2", "Task, with comma", "2024-01-15T10:00:00", "2024-01-15T11:00:00", "Done"}, recordRows[1])
+
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,464 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/ical"
+)
+
+// The limits of the import: the size of the .ics file and the events in the preview
+const (
+	importMaxFileSize = 5 << 20
+	importMaxEvents   = 500
+)
+
+type importForm struct {
+	DateFrom string `form:"date_from" validate:"required,datetime=2006-01-02" label:"From"`
+	DateTo   string `form:"date_to" validate:"required,datetime=2006-01-02" label:"To"`
+	Rules    string `form:"rules" validate:"max=10000" label:"Title Rules"`
+}
+
+// The line "pattern => Task title" of the rules: the events with the pattern in the summary get the task
+type importRule struct {
+	Pattern string
+	TaskID  int
+}
+
+// An event of the file in the preview. The events that cannot be imported have SkipReason and no inputs.
+type importRow struct {
+	Index      int
+	Include    bool
+	TimeStart  string
+	TimeEnd    string
+	Summary    string
+	Comment    string
+	TaskID     int
+	SkipReason string
+	Errors     []template.HTML
+}
+
+// GET /records/import
+func (h *DashboardHandlers) HandleRecordsImport(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	now, _ := utils.NowWithTimezone(user.TimeZone)
+	utils.RenderTemplate(w, "dashboard/records_import", utils.TplData{
+		"Title": "Import Records",
+		"User":  user,
+		"Tasks": tasks,
+		"Form": importForm{
+			DateFrom: now.AddDate(0, 0, -6).Format("2006-01-02"),
+			DateTo:   now.Format("2006-01-02"),
+		},
+		"Errors": utils.FormErrors{},
+	})
+}
+
+// POST /records/import/preview
+// Reads the uploaded .ics file and shows the events of the range with the tasks of the rules.
+// The events that overlap the records of the user or the previous events of the file are not included by default.
+func (h *DashboardHandlers) HandleRecordsImportPreview(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RenderBlockNeedLogin(w)
+		return
+	}
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	tplData := utils.TplData{"Tasks": tasks}
+	render := func(form importForm, formErrors utils.FormErrors) {
+		tplData["Form"] = form
+		tplData["Errors"] = formErrors
+		utils.RenderTemplateWithoutLayout(w, "dashboard/records_import_form", "dashboard/records_import_form", tplData)
+	}
+
+	// The form fields are small, the limit is the file
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxFileSize+64<<10)
+	if err := r.ParseMultipartForm(importMaxFileSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			render(importForm{}, utils.FormErrors{"File": {template.HTML(fmt.Sprintf("The file is larger than %d MB", importMaxFileSize>>20))}})
+			return
+		}
+		utils.RenderError(w, r, fmt.Errorf("records import form: %w", utils.ErrInvalidInput))
+		return
+	}
+	var form importForm
+	if err := utils.ParseFormToStruct(r, &form); err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	formErrors := utils.NewValidator(&form).Validate()
+	rules := parseImportRules(form.Rules, tasks, formErrors)
+	dateFrom, _ := time.Parse("2006-01-02", form.DateFrom)
+	dateTo, _ := time.Parse("2006-01-02", form.DateTo)
+	if !formErrors.HasErrorsField("DateFrom") && !formErrors.HasErrorsField("DateTo") && dateTo.Before(dateFrom) {
+		formErrors.Add("DateTo", "To must not be before From")
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		formErrors.Add("File", "Select an .ics file")
+	}
+	if formErrors.HasErrors() {
+		render(form, formErrors)
+		return
+	}
+	defer file.Close()
+
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	events, err := ical.Parse(file, loc)
+	if err != nil {
+		formErrors.Add("File", "The file is not a valid iCalendar file: "+strings.TrimPrefix(err.Error(), ical.ErrInvalidCalendar.Error()+": "))
+		render(form, formErrors)
+		return
+	}
+
+	rows, err := h.importRows(r, user, events, dateFrom, dateTo.AddDate(0, 0, 1), rules)
+	if errors.Is(err, utils.ErrInvalidInput) {
+		formErrors.Add("File", fmt.Sprintf("The range has more than %d events, select a shorter range", importMaxEvents))
+		render(form, formErrors)
+		return
+	}
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	tplData["Rows"] = rows
+	tplData["Previewed"] = true
+	render(form, formErrors)
+}
+
+// POST /records/import
+// Creates the records of the included rows of the preview. A row that overlaps a record
+// is skipped and shown with the errors, the others are created.
+func (h *DashboardHandlers) HandleRecordsImportCreate(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RenderBlockNeedLogin(w)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		utils.RenderError(w, r, fmt.Errorf("records import form: %w", utils.ErrInvalidInput))
+		return
+	}
+	timeStarts, timeEnds := r.PostForm["time_start"], r.PostForm["time_end"]
+	taskIDs, comments, summaries := r.PostForm["task_id"], r.PostForm["comment"], r.PostForm["summary"]
+	count := len(timeStarts)
+	if len(timeEnds) != count || len(taskIDs) != count || len(comments) != count || len(summaries) != count {
+		utils.RenderError(w, r, fmt.Errorf("records import rows: %w", utils.ErrInvalidInput))
+		return
+	}
+
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	userTasks := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		userTasks[task.ID] = true
+	}
+
+	created := 0
+	var notCreated []importRow
+	for _, indexStr := range r.PostForm["include"] {
+		index, err := strconv.Atoi(indexStr)
+		if err != nil || index < 0 || index >= count {
+			utils.RenderError(w, r, fmt.Errorf("records import row %q: %w", indexStr, utils.ErrInvalidInput))
+			return
+		}
+		taskID, _ := strconv.Atoi(taskIDs[index])
+		row := importRow{
+			Index:     index,
+			TimeStart: timeStarts[index],
+			TimeEnd:   timeEnds[index],
+			Summary:   summaries[index],
+			Comment:   comments[index],
+			TaskID:    taskID,
+		}
+		form := recordForm{TaskID: taskID, TimeStart: row.TimeStart, TimeEnd: row.TimeEnd, Comment: row.Comment}
+		formErrors := utils.FormErrors{}
+		if userTasks[taskID] {
+			formErrors = utils.NewValidator(&form).Validate()
+		} else {
+			formErrors.Add("TaskID", "Select a task")
+		}
+		if !formErrors.HasErrors() && form.TimeEnd == "" {
+			formErrors.Add("TimeEnd", "Time End is required")
+		}
+		if !formErrors.HasErrors() {
+			if err := h.validateIntersectingRecords(r.Context(), form, user, 0, formErrors); err != nil {
+				utils.RenderError(w, r, err)
+				return
+			}
+		}
+		if !formErrors.HasErrors() {
+			_, err = h.repo.CreateRecord(r.Context(), &Record{
+				TaskID:    form.TaskID,
+				TimeStart: *parseTimeFromInput(form.TimeStart),
+				TimeEnd:   parseTimeFromInput(form.TimeEnd),
+				Comment:   form.Comment,
+			})
+			switch {
+			case errors.Is(err, ErrRecordsOverlap), errors.Is(err, ErrRecordInProgressExists):
+				// A record saved by a concurrent request after the validation
+				formErrors.Add("TimeEnd", "The selected time overlaps with other entries")
+			case err != nil:
+				utils.RenderError(w, r, err)
+				return
+			default:
+				created++
+				continue
+			}
+		}
+		for _, messages := range formErrors {
+			row.Errors = append(row.Errors, messages...)
+		}
+		notCreated = append(notCreated, row)
+	}
+
+	w.Header().Set("HX-Trigger", "load-records")
+	utils.RenderTemplateWithoutLayout(w, "dashboard/records_import_result", "dashboard/records_import_result", utils.TplData{
+		"Created":    created,
+		"NotCreated": notCreated,
+	})
+}
+
+// The rows of the events that start in [start, end) of the wall clock of the user, sorted by the start.
+// The recurring events are expanded to the occurrences, a series with an unsupported rule is one skipped row.
+// Returns utils.ErrInvalidInput if there are more than importMaxEvents events.
+func (h *DashboardHandlers) importRows(r *http.Request, user *users.User, events []ical.Event, start, end time.Time, rules []importRule) ([]importRow, error) {
+	type wallClockEvent struct {
+		ical.Event
+		start, end time.Time
+	}
+	// The occurrences of the series replaced by the modified ones (RECURRENCE-ID), by UID
+	replaced := make(map[string][]time.Time)
+	for _, event := range events {
+		if !event.RecurrenceID.IsZero() {
+			replaced[event.UID] = append(replaced[event.UID], event.RecurrenceID)
+		}
+	}
+
+	var inRange []wallClockEvent
+	var skippedSeries []importRow
+	for _, event := range events {
+		occurrences := []ical.Event{event}
+		if event.RRule != "" {
+			event.ExDates = append(slices.Clip(event.ExDates), replaced[event.UID]...)
+			var err error
+			occurrences, err = importOccurrences(event, end)
+			if err != nil {
+				firstStart, _ := utils.WallClock(event.Start, user.TimeZone)
+				if firstStart.Before(end) {
+					skippedSeries = append(skippedSeries, importRow{
+						TimeStart:  firstStart.Format("2006-01-02T15:04"),
+						Summary:    event.Summary,
+						SkipReason: "Recurring event, " + strings.TrimSuffix(err.Error(), ": "+utils.ErrInvalidInput.Error()),
+					})
+				}
+				continue
+			}
+		}
+		for _, occurrence := range occurrences {
+			// The moments of the file to the wall clock of the user, like the records
+			eventStart, _ := utils.WallClock(occurrence.Start, user.TimeZone)
+			eventEnd, _ := utils.WallClock(occurrence.End, user.TimeZone)
+			if occurrence.AllDay {
+				// The dates are the same in any time zone
+				eventStart = time.Date(occurrence.Start.Year(), occurrence.Start.Month(), occurrence.Start.Day(), 0, 0, 0, 0, time.UTC)
+			}
+			if eventStart.Before(start) || !eventStart.Before(end) {
+				continue
+			}
+			inRange = append(inRange, wallClockEvent{Event: occurrence, start: eventStart.Truncate(time.Minute), end: eventEnd.Truncate(time.Minute)})
+		}
+	}
+	if len(inRange) > importMaxEvents {
+		return nil, fmt.Errorf("%d events: %w", len(inRange), utils.ErrInvalidInput)
+	}
+	sort.SliceStable(inRange, func(i, j int) bool { return inRange[i].start.Before(inRange[j].start) })
+
+	rows := make([]importRow, 0, len(skippedSeries)+len(inRange))
+	rows = append(rows, skippedSeries...)
+	index := 0
+	// The end of the included events, to find the events of the file that overlap each other
+	var includedEnd time.Time
+	for _, event := range inRange {
+		row := importRow{
+			TimeStart: event.start.Format("2006-01-02T15:04"),
+			TimeEnd:   event.end.Format("2006-01-02T15:04"),
+			Summary:   event.Summary,
+			Comment:   event.Summary,
+		}
+		switch {
+		case event.AllDay:
+			row.SkipReason = "All-day event"
+			row.TimeEnd = ""
+		case !event.end.After(event.start):
+			row.SkipReason = "No duration"
+		}
+		if row.SkipReason != "" {
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Index = index
+		index++
+		row.TaskID = matchImportRule(rules, event.Summary)
+
+		formErrors := utils.FormErrors{}
+		form := recordForm{TimeStart: row.TimeStart, TimeEnd: row.TimeEnd}
+		if err := h.validateIntersectingRecords(r.Context(), form, user, 0, formErrors); err != nil {
+			return nil, err
+		}
+		for _, messages := range formErrors {
+			row.Errors = append(row.Errors, messages...)
+		}
+		if event.start.Before(includedEnd) {
+			row.Errors = append(row.Errors, "Overlaps a previous event of the file")
+		}
+		row.Include = row.TaskID != 0 && len(row.Errors) == 0
+		if row.Include {
+			includedEnd = event.end
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// The occurrences of the recurring event that start before the date end, or on the next day
+// as the time zone of the event may be ahead of the user. The rule is read by ParseSchedule with
+// COUNT and FREQ=YEARLY in addition. The occurrences on the dates of ExDates are left out,
+// but counted by COUNT as in RFC 5545. An error is utils.ErrInvalidInput for an unsupported rule.
+func importOccurrences(event ical.Event, end time.Time) ([]ical.Event, error) {
+	var parts []string
+	count := 0
+	yearly := false
+	for _, part := range strings.Split(strings.ToUpper(event.RRule), ";") {
+		name, value, _ := strings.Cut(part, "=")
+		switch {
+		case name == "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT %q: %w", value, utils.ErrInvalidInput)
+			}
+			count = n
+		case name == "WKST" && value == "MO":
+			// The weeks of Schedule start on Monday
+		case name == "FREQ" && value == "YEARLY":
+			yearly = true
+			parts = append(parts, "FREQ="+FreqMonthly)
+		default:
+			parts = append(parts, part)
+		}
+	}
+	schedule, err := ParseSchedule(strings.Join(parts, ";"))
+	if err != nil {
+		return nil, err
+	}
+	if yearly {
+		// Every 12 months on the day of the month of the start, February 29 only in the leap years
+		if len(schedule.ByDay) > 0 || len(schedule.ByMonthDay) > 0 {
+			return nil, fmt.Errorf("FREQ=YEARLY with BYDAY or BYMONTHDAY is not supported: %w", utils.ErrInvalidInput)
+		}
+		schedule.Interval *= 12
+	}
+
+	// The dates of the schedule are the dates of the time zone of the event
+	loc := event.Start.Location()
+	dateStart := time.Date(event.Start.Year(), event.Start.Month(), event.Start.Day(), 0, 0, 0, 0, time.UTC)
+	excluded := make(map[string]bool, len(event.ExDates))
+	for _, exDate := range event.ExDates {
+		excluded[exDate.In(loc).Format("2006-01-02")] = true
+	}
+	duration := event.End.Sub(event.Start)
+	lastDay := end.AddDate(0, 0, 1)
+	if !schedule.Until.IsZero() && schedule.Until.Before(lastDay) {
+		lastDay = schedule.Until
+	}
+
+	var occurrences []ical.Event
+	n := 0
+	for day := dateStart; !day.After(lastDay) && (count == 0 || n < count); day = day.AddDate(0, 0, 1) {
+		if !schedule.OccursOn(dateStart, day) {
+			continue
+		}
+		n++
+		if excluded[day.Format("2006-01-02")] {
+			continue
+		}
+		occurrence := event
+		occurrence.Start = time.Date(day.Year(), day.Month(), day.Day(), event.Start.Hour(), event.Start.Minute(), event.Start.Second(), 0, loc)
+		occurrence.End = occurrence.Start.Add(duration)
+		occurrence.RRule, occurrence.ExDates = "", nil
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, nil
+}
+
+// Reads the lines "pattern => Task title". The task is found by the title ignoring the case.
+// An invalid line adds an error of "Rules".
+func parseImportRules(text string, tasks []*Task, formErrors utils.FormErrors) []importRule {
+	var rules []importRule
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pattern, taskTitle, found := strings.Cut(line, "=>")
+		pattern, taskTitle = strings.TrimSpace(pattern), strings.TrimSpace(taskTitle)
+		if !found || pattern == "" || taskTitle == "" {
+			formErrors.Add("Rules", fmt.Sprintf("Line %d: use \"text in the title => Task title\"", i+1))
+			continue
+		}
+		taskID := 0
+		for _, task := range tasks {
+			if strings.EqualFold(task.Title, taskTitle) {
+				taskID = task.ID
+				break
+			}
+		}
+		if taskID == 0 {
+			formErrors.Add("Rules", fmt.Sprintf("Line %d: task %q not found", i+1, taskTitle))
+			continue
+		}
+		rules = append(rules, importRule{Pattern: pattern, TaskID: taskID})
+	}
+	return rules
+}
+
+// The task of the first rule with the pattern in the summary ignoring the case, 0 if none matches
+func matchImportRule(rules []importRule, summary string) int {
+	summary = strings.ToLower(summary)
+	for _, rule := range rules {
+		if strings.Contains(summary, strings.ToLower(rule.Pattern)) {
+			return rule.TaskID
+		}
+	}
+	return 0
+}
@@ -0,0 +1,287 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_HandleRecordsImport.*
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardHandlers_HandleRecordsImport(t *testing.T) {
+	SetAppDir()
+	ctx := context.Background()
+	user := &users.User{ID: 1, TimeZone: "Europe/Moscow"}
+
+	newHandler := func(t *testing.T) (*DashboardHandlers, *DashboardRepositoryMem, int) {
+		repo := NewDashboardRepositoryMem()
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: "Meetings"})
+		require.NoError(t, err)
+		_, err = repo.CreateTask(ctx, &Task{UserID: 2, Title: "Other"})
+		require.NoError(t, err)
+		// 2024-12-03 10:00-11:00 of the wall clock
+		timeEnd := time.Date(2024, 12, 3, 11, 0, 0, 0, time.UTC)
+		_, err = repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC), TimeEnd: &timeEnd})
+		require.NoError(t, err)
+		return NewDashboardHandler(repo), repo, taskID
+	}
+	withUser := func(r *http.Request, u *users.User) *http.Request {
+		if u == nil {
+			return r
+		}
+		return r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, u))
+	}
+	previewRequest := func(t *testing.T, fields map[string]string, file string) *http.Request {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for name, value := range fields {
+			require.NoError(t, mw.WriteField(name, value))
+		}
+		if file != "" {
+			fw, err := mw.CreateFormFile("file", "calendar.ics")
+			require.NoError(t, err)
+			_, err = fw.Write([]byte(file))
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+		r := httptest.NewRequest(http.MethodPost, "/records/import/preview", &body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return withUser(r, user)
+	}
+	calendarFile := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART:20241202T070000Z",
+		"DTEND:20241202T080000Z",
+		"SUMMARY:Team Standup",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241203T073000Z",
+		"DTEND:20241203T080000Z",
+		"SUMMARY:Review",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20241204",
+		"SUMMARY:Holiday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241210T070000Z",
+		"DTEND:20241210T080000Z",
+		"SUMMARY:Out of range",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241202T073000Z",
+		"DTEND:20241202T090000Z",
+		"SUMMARY:Standup follow-up",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241204T120000",
+		"DTEND:20241204T130000",
+		"SUMMARY:Coding",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	validFields := map[string]string{
+		"date_from": "2024-12-01",
+		"date_to":   "2024-12-05",
+		"rules":     "standup => meetings\n",
+	}
+
+	t.Run("PageRedirectsToLogin", func(t *testing.T) {
+		handler, _, _ := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.HandleRecordsImport(w, httptest.NewRequest(http.MethodGet, "/records/import", nil))
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		handler, _, _ := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.HandleRecordsImport(w, withUser(httptest.NewRequest(http.MethodGet, "/records/import", nil), user))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `hx-post="/records/import/preview"`)
+		assert.Contains(t, w.Body.String(), "Meetings")
+		assert.NotContains(t, w.Body.String(), "Other")
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		handler, _, taskID := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.HandleRecordsImportPreview(w, previewRequest(t, validFields, calendarFile))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		// The UTC times of the file in the wall clock of Moscow
+		assert.Contains(t, body, `value="2024-12-02T10:00"`)
+		assert.Contains(t, body, `value="2024-12-02T11:00"`)
+		assert.Contains(t, body, `value="2024-12-04T12:00"`)
+		assert.NotContains(t, body, "Out of range")
+		assert.Contains(t, body, "All-day event")
+		assert.Contains(t, body, "The selected time overlaps with other entries")
+		assert.Contains(t, body, "Overlaps a previous event of the file")
+
+		assert.Equal(t, 4, strings.Count(body, `name="include"`))
+		// Only "Team Standup" has a task of the rules and no overlaps
+		assert.Equal(t, 1, strings.Count(body, `value="0" checked`))
+		assert.Equal(t, 2, strings.Count(body, `value="`+strconv.Itoa(taskID)+`" selected`))
+	})
+
+	t.Run("PreviewRecurring", func(t *testing.T) {
+		handler, _, _ := newHandler(t)
+		file := strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"BEGIN:VEVENT",
+			"UID:sync@example.com",
+			"DTSTART;TZID=Europe/Berlin:20241125T090000",
+			"DURATION:PT30M",
+			"SUMMARY:Sync",
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5",
+			"EXDATE;TZID=Europe/Berlin:20241204T090000",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:sync@example.com",
+			"RECURRENCE-ID;TZID=Europe/Berlin:20241209T090000",
+			"DTSTART;TZID=Europe/Berlin:20241209T150000",
+			"DURATION:PT30M",
+			"SUMMARY:Sync moved",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"DTSTART:20241101T070000Z",
+			"DTEND:20241101T080000Z",
+			"SUMMARY:Planning",
+			"RRULE:FREQ=MONTHLY;BYDAY=1MO",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"DTSTART;VALUE=DATE:20201205",
+			"SUMMARY:Birthday",
+			"RRULE:FREQ=YEARLY",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}, "\r\n")
+		fields := map[string]string{"date_from": "2024-12-01", "date_to": "2024-12-12", "rules": "sync => meetings"}
+		w := httptest.NewRecorder()
+		handler.HandleRecordsImportPreview(w, previewRequest(t, fields, file))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		// 09:00 of Berlin is 11:00 of Moscow
+		assert.Contains(t, body, `value="2024-12-02T11:00"`)
+		assert.NotContains(t, body, "2024-11-27T11:00", "before the range")
+		assert.NotContains(t, body, "2024-12-04T11:00", "EXDATE")
+		assert.NotContains(t, body, "2024-12-09T11:00", "replaced by RECURRENCE-ID")
+		assert.Contains(t, body, `value="2024-12-09T17:00"`)
+		assert.NotContains(t, body, "2024-12-11T11:00", "after COUNT")
+		assert.Equal(t, 2, strings.Count(body, `name="include"`))
+		assert.Equal(t, 2, strings.Count(body, " checked"))
+
+		assert.Contains(t, body, "Recurring event, BYDAY &#34;1MO&#34;")
+		assert.Contains(t, body, "2024-12-05T00:00")
+		assert.Contains(t, body, "All-day event")
+	})
+
+	t.Run("PreviewFormErrors", func(t *testing.T) {
+		handler, _, _ := newHandler(t)
+		testCases := map[string]struct {
+			fields   map[string]string
+			file     string
+			expected string
+		}{
+			"NoFile":       {validFields, "", "Select an .ics file"},
+			"InvalidFile":  {validFields, "Subject,Start Date", "The file is not a valid iCalendar file: no BEGIN:VCALENDAR"},
+			"UnknownTask":  {map[string]string{"date_from": "2024-12-01", "date_to": "2024-12-05", "rules": "standup => Calls"}, calendarFile, `task &#34;Calls&#34; not found`},
+			"InvalidRule":  {map[string]string{"date_from": "2024-12-01", "date_to": "2024-12-05", "rules": "standup"}, calendarFile, "Line 1: use"},
+			"InvalidRange": {map[string]string{"date_from": "2024-12-05", "date_to": "2024-12-01"}, calendarFile, "To must not be before From"},
+			"TooLarge":     {validFields, "BEGIN:VCALENDAR\r\n" + strings.Repeat("X-PAD:"+strings.Repeat("a", 1000)+"\r\n", importMaxFileSize/1000+100), "The file is larger than 5 MB"},
+		}
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				handler.HandleRecordsImportPreview(w, previewRequest(t, tc.fields, tc.file))
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), tc.expected)
+				assert.NotContains(t, w.Body.String(), `name="include"`)
+			})
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		handler, repo, taskID := newHandler(t)
+		form := url.Values{
+			"include":    {"0", "1", "2"},
+			"time_start": {"2024-12-02T10:00", "2024-12-03T10:30", "2024-12-04T12:00", "2024-12-05T12:00"},
+			"time_end":   {"2024-12-02T11:00", "2024-12-03T11:00", "2024-12-04T13:00", "2024-12-05T13:00"},
+			"task_id":    {strconv.Itoa(taskID), strconv.Itoa(taskID), "0", strconv.Itoa(taskID)},
+			"comment":    {"Team Standup", "Review", "Coding", "Not included"},
+			"summary":    {"Team Standup", "Review", "Coding", "Not included"},
+		}
+		r := httptest.NewRequest(http.MethodPost, "/records/import", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.HandleRecordsImportCreate(w, withUser(r, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "load-records", w.Header().Get("HX-Trigger"))
+		body := w.Body.String()
+		assert.Contains(t, body, "Created records: 1")
+		assert.Contains(t, body, "The selected time overlaps with other entries")
+		assert.Contains(t, body, "Select a task")
+
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{
+			UserID:        user.ID,
+			StartInterval: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			EndInterval:   time.Date(2024, 12, 6, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC), records[0].TimeStart)
+		assert.Equal(t, "Team Standup", records[0].Comment)
+	})
+
+	t.Run("CreateTaskOfAnotherUser", func(t *testing.T) {
+		handler, repo, _ := newHandler(t)
+		form := url.Values{
+			"include":    {"0"},
+			"time_start": {"2024-12-02T10:00"},
+			"time_end":   {"2024-12-02T11:00"},
+			"task_id":    {"2"},
+			"comment":    {""},
+			"summary":    {""},
+		}
+		r := httptest.NewRequest(http.MethodPost, "/records/import", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.HandleRecordsImportCreate(w, withUser(r, user))
+
+		assert.Contains(t, w.Body.String(), "Created records: 0")
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{UserID: 2})
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("CreateInvalidRows", func(t *testing.T) {
+		handler, _, taskID := newHandler(t)
+		for name, form := range map[string]url.Values{
+			"IndexOutOfRange": {"include": {"1"}, "time_start": {"2024-12-02T10:00"}, "time_end": {"2024-12-02T11:00"}, "task_id": {strconv.Itoa(taskID)}, "comment": {""}, "summary": {""}},
+			"MissingColumn":   {"include": {"0"}, "time_start": {"2024-12-02T10:00"}, "task_id": {strconv.Itoa(taskID)}, "comment": {""}, "summary": {""}},
+		} {
+			r := httptest.NewRequest(http.MethodPost, "/records/import", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			handler.HandleRecordsImportCreate(w, withUser(r, user))
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+}
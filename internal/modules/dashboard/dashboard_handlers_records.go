@@ -1,8 +1,10 @@
 package dashboard
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"html"
+	"html/template"
 	"net/http"
 	"strconv"
 	"time"
@@ -32,14 +34,14 @@ func (h *DashboardHandlers) HandleRecordsNew(w http.ResponseWriter, r *http.Requ
 	taskIdStr := r.URL.Query().Get("taskId")
 	if taskIdStr != "" {
 		taskId, _ = strconv.Atoi(taskIdStr)
-		task := h.repo.TaskByID(taskId)
-		if task == nil {
-			http.Error(w, "Task not found", http.StatusNotFound)
+		task, err := h.repo.TaskByID(r.Context(), taskId)
+		if err != nil {
+			utils.RenderError(w, r, err)
 			return
 		}
 
 		if task.UserID != user.ID {
-			http.Error(w, "Access denied", http.StatusForbidden)
+			utils.RenderError(w, r, fmt.Errorf("task %d: %w", task.ID, utils.ErrForbidden))
 			return
 		}
 	}
@@ -62,7 +64,7 @@ func (h *DashboardHandlers) HandleRecordsNew(w http.ResponseWriter, r *http.Requ
 		TimeStart: timeStart,
 		TimeEnd:   timeEnd,
 	}
-	h.renderRecordForm(w, form, utils.FormErrors{}, h.repo.Tasks(user.ID, ""))
+	h.renderRecordFormWithActiveTasks(w, r, user, form, utils.FormErrors{})
 }
 
 // POST /records
@@ -76,37 +78,50 @@ func (h *DashboardHandlers) HandleRecordsCreate(w http.ResponseWriter, r *http.R
 	var form recordForm
 	err := utils.ParseFormToStruct(r, &form)
 	if err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+		utils.RenderError(w, r, utils.ErrInvalidInput)
 		return
 	}
 	formErrors := utils.NewValidator(&form).Validate()
 	if formErrors.HasErrors() {
-		h.renderRecordForm(w, form, formErrors, h.repo.Tasks(user.ID, ""))
+		h.renderRecordFormWithActiveTasks(w, r, user, form, formErrors)
 		return
 	}
 
-	task := h.repo.TaskByID(form.TaskID)
-	if task == nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	task, err := h.repo.TaskByID(r.Context(), form.TaskID)
+	if err != nil {
+		utils.RenderError(w, r, err)
 		return
 	}
 
 	if task.UserID != user.ID {
-		http.Error(w, "Access denied", http.StatusForbidden)
+		utils.RenderError(w, r, fmt.Errorf("task %d: %w", task.ID, utils.ErrForbidden))
 		return
 	}
 
-	h.validateIntersectingRecords(form, user, 0, formErrors)
+	err = h.validateIntersectingRecords(r.Context(), form, user, 0, formErrors)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	if formErrors.HasErrors() {
-		h.renderRecordForm(w, form, formErrors, h.repo.Tasks(user.ID, ""))
+		h.renderRecordFormWithActiveTasks(w, r, user, form, formErrors)
 		return
 	}
-	h.repo.CreateRecord(&Record{
+	_, err = h.repo.CreateRecord(r.Context(), &Record{
 		TaskID:    form.TaskID,
 		TimeStart: *parseTimeFromInput(form.TimeStart),
 		TimeEnd:   parseTimeFromInput(form.TimeEnd),
 		Comment:   form.Comment,
 	})
+	if err != nil {
+		tasks, tasksErr := h.repo.Tasks(r.Context(), user.ID, "")
+		if tasksErr != nil {
+			utils.RenderError(w, r, tasksErr)
+			return
+		}
+		h.handleRecordSaveError(w, r, err, form, user, 0, tasks)
+		return
+	}
 
 	w.Header().Set("HX-Trigger", "load-records, close-modal")
 	w.Write([]byte("ok"))
@@ -127,7 +142,11 @@ func (h *DashboardHandlers) HandleRecordsEdit(w http.ResponseWriter, r *http.Req
 		Comment:   record.Comment,
 	}
 	// List active tasks
-	tasks := h.repo.Tasks(user.ID, "")
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	if record.Task.IsCompleted {
 		// Add current inactive task
 		tasks = append(tasks, record.Task)
@@ -146,12 +165,16 @@ func (h *DashboardHandlers) HandleRecordsUpdate(w http.ResponseWriter, r *http.R
 	var form recordForm
 	err := utils.ParseFormToStruct(r, &form)
 	if err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+		utils.RenderError(w, r, utils.ErrInvalidInput)
 		return
 	}
 
 	// List active tasks
-	tasks := h.repo.Tasks(user.ID, "")
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	if record.Task.IsCompleted {
 		// Add current inactive task
 		tasks = append(tasks, record.Task)
@@ -164,19 +187,27 @@ func (h *DashboardHandlers) HandleRecordsUpdate(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	h.validateIntersectingRecords(form, user, record.ID, formErrors)
+	err = h.validateIntersectingRecords(r.Context(), form, user, record.ID, formErrors)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	if formErrors.HasErrors() {
 		h.renderRecordForm(w, form, formErrors, tasks)
 		return
 	}
 
-	h.repo.UpdateRecord(&Record{
+	err = h.repo.UpdateRecord(r.Context(), &Record{
 		ID:        record.ID,
 		TaskID:    form.TaskID,
 		TimeStart: *parseTimeFromInput(form.TimeStart),
 		TimeEnd:   parseTimeFromInput(form.TimeEnd),
 		Comment:   form.Comment,
 	})
+	if err != nil {
+		h.handleRecordSaveError(w, r, err, form, user, record.ID, tasks)
+		return
+	}
 	w.Header().Set("HX-Trigger", "load-records, close-modal")
 	w.Write([]byte(`ok`))
 }
@@ -187,7 +218,11 @@ func (h *DashboardHandlers) HandleRecordsDelete(w http.ResponseWriter, r *http.R
 	if user == nil || record == nil {
 		return
 	}
-	h.repo.DeleteRecord(record.ID)
+	err := h.repo.DeleteRecord(r.Context(), record.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	w.Header().Set("HX-Trigger", "load-records")
 	w.Write([]byte(`ok`))
 }
@@ -209,12 +244,16 @@ func (h *DashboardHandlers) HandleRecordsList(w http.ResponseWriter, r *http.Req
 	}
 	// D("filterRecords r", "filterRecords", filterRecords)
 
-	dailyRecords := h.repo.DailyRecords(filterRecords, nowWithTimezone)
+	dailyRecords, err := h.dailyRecordsWithPending(r.Context(), filterRecords, nowWithTimezone)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 
 	previousWeek := utils.FormatISOWeek(startInterval.AddDate(0, 0, -7), user.IsWeekStartMonday)
 	nextWeek := utils.FormatISOWeek(endInterval.AddDate(0, 0, 7), user.IsWeekStartMonday)
 	week = utils.FormatISOWeek(startInterval, user.IsWeekStartMonday)
-	utils.RenderTemplateWithoutLayout(w, []string{"dashboard/record_list", "dashboard/record_list_navigation"}, "dashboard/record_list", utils.TplData{
+	utils.RenderTemplateWithoutLayout(w, "dashboard/record_list", "dashboard/record_list", utils.TplData{
 		"DailyRecords":    dailyRecords,
 		"User":            user,
 		"Week":            week,
@@ -225,13 +264,22 @@ func (h *DashboardHandlers) HandleRecordsList(w http.ResponseWriter, r *http.Req
 }
 
 func (h *DashboardHandlers) renderRecordForm(w http.ResponseWriter, form recordForm, formErrors utils.FormErrors, tasks []*Task) {
-	utils.RenderTemplateWithoutLayout(w, []string{"dashboard/record_form"}, "dashboard/record_form", utils.TplData{
+	utils.RenderTemplateWithoutLayout(w, "dashboard/record_form", "dashboard/record_form", utils.TplData{
 		"Errors": formErrors,
 		"Form":   form,
 		"Tasks":  tasks,
 	})
 }
 
+func (h *DashboardHandlers) renderRecordFormWithActiveTasks(w http.ResponseWriter, r *http.Request, user *users.User, form recordForm, formErrors utils.FormErrors) {
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	h.renderRecordForm(w, form, formErrors, tasks)
+}
+
 func (h *DashboardHandlers) getUserAndRecord(w http.ResponseWriter, r *http.Request) (user *users.User, record *Record) {
 	user = users.GetUserFromRequest(r)
 	if user == nil {
@@ -242,73 +290,106 @@ func (h *DashboardHandlers) getUserAndRecord(w http.ResponseWriter, r *http.Requ
 	recordIDStr := r.PathValue("id")
 	recordID, err := strconv.Atoi(recordIDStr)
 	if err != nil {
-		http.Error(w, "Invalid record ID", http.StatusBadRequest)
+		utils.RenderError(w, r, fmt.Errorf("record ID %q: %w", recordIDStr, utils.ErrInvalidInput))
 		return
 	}
 
-	record = h.repo.RecordByIDWithTask(recordID)
-	if record == nil {
-		http.Error(w, "Record not found", http.StatusNotFound)
-		return
+	record, err = h.repo.RecordByIDWithTask(r.Context(), recordID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return user, nil
 	}
 
 	if record.Task.UserID != user.ID {
-		http.Error(w, "Access denied", http.StatusForbidden)
-		return
+		utils.RenderError(w, r, fmt.Errorf("record %d: %w", record.ID, utils.ErrForbidden))
+		return user, nil
 	}
 	return
 }
 
-func (h *DashboardHandlers) validateIntersectingRecords(form recordForm, user *users.User, currentRecordId int, formErrors utils.FormErrors) {
+// Adds the form errors. The returned error is an error of the repository.
+func (h *DashboardHandlers) validateIntersectingRecords(ctx context.Context, form recordForm, user *users.User, currentRecordId int, formErrors utils.FormErrors) error {
 	timeStart := parseTimeFromInput(form.TimeStart)
 	timeEnd := parseTimeFromInput(form.TimeEnd)
 	effectiveEnd := utils.EffectiveTime(timeEnd, user.TimeZone)
 
 	if timeEnd != nil && (timeEnd.Before(*timeStart) || timeEnd.Equal(*timeStart)) {
 		formErrors.Add("TimeEnd", "Time End must be greater than Time Start")
-		return
+		return nil
 	}
 
 	if timeEnd == nil {
-		intersectingRecords := h.repo.RecordsWithTasks(FilterRecords{
+		intersectingRecords, err := h.repo.RecordsWithTasks(ctx, FilterRecords{
 			UserID:      user.ID,
 			NotRecordID: currentRecordId,
 			InProgress:  true,
 		})
+		if err != nil {
+			return err
+		}
 		if len(intersectingRecords) > 0 {
 			message := "You are already doing task: " + recordToString(intersectingRecords[0], user)
-			formErrors.Add("TimeEnd", message)
-			return
+			formErrors.AddHTML("TimeEnd", message)
+			return nil
 		}
 	}
 
 	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
 	excludeInProgress := nowWithTimezone.Before(*timeStart)
-	intersectingRecords := h.repo.RecordsWithTasks(FilterRecords{
+	intersectingRecords, err := h.repo.RecordsWithTasks(ctx, FilterRecords{
 		UserID:            user.ID,
 		StartInterval:     *timeStart,
 		EndInterval:       *effectiveEnd,
 		NotRecordID:       currentRecordId,
 		ExcludeInProgress: excludeInProgress,
 	})
+	if err != nil {
+		return err
+	}
 	if len(intersectingRecords) > 0 {
-		message := "The selected time overlaps with other entries: "
+		message := template.HTML("The selected time overlaps with other entries: ")
 		for _, record := range intersectingRecords {
 			message += "<br> " + recordToString(record, user)
 		}
 
-		formErrors.Add("TimeEnd", message)
+		formErrors.AddHTML("TimeEnd", message)
+	}
+	return nil
+}
+
+// Constraint violations of the database are shown as the same form errors as validateIntersectingRecords shows.
+// They happen when a concurrent request saved a record after the validation of this one.
+func (h *DashboardHandlers) handleRecordSaveError(w http.ResponseWriter, r *http.Request, err error, form recordForm, user *users.User, currentRecordId int, tasks []*Task) {
+	if !errors.Is(err, ErrRecordsOverlap) && !errors.Is(err, ErrRecordInProgressExists) {
+		utils.RenderError(w, r, err)
+		return
+	}
+
+	formErrors := utils.FormErrors{}
+	// The conflicting record is already committed, so the validation finds it
+	validateErr := h.validateIntersectingRecords(r.Context(), form, user, currentRecordId, formErrors)
+	if validateErr != nil {
+		utils.Logger(r.Context()).Warn("handleRecordSaveError validateIntersectingRecords", "err", validateErr)
+	}
+	if !formErrors.HasErrors() {
+		if errors.Is(err, ErrRecordInProgressExists) {
+			formErrors.Add("TimeEnd", "You are already doing another task")
+		} else {
+			formErrors.Add("TimeEnd", "The selected time overlaps with other entries")
+		}
 	}
+	h.renderRecordForm(w, form, formErrors, tasks)
 }
 
-func recordToString(record *Record, user *users.User) string {
-	return fmt.Sprintf(
+// The link for the form errors. The title and the comment are escaped, so the result is trusted HTML.
+func recordToString(record *Record, user *users.User) template.HTML {
+	return template.HTML(fmt.Sprintf(
 		"<a href=\"/dashboard?record=%d\" target=\"_blank\">%s %s %s</a>",
 		record.ID,
-		html.EscapeString(record.Task.Title),
-		utils.FormatTimeRange(record.TimeStart, record.TimeEnd, user.TimeZone),
-		html.EscapeString(record.Comment),
-	)
+		template.HTMLEscapeString(record.Task.Title),
+		template.HTMLEscapeString(utils.FormatTimeRange(record.TimeStart, record.TimeEnd, user.TimeZone)),
+		template.HTMLEscapeString(record.Comment),
+	))
 }
 
 // Time can be nil, so *time.Time
@@ -5,6 +5,9 @@ package dashboard
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -49,8 +52,8 @@ func TestDashboardHandlers_HandleRecordsNew(t *testing.T) {
 			SortOrder:   1,
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/new?taskId=1&date=2024-01-01", nil)
@@ -80,8 +83,8 @@ func TestDashboardHandlers_HandleRecordsNew(t *testing.T) {
 			SortOrder:   1,
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/new?taskId=1", nil)
@@ -102,7 +105,7 @@ func TestDashboardHandlers_HandleRecordsNew(t *testing.T) {
 		handler := NewDashboardHandler(repo)
 
 		user := &users.User{ID: 1, TimeZone: "UTC", IsWeekStartMonday: true}
-		repo.On("TaskByID", 1).Return(nil)
+		repo.On("TaskByID", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/new?taskId=1", nil)
@@ -114,7 +117,7 @@ func TestDashboardHandlers_HandleRecordsNew(t *testing.T) {
 		handler.HandleRecordsNew(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Task not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("AccessDenied", func(t *testing.T) {
@@ -123,7 +126,7 @@ func TestDashboardHandlers_HandleRecordsNew(t *testing.T) {
 
 		user := &users.User{ID: 1, TimeZone: "UTC", IsWeekStartMonday: true}
 		task := &Task{ID: 1, UserID: 2} // Task belongs to another user
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/new?taskId=1", nil)
@@ -175,8 +178,8 @@ func TestDashboardHandlers_HandleRecordsCreate(t *testing.T) {
 			"comment":    {"Test comment"},
 		}
 
-		repo.On("TaskByID", 1).Return(task)
-		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{})
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
 		repo.On("CreateRecord", mock.Anything).Return(1, nil)
 
 		w := httptest.NewRecorder()
@@ -193,6 +196,104 @@ func TestDashboardHandlers_HandleRecordsCreate(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "ok")
 	})
 
+	t.Run("OverlapConstraintViolation", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, TimeZone: "UTC"}
+		task := &Task{ID: 1, UserID: 1, Title: "Test Task"}
+		concurrentRecord := &Record{
+			ID:        2,
+			TaskID:    1,
+			TimeStart: time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+			TimeEnd:   parseTimeFromInput("2024-01-01T15:00"),
+			Comment:   "Concurrent record",
+			Task:      task,
+		}
+
+		form := url.Values{
+			"task_id":    {"1"},
+			"time_start": {"2024-01-01T12:00"},
+			"time_end":   {"2024-01-01T14:00"},
+		}
+
+		repo.On("TaskByID", 1).Return(task, nil)
+		// The concurrent record is not yet saved during the validation, but is found after the constraint violation
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil).Once()
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{concurrentRecord}, nil).Once()
+		repo.On("CreateRecord", mock.Anything).Return(0, fmt.Errorf("%w: conflicting key value", ErrRecordsOverlap))
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleRecordsCreate(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Contains(t, w.Body.String(), "The selected time overlaps with other entries")
+		assert.Contains(t, w.Body.String(), "Concurrent record")
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+	})
+
+	t.Run("InProgressConstraintViolation", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, TimeZone: "UTC"}
+		task := &Task{ID: 1, UserID: 1, Title: "Test Task"}
+
+		form := url.Values{
+			"task_id":    {"1"},
+			"time_start": {"2024-01-01T12:00"},
+		}
+
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
+		repo.On("CreateRecord", mock.Anything).Return(0, fmt.Errorf("%w: duplicate key value", ErrRecordInProgressExists))
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleRecordsCreate(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Contains(t, w.Body.String(), "You are already doing another task")
+	})
+
+	t.Run("CreateRecordError", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, TimeZone: "UTC"}
+		task := &Task{ID: 1, UserID: 1, Title: "Test Task"}
+
+		form := url.Values{
+			"task_id":    {"1"},
+			"time_start": {"2024-01-01T12:00"},
+			"time_end":   {"2024-01-01T14:00"},
+		}
+
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
+		repo.On("CreateRecord", mock.Anything).Return(0, errors.New("database error"))
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleRecordsCreate(w, r)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+	})
+
 	t.Run("ValidateIntersectingRecordsError", func(t *testing.T) {
 		repo := new(MockDashboardRepository)
 		handler := NewDashboardHandler(repo)
@@ -244,9 +345,9 @@ func TestDashboardHandlers_HandleRecordsCreate(t *testing.T) {
 			},
 		}
 
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
-		repo.On("TaskByID", 1).Return(task)
-		repo.On("RecordsWithTasks", mock.Anything).Return(intersectingRecords)
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return(intersectingRecords, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
@@ -297,7 +398,7 @@ func TestDashboardHandlers_HandleRecordsCreate(t *testing.T) {
 			"comment":    {"Test comment"},
 		}
 
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
@@ -324,7 +425,7 @@ func TestDashboardHandlers_HandleRecordsCreate(t *testing.T) {
 			"comment":    {"Test comment"},
 		}
 
-		repo.On("TaskByID", 1).Return(nil)
+		repo.On("TaskByID", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
@@ -351,7 +452,7 @@ func TestDashboardHandlers_HandleRecordsCreate(t *testing.T) {
 		}
 
 		task := &Task{ID: 1, UserID: 2} // Task belongs to another user
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records", strings.NewReader(form.Encode()))
@@ -388,7 +489,7 @@ func TestDashboardHandlers_HandleRecordsEdit(t *testing.T) {
 		repo := new(MockDashboardRepository)
 		handler := NewDashboardHandler(repo)
 
-		repo.On("RecordByIDWithTask", 1).Return(nil, nil)
+		repo.On("RecordByIDWithTask", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/1", nil)
@@ -402,7 +503,7 @@ func TestDashboardHandlers_HandleRecordsEdit(t *testing.T) {
 		handler.HandleRecordsEdit(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Record not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("AccessDenied", func(t *testing.T) {
@@ -413,7 +514,7 @@ func TestDashboardHandlers_HandleRecordsEdit(t *testing.T) {
 		task := &Task{ID: 1, UserID: 2} // Task belongs to another user
 		record := &Record{ID: 1, TaskID: 1, Task: task}
 		repo.On("RecordByIDWithTask", 1).Return(record, nil)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/1", nil)
@@ -452,7 +553,7 @@ func TestDashboardHandlers_HandleRecordsEdit(t *testing.T) {
 		}
 
 		repo.On("RecordByIDWithTask", 1).Return(record, nil)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records/1", nil)
@@ -483,7 +584,7 @@ func TestDashboardHandlers_HandleRecordsUpdate(t *testing.T) {
 		r := httptest.NewRequest(http.MethodPost, "/records/1", nil)
 		r.SetPathValue("id", "1")
 
-		repo.On("RecordByIDWithTask", 1).Return(nil, nil)
+		repo.On("RecordByIDWithTask", 1).Return(nil, utils.ErrNotFound)
 
 		handler.HandleRecordsUpdate(w, r)
 
@@ -543,7 +644,7 @@ func TestDashboardHandlers_HandleRecordsUpdate(t *testing.T) {
 		}
 
 		repo.On("RecordByIDWithTask", 1).Return(record, nil)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records/1", strings.NewReader(form.Encode()))
@@ -576,9 +677,9 @@ func TestDashboardHandlers_HandleRecordsUpdate(t *testing.T) {
 		}
 
 		repo.On("RecordByIDWithTask", 1).Return(record, nil)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
 		repo.On("UpdateRecord", mock.Anything).Return(nil)
-		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{})
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records/1", strings.NewReader(form.Encode()))
@@ -595,6 +696,38 @@ func TestDashboardHandlers_HandleRecordsUpdate(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "ok")
 	})
 
+	t.Run("OverlapConstraintViolation", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, TimeZone: "UTC"}
+		task := &Task{ID: 1, UserID: 1, Title: "Test Task"}
+		record := &Record{ID: 1, TaskID: 1, TimeStart: time.Now(), TimeEnd: nil, Task: task}
+
+		form := url.Values{
+			"task_id":    {"1"},
+			"time_start": {"2024-01-01T12:00"},
+			"time_end":   {"2024-01-01T14:00"},
+		}
+
+		repo.On("RecordByIDWithTask", 1).Return(record, nil)
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
+		repo.On("UpdateRecord", mock.Anything).Return(fmt.Errorf("%w: conflicting key value", ErrRecordsOverlap))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/records/1", strings.NewReader(form.Encode()))
+		r.SetPathValue("id", "1")
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleRecordsUpdate(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Contains(t, w.Body.String(), "The selected time overlaps with other entries")
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+	})
+
 	t.Run("IntersectingRecordsError", func(t *testing.T) {
 		repo := new(MockDashboardRepository)
 		handler := NewDashboardHandler(repo)
@@ -622,8 +755,8 @@ func TestDashboardHandlers_HandleRecordsUpdate(t *testing.T) {
 		}
 
 		repo.On("RecordByIDWithTask", 1).Return(record, nil)
-		repo.On("Tasks", user.ID, "").Return([]*Task{task})
-		repo.On("RecordsWithTasks", mock.Anything).Return(intersectingRecords)
+		repo.On("Tasks", user.ID, "").Return([]*Task{task}, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return(intersectingRecords, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/records/1", strings.NewReader(form.Encode()))
@@ -653,7 +786,7 @@ func TestDashboardHandlers_HandleRecordsDelete(t *testing.T) {
 		r := httptest.NewRequest(http.MethodDelete, "/records/1", nil)
 		r.SetPathValue("id", "1")
 
-		repo.On("RecordByIDWithTask", 1).Return(nil, nil)
+		repo.On("RecordByIDWithTask", 1).Return(nil, utils.ErrNotFound)
 
 		handler.HandleRecordsDelete(w, r)
 
@@ -673,12 +806,12 @@ func TestDashboardHandlers_HandleRecordsDelete(t *testing.T) {
 		ctx = context.WithValue(ctx, users.ContextUserKey, user)
 		r = r.WithContext(ctx)
 
-		repo.On("RecordByIDWithTask", 1).Return(nil, nil)
+		repo.On("RecordByIDWithTask", 1).Return(nil, utils.ErrNotFound)
 
 		handler.HandleRecordsDelete(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Record not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("Success", func(t *testing.T) {
@@ -706,6 +839,30 @@ func TestDashboardHandlers_HandleRecordsDelete(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 		assert.Equal(t, "ok", w.Body.String())
 	})
+
+	t.Run("DeletedByConcurrentRequest", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, TimeZone: "UTC"}
+		task := &Task{ID: 1, UserID: 1, Title: "Test Task"}
+		record := &Record{ID: 1, TaskID: 1, TimeStart: time.Now(), Task: task}
+
+		repo.On("RecordByIDWithTask", 1).Return(record, nil)
+		repo.On("DeleteRecord", 1).Return(fmt.Errorf("record 1: %w", utils.ErrNotFound))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/records/1", nil)
+		r.SetPathValue("id", "1")
+		r.Header.Set("HX-Request", "true")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleRecordsDelete(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
+	})
 }
 
 // docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_HandleRecordsList
@@ -762,6 +919,7 @@ func TestDashboardHandlers_HandleRecordsList(t *testing.T) {
 			},
 		}
 		repo.On("DailyRecords", mock.Anything, mock.Anything).Return(dailyRecords, nil)
+		repo.On("RecurringRecords", user.ID).Return([]*RecurringRecord{}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records", nil)
@@ -784,6 +942,7 @@ func TestDashboardHandlers_HandleRecordsList(t *testing.T) {
 		user := &users.User{ID: 1, TimeZone: "UTC"}
 
 		repo.On("DailyRecords", mock.Anything, mock.Anything).Return([]DailyRecords{}, nil)
+		repo.On("RecurringRecords", user.ID).Return([]*RecurringRecord{}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records", nil)
@@ -835,6 +994,7 @@ func TestDashboardHandlers_HandleRecordsList(t *testing.T) {
 			},
 		}
 		repo.On("DailyRecords", mock.Anything, mock.Anything).Return(dailyRecords, nil)
+		repo.On("RecurringRecords", user.ID).Return([]*RecurringRecord{}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/records?week=2024-W01", nil)
@@ -883,7 +1043,7 @@ func TestDashboardHandlers_getUserAndRecord(t *testing.T) {
 		handler.getUserAndRecord(w, r)
 
 		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Invalid record ID")
+		assert.Contains(t, w.Body.String(), "The request is invalid.")
 	})
 
 	t.Run("RecordNotFound", func(t *testing.T) {
@@ -896,13 +1056,13 @@ func TestDashboardHandlers_getUserAndRecord(t *testing.T) {
 		ctx := context.WithValue(r.Context(), users.ContextUserKey, user)
 		r = r.WithContext(ctx)
 
-		repo.On("RecordByIDWithTask", 1).Return(nil)
+		repo.On("RecordByIDWithTask", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		handler.getUserAndRecord(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Record not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("AccessDenied", func(t *testing.T) {
@@ -921,7 +1081,7 @@ func TestDashboardHandlers_getUserAndRecord(t *testing.T) {
 			Task:   &Task{UserID: 2}, // Task belongs to another user
 		}
 
-		repo.On("RecordByIDWithTask", 1).Return(record)
+		repo.On("RecordByIDWithTask", 1).Return(record, nil)
 
 		w := httptest.NewRecorder()
 		handler.getUserAndRecord(w, r)
@@ -946,7 +1106,7 @@ func TestDashboardHandlers_getUserAndRecord(t *testing.T) {
 			Task:   &Task{UserID: 1}, // Task belongs to the current user
 		}
 
-		repo.On("RecordByIDWithTask", 1).Return(record)
+		repo.On("RecordByIDWithTask", 1).Return(record, nil)
 
 		w := httptest.NewRecorder()
 		fetchedUser, fetchedRecord := handler.getUserAndRecord(w, r)
@@ -971,10 +1131,10 @@ func TestDashboardHandlers_validateIntersectingRecords(t *testing.T) {
 		}
 		formErrors := utils.FormErrors{}
 
-		handler.validateIntersectingRecords(form, user, 0, formErrors)
+		handler.validateIntersectingRecords(context.Background(), form, user, 0, formErrors)
 
 		assert.Contains(t, formErrors, "TimeEnd")
-		assert.Contains(t, formErrors["TimeEnd"], "Time End must be greater than Time Start")
+		assert.Contains(t, formErrors["TimeEnd"], template.HTML("Time End must be greater than Time Start"))
 	})
 
 	t.Run("IntersectingTaskInProgress", func(t *testing.T) {
@@ -997,9 +1157,9 @@ func TestDashboardHandlers_validateIntersectingRecords(t *testing.T) {
 			Comment:   "Ongoing task",
 			Task:      &Task{ID: 1, UserID: 1, Title: "Ongoing Task"},
 		}
-		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{intersectingRecord})
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{intersectingRecord}, nil)
 
-		handler.validateIntersectingRecords(form, user, 0, formErrors)
+		handler.validateIntersectingRecords(context.Background(), form, user, 0, formErrors)
 
 		assert.Contains(t, formErrors, "TimeEnd")
 		assert.Contains(t, formErrors["TimeEnd"][0], "The selected time overlaps with other entries:")
@@ -1017,9 +1177,9 @@ func TestDashboardHandlers_validateIntersectingRecords(t *testing.T) {
 		formErrors := utils.FormErrors{}
 
 		// Mock repository to return no intersecting records
-		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{})
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
 
-		handler.validateIntersectingRecords(form, user, 0, formErrors)
+		handler.validateIntersectingRecords(context.Background(), form, user, 0, formErrors)
 
 		assert.NotContains(t, formErrors, "TimeEnd") // No errors expected
 	})
@@ -1052,9 +1212,9 @@ func TestDashboardHandlers_validateIntersectingRecords(t *testing.T) {
 			Comment:   "Intersecting task 2",
 			Task:      &Task{ID: 2, UserID: 1, Title: "Task 2"},
 		}
-		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{intersectingRecord1, intersectingRecord2})
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{intersectingRecord1, intersectingRecord2}, nil)
 
-		handler.validateIntersectingRecords(form, user, 0, formErrors)
+		handler.validateIntersectingRecords(context.Background(), form, user, 0, formErrors)
 
 		assert.Contains(t, formErrors, "TimeEnd")
 		assert.Contains(t, formErrors["TimeEnd"][0], "The selected time overlaps with other entries:")
@@ -1082,9 +1242,9 @@ func TestDashboardHandlers_validateIntersectingRecords(t *testing.T) {
 			Comment:   "Ongoing task",
 			Task:      &Task{ID: 1, UserID: 1, Title: "Ongoing Task"},
 		}
-		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{intersectingRecord})
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{intersectingRecord}, nil)
 
-		handler.validateIntersectingRecords(form, user, 0, formErrors)
+		handler.validateIntersectingRecords(context.Background(), form, user, 0, formErrors)
 
 		assert.Contains(t, formErrors, "TimeEnd")
 		assert.Contains(t, formErrors["TimeEnd"][0], "You are already doing task:")
@@ -0,0 +1,293 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+type recurringRecordForm struct {
+	ID        int
+	TaskID    int    `form:"task_id" validate:"required"`
+	TimeStart string `form:"time_start" validate:"required,datetime=15:04" label:"Time Start"`
+	Duration  string `form:"duration" validate:"required,datetime=15:04" label:"Duration"`
+	Rule      string `form:"rule" validate:"required,max=255" label:"Schedule"`
+	DateStart string `form:"date_start" validate:"required,datetime=2006-01-02" label:"First Day"`
+	Comment   string `form:"comment" validate:"max=10000"`
+}
+
+// GET /recurring-records
+func (h *DashboardHandlers) HandleRecurringRecords(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+	recurringRecords, err := h.repo.RecurringRecords(r.Context(), user.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	tplData := utils.TplData{
+		"Title":            "Recurring Records",
+		"User":             user,
+		"RecurringRecords": recurringRecords,
+	}
+	if utils.IsHtmxRequest(r) {
+		utils.RenderTemplateWithoutLayout(w, "dashboard/recurring_record_list", "dashboard/recurring_record_list", tplData)
+	} else {
+		utils.RenderTemplate(w, "dashboard/recurring_records", tplData)
+	}
+}
+
+// GET /recurring-records/new
+func (h *DashboardHandlers) HandleRecurringRecordsNew(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RenderBlockNeedLogin(w)
+		return
+	}
+	now, _ := utils.NowWithTimezone(user.TimeZone)
+	form := recurringRecordForm{
+		TimeStart: "09:00",
+		Duration:  "01:00",
+		Rule:      "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		DateStart: now.Format("2006-01-02"),
+	}
+	h.renderRecurringRecordFormWithActiveTasks(w, r, user, form, utils.FormErrors{})
+}
+
+// POST /recurring-records
+func (h *DashboardHandlers) HandleRecurringRecordsCreate(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RenderBlockNeedLogin(w)
+		return
+	}
+
+	var form recurringRecordForm
+	err := utils.ParseFormToStruct(r, &form)
+	if err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	recurringRecord, formErrors := parseRecurringRecordForm(&form)
+	if formErrors.HasErrors() {
+		h.renderRecurringRecordFormWithActiveTasks(w, r, user, form, formErrors)
+		return
+	}
+
+	task, err := h.repo.TaskByID(r.Context(), form.TaskID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	if task.UserID != user.ID {
+		utils.RenderError(w, r, fmt.Errorf("task %d: %w", task.ID, utils.ErrForbidden))
+		return
+	}
+
+	_, err = h.repo.CreateRecurringRecord(r.Context(), recurringRecord)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	w.Header().Set("HX-Trigger", "load-recurring-records, load-records, close-modal")
+	w.Write([]byte("ok"))
+}
+
+// GET /recurring-records/{id}
+func (h *DashboardHandlers) HandleRecurringRecordsEdit(w http.ResponseWriter, r *http.Request) {
+	user, recurringRecord := h.getUserAndRecurringRecord(w, r)
+	if user == nil || recurringRecord == nil {
+		return
+	}
+
+	form := recurringRecordForm{
+		ID:        recurringRecord.ID,
+		TaskID:    recurringRecord.TaskID,
+		TimeStart: recurringRecord.FormatTimeOfDay(),
+		Duration:  time.Time{}.Add(recurringRecord.Duration).Format("15:04"),
+		Rule:      recurringRecord.Rule,
+		DateStart: recurringRecord.DateStart.Format("2006-01-02"),
+		Comment:   recurringRecord.Comment,
+	}
+	tasks, err := h.recurringRecordTasks(r, user, recurringRecord)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	h.renderRecurringRecordForm(w, form, utils.FormErrors{}, tasks)
+}
+
+// POST /recurring-records/{id}
+func (h *DashboardHandlers) HandleRecurringRecordsUpdate(w http.ResponseWriter, r *http.Request) {
+	user, existing := h.getUserAndRecurringRecord(w, r)
+	if user == nil || existing == nil {
+		return
+	}
+
+	var form recurringRecordForm
+	err := utils.ParseFormToStruct(r, &form)
+	if err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	form.ID = existing.ID
+
+	recurringRecord, formErrors := parseRecurringRecordForm(&form)
+	if formErrors.HasErrors() {
+		tasks, err := h.recurringRecordTasks(r, user, existing)
+		if err != nil {
+			utils.RenderError(w, r, err)
+			return
+		}
+		h.renderRecurringRecordForm(w, form, formErrors, tasks)
+		return
+	}
+
+	if form.TaskID != existing.TaskID {
+		task, err := h.repo.TaskByID(r.Context(), form.TaskID)
+		if err != nil {
+			utils.RenderError(w, r, err)
+			return
+		}
+		if task.UserID != user.ID {
+			utils.RenderError(w, r, fmt.Errorf("task %d: %w", task.ID, utils.ErrForbidden))
+			return
+		}
+	}
+
+	recurringRecord.ID = existing.ID
+	err = h.repo.UpdateRecurringRecord(r.Context(), recurringRecord)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	now, _ := utils.NowWithTimezone(user.TimeZone)
+	err = h.repo.SetRecurringRecordMaterialized(r.Context(), existing.ID, materializedAfterEdit(recurringRecord, existing.MaterializedUntil, now))
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	w.Header().Set("HX-Trigger", "load-recurring-records, load-records, close-modal")
+	w.Write([]byte("ok"))
+}
+
+// DELETE /recurring-records/{id}
+// The records created from the recurring record are kept.
+func (h *DashboardHandlers) HandleRecurringRecordsDelete(w http.ResponseWriter, r *http.Request) {
+	user, recurringRecord := h.getUserAndRecurringRecord(w, r)
+	if user == nil || recurringRecord == nil {
+		return
+	}
+	err := h.repo.DeleteRecurringRecord(r.Context(), recurringRecord.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	w.Header().Set("HX-Trigger", "load-recurring-records, load-records")
+	w.Write([]byte("ok"))
+}
+
+// Validates the form and converts it. The times are valid if there are no errors.
+func parseRecurringRecordForm(form *recurringRecordForm) (*RecurringRecord, utils.FormErrors) {
+	formErrors := utils.NewValidator(form).Validate()
+	if formErrors.HasErrors() {
+		return nil, formErrors
+	}
+	if _, err := ParseSchedule(form.Rule); err != nil {
+		formErrors.Add("Rule", "Schedule is invalid: "+err.Error())
+	}
+	timeStart, _ := time.Parse("15:04", form.TimeStart)
+	duration, _ := time.Parse("15:04", form.Duration)
+	dateStart, _ := time.Parse("2006-01-02", form.DateStart)
+	if duration.Hour() == 0 && duration.Minute() == 0 {
+		formErrors.Add("Duration", "Duration must be greater than 0")
+	}
+	if formErrors.HasErrors() {
+		return nil, formErrors
+	}
+	return &RecurringRecord{
+		TaskID:    form.TaskID,
+		TimeOfDay: time.Duration(timeStart.Hour())*time.Hour + time.Duration(timeStart.Minute())*time.Minute,
+		Duration:  time.Duration(duration.Hour())*time.Hour + time.Duration(duration.Minute())*time.Minute,
+		Rule:      form.Rule,
+		DateStart: dateStart,
+		Comment:   form.Comment,
+	}, formErrors
+}
+
+// Active tasks and the task of the recurring record if it is completed
+func (h *DashboardHandlers) recurringRecordTasks(r *http.Request, user *users.User, recurringRecord *RecurringRecord) ([]*Task, error) {
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	if recurringRecord.Task.IsCompleted {
+		tasks = append(tasks, recurringRecord.Task)
+	}
+	return tasks, nil
+}
+
+func (h *DashboardHandlers) renderRecurringRecordForm(w http.ResponseWriter, form recurringRecordForm, formErrors utils.FormErrors, tasks []*Task) {
+	utils.RenderTemplateWithoutLayout(w, "dashboard/recurring_record_form", "dashboard/recurring_record_form", utils.TplData{
+		"Errors": formErrors,
+		"Form":   form,
+		"Tasks":  tasks,
+	})
+}
+
+func (h *DashboardHandlers) renderRecurringRecordFormWithActiveTasks(w http.ResponseWriter, r *http.Request, user *users.User, form recurringRecordForm, formErrors utils.FormErrors) {
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, "")
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	h.renderRecurringRecordForm(w, form, formErrors, tasks)
+}
+
+func (h *DashboardHandlers) getUserAndRecurringRecord(w http.ResponseWriter, r *http.Request) (user *users.User, recurringRecord *RecurringRecord) {
+	user = users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RenderBlockNeedLogin(w)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.RenderError(w, r, fmt.Errorf("recurring record ID %q: %w", idStr, utils.ErrInvalidInput))
+		return
+	}
+
+	recurringRecord, err = h.repo.RecurringRecordByID(r.Context(), id)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return user, nil
+	}
+
+	if recurringRecord.UserID != user.ID {
+		utils.RenderError(w, r, fmt.Errorf("recurring record %d: %w", recurringRecord.ID, utils.ErrForbidden))
+		return user, nil
+	}
+	return
+}
+
+// DailyRecords of the user of filterRecords with the pending occurrences of the recurring records
+func (h *DashboardHandlers) dailyRecordsWithPending(ctx context.Context, filterRecords FilterRecords, nowWithTimezone time.Time) ([]DailyRecords, error) {
+	dailyRecords, err := h.repo.DailyRecords(ctx, filterRecords, nowWithTimezone)
+	if err != nil {
+		return nil, err
+	}
+	recurringRecords, err := h.repo.RecurringRecords(ctx, filterRecords.UserID)
+	if err != nil {
+		return nil, err
+	}
+	addPendingRecords(dailyRecords, recurringRecords)
+	return dailyRecords, nil
+}
@@ -0,0 +1,221 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_HandleRecurringRecords.*
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardHandlers_HandleRecurringRecords(t *testing.T) {
+	SetAppDir()
+	ctx := context.Background()
+	user := &users.User{ID: 1, TimeZone: "UTC", IsWeekStartMonday: true}
+	otherUser := &users.User{ID: 2, TimeZone: "UTC"}
+
+	// The repository with a task of the user and a recurring record of another user
+	newHandler := func(t *testing.T) (*DashboardHandlers, *DashboardRepositoryMem, int, int) {
+		repo := NewDashboardRepositoryMem()
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: "Standup", Color: "#FF5733"})
+		require.NoError(t, err)
+		otherTaskID, err := repo.CreateTask(ctx, &Task{UserID: otherUser.ID, Title: "Other"})
+		require.NoError(t, err)
+		otherID, err := repo.CreateRecurringRecord(ctx, &RecurringRecord{
+			TaskID: otherTaskID, TimeOfDay: time.Hour, Duration: time.Hour, Rule: "FREQ=DAILY", DateStart: time.Now(),
+		})
+		require.NoError(t, err)
+		return NewDashboardHandler(repo), repo, taskID, otherID
+	}
+	request := func(method, target string, form url.Values, u *users.User) *http.Request {
+		var r *http.Request
+		if form != nil {
+			r = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r = httptest.NewRequest(method, target, nil)
+		}
+		if u == nil {
+			return r
+		}
+		return r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, u))
+	}
+	validForm := func(taskID int) url.Values {
+		return url.Values{
+			"task_id":    {strconv.Itoa(taskID)},
+			"time_start": {"09:30"},
+			"duration":   {"01:15"},
+			"rule":       {"FREQ=WEEKLY;BYDAY=MO,WE"},
+			"date_start": {"2024-12-02"},
+			"comment":    {"Daily standup"},
+		}
+	}
+
+	t.Run("PageRedirectsToLogin", func(t *testing.T) {
+		handler, _, _, _ := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.HandleRecurringRecords(w, request(http.MethodGet, "/recurring-records", nil, nil))
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		handler, repo, taskID, _ := newHandler(t)
+
+		w := httptest.NewRecorder()
+		handler.HandleRecurringRecordsCreate(w, request(http.MethodPost, "/recurring-records", validForm(taskID), user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "load-recurring-records, load-records, close-modal", w.Header().Get("HX-Trigger"))
+		recurringRecords, err := repo.RecurringRecords(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, recurringRecords, 1)
+		assert.Equal(t, taskID, recurringRecords[0].TaskID)
+		assert.Equal(t, 9*time.Hour+30*time.Minute, recurringRecords[0].TimeOfDay)
+		assert.Equal(t, 75*time.Minute, recurringRecords[0].Duration)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", recurringRecords[0].Rule)
+		assert.Equal(t, time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC), recurringRecords[0].DateStart)
+		assert.Equal(t, "Daily standup", recurringRecords[0].Comment)
+
+		// The list shows only the recurring records of the user
+		w = httptest.NewRecorder()
+		handler.HandleRecurringRecords(w, request(http.MethodGet, "/recurring-records", nil, user))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "<html")
+		assert.Contains(t, w.Body.String(), "Standup")
+		assert.Contains(t, w.Body.String(), "09:30 (1h 15m), FREQ=WEEKLY;BYDAY=MO,WE")
+		assert.NotContains(t, w.Body.String(), "Other")
+	})
+
+	t.Run("CreateFormErrors", func(t *testing.T) {
+		handler, repo, taskID, _ := newHandler(t)
+		form := validForm(taskID)
+		form.Set("rule", "FREQ=HOURLY")
+		form.Set("duration", "00:00")
+
+		w := httptest.NewRecorder()
+		handler.HandleRecurringRecordsCreate(w, request(http.MethodPost, "/recurring-records", form, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+		assert.Contains(t, w.Body.String(), "Schedule is invalid")
+		assert.Contains(t, w.Body.String(), "Duration must be greater than 0")
+		recurringRecords, err := repo.RecurringRecords(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, recurringRecords)
+	})
+
+	t.Run("CreateForTaskOfAnotherUser", func(t *testing.T) {
+		handler, _, _, _ := newHandler(t)
+		w := httptest.NewRecorder()
+		handler.HandleRecurringRecordsCreate(w, request(http.MethodPost, "/recurring-records", validForm(2), user))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("EditAndUpdate", func(t *testing.T) {
+		handler, repo, taskID, _ := newHandler(t)
+		id, err := repo.CreateRecurringRecord(ctx, &RecurringRecord{
+			TaskID: taskID, TimeOfDay: 8 * time.Hour, Duration: 30 * time.Minute, Rule: "FREQ=DAILY", DateStart: time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r := request(http.MethodGet, "/recurring-records/"+strconv.Itoa(id), nil, user)
+		r.SetPathValue("id", strconv.Itoa(id))
+		handler.HandleRecurringRecordsEdit(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `value="08:00"`)
+		assert.Contains(t, w.Body.String(), `value="00:30"`)
+		assert.Contains(t, w.Body.String(), `value="FREQ=DAILY"`)
+
+		w = httptest.NewRecorder()
+		r = request(http.MethodPost, "/recurring-records/"+strconv.Itoa(id), validForm(taskID), user)
+		r.SetPathValue("id", strconv.Itoa(id))
+		handler.HandleRecurringRecordsUpdate(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "load-recurring-records, load-records, close-modal", w.Header().Get("HX-Trigger"))
+		recurringRecord, err := repo.RecurringRecordByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, 9*time.Hour+30*time.Minute, recurringRecord.TimeOfDay)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", recurringRecord.Rule)
+		// The job does not go back to the first day of 2024
+		require.NotNil(t, recurringRecord.MaterializedUntil)
+		assert.WithinDuration(t, time.Now(), *recurringRecord.MaterializedUntil, 48*time.Hour)
+	})
+
+	t.Run("AnotherUser", func(t *testing.T) {
+		handler, repo, _, otherID := newHandler(t)
+		id := strconv.Itoa(otherID)
+
+		w := httptest.NewRecorder()
+		r := request(http.MethodGet, "/recurring-records/"+id, nil, user)
+		r.SetPathValue("id", id)
+		handler.HandleRecurringRecordsEdit(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		r = request(http.MethodDelete, "/recurring-records/"+id, nil, user)
+		r.SetPathValue("id", id)
+		handler.HandleRecurringRecordsDelete(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		_, err := repo.RecurringRecordByID(ctx, otherID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		handler, repo, _, otherID := newHandler(t)
+		id := strconv.Itoa(otherID)
+
+		w := httptest.NewRecorder()
+		r := request(http.MethodDelete, "/recurring-records/"+id, nil, otherUser)
+		r.SetPathValue("id", id)
+		handler.HandleRecurringRecordsDelete(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "load-recurring-records, load-records", w.Header().Get("HX-Trigger"))
+		recurringRecords, err := repo.RecurringRecords(ctx, otherUser.ID)
+		require.NoError(t, err)
+		assert.Empty(t, recurringRecords)
+
+		w = httptest.NewRecorder()
+		handler.HandleRecurringRecordsDelete(w, r)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("PendingOnDashboard", func(t *testing.T) {
+		handler, repo, taskID, _ := newHandler(t)
+		_, err := repo.CreateRecurringRecord(ctx, &RecurringRecord{
+			TaskID: taskID, TimeOfDay: 9 * time.Hour, Duration: time.Hour, Rule: "FREQ=DAILY", DateStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		handler.HandleRecordsList(w, request(http.MethodGet, "/records?week=2024-W01", nil, user))
+		assert.Equal(t, http.StatusOK, w.Code)
+		// Every day of the week
+		assert.Equal(t, 7, strings.Count(w.Body.String(), "Pending: 09:00 - 10:00 (1h)"))
+	})
+
+	t.Run("PendingRepositoryError", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+		repo.On("DailyRecords", mock.Anything, mock.Anything).Return([]DailyRecords{}, nil)
+		repo.On("RecurringRecords", user.ID).Return(nil, errors.New("connection refused"))
+
+		w := httptest.NewRecorder()
+		handler.HandleRecordsList(w, request(http.MethodGet, "/records?week=2024-W01", nil, user))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "connection refused")
+	})
+}
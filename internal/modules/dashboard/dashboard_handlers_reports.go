@@ -17,7 +17,11 @@ func (h *DashboardHandlers) HandleReports(w http.ResponseWriter, r *http.Request
 	monthStr := r.URL.Query().Get("month")
 	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
 	startInterval, endInterval := getMonthInterval(monthStr, nowWithTimezone)
-	reportData := h.repo.Reports(user.ID, startInterval, endInterval, nowWithTimezone)
+	reportData, err := h.repo.Reports(r.Context(), user.ID, startInterval, endInterval, nowWithTimezone)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	tplData := utils.TplData{
 		"Title":         "Reports",
 		"User":          user,
@@ -26,10 +30,10 @@ func (h *DashboardHandlers) HandleReports(w http.ResponseWriter, r *http.Request
 		"PreviousMonth": startInterval.AddDate(0, -1, 0).Format("2006-01"),
 		"NextMonth":     startInterval.AddDate(0, 1, 0).Format("2006-01"),
 	}
-	if r.Header.Get("HX-Request") == "true" {
-		utils.RenderTemplateWithoutLayout(w, []string{"dashboard/reports"}, "content", tplData)
+	if utils.IsHtmxRequest(r) {
+		utils.RenderTemplateWithoutLayout(w, "dashboard/reports", "content", tplData)
 	} else {
-		utils.RenderTemplate(w, []string{"dashboard/reports"}, tplData)
+		utils.RenderTemplate(w, "dashboard/reports", tplData)
 	}
 }
 
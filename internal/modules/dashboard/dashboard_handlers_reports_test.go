@@ -66,7 +66,7 @@ func TestDashboardHandlers_HandleReports(t *testing.T) {
 
 		user := &users.User{ID: 1, TimeZone: "UTC"}
 
-		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData)
+		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/reports?month=2024-01", nil)
@@ -90,7 +90,7 @@ func TestDashboardHandlers_HandleReports(t *testing.T) {
 
 		user := &users.User{ID: 1, TimeZone: "UTC"}
 
-		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData)
+		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/reports?month=2023-12", nil)
@@ -114,7 +114,7 @@ func TestDashboardHandlers_HandleReports(t *testing.T) {
 
 		user := &users.User{ID: 1, TimeZone: "UTC"}
 
-		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData)
+		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/reports?month=2024-01", nil)
@@ -138,7 +138,7 @@ func TestDashboardHandlers_HandleReports(t *testing.T) {
 
 		user := &users.User{ID: 1, TimeZone: "UTC"}
 
-		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData)
+		repo.On("Reports", user.ID, mock.Anything, mock.Anything, mock.Anything).Return(reportData, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/reports?month=invalid", nil)
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time-tracker/internal/modules/users"
 	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/metrics"
 )
 
 type formTask struct {
@@ -38,7 +39,7 @@ func (h *DashboardHandlers) HandleTasksCreate(w http.ResponseWriter, r *http.Req
 	var form formTask
 	err := utils.ParseFormToStruct(r, &form)
 	if err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+		utils.RenderError(w, r, utils.ErrInvalidInput)
 		return
 	}
 	formErrors := utils.NewValidator(&form).Validate()
@@ -47,13 +48,18 @@ func (h *DashboardHandlers) HandleTasksCreate(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	h.repo.CreateTask(&Task{
+	_, err = h.repo.CreateTask(r.Context(), &Task{
 		UserID:      user.ID,
 		Title:       form.Title,
 		Description: form.Description,
 		Color:       form.Color,
 		IsCompleted: form.IsCompleted,
 	})
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	metrics.TasksCreated.Inc()
 
 	w.Header().Set("HX-Trigger", "load-tasks, close-modal")
 	w.Write([]byte("ok"))
@@ -85,7 +91,7 @@ func (h *DashboardHandlers) HandleTasksUpdate(w http.ResponseWriter, r *http.Req
 	var form formTask
 	err := utils.ParseFormToStruct(r, &form)
 	if err != nil {
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+		utils.RenderError(w, r, utils.ErrInvalidInput)
 		return
 	}
 	formErrors := utils.NewValidator(&form).Validate()
@@ -95,9 +101,14 @@ func (h *DashboardHandlers) HandleTasksUpdate(w http.ResponseWriter, r *http.Req
 	}
 
 	if form.IsCompleted != task.IsCompleted {
-		task.SortOrder = h.repo.GetMaxSortOrder(user.ID, form.IsCompleted) + 1
+		maxSortOrder, err := h.repo.GetMaxSortOrder(r.Context(), user.ID, form.IsCompleted)
+		if err != nil {
+			utils.RenderError(w, r, err)
+			return
+		}
+		task.SortOrder = maxSortOrder + 1
 	}
-	h.repo.UpdateTask(&Task{
+	err = h.repo.UpdateTask(r.Context(), &Task{
 		ID:          task.ID,
 		Title:       form.Title,
 		Description: form.Description,
@@ -105,6 +116,10 @@ func (h *DashboardHandlers) HandleTasksUpdate(w http.ResponseWriter, r *http.Req
 		IsCompleted: form.IsCompleted,
 		SortOrder:   task.SortOrder,
 	})
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 
 	w.Header().Set("HX-Trigger", "load-tasks, load-records, close-modal")
 	w.Write([]byte(`ok`))
@@ -116,7 +131,11 @@ func (h *DashboardHandlers) HandleTasksDelete(w http.ResponseWriter, r *http.Req
 	if user == nil || task == nil {
 		return
 	}
-	h.repo.DeleteTask(task.ID)
+	err := h.repo.DeleteTask(r.Context(), task.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 	w.Header().Set("HX-Trigger", "load-tasks, load-records")
 	w.Write([]byte(`ok`))
 }
@@ -129,8 +148,12 @@ func (h *DashboardHandlers) HandleTaskList(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	taskCompleted := r.URL.Query().Get("taskCompleted")
-	tasks := h.repo.Tasks(user.ID, taskCompleted)
-	utils.RenderTemplateWithoutLayout(w, []string{"dashboard/task_list"}, "dashboard/task_list", utils.TplData{
+	tasks, err := h.repo.Tasks(r.Context(), user.ID, taskCompleted)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	utils.RenderTemplateWithoutLayout(w, "dashboard/task_list", "dashboard/task_list", utils.TplData{
 		"Tasks":         tasks,
 		"TaskCompleted": taskCompleted,
 	})
@@ -159,9 +182,14 @@ func (h *DashboardHandlers) HandleUpdateSortOrder(w http.ResponseWriter, r *http
 	}
 
 	for _, task := range order {
-		err := h.repo.UpdateTaskSortOrder(task.ID, user.ID, task.SortOrder)
+		err := h.repo.UpdateTaskSortOrder(r.Context(), task.ID, user.ID, task.SortOrder)
 		if err != nil {
-			http.Error(w, "Error updating task order", http.StatusInternalServerError)
+			// The request is sent via fetch, so the text is enough instead of the error page
+			status, _, message := utils.ErrorStatus(err)
+			if status >= http.StatusInternalServerError {
+				utils.Logger(r.Context()).Error("HandleUpdateSortOrder UpdateTaskSortOrder", "taskID", task.ID, "err", err)
+			}
+			http.Error(w, message, status)
 			return
 		}
 	}
@@ -173,7 +201,7 @@ func (h *DashboardHandlers) HandleUpdateSortOrder(w http.ResponseWriter, r *http
 }
 
 func (h *DashboardHandlers) renderTaskForm(w http.ResponseWriter, form formTask, formErrors utils.FormErrors, url string) {
-	utils.RenderTemplateWithoutLayout(w, []string{"dashboard/task_form"}, "dashboard/task_form", utils.TplData{
+	utils.RenderTemplateWithoutLayout(w, "dashboard/task_form", "dashboard/task_form", utils.TplData{
 		"Errors": formErrors,
 		"Form":   form,
 		"URL":    url,
@@ -190,18 +218,18 @@ func (h *DashboardHandlers) getUserAndTask(w http.ResponseWriter, r *http.Reques
 	taskIDStr := r.PathValue("id")
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		utils.RenderError(w, r, fmt.Errorf("task ID %q: %w", taskIDStr, utils.ErrInvalidInput))
 		return
 	}
 
-	task = h.repo.TaskByID(taskID)
-	if task == nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
-		return
+	task, err = h.repo.TaskByID(r.Context(), taskID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return user, nil
 	}
 
 	if task.UserID != user.ID {
-		http.Error(w, "Access denied", http.StatusForbidden)
+		utils.RenderError(w, r, fmt.Errorf("task %d: %w", task.ID, utils.ErrForbidden))
 		return user, nil
 	}
 	return
@@ -5,6 +5,7 @@ package dashboard
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"testing"
 	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -141,6 +143,30 @@ func TestDashboardHandlers_HandleTasksCreate(t *testing.T) {
 		assert.Equal(t, "load-tasks, close-modal", w.Header().Get("HX-Trigger"))
 		assert.Equal(t, "ok", w.Body.String())
 	})
+
+	t.Run("CreateTaskError", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1}
+		form := url.Values{
+			"title": {"title test"},
+			"color": {"#DDAA88"},
+		}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/tasks/create", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		repo.On("CreateTask", mock.Anything).Return(0, errors.New("connection refused"))
+
+		handler.HandleTasksCreate(w, r)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+		assert.Contains(t, w.Body.String(), "Error. Please try again later.")
+	})
 }
 
 // docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_HandleTasksEdit
@@ -166,7 +192,7 @@ func TestDashboardHandlers_HandleTasksEdit(t *testing.T) {
 		handler := NewDashboardHandler(repo)
 
 		user := &users.User{ID: 1}
-		repo.On("TaskByID", 1).Return(nil)
+		repo.On("TaskByID", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
@@ -179,7 +205,7 @@ func TestDashboardHandlers_HandleTasksEdit(t *testing.T) {
 		handler.HandleTasksEdit(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Task not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("RenderTaskFormWithTask", func(t *testing.T) {
@@ -195,7 +221,7 @@ func TestDashboardHandlers_HandleTasksEdit(t *testing.T) {
 			Color:       "#FF5733",
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
@@ -222,7 +248,7 @@ func TestDashboardHandlers_HandleTasksEdit(t *testing.T) {
 			ID:     1,
 			UserID: 2, // Task belongs to another user
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
@@ -262,7 +288,7 @@ func TestDashboardHandlers_HandleTasksUpdate(t *testing.T) {
 		handler := NewDashboardHandler(repo)
 
 		user := &users.User{ID: 1}
-		repo.On("TaskByID", 1).Return(nil)
+		repo.On("TaskByID", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/tasks/1", nil)
@@ -275,7 +301,7 @@ func TestDashboardHandlers_HandleTasksUpdate(t *testing.T) {
 		handler.HandleTasksUpdate(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Task not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("ParseFormError", func(t *testing.T) {
@@ -291,7 +317,7 @@ func TestDashboardHandlers_HandleTasksUpdate(t *testing.T) {
 			Color:       "#FF5733",
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 		w := httptest.NewRecorder()
 		r := BadRequestPost("/tasks/1")
 		r.SetPathValue("id", "1")
@@ -319,7 +345,7 @@ func TestDashboardHandlers_HandleTasksUpdate(t *testing.T) {
 			Color:       "#FF5733",
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		form := url.Values{
 			"title":       {"title test"},
@@ -354,8 +380,8 @@ func TestDashboardHandlers_HandleTasksUpdate(t *testing.T) {
 			Color:       "#FF5733",
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
-		repo.On("GetMaxSortOrder", mock.Anything, mock.Anything).Return(1)
+		repo.On("TaskByID", 1).Return(task, nil)
+		repo.On("GetMaxSortOrder", mock.Anything, mock.Anything).Return(1, nil)
 
 		form := url.Values{
 			"title":        {"Updated Task Title"},
@@ -390,7 +416,7 @@ func TestDashboardHandlers_HandleTasksUpdate(t *testing.T) {
 			ID:     1,
 			UserID: 2, // Task belongs to another user
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPost, "/tasks/1", nil)
@@ -429,7 +455,7 @@ func TestDashboardHandlers_HandleTasksDelete(t *testing.T) {
 		handler := NewDashboardHandler(repo)
 
 		user := &users.User{ID: 1}
-		repo.On("TaskByID", 1).Return(nil)
+		repo.On("TaskByID", 1).Return(nil, utils.ErrNotFound)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
@@ -442,7 +468,7 @@ func TestDashboardHandlers_HandleTasksDelete(t *testing.T) {
 		handler.HandleTasksDelete(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Task not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("DeleteTaskSuccess", func(t *testing.T) {
@@ -458,7 +484,7 @@ func TestDashboardHandlers_HandleTasksDelete(t *testing.T) {
 			Color:       "#FF5733",
 			IsCompleted: false,
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 		repo.On("DeleteTask", task.ID).Return(nil)
 
 		w := httptest.NewRecorder()
@@ -485,7 +511,7 @@ func TestDashboardHandlers_HandleTasksDelete(t *testing.T) {
 			ID:     1,
 			UserID: 2, // Task belongs to another user
 		}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
@@ -524,7 +550,7 @@ func TestDashboardHandlers_HandleTaskList(t *testing.T) {
 		handler := NewDashboardHandler(repo)
 
 		user := &users.User{ID: 1}
-		repo.On("Tasks", user.ID, "").Return([]*Task{})
+		repo.On("Tasks", user.ID, "").Return([]*Task{}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
@@ -540,6 +566,27 @@ func TestDashboardHandlers_HandleTaskList(t *testing.T) {
 		assert.NotContains(t, w.Body.String(), "draggable")
 	})
 
+	t.Run("RepositoryErrorRendersFragment", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1}
+		repo.On("Tasks", user.ID, "").Return(nil, errors.New("connection refused"))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		r.Header.Set("HX-Request", "true")
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleTaskList(w, r)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+		assert.NotContains(t, w.Body.String(), "<html")
+		assert.Contains(t, w.Body.String(), `role="alert"`)
+		assert.Contains(t, w.Body.String(), "Error. Please try again later.")
+		assert.NotContains(t, w.Body.String(), "Create Task")
+	})
+
 	t.Run("RenderTaskListWithTasks", func(t *testing.T) {
 		repo := new(MockDashboardRepository)
 		handler := NewDashboardHandler(repo)
@@ -547,7 +594,7 @@ func TestDashboardHandlers_HandleTaskList(t *testing.T) {
 		user := &users.User{ID: 1}
 		task1 := &Task{ID: 1, Title: "Task 1", UserID: 1, IsCompleted: false}
 		task2 := &Task{ID: 2, Title: "Task 2", UserID: 1, IsCompleted: true}
-		repo.On("Tasks", user.ID, "").Return([]*Task{task1, task2})
+		repo.On("Tasks", user.ID, "").Return([]*Task{task1, task2}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
@@ -570,7 +617,7 @@ func TestDashboardHandlers_HandleTaskList(t *testing.T) {
 		user := &users.User{ID: 1}
 		task1 := &Task{ID: 1, Title: "Task 1", UserID: 1, IsCompleted: true}
 		task2 := &Task{ID: 2, Title: "Task 2", UserID: 1, IsCompleted: true}
-		repo.On("Tasks", user.ID, "true").Return([]*Task{task1, task2})
+		repo.On("Tasks", user.ID, "true").Return([]*Task{task1, task2}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks?taskCompleted=true", nil)
@@ -593,7 +640,7 @@ func TestDashboardHandlers_HandleTaskList(t *testing.T) {
 		user := &users.User{ID: 1}
 		task1 := &Task{ID: 1, Title: "Task 1", UserID: 1, IsCompleted: false}
 		task2 := &Task{ID: 2, Title: "Task 2", UserID: 1, IsCompleted: false}
-		repo.On("Tasks", user.ID, "false").Return([]*Task{task1, task2})
+		repo.On("Tasks", user.ID, "false").Return([]*Task{task1, task2}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks?taskCompleted=false", nil)
@@ -664,7 +711,7 @@ func TestDashboardHandlers_HandleUpdateSortOrder(t *testing.T) {
 		handler.HandleUpdateSortOrder(w, r)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Error updating task order")
+		assert.Contains(t, w.Body.String(), "Error. Please try again later.")
 	})
 
 	t.Run("UpdateSortOrderSuccess", func(t *testing.T) {
@@ -725,7 +772,7 @@ func TestDashboardHandlers_getUserAndTask(t *testing.T) {
 		handler.getUserAndTask(w, r)
 
 		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Invalid task ID")
+		assert.Contains(t, w.Body.String(), "The request is invalid.")
 	})
 
 	t.Run("TaskNotFound", func(t *testing.T) {
@@ -741,12 +788,12 @@ func TestDashboardHandlers_getUserAndTask(t *testing.T) {
 		ctx = context.WithValue(ctx, users.ContextUserKey, user)
 		r = r.WithContext(ctx)
 
-		repo.On("TaskByID", 999).Return(nil)
+		repo.On("TaskByID", 999).Return(nil, utils.ErrNotFound)
 
 		handler.getUserAndTask(w, r)
 
 		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Task not found")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
 	})
 
 	t.Run("AccessDeniedForOtherUser", func(t *testing.T) {
@@ -755,7 +802,7 @@ func TestDashboardHandlers_getUserAndTask(t *testing.T) {
 
 		user := &users.User{ID: 1}
 		task := &Task{ID: 1, UserID: 2}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
@@ -777,7 +824,7 @@ func TestDashboardHandlers_getUserAndTask(t *testing.T) {
 
 		user := &users.User{ID: 1}
 		task := &Task{ID: 1, UserID: 1}
-		repo.On("TaskByID", 1).Return(task)
+		repo.On("TaskByID", 1).Return(task, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
@@ -5,11 +5,16 @@ package dashboard
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -68,8 +73,9 @@ func TestDashboardHandlers_HandleDashboard(t *testing.T) {
 				},
 			},
 		}
-		repo.On("Tasks", user.ID, "").Return(tasks)
-		repo.On("DailyRecords", mock.Anything, mock.Anything).Return(dailyRecords)
+		repo.On("Tasks", user.ID, "").Return(tasks, nil)
+		repo.On("DailyRecords", mock.Anything, mock.Anything).Return(dailyRecords, nil)
+		repo.On("RecurringRecords", user.ID).Return([]*RecurringRecord{}, nil)
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
@@ -81,9 +87,32 @@ func TestDashboardHandlers_HandleDashboard(t *testing.T) {
 		handler.HandleDashboard(w, r)
 
 		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
-		assert.Contains(t, w.Body.String(), "Tasks & Records Dashboard")
+		assert.Contains(t, w.Body.String(), "Tasks &amp; Records Dashboard")
 		assert.Contains(t, w.Body.String(), "Test Task")
 		assert.Contains(t, w.Body.String(), "This is a test record")
+		// The live updates
+		assert.Contains(t, w.Body.String(), `sse-connect="/events"`)
+		assert.Regexp(t, `<script src='/js/htmx-ext-sse.js\?v=[0-9a-f]{16}'>`, w.Body.String())
+	})
+
+	t.Run("renders error page if the repository fails", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		handler := NewDashboardHandler(repo)
+
+		user := &users.User{ID: 1, TimeZone: "UTC", IsWeekStartMonday: true}
+		repo.On("DailyRecords", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		r = r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+
+		handler.HandleDashboard(w, r)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+		assert.Contains(t, w.Body.String(), "<html")
+		assert.Contains(t, w.Body.String(), "Error. Please try again later.")
+		assert.NotContains(t, w.Body.String(), "connection refused")
+		repo.AssertNotCalled(t, "Tasks", mock.Anything, mock.Anything)
 	})
 }
 
@@ -118,3 +147,87 @@ func TestDashboardHandlers_getWeekInterval(t *testing.T) {
 		assert.True(t, start.Before(end))
 	})
 }
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardHandlers_XSS
+func TestDashboardHandlers_XSS(t *testing.T) {
+	SetAppDir()
+	const titlePayload = `<script>alert("title")</script>`
+	const commentPayload = `"><img src=x onerror=alert("comment")>`
+
+	ctx := context.Background()
+	repo := NewDashboardRepositoryMem()
+	handler := NewDashboardHandler(repo)
+	user := &users.User{ID: 1, TimeZone: "UTC", IsWeekStartMonday: true}
+
+	taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: titlePayload, Description: titlePayload, Color: "#FF5733"})
+	assert.NoError(t, err)
+	nowWithTimezone, _ := utils.NowWithTimezone(user.TimeZone)
+	timeStart := time.Date(nowWithTimezone.Year(), nowWithTimezone.Month(), nowWithTimezone.Day(), 0, 10, 0, 0, time.UTC)
+	timeEnd := timeStart.Add(time.Hour)
+	recordID, err := repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: timeStart, TimeEnd: &timeEnd, Comment: commentPayload})
+	assert.NoError(t, err)
+
+	assertEscaped := func(t *testing.T, body string) {
+		assert.NotContains(t, body, "<script>alert")
+		assert.NotContains(t, body, "<img src=x")
+	}
+	request := func(method, url string, form url.Values) *http.Request {
+		var r *http.Request
+		if form != nil {
+			r = httptest.NewRequest(method, url, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r = httptest.NewRequest(method, url, nil)
+		}
+		return r.WithContext(context.WithValue(r.Context(), users.ContextUserKey, user))
+	}
+
+	t.Run("Dashboard", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleDashboard(w, request(http.MethodGet, "/dashboard", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assertEscaped(t, w.Body.String())
+		assert.Contains(t, w.Body.String(), "&lt;script&gt;alert(&#34;title&#34;)&lt;/script&gt;")
+		assert.Contains(t, w.Body.String(), "&#34;&gt;&lt;img src=x onerror=alert(&#34;comment&#34;)&gt;")
+	})
+
+	t.Run("TaskForm", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := request(http.MethodGet, "/tasks/1", nil)
+		r.SetPathValue("id", strconv.Itoa(taskID))
+		handler.HandleTasksEdit(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assertEscaped(t, w.Body.String())
+	})
+
+	t.Run("RecordForm", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := request(http.MethodGet, "/records/1", nil)
+		r.SetPathValue("id", strconv.Itoa(recordID))
+		handler.HandleRecordsEdit(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assertEscaped(t, w.Body.String())
+	})
+
+	t.Run("Reports", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.HandleReports(w, request(http.MethodGet, "/reports", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assertEscaped(t, w.Body.String())
+	})
+
+	// recordToString is the trusted HTML of the form error, the link stays, the title and the comment are escaped
+	t.Run("OverlapFormError", func(t *testing.T) {
+		form := url.Values{
+			"task_id":    {strconv.Itoa(taskID)},
+			"time_start": {timeStart.Add(30 * time.Minute).Format("2006-01-02T15:04")},
+			"time_end":   {timeEnd.Add(30 * time.Minute).Format("2006-01-02T15:04")},
+		}
+		w := httptest.NewRecorder()
+		handler.HandleRecordsCreate(w, request(http.MethodPost, "/records", form))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assertEscaped(t, w.Body.String())
+		assert.Contains(t, w.Body.String(), "The selected time overlaps with other entries")
+		assert.Contains(t, w.Body.String(), `<a href="/dashboard?record=`+strconv.Itoa(recordID)+`" target="_blank">`)
+	})
+}
@@ -1,6 +1,18 @@
 package dashboard
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// Returned by CreateRecord and UpdateRecord if the storage rejects the record.
+// validateIntersectingRecords checks the same rules, so these errors occur only when concurrent requests race.
+// Both are utils.ErrConflict.
+var ErrRecordsOverlap = fmt.Errorf("record overlaps with another record: %w", utils.ErrConflict)
+var ErrRecordInProgressExists = fmt.Errorf("another record is in progress: %w", utils.ErrConflict)
 
 type Task struct {
 	ID          int
@@ -28,9 +40,66 @@ type Record struct {
 	TimeEndIntraday   time.Time
 }
 
+// RecurringRecord is the template of the records created by RecurringRecordsService on the days of Rule
+type RecurringRecord struct {
+	ID     int
+	TaskID int
+	// The user of the task, set by the repository
+	UserID int
+	// The start after midnight in the wall clock time of the user, whole minutes
+	TimeOfDay time.Duration
+	// Whole minutes
+	Duration time.Duration
+	// See ParseSchedule, e.g. "FREQ=WEEKLY;BYDAY=MO,WE,FR"
+	Rule string
+	// The first day of the schedule, at midnight
+	DateStart time.Time
+	// The last day processed by the job, nil if none.
+	// The occurrences after it are pending, they are shown on the dashboard.
+	MaterializedUntil *time.Time
+	Comment           string
+
+	Task *Task
+}
+
+// Occurrence returns the times of the record created on the day
+func (rr *RecurringRecord) Occurrence(day time.Time) (timeStart time.Time, timeEnd time.Time) {
+	timeStart = day.Add(rr.TimeOfDay)
+	return timeStart, timeStart.Add(rr.Duration)
+}
+
+// FormatTimeOfDay returns the start as "15:04"
+func (rr *RecurringRecord) FormatTimeOfDay() string {
+	return time.Time{}.Add(rr.TimeOfDay).Format("15:04")
+}
+
+type FilterRecords struct {
+	UserID        int
+	RecordID      int
+	NotRecordID   int
+	StartInterval time.Time
+	EndInterval   time.Time
+	InProgress    bool
+	// If StartInterval is in the future, then time_end IS NULL entries should be excluded.
+	// Because we can consider time_end = now() and now() < StartInterval, i.e. time_end < StartInterval.
+	ExcludeInProgress bool
+}
+
 type DailyRecords struct {
 	Day     time.Time
 	Records []Record
+	// Occurrences of the recurring records not created yet, see addPendingRecords
+	Pending []PendingRecord
+}
+
+// PendingRecord is an occurrence of a recurring record that the job has not created yet
+type PendingRecord struct {
+	RecurringRecord *RecurringRecord
+	TimeStart       time.Time
+	TimeEnd         time.Time
+
+	StartPercent    float32
+	DurationPercent float32
 }
 
 type ReportRow struct {
@@ -47,25 +116,148 @@ type ReportData struct {
 	TotalDuration      time.Duration
 }
 
+// Methods that get or change one task or record by ID return utils.ErrNotFound if it does not exist.
+// Other errors are errors of the storage, they are not logged by the repository.
+// DashboardRepositoryContract in the tests describes the behavior that every implementation must follow.
 type DashboardRepository interface {
-	Tasks(userID int, taskCompleted string) (tasks []*Task)
-	TaskByID(id int) *Task
-	CreateTask(task *Task) (int, error)
-	UpdateTask(task *Task) error
-	DeleteTask(id int) error
-	GetMaxSortOrder(userId int, isCompleted bool) (maxSortOrder int)
-	UpdateTaskSortOrder(taskID, userID, sortOrder int) error
-
-	RecordsWithTasks(filterRecords FilterRecords) (records []*Record)
-	RecordByIDWithTask(recordID int) *Record
-	CreateRecord(record *Record) (int, error)
-	UpdateRecord(record *Record) error
-	DeleteRecord(recordID int) error
-	DailyRecords(filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords)
+	Tasks(ctx context.Context, userID int, taskCompleted string) (tasks []*Task, err error)
+	TaskByID(ctx context.Context, id int) (*Task, error)
+	CreateTask(ctx context.Context, task *Task) (int, error)
+	UpdateTask(ctx context.Context, task *Task) error
+	DeleteTask(ctx context.Context, id int) error
+	GetMaxSortOrder(ctx context.Context, userId int, isCompleted bool) (maxSortOrder int, err error)
+	UpdateTaskSortOrder(ctx context.Context, taskID, userID, sortOrder int) error
+
+	RecordsWithTasks(ctx context.Context, filterRecords FilterRecords) (records []*Record, err error)
+	RecordByIDWithTask(ctx context.Context, recordID int) (*Record, error)
+	CreateRecord(ctx context.Context, record *Record) (int, error)
+	UpdateRecord(ctx context.Context, record *Record) error
+	DeleteRecord(ctx context.Context, recordID int) error
+	// Records in progress of all users, i.e. the running timers
+	CountRecordsInProgress(ctx context.Context) (int, error)
+	DailyRecords(ctx context.Context, filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords, err error)
 	Reports(
+		ctx context.Context,
 		userID int,
 		startInterval time.Time,
 		endInterval time.Time,
 		nowWithTimezone time.Time,
-	) ReportData
+	) (ReportData, error)
+
+	// Recurring records of the user with their tasks, of all users if userID is 0
+	RecurringRecords(ctx context.Context, userID int) ([]*RecurringRecord, error)
+	RecurringRecordByID(ctx context.Context, id int) (*RecurringRecord, error)
+	CreateRecurringRecord(ctx context.Context, recurringRecord *RecurringRecord) (int, error)
+	// Changes everything but MaterializedUntil, the records created before are kept
+	UpdateRecurringRecord(ctx context.Context, recurringRecord *RecurringRecord) error
+	DeleteRecurringRecord(ctx context.Context, id int) error
+	SetRecurringRecordMaterialized(ctx context.Context, id int, materializedUntil time.Time) error
+}
+
+// Splits the records into the days of the filter interval.
+// A record that lasts several days is added to each day with the intraday start, end and duration.
+func groupDailyRecords(records []*Record, filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords) {
+	dateFirstDay := filterRecords.StartInterval.Truncate(24 * time.Hour)
+	dateLastDay := filterRecords.EndInterval.Truncate(24 * time.Hour)
+
+	dayMap := make(map[time.Time][]Record)
+	for d := dateFirstDay; !d.After(dateLastDay); d = d.Add(24 * time.Hour) {
+		dayMap[d] = []Record{}
+	}
+
+	for _, record := range records {
+		timeEnd := record.TimeEnd
+		if timeEnd == nil {
+			timeEnd = &nowWithTimezone
+		}
+		lastDayRecord := timeEnd.Truncate(24 * time.Hour)
+
+		dayRecord := record.TimeStart.Truncate(24 * time.Hour)
+		for ; !dayRecord.After(lastDayRecord) && !dayRecord.Equal(*timeEnd); dayRecord = dayRecord.Add(24 * time.Hour) {
+			// Because there will be different StartPercent, DurationPercent, Duration if the recording lasts several days
+			recordCopy := *record
+			dayEndRecord := dayRecord.Add(24 * time.Hour)
+
+			timeStartIntraday := recordCopy.TimeStart
+			if timeStartIntraday.Before(dayRecord) {
+				timeStartIntraday = dayRecord
+			}
+
+			timeEndIntraday := *timeEnd
+			// D("t", "timeEndIntraday", timeEndIntraday, "dayEndRecord", dayEndRecord, "1", timeEndIntraday.After(dayEndRecord))
+			if timeEndIntraday.After(dayEndRecord) {
+				timeEndIntraday = dayEndRecord
+			}
+
+			recordCopy.TimeStartIntraday = timeStartIntraday
+			recordCopy.TimeEndIntraday = timeEndIntraday
+			recordCopy.Duration = timeEndIntraday.Sub(timeStartIntraday)
+
+			totalDaySeconds := float32(86400)
+			recordCopy.StartPercent = float32(timeStartIntraday.Sub(dayRecord)/time.Second) / totalDaySeconds * 100
+			recordCopy.DurationPercent = float32(timeEndIntraday.Sub(timeStartIntraday)/time.Second) / totalDaySeconds * 100
+
+			dayMap[dayRecord] = append(dayMap[dayRecord], recordCopy)
+		}
+	}
+
+	for d := dateFirstDay; !d.After(dateLastDay); d = d.Add(24 * time.Hour) {
+		dailyRecords = append(dailyRecords, DailyRecords{
+			Day:     d,
+			Records: dayMap[d],
+		})
+	}
+
+	return dailyRecords
+}
+
+// Sums the durations of the daily records per task and per day
+func buildReportData(dailyRecords []DailyRecords) ReportData {
+	var reportRows []ReportRow
+	var days []time.Time
+	var totalDuration time.Duration
+	reportRowsMap := make(map[int]*ReportRow)
+	dailyTotalDuration := make(map[time.Time]time.Duration)
+
+	for _, dailyRecord := range dailyRecords {
+		days = append(days, dailyRecord.Day)
+		for _, record := range dailyRecord.Records {
+			// Create reportRowsMap[record.TaskID]
+			if _, exists := reportRowsMap[record.TaskID]; !exists {
+				reportRowsMap[record.TaskID] = &ReportRow{
+					Task:           record.Task,
+					DailyDurations: make(map[time.Time]time.Duration),
+				}
+			}
+			// Filling reportRowsMap[record.TaskID]
+			reportRowsMap[record.TaskID].DailyDurations[dailyRecord.Day] += record.Duration
+			reportRowsMap[record.TaskID].TotalDuration += record.Duration
+			dailyTotalDuration[dailyRecord.Day] += record.Duration
+			totalDuration += record.Duration
+		}
+	}
+	// Calculate DurationPercent
+	for _, row := range reportRowsMap {
+		if totalDuration > 0 {
+			row.DurationPercent = float64(row.TotalDuration) / float64(totalDuration) * 100
+		}
+	}
+	// reportRowsMap -> reportRows slice
+	for _, row := range reportRowsMap {
+		reportRows = append(reportRows, *row)
+	}
+	// Sort reportRows
+	sort.Slice(reportRows, func(i, j int) bool {
+		if reportRows[i].Task.IsCompleted != reportRows[j].Task.IsCompleted {
+			return !reportRows[i].Task.IsCompleted
+		}
+		return reportRows[i].Task.SortOrder < reportRows[j].Task.SortOrder
+	})
+
+	return ReportData{
+		ReportRows:         reportRows,
+		Days:               days,
+		DailyTotalDuration: dailyTotalDuration,
+		TotalDuration:      totalDuration,
+	}
 }
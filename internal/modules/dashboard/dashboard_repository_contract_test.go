@@ -0,0 +1,524 @@
+// For all go:build
+// The contract of DashboardRepository. Every implementation must pass it:
+// DashboardRepositoryMem in the unit tests, DashboardRepositoryPostgres in the integration tests.
+package dashboard
+
+import (
+	"context"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns an empty repository and two users without tasks
+type DashboardRepositoryFactory func(t *testing.T) (repo DashboardRepository, userID int, otherUserID int)
+
+func DashboardRepositoryContract(t *testing.T, newRepo DashboardRepositoryFactory) {
+	ctx := context.Background()
+
+	// 2024-12-02 is Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 12, day, hour, minute, 0, 0, time.UTC)
+	}
+	atPtr := func(day, hour, minute int) *time.Time {
+		tm := at(day, hour, minute)
+		return &tm
+	}
+	createTask := func(t *testing.T, repo DashboardRepository, userID int, title string, isCompleted bool) *Task {
+		task := &Task{UserID: userID, Title: title, Color: "#FF0000", IsCompleted: isCompleted}
+		id, err := repo.CreateTask(ctx, task)
+		require.NoError(t, err)
+		task.ID = id
+		return task
+	}
+	createRecord := func(t *testing.T, repo DashboardRepository, taskID int, timeStart time.Time, timeEnd *time.Time) *Record {
+		record := &Record{TaskID: taskID, TimeStart: timeStart, TimeEnd: timeEnd, Comment: "comment"}
+		id, err := repo.CreateRecord(ctx, record)
+		require.NoError(t, err)
+		record.ID = id
+		return record
+	}
+	recordIDs := func(records []*Record) (ids []int) {
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		return
+	}
+	taskTitles := func(tasks []*Task) (titles []string) {
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return
+	}
+
+	t.Run("CreateTaskSortOrder", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+
+		task1 := createTask(t, repo, userID, "Task 1", false)
+		task2 := createTask(t, repo, userID, "Task 2", false)
+		completed := createTask(t, repo, userID, "Completed", true)
+		other := createTask(t, repo, otherUserID, "Other", false)
+
+		assert.NotZero(t, task1.ID)
+		assert.NotEqual(t, task1.ID, task2.ID)
+		assert.Equal(t, 1, task1.SortOrder)
+		assert.Equal(t, 2, task2.SortOrder)
+		// The sort order is counted separately for completed tasks and for each user
+		assert.Equal(t, 1, completed.SortOrder)
+		assert.Equal(t, 1, other.SortOrder)
+
+		maxSortOrder, err := repo.GetMaxSortOrder(ctx, userID, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, maxSortOrder)
+		maxSortOrder, err = repo.GetMaxSortOrder(ctx, userID+otherUserID+1000, false)
+		require.NoError(t, err)
+		assert.Equal(t, 0, maxSortOrder)
+	})
+
+	t.Run("Tasks", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+
+		createTask(t, repo, userID, "Completed", true)
+		createTask(t, repo, userID, "Task 1", false)
+		task2 := createTask(t, repo, userID, "Task 2", false)
+		createTask(t, repo, otherUserID, "Other", false)
+		require.NoError(t, repo.UpdateTaskSortOrder(ctx, task2.ID, userID, 0))
+
+		tasks, err := repo.Tasks(ctx, userID, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Task 2", "Task 1"}, taskTitles(tasks))
+
+		tasks, err = repo.Tasks(ctx, userID, "completed")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Completed"}, taskTitles(tasks))
+
+		tasks, err = repo.Tasks(ctx, userID, "all")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Task 2", "Task 1", "Completed"}, taskTitles(tasks))
+
+		tasks, err = repo.Tasks(ctx, userID+otherUserID+1000, "all")
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("TaskByID", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		created := createTask(t, repo, userID, "Task 1", false)
+
+		task, err := repo.TaskByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created, task)
+
+		_, err = repo.TaskByID(ctx, created.ID+1000)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("UpdateTask", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		created := createTask(t, repo, userID, "Task 1", false)
+
+		updated := &Task{
+			ID:          created.ID,
+			UserID:      userID,
+			Title:       "Updated",
+			Description: "Description",
+			Color:       "#00FF00",
+			IsCompleted: true,
+			SortOrder:   7,
+		}
+		require.NoError(t, repo.UpdateTask(ctx, updated))
+
+		task, err := repo.TaskByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, updated, task)
+
+		err = repo.UpdateTask(ctx, &Task{ID: created.ID + 1000, Title: "Missing", Color: "#00FF00"})
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("DeleteTaskDeletesRecords", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		record := createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+
+		require.NoError(t, repo.DeleteTask(ctx, task.ID))
+
+		_, err := repo.TaskByID(ctx, task.ID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		_, err = repo.RecordByIDWithTask(ctx, record.ID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteTask(ctx, task.ID), utils.ErrNotFound)
+	})
+
+	t.Run("UpdateTaskSortOrderOfAnotherUser", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+
+		err := repo.UpdateTaskSortOrder(ctx, task.ID, otherUserID, 5)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+
+		unchanged, err := repo.TaskByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, unchanged.SortOrder)
+	})
+
+	t.Run("RecordsWithTasksFilters", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		otherTask := createTask(t, repo, otherUserID, "Other", false)
+
+		monday := createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+		tuesday := createRecord(t, repo, task.ID, at(3, 9, 0), atPtr(3, 10, 0))
+		inProgress := createRecord(t, repo, task.ID, at(4, 9, 0), nil)
+		createRecord(t, repo, otherTask.ID, at(2, 9, 0), atPtr(2, 10, 0))
+
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID})
+		require.NoError(t, err)
+		assert.Equal(t, []int{monday.ID, tuesday.ID, inProgress.ID}, recordIDs(records))
+		require.NotNil(t, records[0].Task)
+		assert.Equal(t, "Task 1", records[0].Task.Title)
+		assert.Equal(t, userID, records[0].Task.UserID)
+		assert.Equal(t, "comment", records[0].Comment)
+		assert.True(t, at(2, 9, 0).Equal(records[0].TimeStart))
+		require.NotNil(t, records[0].TimeEnd)
+		assert.True(t, at(2, 10, 0).Equal(*records[0].TimeEnd))
+		assert.Nil(t, records[2].TimeEnd)
+
+		records, err = repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID, RecordID: tuesday.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []int{tuesday.ID}, recordIDs(records))
+
+		records, err = repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID, NotRecordID: tuesday.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []int{monday.ID, inProgress.ID}, recordIDs(records))
+
+		records, err = repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID, InProgress: true})
+		require.NoError(t, err)
+		assert.Equal(t, []int{inProgress.ID}, recordIDs(records))
+
+		records, err = repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID, ExcludeInProgress: true})
+		require.NoError(t, err)
+		assert.Equal(t, []int{monday.ID, tuesday.ID}, recordIDs(records))
+	})
+
+	t.Run("RecordsWithTasksInterval", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+
+		before := createRecord(t, repo, task.ID, at(2, 8, 0), atPtr(2, 9, 0))
+		inside := createRecord(t, repo, task.ID, at(2, 9, 30), atPtr(2, 10, 30))
+		after := createRecord(t, repo, task.ID, at(2, 11, 0), atPtr(2, 12, 0))
+		inProgress := createRecord(t, repo, task.ID, at(2, 7, 0), nil)
+
+		// A record that ends at the start of the interval or starts at its end is not in the interval
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{
+			UserID:        userID,
+			StartInterval: at(2, 9, 0),
+			EndInterval:   at(2, 11, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{inProgress.ID, inside.ID}, recordIDs(records))
+
+		records, err = repo.RecordsWithTasks(ctx, FilterRecords{
+			UserID:        userID,
+			StartInterval: at(2, 8, 30),
+			EndInterval:   at(2, 11, 1),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{inProgress.ID, before.ID, inside.ID, after.ID}, recordIDs(records))
+	})
+
+	t.Run("RecordsWithTasksShareTask", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+		createRecord(t, repo, task.ID, at(3, 9, 0), atPtr(3, 10, 0))
+
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Same(t, records[0].Task, records[1].Task)
+	})
+
+	t.Run("RecordByIDWithTask", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		created := createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+
+		record, err := repo.RecordByIDWithTask(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, record.ID)
+		assert.Equal(t, task.ID, record.TaskID)
+		assert.Equal(t, "Task 1", record.Task.Title)
+
+		_, err = repo.RecordByIDWithTask(ctx, created.ID+1000)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("UpdateRecord", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		task2 := createTask(t, repo, userID, "Task 2", false)
+		created := createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+
+		// The record may overlap its previous version
+		err := repo.UpdateRecord(ctx, &Record{ID: created.ID, TaskID: task2.ID, TimeStart: at(2, 9, 30), TimeEnd: nil, Comment: "updated"})
+		require.NoError(t, err)
+
+		record, err := repo.RecordByIDWithTask(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, task2.ID, record.TaskID)
+		assert.Equal(t, "Task 2", record.Task.Title)
+		assert.True(t, at(2, 9, 30).Equal(record.TimeStart))
+		assert.Nil(t, record.TimeEnd)
+		assert.Equal(t, "updated", record.Comment)
+
+		err = repo.UpdateRecord(ctx, &Record{ID: created.ID + 1000, TaskID: task.ID, TimeStart: at(5, 9, 0), TimeEnd: atPtr(5, 10, 0)})
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("DeleteRecord", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		record := createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+
+		require.NoError(t, repo.DeleteRecord(ctx, record.ID))
+
+		_, err := repo.RecordByIDWithTask(ctx, record.ID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteRecord(ctx, record.ID), utils.ErrNotFound)
+		// The time of the deleted record is free
+		createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+	})
+
+	t.Run("CreateRecordOfMissingTask", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+
+		_, err := repo.CreateRecord(ctx, &Record{TaskID: task.ID + 1000, TimeStart: at(2, 9, 0), TimeEnd: atPtr(2, 10, 0)})
+		assert.Error(t, err)
+	})
+
+	t.Run("RecordsOverlap", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		task2 := createTask(t, repo, userID, "Task 2", false)
+		otherTask := createTask(t, repo, otherUserID, "Other", false)
+		existing := createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+
+		tests := []struct {
+			name      string
+			timeStart time.Time
+			timeEnd   *time.Time
+		}{
+			{"Same", at(2, 9, 0), atPtr(2, 10, 0)},
+			{"Inside", at(2, 9, 15), atPtr(2, 9, 45)},
+			{"Around", at(2, 8, 0), atPtr(2, 11, 0)},
+			{"StartsInside", at(2, 9, 59), atPtr(2, 11, 0)},
+			{"EndsInside", at(2, 8, 0), atPtr(2, 9, 1)},
+			{"InProgressStartsInside", at(2, 9, 30), nil},
+			{"InProgressStartsAtStart", at(2, 9, 0), nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Another task of the same user
+				_, err := repo.CreateRecord(ctx, &Record{TaskID: task2.ID, TimeStart: tt.timeStart, TimeEnd: tt.timeEnd})
+				assert.ErrorIs(t, err, ErrRecordsOverlap)
+				assert.ErrorIs(t, err, utils.ErrConflict)
+			})
+		}
+
+		t.Run("Adjacent", func(t *testing.T) {
+			before := createRecord(t, repo, task2.ID, at(2, 8, 0), atPtr(2, 9, 0))
+			after := createRecord(t, repo, task2.ID, at(2, 10, 0), atPtr(2, 11, 0))
+			// The running record starts when the previous record ends
+			inProgress := createRecord(t, repo, task2.ID, at(2, 11, 0), nil)
+			for _, record := range []*Record{before, after, inProgress} {
+				require.NoError(t, repo.DeleteRecord(ctx, record.ID))
+			}
+		})
+
+		t.Run("AnotherUser", func(t *testing.T) {
+			createRecord(t, repo, otherTask.ID, at(2, 9, 0), atPtr(2, 10, 0))
+		})
+
+		t.Run("Update", func(t *testing.T) {
+			moved := createRecord(t, repo, task2.ID, at(2, 12, 0), atPtr(2, 13, 0))
+
+			err := repo.UpdateRecord(ctx, &Record{ID: moved.ID, TaskID: task2.ID, TimeStart: at(2, 9, 30), TimeEnd: atPtr(2, 13, 0)})
+			assert.ErrorIs(t, err, ErrRecordsOverlap)
+
+			// The failed update does not change the record
+			record, err := repo.RecordByIDWithTask(ctx, moved.ID)
+			require.NoError(t, err)
+			assert.True(t, at(2, 12, 0).Equal(record.TimeStart))
+		})
+
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{UserID: userID, RecordID: existing.ID})
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("RecordInProgressExists", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		task2 := createTask(t, repo, userID, "Task 2", false)
+		otherTask := createTask(t, repo, otherUserID, "Other", false)
+		createRecord(t, repo, task.ID, at(2, 9, 0), nil)
+
+		_, err := repo.CreateRecord(ctx, &Record{TaskID: task2.ID, TimeStart: at(3, 9, 0), TimeEnd: nil})
+		assert.ErrorIs(t, err, ErrRecordInProgressExists)
+		assert.ErrorIs(t, err, utils.ErrConflict)
+
+		// Another user can have a running record at the same time
+		createRecord(t, repo, otherTask.ID, at(2, 9, 0), nil)
+
+		finished := createRecord(t, repo, task2.ID, at(3, 9, 0), atPtr(3, 10, 0))
+		err = repo.UpdateRecord(ctx, &Record{ID: finished.ID, TaskID: task2.ID, TimeStart: at(3, 9, 0), TimeEnd: nil})
+		assert.ErrorIs(t, err, ErrRecordInProgressExists)
+	})
+
+	// The count is of all users, the shared database of the integration tests can have other records
+	t.Run("CountRecordsInProgress", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		before, err := repo.CountRecordsInProgress(ctx)
+		require.NoError(t, err)
+
+		task := createTask(t, repo, userID, "Task 1", false)
+		otherTask := createTask(t, repo, otherUserID, "Other", false)
+		createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 10, 0))
+		createRecord(t, repo, task.ID, at(2, 11, 0), nil)
+		createRecord(t, repo, otherTask.ID, at(2, 11, 0), nil)
+
+		count, err := repo.CountRecordsInProgress(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before+2, count)
+	})
+
+	t.Run("DailyRecords", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		// Lasts over midnight
+		createRecord(t, repo, task.ID, at(2, 22, 0), atPtr(3, 2, 0))
+		createRecord(t, repo, task.ID, at(4, 9, 0), nil)
+
+		filter := FilterRecords{UserID: userID, StartInterval: at(2, 0, 0), EndInterval: time.Date(2024, 12, 4, 23, 59, 59, 0, time.UTC)}
+		dailyRecords, err := repo.DailyRecords(ctx, filter, at(4, 12, 0))
+		require.NoError(t, err)
+
+		require.Len(t, dailyRecords, 3)
+		assert.True(t, at(2, 0, 0).Equal(dailyRecords[0].Day))
+		require.Len(t, dailyRecords[0].Records, 1)
+		assert.Equal(t, 2*time.Hour, dailyRecords[0].Records[0].Duration)
+		require.Len(t, dailyRecords[1].Records, 1)
+		assert.Equal(t, 2*time.Hour, dailyRecords[1].Records[0].Duration)
+		assert.True(t, at(3, 0, 0).Equal(dailyRecords[1].Records[0].TimeStartIntraday))
+		// The running record lasts until now
+		require.Len(t, dailyRecords[2].Records, 1)
+		assert.Equal(t, 3*time.Hour, dailyRecords[2].Records[0].Duration)
+	})
+
+	t.Run("Reports", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		task2 := createTask(t, repo, userID, "Task 2", false)
+		createRecord(t, repo, task.ID, at(2, 9, 0), atPtr(2, 12, 0))
+		createRecord(t, repo, task2.ID, at(3, 9, 0), atPtr(3, 10, 0))
+
+		report, err := repo.Reports(ctx, userID, at(1, 0, 0), time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC), at(31, 12, 0))
+		require.NoError(t, err)
+
+		assert.Len(t, report.Days, 31)
+		assert.Equal(t, 4*time.Hour, report.TotalDuration)
+		require.Len(t, report.ReportRows, 2)
+		assert.Equal(t, "Task 1", report.ReportRows[0].Task.Title)
+		assert.Equal(t, 3*time.Hour, report.ReportRows[0].TotalDuration)
+		assert.InDelta(t, 75.0, report.ReportRows[0].DurationPercent, 0.001)
+		assert.Equal(t, time.Hour, report.DailyTotalDuration[at(3, 0, 0)])
+	})
+
+	t.Run("RecurringRecords", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		task := createTask(t, repo, userID, "Task 1", false)
+		otherTask := createTask(t, repo, otherUserID, "Other", false)
+		createRecurringRecord := func(t *testing.T, taskID int, timeOfDay time.Duration) *RecurringRecord {
+			recurringRecord := &RecurringRecord{
+				TaskID:    taskID,
+				TimeOfDay: timeOfDay,
+				Duration:  90 * time.Minute,
+				Rule:      "FREQ=WEEKLY;BYDAY=MO,WE",
+				DateStart: at(2, 0, 0),
+				Comment:   "standup",
+			}
+			id, err := repo.CreateRecurringRecord(ctx, recurringRecord)
+			require.NoError(t, err)
+			recurringRecord.ID = id
+			return recurringRecord
+		}
+		late := createRecurringRecord(t, task.ID, 14*time.Hour)
+		early := createRecurringRecord(t, task.ID, 9*time.Hour+30*time.Minute)
+		other := createRecurringRecord(t, otherTask.ID, 9*time.Hour)
+
+		// The user is the user of the task, the task is loaded
+		recurringRecord, err := repo.RecurringRecordByID(ctx, early.ID)
+		require.NoError(t, err)
+		assert.Equal(t, userID, recurringRecord.UserID)
+		assert.Equal(t, 9*time.Hour+30*time.Minute, recurringRecord.TimeOfDay)
+		assert.Equal(t, 90*time.Minute, recurringRecord.Duration)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", recurringRecord.Rule)
+		assert.True(t, at(2, 0, 0).Equal(recurringRecord.DateStart))
+		assert.Nil(t, recurringRecord.MaterializedUntil)
+		assert.Equal(t, "standup", recurringRecord.Comment)
+		require.NotNil(t, recurringRecord.Task)
+		assert.Equal(t, "Task 1", recurringRecord.Task.Title)
+
+		_, err = repo.RecurringRecordByID(ctx, other.ID+1000)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+
+		// Ordered by the start
+		recurringRecords, err := repo.RecurringRecords(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, recurringRecords, 2)
+		assert.Equal(t, early.ID, recurringRecords[0].ID)
+		assert.Equal(t, late.ID, recurringRecords[1].ID)
+
+		// All users, the shared database of the integration tests can have others
+		recurringRecords, err = repo.RecurringRecords(ctx, 0)
+		require.NoError(t, err)
+		ids := []int{}
+		for _, recurringRecord := range recurringRecords {
+			ids = append(ids, recurringRecord.ID)
+		}
+		assert.Subset(t, ids, []int{early.ID, late.ID, other.ID})
+
+		require.NoError(t, repo.SetRecurringRecordMaterialized(ctx, late.ID, at(4, 0, 0)))
+		late.TimeOfDay = 15 * time.Hour
+		late.Rule = "FREQ=DAILY"
+		require.NoError(t, repo.UpdateRecurringRecord(ctx, late))
+		recurringRecord, err = repo.RecurringRecordByID(ctx, late.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Hour, recurringRecord.TimeOfDay)
+		assert.Equal(t, "FREQ=DAILY", recurringRecord.Rule)
+		// The update keeps the progress of the job
+		require.NotNil(t, recurringRecord.MaterializedUntil)
+		assert.True(t, at(4, 0, 0).Equal(*recurringRecord.MaterializedUntil))
+
+		err = repo.UpdateRecurringRecord(ctx, &RecurringRecord{ID: other.ID + 1000, TaskID: task.ID, Duration: time.Hour, Rule: "FREQ=DAILY", DateStart: at(2, 0, 0)})
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		err = repo.SetRecurringRecordMaterialized(ctx, other.ID+1000, at(4, 0, 0))
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+
+		require.NoError(t, repo.DeleteRecurringRecord(ctx, early.ID))
+		_, err = repo.RecurringRecordByID(ctx, early.ID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteRecurringRecord(ctx, early.ID), utils.ErrNotFound)
+
+		// Deleted with the task
+		require.NoError(t, repo.DeleteTask(ctx, task.ID))
+		_, err = repo.RecurringRecordByID(ctx, late.ID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+}
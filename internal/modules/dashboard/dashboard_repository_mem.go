@@ -0,0 +1,520 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// DashboardRepositoryMem has the same semantics as DashboardRepositoryPostgres,
+// including the constraints of the records, see the migration add_records_overlap_constraints.
+// Returned tasks and records are copies, changing them does not change the repository.
+type DashboardRepositoryMem struct {
+	mu                    sync.Mutex
+	tasks                 map[int]*Task
+	records               map[int]*Record
+	recurringRecords      map[int]*RecurringRecord
+	nextTaskID            int
+	nextRecordID          int
+	nextRecurringRecordID int
+}
+
+func NewDashboardRepositoryMem() *DashboardRepositoryMem {
+	return &DashboardRepositoryMem{
+		tasks:                 make(map[int]*Task),
+		records:               make(map[int]*Record),
+		recurringRecords:      make(map[int]*RecurringRecord),
+		nextTaskID:            1,
+		nextRecordID:          1,
+		nextRecurringRecordID: 1,
+	}
+}
+
+func (repo *DashboardRepositoryMem) Tasks(_ context.Context, userID int, taskCompleted string) (tasks []*Task, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, task := range repo.tasks {
+		if task.UserID != userID {
+			continue
+		}
+		switch taskCompleted {
+		case "completed":
+			if !task.IsCompleted {
+				continue
+			}
+		case "all":
+		default:
+			if task.IsCompleted {
+				continue
+			}
+		}
+		taskCopy := *task
+		tasks = append(tasks, &taskCopy)
+	}
+
+	// ORDER BY is_completed ASC, sort_order ASC
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].IsCompleted != tasks[j].IsCompleted {
+			return !tasks[i].IsCompleted
+		}
+		if tasks[i].SortOrder != tasks[j].SortOrder {
+			return tasks[i].SortOrder < tasks[j].SortOrder
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+func (repo *DashboardRepositoryMem) TaskByID(_ context.Context, id int) (*Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	task, exists := repo.tasks[id]
+	if !exists {
+		return nil, fmt.Errorf("task %d: %w", id, utils.ErrNotFound)
+	}
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (repo *DashboardRepositoryMem) CreateTask(_ context.Context, task *Task) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	task.SortOrder = repo.maxSortOrder(task.UserID, task.IsCompleted) + 1
+
+	taskCopy := *task
+	taskCopy.ID = repo.nextTaskID
+	repo.nextTaskID++
+	repo.tasks[taskCopy.ID] = &taskCopy
+	return taskCopy.ID, nil
+}
+
+// The owner of the task is not changed, as in UPDATE tasks of DashboardRepositoryPostgres
+func (repo *DashboardRepositoryMem) UpdateTask(_ context.Context, task *Task) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	existing, exists := repo.tasks[task.ID]
+	if !exists {
+		return fmt.Errorf("task %d: %w", task.ID, utils.ErrNotFound)
+	}
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.Color = task.Color
+	existing.IsCompleted = task.IsCompleted
+	existing.SortOrder = task.SortOrder
+	return nil
+}
+
+// Records and recurring records of the task are deleted too (ON DELETE CASCADE)
+func (repo *DashboardRepositoryMem) DeleteTask(_ context.Context, id int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.tasks[id]; !exists {
+		return fmt.Errorf("task %d: %w", id, utils.ErrNotFound)
+	}
+	delete(repo.tasks, id)
+	for recordID, record := range repo.records {
+		if record.TaskID == id {
+			delete(repo.records, recordID)
+		}
+	}
+	for recurringRecordID, recurringRecord := range repo.recurringRecords {
+		if recurringRecord.TaskID == id {
+			delete(repo.recurringRecords, recurringRecordID)
+		}
+	}
+	return nil
+}
+
+func (repo *DashboardRepositoryMem) GetMaxSortOrder(_ context.Context, userId int, isCompleted bool) (maxSortOrder int, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.maxSortOrder(userId, isCompleted), nil
+}
+
+// The caller must hold the lock
+func (repo *DashboardRepositoryMem) maxSortOrder(userID int, isCompleted bool) (maxSortOrder int) {
+	for _, task := range repo.tasks {
+		if task.UserID == userID && task.IsCompleted == isCompleted && task.SortOrder > maxSortOrder {
+			maxSortOrder = task.SortOrder
+		}
+	}
+	return
+}
+
+func (repo *DashboardRepositoryMem) UpdateTaskSortOrder(_ context.Context, taskID, userID, sortOrder int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	task, exists := repo.tasks[taskID]
+	if !exists || task.UserID != userID {
+		return fmt.Errorf("task %d: %w", taskID, utils.ErrNotFound)
+	}
+	task.SortOrder = sortOrder
+	return nil
+}
+
+func (repo *DashboardRepositoryMem) RecordsWithTasks(_ context.Context, filterRecords FilterRecords) (records []*Record, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// One copy of a task for all its records, as in DashboardRepositoryPostgres
+	taskMap := make(map[int]*Task)
+	for _, record := range repo.records {
+		task := repo.tasks[record.TaskID]
+		if !matchFilterRecords(record, task, filterRecords) {
+			continue
+		}
+
+		recordCopy := *record
+		if _, exists := taskMap[task.ID]; !exists {
+			taskCopy := *task
+			taskMap[task.ID] = &taskCopy
+		}
+		recordCopy.Task = taskMap[task.ID]
+		records = append(records, &recordCopy)
+	}
+
+	// ORDER BY r.time_start ASC
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].TimeStart.Equal(records[j].TimeStart) {
+			return records[i].TimeStart.Before(records[j].TimeStart)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+// The same conditions as the WHERE of RecordsWithTasks of DashboardRepositoryPostgres
+func matchFilterRecords(record *Record, task *Task, filterRecords FilterRecords) bool {
+	if filterRecords.UserID > 0 && task.UserID != filterRecords.UserID {
+		return false
+	}
+	if filterRecords.RecordID > 0 && record.ID != filterRecords.RecordID {
+		return false
+	}
+	if filterRecords.NotRecordID > 0 && record.ID == filterRecords.NotRecordID {
+		return false
+	}
+	// Recording must end after interval starts or does not end
+	if !filterRecords.StartInterval.IsZero() && record.TimeEnd != nil && !record.TimeEnd.After(filterRecords.StartInterval) {
+		return false
+	}
+	// Recording must start before the end of the interval
+	if !filterRecords.EndInterval.IsZero() && !record.TimeStart.Before(filterRecords.EndInterval) {
+		return false
+	}
+	if filterRecords.InProgress && record.TimeEnd != nil {
+		return false
+	}
+	if filterRecords.ExcludeInProgress && record.TimeEnd == nil {
+		return false
+	}
+	return true
+}
+
+func (repo *DashboardRepositoryMem) RecordByIDWithTask(ctx context.Context, recordID int) (*Record, error) {
+	records, err := repo.RecordsWithTasks(ctx, FilterRecords{
+		RecordID: recordID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("record %d: %w", recordID, utils.ErrNotFound)
+	}
+	return records[0], nil
+}
+
+func (repo *DashboardRepositoryMem) CreateRecord(_ context.Context, record *Record) (newRecordID int, error error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	err := repo.checkRecordConstraints(record, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	recordCopy := copyRecord(record)
+	recordCopy.ID = repo.nextRecordID
+	repo.nextRecordID++
+	repo.records[recordCopy.ID] = recordCopy
+	return recordCopy.ID, nil
+}
+
+func (repo *DashboardRepositoryMem) UpdateRecord(_ context.Context, record *Record) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.records[record.ID]; !exists {
+		return fmt.Errorf("record %d: %w", record.ID, utils.ErrNotFound)
+	}
+	err := repo.checkRecordConstraints(record, record.ID)
+	if err != nil {
+		return err
+	}
+
+	repo.records[record.ID] = copyRecord(record)
+	return nil
+}
+
+func (repo *DashboardRepositoryMem) DeleteRecord(_ context.Context, recordID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.records[recordID]; !exists {
+		return fmt.Errorf("record %d: %w", recordID, utils.ErrNotFound)
+	}
+	delete(repo.records, recordID)
+	return nil
+}
+
+func (repo *DashboardRepositoryMem) CountRecordsInProgress(_ context.Context) (count int, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, record := range repo.records {
+		if record.TimeEnd == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (repo *DashboardRepositoryMem) DailyRecords(ctx context.Context, filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords, err error) {
+	records, err := repo.RecordsWithTasks(ctx, filterRecords)
+	if err != nil {
+		return nil, err
+	}
+	return groupDailyRecords(records, filterRecords, nowWithTimezone), nil
+}
+
+func (repo *DashboardRepositoryMem) Reports(
+	ctx context.Context,
+	userID int,
+	startInterval time.Time,
+	endInterval time.Time,
+	nowWithTimezone time.Time,
+) (ReportData, error) {
+	recordsFilter := FilterRecords{
+		UserID:        userID,
+		StartInterval: startInterval,
+		EndInterval:   endInterval,
+	}
+	dailyRecords, err := repo.DailyRecords(ctx, recordsFilter, nowWithTimezone)
+	if err != nil {
+		return ReportData{}, err
+	}
+	return buildReportData(dailyRecords), nil
+}
+
+func (repo *DashboardRepositoryMem) RecurringRecords(_ context.Context, userID int) (recurringRecords []*RecurringRecord, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, recurringRecord := range repo.recurringRecords {
+		if userID > 0 && recurringRecord.UserID != userID {
+			continue
+		}
+		recurringRecords = append(recurringRecords, repo.copyRecurringRecord(recurringRecord))
+	}
+
+	// ORDER BY rr.start_minute ASC, rr.id ASC
+	sort.Slice(recurringRecords, func(i, j int) bool {
+		if recurringRecords[i].TimeOfDay != recurringRecords[j].TimeOfDay {
+			return recurringRecords[i].TimeOfDay < recurringRecords[j].TimeOfDay
+		}
+		return recurringRecords[i].ID < recurringRecords[j].ID
+	})
+	return recurringRecords, nil
+}
+
+func (repo *DashboardRepositoryMem) RecurringRecordByID(_ context.Context, id int) (*RecurringRecord, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	recurringRecord, exists := repo.recurringRecords[id]
+	if !exists {
+		return nil, fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	return repo.copyRecurringRecord(recurringRecord), nil
+}
+
+func (repo *DashboardRepositoryMem) CreateRecurringRecord(_ context.Context, recurringRecord *RecurringRecord) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, err := repo.newStoredRecurringRecord(recurringRecord)
+	if err != nil {
+		return 0, err
+	}
+	stored.ID = repo.nextRecurringRecordID
+	stored.MaterializedUntil = nil
+	repo.nextRecurringRecordID++
+	repo.recurringRecords[stored.ID] = stored
+	return stored.ID, nil
+}
+
+func (repo *DashboardRepositoryMem) UpdateRecurringRecord(_ context.Context, recurringRecord *RecurringRecord) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	existing, exists := repo.recurringRecords[recurringRecord.ID]
+	if !exists {
+		return fmt.Errorf("recurring record %d: %w", recurringRecord.ID, utils.ErrNotFound)
+	}
+	stored, err := repo.newStoredRecurringRecord(recurringRecord)
+	if err != nil {
+		return err
+	}
+	stored.MaterializedUntil = existing.MaterializedUntil
+	repo.recurringRecords[stored.ID] = stored
+	return nil
+}
+
+func (repo *DashboardRepositoryMem) DeleteRecurringRecord(_ context.Context, id int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.recurringRecords[id]; !exists {
+		return fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	delete(repo.recurringRecords, id)
+	return nil
+}
+
+func (repo *DashboardRepositoryMem) SetRecurringRecordMaterialized(_ context.Context, id int, materializedUntil time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	recurringRecord, exists := repo.recurringRecords[id]
+	if !exists {
+		return fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	recurringRecord.MaterializedUntil = &materializedUntil
+	return nil
+}
+
+// Checks the constraints of the recurring_records table and sets the user of the task, as the INSERT does.
+// The caller must hold the lock.
+func (repo *DashboardRepositoryMem) newStoredRecurringRecord(recurringRecord *RecurringRecord) (*RecurringRecord, error) {
+	task, exists := repo.tasks[recurringRecord.TaskID]
+	if !exists {
+		return nil, fmt.Errorf("task %d of the recurring record does not exist", recurringRecord.TaskID)
+	}
+	startMinute, durationMinutes := minutes(recurringRecord.TimeOfDay), minutes(recurringRecord.Duration)
+	if startMinute < 0 || startMinute >= 24*60 || durationMinutes <= 0 {
+		return nil, fmt.Errorf("recurring record violates check constraint: start %d, duration %d", startMinute, durationMinutes)
+	}
+	return &RecurringRecord{
+		ID:                recurringRecord.ID,
+		TaskID:            recurringRecord.TaskID,
+		UserID:            task.UserID,
+		TimeOfDay:         time.Duration(startMinute) * time.Minute,
+		Duration:          time.Duration(durationMinutes) * time.Minute,
+		Rule:              recurringRecord.Rule,
+		DateStart:         recurringRecord.DateStart,
+		MaterializedUntil: recurringRecord.MaterializedUntil,
+		Comment:           recurringRecord.Comment,
+	}, nil
+}
+
+// Copies the recurring record with a copy of its task. The caller must hold the lock.
+func (repo *DashboardRepositoryMem) copyRecurringRecord(recurringRecord *RecurringRecord) *RecurringRecord {
+	recurringRecordCopy := *recurringRecord
+	if recurringRecord.MaterializedUntil != nil {
+		materializedUntil := *recurringRecord.MaterializedUntil
+		recurringRecordCopy.MaterializedUntil = &materializedUntil
+	}
+	taskCopy := *repo.tasks[recurringRecord.TaskID]
+	recurringRecordCopy.Task = &taskCopy
+	return &recurringRecordCopy
+}
+
+// Checks the constraints of the records table for the record that replaces currentRecordID (0 for a new record).
+// The caller must hold the lock.
+func (repo *DashboardRepositoryMem) checkRecordConstraints(record *Record, currentRecordID int) error {
+	task, exists := repo.tasks[record.TaskID]
+	if !exists {
+		// records.user_id is NOT NULL, the subquery of the task returns NULL
+		return fmt.Errorf("task %d of the record does not exist", record.TaskID)
+	}
+	newRange, err := newRecordRange(record)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range repo.records {
+		if other.ID == currentRecordID || repo.tasks[other.TaskID].UserID != task.UserID {
+			continue
+		}
+		// records_one_in_progress_per_user
+		if record.TimeEnd == nil && other.TimeEnd == nil {
+			return fmt.Errorf("%w: record %d", ErrRecordInProgressExists, other.ID)
+		}
+		otherRange, _ := newRecordRange(other)
+		// records_no_overlap
+		if newRange.overlaps(otherRange) {
+			return fmt.Errorf("%w: record %d", ErrRecordsOverlap, other.ID)
+		}
+	}
+	return nil
+}
+
+// The range of the records_no_overlap constraint:
+// [time_start, time_end) for a finished record, [time_start, time_start] for a record in progress.
+type recordRange struct {
+	lower          time.Time
+	upper          time.Time
+	upperInclusive bool
+}
+
+func newRecordRange(record *Record) (recordRange, error) {
+	if record.TimeEnd == nil {
+		return recordRange{lower: record.TimeStart, upper: record.TimeStart, upperInclusive: true}, nil
+	}
+	if record.TimeEnd.Before(record.TimeStart) {
+		return recordRange{}, errors.New("range lower bound must be less than or equal to range upper bound")
+	}
+	return recordRange{lower: record.TimeStart, upper: *record.TimeEnd}, nil
+}
+
+func (rr recordRange) isEmpty() bool {
+	return !rr.upperInclusive && !rr.lower.Before(rr.upper)
+}
+
+// The && operator of ranges: empty ranges do not overlap anything
+func (rr recordRange) overlaps(other recordRange) bool {
+	if rr.isEmpty() || other.isEmpty() {
+		return false
+	}
+	return startsBeforeEnd(rr.lower, other) && startsBeforeEnd(other.lower, rr)
+}
+
+func startsBeforeEnd(lower time.Time, rr recordRange) bool {
+	return lower.Before(rr.upper) || (rr.upperInclusive && lower.Equal(rr.upper))
+}
+
+// Copies the stored fields of the record, the task and the calculated fields are not stored
+func copyRecord(record *Record) *Record {
+	recordCopy := &Record{
+		ID:        record.ID,
+		TaskID:    record.TaskID,
+		TimeStart: record.TimeStart,
+		Comment:   record.Comment,
+	}
+	if record.TimeEnd != nil {
+		timeEnd := *record.TimeEnd
+		recordCopy.TimeEnd = &timeEnd
+	}
+	return recordCopy
+}
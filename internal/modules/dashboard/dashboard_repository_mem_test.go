@@ -0,0 +1,13 @@
+//go:build unit
+
+package dashboard
+
+import (
+	"testing"
+)
+
+func TestDashboardRepositoryMem_Contract(t *testing.T) {
+	DashboardRepositoryContract(t, func(t *testing.T) (DashboardRepository, int, int) {
+		return NewDashboardRepositoryMem(), 1, 2
+	})
+}
@@ -2,6 +2,7 @@ package dashboard
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -15,9 +16,11 @@ type PgxPool interface {
 
 type DashboardRepositoryPostgres struct {
 	// db *pgxpool.Pool
-	db PgxPool
+	db           PgxPool
+	queryTimeout time.Duration
 }
 
-func NewDashboardRepositoryPostgres(db PgxPool) *DashboardRepositoryPostgres {
-	return &DashboardRepositoryPostgres{db: db}
+// queryTimeout limits every query, 0 means no limit
+func NewDashboardRepositoryPostgres(db PgxPool, queryTimeout time.Duration) *DashboardRepositoryPostgres {
+	return &DashboardRepositoryPostgres{db: db, queryTimeout: queryTimeout}
 }
@@ -0,0 +1,51 @@
+//go:build integration
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=integration -run TestDashboardRepositoryPostgres_Contract
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs the contract on the database of the config, the users of each subtest are deleted with their tasks and records
+func TestDashboardRepositoryPostgres_Contract(t *testing.T) {
+	TShort(t)
+	ctx := context.Background()
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+
+	db, err := pgxpool.New(ctx, cfg.GetPostgresDSN())
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	if err := db.Ping(ctx); err != nil {
+		t.Skipf("Database is not available: %v", err)
+	}
+
+	createUser := func(t *testing.T) int {
+		var userID int
+		email := fmt.Sprintf("contract-%d@example.com", time.Now().UnixNano())
+		err := db.QueryRow(ctx, `
+			INSERT INTO users (name, email, password)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, "Contract", email, strings.Repeat("x", 60)).Scan(&userID)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, err := db.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+			require.NoError(t, err)
+		})
+		return userID
+	}
+
+	DashboardRepositoryContract(t, func(t *testing.T) (DashboardRepository, int, int) {
+		return NewDashboardRepositoryPostgres(db, cfg.DBQueryTimeout), createUser(t), createUser(t)
+	})
+}
@@ -2,26 +2,16 @@ package dashboard
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
-	"sort"
 	"strings"
 	"time"
-)
+	"time-tracker/internal/utils"
 
-type FilterRecords struct {
-	UserID        int
-	RecordID      int
-	NotRecordID   int
-	StartInterval time.Time
-	EndInterval   time.Time
-	InProgress    bool
-	// If StartInterval is in the future, then time_end IS NULL entries should be excluded.
-	// Because we can consider time_end = now() and now() < StartInterval, i.e. time_end < StartInterval.
-	ExcludeInProgress bool
-}
+	"github.com/jackc/pgx/v5/pgconn"
+)
 
-func (r *DashboardRepositoryPostgres) RecordsWithTasks(filterRecords FilterRecords) (records []*Record) {
+func (r *DashboardRepositoryPostgres) RecordsWithTasks(ctx context.Context, filterRecords FilterRecords) (records []*Record, err error) {
 	query := `
         SELECT 
             r.id, r.task_id, r.time_start, r.time_end, r.comment,
@@ -84,10 +74,11 @@ func (r *DashboardRepositoryPostgres) RecordsWithTasks(filterRecords FilterRecor
 
 	query += " ORDER BY r.time_start ASC"
 
-	rows, err := r.db.Query(context.Background(), query, args...)
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres RecordsWithTasks Query", "err", err)
-		return
+		return nil, fmt.Errorf("DashboardRepositoryPostgres RecordsWithTasks Query: %w", err)
 	}
 	defer rows.Close()
 
@@ -101,8 +92,7 @@ func (r *DashboardRepositoryPostgres) RecordsWithTasks(filterRecords FilterRecor
 			&task.ID, &task.UserID, &task.Title, &task.Description, &task.Color, &task.SortOrder, &task.IsCompleted,
 		)
 		if err != nil {
-			slog.Error("DashboardRepositoryPostgres RecordsWithTasks Scan", "err", err)
-			return
+			return nil, fmt.Errorf("DashboardRepositoryPostgres RecordsWithTasks Scan: %w", err)
 		}
 
 		if existingTask, exists := taskMap[task.ID]; exists {
@@ -115,172 +105,130 @@ func (r *DashboardRepositoryPostgres) RecordsWithTasks(filterRecords FilterRecor
 
 		records = append(records, &record)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("DashboardRepositoryPostgres RecordsWithTasks Rows: %w", err)
+	}
 
-	return
+	return records, nil
 }
 
-func (r *DashboardRepositoryPostgres) RecordByIDWithTask(recordID int) *Record {
-	records := r.RecordsWithTasks(FilterRecords{
+func (r *DashboardRepositoryPostgres) RecordByIDWithTask(ctx context.Context, recordID int) (*Record, error) {
+	records, err := r.RecordsWithTasks(ctx, FilterRecords{
 		RecordID: recordID,
 	})
+	if err != nil {
+		return nil, err
+	}
 	if len(records) == 0 {
-		return nil
+		return nil, fmt.Errorf("record %d: %w", recordID, utils.ErrNotFound)
 	}
-	return records[0]
+	return records[0], nil
 }
 
-func (r *DashboardRepositoryPostgres) CreateRecord(record *Record) (newRecordID int, error error) {
-	err := r.db.QueryRow(context.Background(), `
-        INSERT INTO records (task_id, time_start, time_end, comment)
-        VALUES ($1, $2, $3, $4)
+func (r *DashboardRepositoryPostgres) CreateRecord(ctx context.Context, record *Record) (newRecordID int, error error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err := r.db.QueryRow(ctx, `
+        INSERT INTO records (task_id, time_start, time_end, comment, user_id)
+        VALUES ($1, $2, $3, $4, (SELECT user_id FROM tasks WHERE id = $1))
         RETURNING id
     `, record.TaskID, record.TimeStart, record.TimeEnd, record.Comment).Scan(&newRecordID)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres CreateRecord QueryRow", "err", err)
-		return 0, err
+		if constraintErr := recordConstraintError(err); constraintErr != nil {
+			return 0, constraintErr
+		}
+		return 0, fmt.Errorf("DashboardRepositoryPostgres CreateRecord QueryRow: %w", err)
 	}
 	return newRecordID, nil
 }
 
-func (r *DashboardRepositoryPostgres) UpdateRecord(record *Record) error {
-	_, err := r.db.Exec(context.Background(), `
+func (r *DashboardRepositoryPostgres) UpdateRecord(ctx context.Context, record *Record) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
         UPDATE records
-        SET task_id = $1, time_start = $2, time_end = $3, comment = $4
+        SET task_id = $1, time_start = $2, time_end = $3, comment = $4, user_id = (SELECT user_id FROM tasks WHERE id = $1)
         WHERE id = $5
     `, record.TaskID, record.TimeStart, record.TimeEnd, record.Comment, record.ID)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres UpdateRecord Query", "err", err)
-		return err
+		if constraintErr := recordConstraintError(err); constraintErr != nil {
+			return constraintErr
+		}
+		return fmt.Errorf("DashboardRepositoryPostgres UpdateRecord Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("record %d: %w", record.ID, utils.ErrNotFound)
 	}
 	return nil
 }
 
-func (r *DashboardRepositoryPostgres) DeleteRecord(recordID int) error {
-	_, err := r.db.Exec(context.Background(), `
+// Maps violations of the records constraints, see the migration add_records_overlap_constraints
+func recordConstraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	switch {
+	// exclusion_violation
+	case pgErr.Code == "23P01" && pgErr.ConstraintName == "records_no_overlap":
+		return fmt.Errorf("%w: %s", ErrRecordsOverlap, pgErr.Message)
+	// unique_violation
+	case pgErr.Code == "23505" && pgErr.ConstraintName == "records_one_in_progress_per_user":
+		return fmt.Errorf("%w: %s", ErrRecordInProgressExists, pgErr.Message)
+	}
+	return nil
+}
+
+func (r *DashboardRepositoryPostgres) DeleteRecord(ctx context.Context, recordID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
         DELETE FROM records WHERE id = $1
     `, recordID)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres DeleteRecord Query", "err", err)
-		return err
+		return fmt.Errorf("DashboardRepositoryPostgres DeleteRecord Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("record %d: %w", recordID, utils.ErrNotFound)
 	}
 	return nil
 }
 
-func (r *DashboardRepositoryPostgres) DailyRecords(filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords) {
-	dateFirstDay := filterRecords.StartInterval.Truncate(24 * time.Hour)
-	dateLastDay := filterRecords.EndInterval.Truncate(24 * time.Hour)
-
-	dayMap := make(map[time.Time][]Record)
-	for d := dateFirstDay; !d.After(dateLastDay); d = d.Add(24 * time.Hour) {
-		dayMap[d] = []Record{}
-	}
-
-	records := r.RecordsWithTasks(filterRecords)
-
-	for _, record := range records {
-		timeEnd := record.TimeEnd
-		if timeEnd == nil {
-			timeEnd = &nowWithTimezone
-		}
-		lastDayRecord := timeEnd.Truncate(24 * time.Hour)
-
-		dayRecord := record.TimeStart.Truncate(24 * time.Hour)
-		for ; !dayRecord.After(lastDayRecord) && !dayRecord.Equal(*timeEnd); dayRecord = dayRecord.Add(24 * time.Hour) {
-			// Because there will be different StartPercent, DurationPercent, Duration if the recording lasts several days
-			recordCopy := *record
-			dayEndRecord := dayRecord.Add(24 * time.Hour)
-
-			timeStartIntraday := recordCopy.TimeStart
-			if timeStartIntraday.Before(dayRecord) {
-				timeStartIntraday = dayRecord
-			}
-
-			timeEndIntraday := *timeEnd
-			// D("t", "timeEndIntraday", timeEndIntraday, "dayEndRecord", dayEndRecord, "1", timeEndIntraday.After(dayEndRecord))
-			if timeEndIntraday.After(dayEndRecord) {
-				timeEndIntraday = dayEndRecord
-			}
-
-			recordCopy.TimeStartIntraday = timeStartIntraday
-			recordCopy.TimeEndIntraday = timeEndIntraday
-			recordCopy.Duration = timeEndIntraday.Sub(timeStartIntraday)
-
-			totalDaySeconds := float32(86400)
-			recordCopy.StartPercent = float32(timeStartIntraday.Sub(dayRecord)/time.Second) / totalDaySeconds * 100
-			recordCopy.DurationPercent = float32(timeEndIntraday.Sub(timeStartIntraday)/time.Second) / totalDaySeconds * 100
-
-			dayMap[dayRecord] = append(dayMap[dayRecord], recordCopy)
-		}
+func (r *DashboardRepositoryPostgres) CountRecordsInProgress(ctx context.Context) (count int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRow(ctx, `
+        SELECT COUNT(*) FROM records WHERE time_end IS NULL
+    `).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("DashboardRepositoryPostgres CountRecordsInProgress QueryRow: %w", err)
 	}
+	return
+}
 
-	for d := dateFirstDay; !d.After(dateLastDay); d = d.Add(24 * time.Hour) {
-		dailyRecords = append(dailyRecords, DailyRecords{
-			Day:     d,
-			Records: dayMap[d],
-		})
+func (r *DashboardRepositoryPostgres) DailyRecords(ctx context.Context, filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords, err error) {
+	records, err := r.RecordsWithTasks(ctx, filterRecords)
+	if err != nil {
+		return nil, err
 	}
-
-	return dailyRecords
+	return groupDailyRecords(records, filterRecords, nowWithTimezone), nil
 }
 
 func (r *DashboardRepositoryPostgres) Reports(
+	ctx context.Context,
 	userID int,
 	startInterval time.Time,
 	endInterval time.Time,
 	nowWithTimezone time.Time,
-) ReportData {
+) (ReportData, error) {
 	recordsFilter := FilterRecords{
 		UserID:        userID,
 		StartInterval: startInterval,
 		EndInterval:   endInterval,
 	}
-	dailyRecords := r.DailyRecords(recordsFilter, nowWithTimezone)
-
-	var reportRows []ReportRow
-	var days []time.Time
-	var totalDuration time.Duration
-	reportRowsMap := make(map[int]*ReportRow)
-	dailyTotalDuration := make(map[time.Time]time.Duration)
-
-	for _, dailyRecord := range dailyRecords {
-		days = append(days, dailyRecord.Day)
-		for _, record := range dailyRecord.Records {
-			// Create reportRowsMap[record.TaskID]
-			if _, exists := reportRowsMap[record.TaskID]; !exists {
-				reportRowsMap[record.TaskID] = &ReportRow{
-					Task:           record.Task,
-					DailyDurations: make(map[time.Time]time.Duration),
-				}
-			}
-			// Filling reportRowsMap[record.TaskID]
-			reportRowsMap[record.TaskID].DailyDurations[dailyRecord.Day] += record.Duration
-			reportRowsMap[record.TaskID].TotalDuration += record.Duration
-			dailyTotalDuration[dailyRecord.Day] += record.Duration
-			totalDuration += record.Duration
-		}
-	}
-	// Calculate DurationPercent
-	for _, row := range reportRowsMap {
-		if totalDuration > 0 {
-			row.DurationPercent = float64(row.TotalDuration) / float64(totalDuration) * 100
-		}
-	}
-	// reportRowsMap -> reportRows slice
-	for _, row := range reportRowsMap {
-		reportRows = append(reportRows, *row)
-	}
-	// Sort reportRows
-	sort.Slice(reportRows, func(i, j int) bool {
-		if reportRows[i].Task.IsCompleted != reportRows[j].Task.IsCompleted {
-			return !reportRows[i].Task.IsCompleted
-		}
-		return reportRows[i].Task.SortOrder < reportRows[j].Task.SortOrder
-	})
-
-	return ReportData{
-		ReportRows:         reportRows,
-		Days:               days,
-		DailyTotalDuration: dailyTotalDuration,
-		TotalDuration:      totalDuration,
+	dailyRecords, err := r.DailyRecords(ctx, recordsFilter, nowWithTimezone)
+	if err != nil {
+		return ReportData{}, err
 	}
+	return buildReportData(dailyRecords), nil
 }
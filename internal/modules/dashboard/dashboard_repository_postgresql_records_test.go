@@ -4,10 +4,13 @@
 package dashboard
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
+	"time-tracker/internal/utils"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,7 +21,7 @@ func TestDashboardRepositoryPostgres_RecordsWithTasks(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		filter := FilterRecords{
@@ -42,8 +45,9 @@ func TestDashboardRepositoryPostgres_RecordsWithTasks(t *testing.T) {
 			WithArgs(filter.UserID, filter.RecordID, filter.NotRecordID, filter.StartInterval, filter.EndInterval).
 			WillReturnRows(rows)
 
-		records := repo.RecordsWithTasks(filter)
+		records, err := repo.RecordsWithTasks(context.Background(), filter)
 
+		require.NoError(t, err)
 		// Diferent tasks for different records
 		require.Len(t, records, 2)
 		assert.Equal(t, 123, records[0].ID)
@@ -79,8 +83,9 @@ func TestDashboardRepositoryPostgres_RecordsWithTasks(t *testing.T) {
 			WithArgs(filter.UserID, filter.RecordID, filter.StartInterval, filter.EndInterval).
 			WillReturnRows(rows)
 
-		records := repo.RecordsWithTasks(filter)
+		records, err := repo.RecordsWithTasks(context.Background(), filter)
 
+		require.Error(t, err)
 		require.Len(t, records, 0)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -106,8 +111,9 @@ func TestDashboardRepositoryPostgres_RecordsWithTasks(t *testing.T) {
 			WithArgs(filter.UserID, filter.StartInterval, filter.EndInterval).
 			WillReturnRows(rows)
 
-		records := repo.RecordsWithTasks(filter)
+		records, err := repo.RecordsWithTasks(context.Background(), filter)
 
+		require.NoError(t, err)
 		// One task for two records
 		require.Len(t, records, 2)
 		assert.Equal(t, 123, records[0].ID)
@@ -135,8 +141,9 @@ func TestDashboardRepositoryPostgres_RecordsWithTasks(t *testing.T) {
 			WithArgs(filter.UserID, filter.RecordID, filter.StartInterval, filter.EndInterval).
 			WillReturnError(fmt.Errorf("query error"))
 
-		records := repo.RecordsWithTasks(filter)
+		records, err := repo.RecordsWithTasks(context.Background(), filter)
 
+		require.Error(t, err)
 		require.Len(t, records, 0)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -147,7 +154,7 @@ func TestDashboardRepositoryPostgres_RecordByIDWithTask(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		recordID := 123
@@ -163,8 +170,9 @@ func TestDashboardRepositoryPostgres_RecordByIDWithTask(t *testing.T) {
 			WithArgs(filter.RecordID).
 			WillReturnRows(rows)
 
-		record := repo.RecordByIDWithTask(recordID)
+		record, err := repo.RecordByIDWithTask(context.Background(), recordID)
 
+		require.NoError(t, err)
 		require.NotNil(t, record)
 		assert.Equal(t, recordID, record.ID)
 		assert.Equal(t, "Task 1", record.Task.Title)
@@ -182,8 +190,9 @@ func TestDashboardRepositoryPostgres_RecordByIDWithTask(t *testing.T) {
 				"id", "user_id", "title", "description", "color", "sort_order", "is_completed",
 			}))
 
-		record := repo.RecordByIDWithTask(recordID)
+		record, err := repo.RecordByIDWithTask(context.Background(), recordID)
 
+		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Nil(t, record)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -194,7 +203,7 @@ func TestDashboardRepositoryPostgres_CreateRecord(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		timeEnd := time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)
@@ -209,7 +218,7 @@ func TestDashboardRepositoryPostgres_CreateRecord(t *testing.T) {
 			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment).
 			WillReturnRows(mockPool.NewRows([]string{"id"}).AddRow(123))
 
-		newRecordID, err := repo.CreateRecord(record)
+		newRecordID, err := repo.CreateRecord(context.Background(), record)
 
 		require.NoError(t, err)
 		assert.Equal(t, 123, newRecordID)
@@ -229,7 +238,7 @@ func TestDashboardRepositoryPostgres_CreateRecord(t *testing.T) {
 			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment).
 			WillReturnError(fmt.Errorf("database insert error"))
 
-		newRecordID, err := repo.CreateRecord(record)
+		newRecordID, err := repo.CreateRecord(context.Background(), record)
 
 		require.Error(t, err)
 		assert.Equal(t, 0, newRecordID)
@@ -242,7 +251,7 @@ func TestDashboardRepositoryPostgres_UpdateRecord(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		timeStart := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
@@ -256,11 +265,11 @@ func TestDashboardRepositoryPostgres_UpdateRecord(t *testing.T) {
 			Comment:   "Updated Comment",
 		}
 
-		mockPool.ExpectExec(`^UPDATE records SET task_id = \$1, time_start = \$2, time_end = \$3, comment = \$4 WHERE id = \$5`).
+		mockPool.ExpectExec(`^UPDATE records SET task_id = \$1, time_start = \$2, time_end = \$3, comment = \$4, user_id = \(SELECT user_id FROM tasks WHERE id = \$1\) WHERE id = \$5`).
 			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment, record.ID).
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.UpdateRecord(record)
+		err := repo.UpdateRecord(context.Background(), record)
 
 		require.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -278,15 +287,88 @@ func TestDashboardRepositoryPostgres_UpdateRecord(t *testing.T) {
 			Comment:   "Updated Comment",
 		}
 
-		mockPool.ExpectExec(`^UPDATE records SET task_id = \$1, time_start = \$2, time_end = \$3, comment = \$4 WHERE id = \$5`).
+		mockPool.ExpectExec(`^UPDATE records SET task_id = \$1, time_start = \$2, time_end = \$3, comment = \$4, user_id = \(SELECT user_id FROM tasks WHERE id = \$1\) WHERE id = \$5`).
 			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment, record.ID).
 			WillReturnError(fmt.Errorf("database update error"))
 
-		err := repo.UpdateRecord(record)
+		err := repo.UpdateRecord(context.Background(), record)
 
 		require.Error(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+	t.Run("NotFound", func(t *testing.T) {
+		record := &Record{ID: 1, TaskID: 1, TimeStart: time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)}
+
+		mockPool.ExpectExec(`^UPDATE records`).
+			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment, record.ID).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdateRecord(context.Background(), record)
+
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestDashboardRepositoryPostgres_RecordConstraintErrors(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
+	timeEnd := time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)
+	record := &Record{
+		ID:        1,
+		TaskID:    1,
+		TimeStart: time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC),
+		TimeEnd:   &timeEnd,
+	}
+
+	t.Run("CreateOverlap", func(t *testing.T) {
+		mockPool.ExpectQuery(`^INSERT INTO records`).
+			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment).
+			WillReturnError(&pgconn.PgError{Code: "23P01", ConstraintName: "records_no_overlap"})
+
+		_, err := repo.CreateRecord(context.Background(), record)
+
+		require.ErrorIs(t, err, ErrRecordsOverlap)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("CreateInProgressExists", func(t *testing.T) {
+		mockPool.ExpectQuery(`^INSERT INTO records`).
+			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment).
+			WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "records_one_in_progress_per_user"})
+
+		_, err := repo.CreateRecord(context.Background(), record)
+
+		require.ErrorIs(t, err, ErrRecordInProgressExists)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateOverlap", func(t *testing.T) {
+		mockPool.ExpectExec(`^UPDATE records`).
+			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment, record.ID).
+			WillReturnError(&pgconn.PgError{Code: "23P01", ConstraintName: "records_no_overlap"})
+
+		err := repo.UpdateRecord(context.Background(), record)
+
+		require.ErrorIs(t, err, ErrRecordsOverlap)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("OtherConstraint", func(t *testing.T) {
+		mockPool.ExpectExec(`^UPDATE records`).
+			WithArgs(record.TaskID, record.TimeStart, record.TimeEnd, record.Comment, record.ID).
+			WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "records_task_id_user_id_fkey"})
+
+		err := repo.UpdateRecord(context.Background(), record)
+
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrRecordsOverlap)
+		require.NotErrorIs(t, err, ErrRecordInProgressExists)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
 
 func TestDashboardRepositoryPostgres_DeleteRecord(t *testing.T) {
@@ -294,7 +376,7 @@ func TestDashboardRepositoryPostgres_DeleteRecord(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		recordID := 1
@@ -303,7 +385,7 @@ func TestDashboardRepositoryPostgres_DeleteRecord(t *testing.T) {
 			WithArgs(recordID).
 			WillReturnResult(pgxmock.NewResult("DELETE", 1))
 
-		err := repo.DeleteRecord(recordID)
+		err := repo.DeleteRecord(context.Background(), recordID)
 
 		require.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -316,11 +398,24 @@ func TestDashboardRepositoryPostgres_DeleteRecord(t *testing.T) {
 			WithArgs(recordID).
 			WillReturnError(fmt.Errorf("database delete error"))
 
-		err := repo.DeleteRecord(recordID)
+		err := repo.DeleteRecord(context.Background(), recordID)
 
 		require.Error(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		recordID := 1
+
+		mockPool.ExpectExec(`^DELETE FROM records WHERE id = \$1`).
+			WithArgs(recordID).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		err := repo.DeleteRecord(context.Background(), recordID)
+
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
 
 func TestDashboardRepositoryPostgres_DailyRecords(t *testing.T) {
@@ -328,7 +423,7 @@ func TestDashboardRepositoryPostgres_DailyRecords(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		filter := FilterRecords{
@@ -350,8 +445,9 @@ func TestDashboardRepositoryPostgres_DailyRecords(t *testing.T) {
 				AddRow(2, 2, time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC), nil, "Test Record 2",
 					2, 2, "Task 2", "Another Description", "#00FF00", 2, true))
 
-		dailyRecords := repo.DailyRecords(filter, nowWithTimezone)
+		dailyRecords, err := repo.DailyRecords(context.Background(), filter, nowWithTimezone)
 
+		require.NoError(t, err)
 		require.Len(t, dailyRecords, 3)
 
 		assert.Equal(t, dailyRecords[0].Day, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))
@@ -385,8 +481,9 @@ func TestDashboardRepositoryPostgres_DailyRecords(t *testing.T) {
 				"id", "user_id", "title", "description", "color", "sort_order", "is_completed",
 			}))
 
-		dailyRecords := repo.DailyRecords(filter, nowWithTimezone)
+		dailyRecords, err := repo.DailyRecords(context.Background(), filter, nowWithTimezone)
 
+		require.NoError(t, err)
 		require.Len(t, dailyRecords, 3)
 
 		assert.Equal(t, dailyRecords[0].Day, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))
@@ -406,7 +503,7 @@ func TestDashboardRepositoryPostgres_Reports(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		startInterval := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
@@ -425,8 +522,9 @@ func TestDashboardRepositoryPostgres_Reports(t *testing.T) {
 				AddRow(2, 2, time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC), nil, "Comment 2",
 					2, userID, "Task 2", "Description 2", "#00FF00", 2, true))
 
-		report := repo.Reports(userID, startInterval, endInterval, nowWithTimezone)
+		report, err := repo.Reports(context.Background(), userID, startInterval, endInterval, nowWithTimezone)
 
+		require.NoError(t, err)
 		require.Len(t, report.ReportRows, 2)
 		assert.Equal(t, "Task 1", report.ReportRows[0].Task.Title)
 		assert.Equal(t, "Task 2", report.ReportRows[1].Task.Title)
@@ -458,8 +556,9 @@ func TestDashboardRepositoryPostgres_Reports(t *testing.T) {
 				AddRow(3, 3, time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC), nil, "Comment 3",
 					3, userID, "Task C", "Description C", "#0000FF", 3, false))
 
-		report := repo.Reports(userID, startInterval, endInterval, nowWithTimezone)
+		report, err := repo.Reports(context.Background(), userID, startInterval, endInterval, nowWithTimezone)
 
+		require.NoError(t, err)
 		require.Len(t, report.ReportRows, 3)
 		assert.Equal(t, "Task B", report.ReportRows[0].Task.Title) // SortOrder = 1
 		assert.Equal(t, "Task A", report.ReportRows[1].Task.Title) // SortOrder = 2
@@ -481,8 +580,9 @@ func TestDashboardRepositoryPostgres_Reports(t *testing.T) {
 				"id", "user_id", "title", "description", "color", "sort_order", "is_completed",
 			}))
 
-		report := repo.Reports(userID, startInterval, endInterval, nowWithTimezone)
+		report, err := repo.Reports(context.Background(), userID, startInterval, endInterval, nowWithTimezone)
 
+		require.NoError(t, err)
 		require.Len(t, report.ReportRows, 0)
 		assert.Len(t, report.Days, 3)
 		assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), report.Days[0])
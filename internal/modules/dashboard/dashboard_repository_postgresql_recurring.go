@@ -0,0 +1,156 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const recurringRecordsQueryPostgres = `
+        SELECT
+            rr.id, rr.task_id, rr.user_id, rr.start_minute, rr.duration_minutes, rr.rule,
+            rr.date_start, rr.materialized_until, rr.comment,
+            t.id, t.user_id, t.title, t.description, t.color, t.sort_order, t.is_completed
+        FROM recurring_records rr
+        JOIN tasks t ON rr.task_id = t.id
+    `
+
+func (r *DashboardRepositoryPostgres) RecurringRecords(ctx context.Context, userID int) (recurringRecords []*RecurringRecord, err error) {
+	query := recurringRecordsQueryPostgres
+	args := []interface{}{}
+	if userID > 0 {
+		query += " WHERE rr.user_id = $1"
+		args = append(args, userID)
+	}
+	query += " ORDER BY rr.start_minute ASC, rr.id ASC"
+
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositoryPostgres RecurringRecords Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		recurringRecord, err := scanRecurringRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("DashboardRepositoryPostgres RecurringRecords Scan: %w", err)
+		}
+		recurringRecords = append(recurringRecords, recurringRecord)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("DashboardRepositoryPostgres RecurringRecords Rows: %w", err)
+	}
+	return recurringRecords, nil
+}
+
+func (r *DashboardRepositoryPostgres) RecurringRecordByID(ctx context.Context, id int) (*RecurringRecord, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	recurringRecord, err := scanRecurringRecord(r.db.QueryRow(ctx, recurringRecordsQueryPostgres+" WHERE rr.id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositoryPostgres RecurringRecordByID QueryRow: %w", err)
+	}
+	return recurringRecord, nil
+}
+
+func (r *DashboardRepositoryPostgres) CreateRecurringRecord(ctx context.Context, recurringRecord *RecurringRecord) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRow(ctx, `
+        INSERT INTO recurring_records (task_id, start_minute, duration_minutes, rule, date_start, comment, user_id)
+        VALUES ($1, $2, $3, $4, $5, $6, (SELECT user_id FROM tasks WHERE id = $1))
+        RETURNING id
+    `, recurringRecord.TaskID, minutes(recurringRecord.TimeOfDay), minutes(recurringRecord.Duration),
+		recurringRecord.Rule, recurringRecord.DateStart, recurringRecord.Comment).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("DashboardRepositoryPostgres CreateRecurringRecord QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *DashboardRepositoryPostgres) UpdateRecurringRecord(ctx context.Context, recurringRecord *RecurringRecord) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
+        UPDATE recurring_records
+        SET task_id = $1, start_minute = $2, duration_minutes = $3, rule = $4, date_start = $5, comment = $6,
+            user_id = (SELECT user_id FROM tasks WHERE id = $1)
+        WHERE id = $7
+    `, recurringRecord.TaskID, minutes(recurringRecord.TimeOfDay), minutes(recurringRecord.Duration),
+		recurringRecord.Rule, recurringRecord.DateStart, recurringRecord.Comment, recurringRecord.ID)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositoryPostgres UpdateRecurringRecord Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("recurring record %d: %w", recurringRecord.ID, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *DashboardRepositoryPostgres) DeleteRecurringRecord(ctx context.Context, id int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
+        DELETE FROM recurring_records WHERE id = $1
+    `, id)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositoryPostgres DeleteRecurringRecord Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *DashboardRepositoryPostgres) SetRecurringRecordMaterialized(ctx context.Context, id int, materializedUntil time.Time) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
+        UPDATE recurring_records SET materialized_until = $1 WHERE id = $2
+    `, materializedUntil, id)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositoryPostgres SetRecurringRecordMaterialized Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+// rowScanner is pgx.Row, pgx.Rows and *sql.Row, *sql.Rows of SQLite
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// Scans the columns of recurringRecordsQueryPostgres and recurringRecordsQuerySQLite
+func scanRecurringRecord(row rowScanner) (*RecurringRecord, error) {
+	var recurringRecord RecurringRecord
+	var task Task
+	var startMinute, durationMinutes int
+	err := row.Scan(
+		&recurringRecord.ID, &recurringRecord.TaskID, &recurringRecord.UserID, &startMinute, &durationMinutes, &recurringRecord.Rule,
+		&recurringRecord.DateStart, &recurringRecord.MaterializedUntil, &recurringRecord.Comment,
+		&task.ID, &task.UserID, &task.Title, &task.Description, &task.Color, &task.SortOrder, &task.IsCompleted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	recurringRecord.TimeOfDay = time.Duration(startMinute) * time.Minute
+	recurringRecord.Duration = time.Duration(durationMinutes) * time.Minute
+	recurringRecord.Task = &task
+	return &recurringRecord, nil
+}
+
+// The columns of the durations are in whole minutes
+func minutes(d time.Duration) int {
+	return int(d / time.Minute)
+}
@@ -0,0 +1,162 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardRepositoryPostgres_.*RecurringRecord.*
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardRepositoryPostgres_RecurringRecords(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
+	columns := []string{
+		"id", "task_id", "user_id", "start_minute", "duration_minutes", "rule", "date_start", "materialized_until", "comment",
+		"id", "user_id", "title", "description", "color", "sort_order", "is_completed",
+	}
+	dateStart := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+	materializedUntil := time.Date(2024, 12, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("ByUser", func(t *testing.T) {
+		rows := mockPool.NewRows(columns).
+			AddRow(1, 2, 3, 570, 75, "FREQ=DAILY", dateStart, &materializedUntil, "standup", 2, 3, "Task", "", "#FF0000", 1, false).
+			AddRow(2, 2, 3, 600, 30, "FREQ=WEEKLY", dateStart, nil, "", 2, 3, "Task", "", "#FF0000", 1, false)
+		mockPool.ExpectQuery(`FROM recurring_records rr\s+JOIN tasks t ON rr.task_id = t.id\s+WHERE rr.user_id = \$1 ORDER BY rr.start_minute ASC, rr.id ASC$`).
+			WithArgs(3).
+			WillReturnRows(rows)
+
+		recurringRecords, err := repo.RecurringRecords(context.Background(), 3)
+
+		require.NoError(t, err)
+		require.Len(t, recurringRecords, 2)
+		assert.Equal(t, 9*time.Hour+30*time.Minute, recurringRecords[0].TimeOfDay)
+		assert.Equal(t, 75*time.Minute, recurringRecords[0].Duration)
+		assert.Equal(t, materializedUntil, *recurringRecords[0].MaterializedUntil)
+		assert.Equal(t, "Task", recurringRecords[0].Task.Title)
+		assert.Nil(t, recurringRecords[1].MaterializedUntil)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("AllUsers", func(t *testing.T) {
+		mockPool.ExpectQuery(`JOIN tasks t ON rr.task_id = t.id\s+ORDER BY rr.start_minute ASC, rr.id ASC$`).
+			WithArgs().
+			WillReturnRows(mockPool.NewRows(columns))
+
+		recurringRecords, err := repo.RecurringRecords(context.Background(), 0)
+
+		require.NoError(t, err)
+		assert.Empty(t, recurringRecords)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		mockPool.ExpectQuery(".*").WithArgs(3).WillReturnError(fmt.Errorf("query error"))
+
+		_, err := repo.RecurringRecords(context.Background(), 3)
+
+		assert.ErrorContains(t, err, "DashboardRepositoryPostgres RecurringRecords Query: query error")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestDashboardRepositoryPostgres_RecurringRecordByID(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
+
+	mockPool.ExpectQuery(`WHERE rr.id = \$1$`).
+		WithArgs(5).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.RecurringRecordByID(context.Background(), 5)
+
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestDashboardRepositoryPostgres_CreateRecurringRecord(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
+	dateStart := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+
+	mockPool.ExpectQuery(`INSERT INTO recurring_records`).
+		WithArgs(2, 570, 75, "FREQ=DAILY", dateStart, "standup").
+		WillReturnRows(mockPool.NewRows([]string{"id"}).AddRow(7))
+
+	id, err := repo.CreateRecurringRecord(context.Background(), &RecurringRecord{
+		TaskID:    2,
+		TimeOfDay: 9*time.Hour + 30*time.Minute,
+		Duration:  75 * time.Minute,
+		Rule:      "FREQ=DAILY",
+		DateStart: dateStart,
+		Comment:   "standup",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, id)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestDashboardRepositoryPostgres_UpdateDeleteRecurringRecord(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
+	ctx := context.Background()
+	dateStart := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("UpdateNotFound", func(t *testing.T) {
+		mockPool.ExpectExec(`UPDATE recurring_records`).
+			WithArgs(2, 570, 75, "FREQ=DAILY", dateStart, "", 9).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdateRecurringRecord(ctx, &RecurringRecord{
+			ID: 9, TaskID: 2, TimeOfDay: 570 * time.Minute, Duration: 75 * time.Minute, Rule: "FREQ=DAILY", DateStart: dateStart,
+		})
+
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("SetMaterialized", func(t *testing.T) {
+		mockPool.ExpectExec(`UPDATE recurring_records SET materialized_until = \$1 WHERE id = \$2`).
+			WithArgs(dateStart, 9).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.SetRecurringRecordMaterialized(ctx, 9, dateStart)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mockPool.ExpectExec(`DELETE FROM recurring_records WHERE id = \$1`).
+			WithArgs(9).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mockPool.ExpectExec(`DELETE FROM recurring_records WHERE id = \$1`).
+			WithArgs(9).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		assert.NoError(t, repo.DeleteRecurringRecord(ctx, 9))
+		assert.ErrorIs(t, repo.DeleteRecurringRecord(ctx, 9), utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
@@ -2,12 +2,14 @@ package dashboard
 
 import (
 	"context"
-	"log/slog"
+	"errors"
+	"fmt"
+	"time-tracker/internal/utils"
 
 	"github.com/jackc/pgx/v5"
 )
 
-func (r *DashboardRepositoryPostgres) Tasks(userID int, taskCompleted string) (tasks []*Task) {
+func (r *DashboardRepositoryPostgres) Tasks(ctx context.Context, userID int, taskCompleted string) (tasks []*Task, err error) {
 	query := `
 		SELECT id, user_id, title, description, color, sort_order, is_completed
 		FROM tasks WHERE user_id = $1
@@ -21,17 +23,17 @@ func (r *DashboardRepositoryPostgres) Tasks(userID int, taskCompleted string) (t
 		query += " AND is_completed = false"
 	}
 	query += " ORDER BY is_completed ASC, sort_order ASC"
-	rows, err := r.db.Query(context.Background(), query, userID)
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, query, userID)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres Tasks Query", "err", err)
-		return
+		return nil, fmt.Errorf("DashboardRepositoryPostgres Tasks Query: %w", err)
 	}
 	defer rows.Close()
 
 	taskValues, err := pgx.CollectRows(rows, pgx.RowToStructByName[Task])
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres Tasks CollectRows", "err", err)
-		return
+		return nil, fmt.Errorf("DashboardRepositoryPostgres Tasks CollectRows: %w", err)
 	}
 
 	// []Task to []*Task
@@ -39,78 +41,105 @@ func (r *DashboardRepositoryPostgres) Tasks(userID int, taskCompleted string) (t
 		task := t
 		tasks = append(tasks, &task)
 	}
-	return
+	return tasks, nil
 }
 
-func (r *DashboardRepositoryPostgres) TaskByID(id int) *Task {
+func (r *DashboardRepositoryPostgres) TaskByID(ctx context.Context, id int) (*Task, error) {
 	var task Task
-	err := r.db.QueryRow(context.Background(), `
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err := r.db.QueryRow(ctx, `
 		SELECT id, user_id, title, description, color, sort_order, is_completed
 		FROM tasks WHERE id = $1
 	`, id).Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Color, &task.SortOrder, &task.IsCompleted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("task %d: %w", id, utils.ErrNotFound)
+	}
 	if err != nil {
-		slog.Warn("DashboardRepositoryPostgres TaskByID Query", "err", err)
-		return nil
+		return nil, fmt.Errorf("DashboardRepositoryPostgres TaskByID QueryRow: %w", err)
 	}
-	return &task
+	return &task, nil
 }
 
-func (r *DashboardRepositoryPostgres) CreateTask(task *Task) (int, error) {
-	maxSortOrder := r.GetMaxSortOrder(task.UserID, task.IsCompleted)
+func (r *DashboardRepositoryPostgres) CreateTask(ctx context.Context, task *Task) (int, error) {
+	maxSortOrder, err := r.GetMaxSortOrder(ctx, task.UserID, task.IsCompleted)
+	if err != nil {
+		return 0, err
+	}
 	task.SortOrder = maxSortOrder + 1
 
 	var newTaskID int
-	err := r.db.QueryRow(context.Background(), `
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRow(ctx, `
 		INSERT INTO tasks (user_id, title, description, color, sort_order, is_completed)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`, task.UserID, task.Title, task.Description, task.Color, task.SortOrder, task.IsCompleted).Scan(&newTaskID)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres CreateTask QueryRow", "err", err)
-		return 0, err
+		return 0, fmt.Errorf("DashboardRepositoryPostgres CreateTask QueryRow: %w", err)
 	}
 	return newTaskID, nil
 }
 
-func (r *DashboardRepositoryPostgres) UpdateTask(task *Task) error {
-	_, err := r.db.Exec(context.Background(), `
+func (r *DashboardRepositoryPostgres) UpdateTask(ctx context.Context, task *Task) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
 		UPDATE tasks
 		SET title = $1, description = $2, color = $3, is_completed = $4, sort_order = $5
 		WHERE id = $6
 	`, task.Title, task.Description, task.Color, task.IsCompleted, task.SortOrder, task.ID)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres UpdateTask Query", "err", err)
-		return err
+		return fmt.Errorf("DashboardRepositoryPostgres UpdateTask Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("task %d: %w", task.ID, utils.ErrNotFound)
 	}
 	return nil
 }
 
-func (r *DashboardRepositoryPostgres) DeleteTask(id int) error {
-	_, err := r.db.Exec(context.Background(), `
+func (r *DashboardRepositoryPostgres) DeleteTask(ctx context.Context, id int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, `
 		DELETE FROM tasks WHERE id = $1
 	`, id)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres DeleteTask Query", "err", err)
-		return err
+		return fmt.Errorf("DashboardRepositoryPostgres DeleteTask Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("task %d: %w", id, utils.ErrNotFound)
 	}
 	return nil
 }
 
-func (r *DashboardRepositoryPostgres) GetMaxSortOrder(userId int, isCompleted bool) (maxSortOrder int) {
-	err := r.db.QueryRow(context.Background(), `
+func (r *DashboardRepositoryPostgres) GetMaxSortOrder(ctx context.Context, userId int, isCompleted bool) (maxSortOrder int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRow(ctx, `
         SELECT COALESCE(MAX(sort_order), 0) 
         FROM tasks 
         WHERE user_id = $1 AND is_completed = $2
     `, userId, isCompleted).Scan(&maxSortOrder)
 	if err != nil {
-		slog.Error("DashboardRepositoryPostgres CreateTask QueryRow", "err", err)
+		return 0, fmt.Errorf("DashboardRepositoryPostgres GetMaxSortOrder QueryRow: %w", err)
 	}
 	return
 }
 
-// userID is needed for access control instead of validation
-func (repo *DashboardRepositoryPostgres) UpdateTaskSortOrder(taskID, userID, sortOrder int) error {
+// userID is needed for access control instead of validation.
+// A task of another user is utils.ErrNotFound.
+func (repo *DashboardRepositoryPostgres) UpdateTaskSortOrder(ctx context.Context, taskID, userID, sortOrder int) error {
 	query := `UPDATE tasks SET sort_order = $1 WHERE id = $2 AND user_id = $3`
-	_, err := repo.db.Exec(context.Background(), query, sortOrder, taskID, userID)
-	return err
+	ctx, cancel := utils.QueryContext(ctx, repo.queryTimeout)
+	defer cancel()
+	result, err := repo.db.Exec(ctx, query, sortOrder, taskID, userID)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositoryPostgres UpdateTaskSortOrder Exec: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("task %d: %w", taskID, utils.ErrNotFound)
+	}
+	return nil
 }
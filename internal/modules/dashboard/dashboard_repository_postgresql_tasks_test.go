@@ -4,9 +4,12 @@
 package dashboard
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time-tracker/internal/utils"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,7 +20,7 @@ func TestDashboardRepositoryPostgres_Tasks(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Completed", func(t *testing.T) {
 		rows := mockPool.NewRows([]string{"id", "user_id", "title", "description", "color", "sort_order", "is_completed"}).
@@ -28,8 +31,9 @@ func TestDashboardRepositoryPostgres_Tasks(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(rows)
 
-		tasks := repo.Tasks(1, "completed")
+		tasks, err := repo.Tasks(context.Background(), 1, "completed")
 
+		require.NoError(t, err)
 		require.Len(t, tasks, 2)
 		assert.Equal(t, "Task 1", tasks[0].Title)
 		assert.Equal(t, "Task 2", tasks[1].Title)
@@ -45,8 +49,9 @@ func TestDashboardRepositoryPostgres_Tasks(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(rows)
 
-		tasks := repo.Tasks(1, "all")
+		tasks, err := repo.Tasks(context.Background(), 1, "all")
 
+		require.NoError(t, err)
 		require.Len(t, tasks, 2)
 		assert.Equal(t, "Task 1", tasks[0].Title)
 		assert.Equal(t, "Task 2", tasks[1].Title)
@@ -62,8 +67,9 @@ func TestDashboardRepositoryPostgres_Tasks(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(rows)
 
-		tasks := repo.Tasks(1, "")
+		tasks, err := repo.Tasks(context.Background(), 1, "")
 
+		require.NoError(t, err)
 		require.Len(t, tasks, 2)
 		assert.Equal(t, "Task 1", tasks[0].Title)
 		assert.Equal(t, "Task 2", tasks[1].Title)
@@ -75,8 +81,9 @@ func TestDashboardRepositoryPostgres_Tasks(t *testing.T) {
 			WithArgs(1).
 			WillReturnError(fmt.Errorf("database error"))
 
-		tasks := repo.Tasks(1, "all")
+		tasks, err := repo.Tasks(context.Background(), 1, "all")
 
+		require.Error(t, err)
 		require.Len(t, tasks, 0)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -91,8 +98,9 @@ func TestDashboardRepositoryPostgres_Tasks(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(rows)
 
-		tasks := repo.Tasks(1, "all")
+		tasks, err := repo.Tasks(context.Background(), 1, "all")
 
+		require.Error(t, err)
 		require.Len(t, tasks, 0)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -103,7 +111,7 @@ func TestDashboardRepositoryPostgres_TaskByID(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		rows := mockPool.NewRows([]string{"id", "user_id", "title", "description", "color", "sort_order", "is_completed"}).
@@ -113,8 +121,9 @@ func TestDashboardRepositoryPostgres_TaskByID(t *testing.T) {
 			WithArgs(1).
 			WillReturnRows(rows)
 
-		task := repo.TaskByID(1)
+		task, err := repo.TaskByID(context.Background(), 1)
 
+		require.NoError(t, err)
 		require.NotNil(t, task)
 		assert.Equal(t, 1, task.ID)
 		assert.Equal(t, "Task 1", task.Title)
@@ -125,10 +134,11 @@ func TestDashboardRepositoryPostgres_TaskByID(t *testing.T) {
 	t.Run("NotFound", func(t *testing.T) {
 		mockPool.ExpectQuery("^SELECT id, user_id, title, description, color, sort_order, is_completed FROM tasks WHERE id = \\$1$").
 			WithArgs(1).
-			WillReturnError(fmt.Errorf("no rows"))
+			WillReturnError(pgx.ErrNoRows)
 
-		task := repo.TaskByID(1)
+		task, err := repo.TaskByID(context.Background(), 1)
 
+		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Nil(t, task)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -138,8 +148,10 @@ func TestDashboardRepositoryPostgres_TaskByID(t *testing.T) {
 			WithArgs(1).
 			WillReturnError(fmt.Errorf("database query error"))
 
-		task := repo.TaskByID(1)
+		task, err := repo.TaskByID(context.Background(), 1)
 
+		require.Error(t, err)
+		require.NotErrorIs(t, err, utils.ErrNotFound)
 		require.Nil(t, task)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -150,7 +162,7 @@ func TestDashboardRepositoryPostgres_CreateTask(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		task := &Task{
@@ -169,7 +181,7 @@ func TestDashboardRepositoryPostgres_CreateTask(t *testing.T) {
 			WithArgs(task.UserID, task.Title, task.Description, task.Color, 5+1, task.IsCompleted).
 			WillReturnRows(mockPool.NewRows([]string{"id"}).AddRow(10))
 
-		newTaskID, err := repo.CreateTask(task)
+		newTaskID, err := repo.CreateTask(context.Background(), task)
 
 		require.NoError(t, err)
 		assert.Equal(t, 10, newTaskID)
@@ -193,7 +205,7 @@ func TestDashboardRepositoryPostgres_CreateTask(t *testing.T) {
 			WithArgs(task.UserID, task.Title, task.Description, task.Color, 5+1, task.IsCompleted).
 			WillReturnError(fmt.Errorf("database insert error"))
 
-		newTaskID, err := repo.CreateTask(task)
+		newTaskID, err := repo.CreateTask(context.Background(), task)
 
 		require.Error(t, err)
 		assert.Equal(t, 0, newTaskID)
@@ -201,12 +213,30 @@ func TestDashboardRepositoryPostgres_CreateTask(t *testing.T) {
 	})
 }
 
+func TestDashboardRepositoryPostgres_CreateTask_MaxSortOrderError(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
+
+	mockPool.ExpectQuery(".*MAX\\(sort_order\\).*").
+		WithArgs(1, false).
+		WillReturnError(fmt.Errorf("database query error"))
+
+	newTaskID, err := repo.CreateTask(context.Background(), &Task{UserID: 1, Title: "Task 1"})
+
+	require.Error(t, err)
+	assert.Equal(t, 0, newTaskID)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
 func TestDashboardRepositoryPostgres_UpdateTask(t *testing.T) {
 	mockPool, err := pgxmock.NewPool()
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		task := &Task{
@@ -223,7 +253,7 @@ func TestDashboardRepositoryPostgres_UpdateTask(t *testing.T) {
 			WithArgs(task.Title, task.Description, task.Color, task.IsCompleted, task.SortOrder, task.ID).
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.UpdateTask(task)
+		err := repo.UpdateTask(context.Background(), task)
 
 		require.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -244,11 +274,24 @@ func TestDashboardRepositoryPostgres_UpdateTask(t *testing.T) {
 			WithArgs(task.Title, task.Description, task.Color, task.IsCompleted, task.SortOrder, task.ID).
 			WillReturnError(fmt.Errorf("database update error"))
 
-		err := repo.UpdateTask(task)
+		err := repo.UpdateTask(context.Background(), task)
 
 		require.Error(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		task := &Task{ID: 1, Title: "Updated Task", Color: "#00FF00"}
+
+		mockPool.ExpectExec(".*UPDATE tasks.*").
+			WithArgs(task.Title, task.Description, task.Color, task.IsCompleted, task.SortOrder, task.ID).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdateTask(context.Background(), task)
+
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
 
 func TestDashboardRepositoryPostgres_DeleteTask(t *testing.T) {
@@ -256,7 +299,7 @@ func TestDashboardRepositoryPostgres_DeleteTask(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		taskID := 1
@@ -265,7 +308,7 @@ func TestDashboardRepositoryPostgres_DeleteTask(t *testing.T) {
 			WithArgs(taskID).
 			WillReturnResult(pgxmock.NewResult("DELETE", 1))
 
-		err := repo.DeleteTask(taskID)
+		err := repo.DeleteTask(context.Background(), taskID)
 
 		require.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -278,11 +321,24 @@ func TestDashboardRepositoryPostgres_DeleteTask(t *testing.T) {
 			WithArgs(taskID).
 			WillReturnError(fmt.Errorf("database delete error"))
 
-		err := repo.DeleteTask(taskID)
+		err := repo.DeleteTask(context.Background(), taskID)
 
 		require.Error(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		taskID := 1
+
+		mockPool.ExpectExec(".*DELETE FROM tasks.*").
+			WithArgs(taskID).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		err := repo.DeleteTask(context.Background(), taskID)
+
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
 
 func TestDashboardRepositoryPostgres_GetMaxSortOrder(t *testing.T) {
@@ -290,7 +346,7 @@ func TestDashboardRepositoryPostgres_GetMaxSortOrder(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		userID := 1
@@ -300,8 +356,9 @@ func TestDashboardRepositoryPostgres_GetMaxSortOrder(t *testing.T) {
 			WithArgs(userID, isCompleted).
 			WillReturnRows(mockPool.NewRows([]string{"max"}).AddRow(5))
 
-		maxSortOrder := repo.GetMaxSortOrder(userID, isCompleted)
+		maxSortOrder, err := repo.GetMaxSortOrder(context.Background(), userID, isCompleted)
 
+		require.NoError(t, err)
 		assert.Equal(t, 5, maxSortOrder)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -314,8 +371,9 @@ func TestDashboardRepositoryPostgres_GetMaxSortOrder(t *testing.T) {
 			WithArgs(userID, isCompleted).
 			WillReturnError(fmt.Errorf("database query error"))
 
-		maxSortOrder := repo.GetMaxSortOrder(userID, isCompleted)
+		maxSortOrder, err := repo.GetMaxSortOrder(context.Background(), userID, isCompleted)
 
+		require.Error(t, err)
 		assert.Equal(t, 0, maxSortOrder)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
@@ -326,7 +384,7 @@ func TestDashboardRepositoryPostgres_UpdateTaskSortOrder(t *testing.T) {
 	require.NoError(t, err)
 	defer mockPool.Close()
 
-	repo := NewDashboardRepositoryPostgres(mockPool)
+	repo := NewDashboardRepositoryPostgres(mockPool, 0)
 
 	t.Run("Success", func(t *testing.T) {
 		taskID := 1
@@ -337,7 +395,7 @@ func TestDashboardRepositoryPostgres_UpdateTaskSortOrder(t *testing.T) {
 			WithArgs(sortOrder, taskID, userID).
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.UpdateTaskSortOrder(taskID, userID, sortOrder)
+		err := repo.UpdateTaskSortOrder(context.Background(), taskID, userID, sortOrder)
 
 		require.NoError(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
@@ -352,9 +410,24 @@ func TestDashboardRepositoryPostgres_UpdateTaskSortOrder(t *testing.T) {
 			WithArgs(sortOrder, taskID, userID).
 			WillReturnError(fmt.Errorf("database update error"))
 
-		err := repo.UpdateTaskSortOrder(taskID, userID, sortOrder)
+		err := repo.UpdateTaskSortOrder(context.Background(), taskID, userID, sortOrder)
 
 		require.Error(t, err)
 		assert.NoError(t, mockPool.ExpectationsWereMet())
 	})
+
+	t.Run("TaskOfAnotherUser", func(t *testing.T) {
+		taskID := 1
+		userID := 2
+		sortOrder := 5
+
+		mockPool.ExpectExec("UPDATE tasks SET sort_order = \\$1 WHERE id = \\$2 AND user_id = \\$3").
+			WithArgs(sortOrder, taskID, userID).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdateTaskSortOrder(context.Background(), taskID, userID, sortOrder)
+
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,356 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+)
+
+// DashboardRepositorySQLite has the same queries as DashboardRepositoryPostgres.
+// The constraints of the records are triggers, see schema.sql of the sqlite package.
+type DashboardRepositorySQLite struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// db is opened by sqlite.Open, queryTimeout limits every query, 0 means no limit
+func NewDashboardRepositorySQLite(db *sql.DB, queryTimeout time.Duration) *DashboardRepositorySQLite {
+	return &DashboardRepositorySQLite{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *DashboardRepositorySQLite) Tasks(ctx context.Context, userID int, taskCompleted string) (tasks []*Task, err error) {
+	query := `
+		SELECT id, user_id, title, description, color, sort_order, is_completed
+		FROM tasks WHERE user_id = $1
+	`
+	switch taskCompleted {
+	case "completed":
+		query += " AND is_completed = true"
+	case "all":
+		// We do not add any conditions for "all"
+	default:
+		query += " AND is_completed = false"
+	}
+	query += " ORDER BY is_completed ASC, sort_order ASC"
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite Tasks Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task Task
+		err := rows.Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Color, &task.SortOrder, &task.IsCompleted)
+		if err != nil {
+			return nil, fmt.Errorf("DashboardRepositorySQLite Tasks Scan: %w", err)
+		}
+		tasks = append(tasks, &task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite Tasks Rows: %w", err)
+	}
+	return tasks, nil
+}
+
+func (r *DashboardRepositorySQLite) TaskByID(ctx context.Context, id int) (*Task, error) {
+	var task Task
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, title, description, color, sort_order, is_completed
+		FROM tasks WHERE id = $1
+	`, id).Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Color, &task.SortOrder, &task.IsCompleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %d: %w", id, utils.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite TaskByID QueryRow: %w", err)
+	}
+	return &task, nil
+}
+
+func (r *DashboardRepositorySQLite) CreateTask(ctx context.Context, task *Task) (int, error) {
+	maxSortOrder, err := r.GetMaxSortOrder(ctx, task.UserID, task.IsCompleted)
+	if err != nil {
+		return 0, err
+	}
+	task.SortOrder = maxSortOrder + 1
+
+	var newTaskID int
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO tasks (user_id, title, description, color, sort_order, is_completed)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, task.UserID, task.Title, task.Description, task.Color, task.SortOrder, task.IsCompleted).Scan(&newTaskID)
+	if err != nil {
+		return 0, fmt.Errorf("DashboardRepositorySQLite CreateTask QueryRow: %w", err)
+	}
+	return newTaskID, nil
+}
+
+func (r *DashboardRepositorySQLite) UpdateTask(ctx context.Context, task *Task) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE tasks
+		SET title = $1, description = $2, color = $3, is_completed = $4, sort_order = $5
+		WHERE id = $6
+	`, task.Title, task.Description, task.Color, task.IsCompleted, task.SortOrder, task.ID)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite UpdateTask Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("task %d", task.ID))
+}
+
+// Records of the task are deleted by ON DELETE CASCADE, sqlite.Open enables foreign keys
+func (r *DashboardRepositorySQLite) DeleteTask(ctx context.Context, id int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM tasks WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite DeleteTask Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("task %d", id))
+}
+
+func (r *DashboardRepositorySQLite) GetMaxSortOrder(ctx context.Context, userId int, isCompleted bool) (maxSortOrder int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(sort_order), 0)
+		FROM tasks
+		WHERE user_id = $1 AND is_completed = $2
+	`, userId, isCompleted).Scan(&maxSortOrder)
+	if err != nil {
+		return 0, fmt.Errorf("DashboardRepositorySQLite GetMaxSortOrder QueryRow: %w", err)
+	}
+	return
+}
+
+// userID is needed for access control instead of validation.
+// A task of another user is utils.ErrNotFound.
+func (r *DashboardRepositorySQLite) UpdateTaskSortOrder(ctx context.Context, taskID, userID, sortOrder int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `UPDATE tasks SET sort_order = $1 WHERE id = $2 AND user_id = $3`, sortOrder, taskID, userID)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite UpdateTaskSortOrder Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("task %d", taskID))
+}
+
+func (r *DashboardRepositorySQLite) RecordsWithTasks(ctx context.Context, filterRecords FilterRecords) (records []*Record, err error) {
+	query := `
+		SELECT
+			r.id, r.task_id, r.time_start, r.time_end, r.comment,
+			t.id, t.user_id, t.title, t.description, t.color, t.sort_order, t.is_completed
+		FROM records r
+		JOIN tasks t ON r.task_id = t.id
+	`
+	filters := []string{}
+	args := []interface{}{}
+
+	// Positional placeholders, numbered like in DashboardRepositoryPostgres
+	addFilter := func(filter string, arg interface{}) {
+		args = append(args, arg)
+		filters = append(filters, fmt.Sprintf(filter, len(args)))
+	}
+	if filterRecords.UserID > 0 {
+		addFilter("t.user_id = $%d", filterRecords.UserID)
+	}
+	if filterRecords.RecordID > 0 {
+		addFilter("r.id = $%d", filterRecords.RecordID)
+	}
+	if filterRecords.NotRecordID > 0 {
+		addFilter("r.id != $%d", filterRecords.NotRecordID)
+	}
+	// Recording must end after interval starts or does not end
+	if !filterRecords.StartInterval.IsZero() {
+		addFilter("(r.time_end > $%d OR r.time_end IS NULL)", sqlite.FormatTime(filterRecords.StartInterval))
+	}
+	// Recording must start before the end of the interval
+	if !filterRecords.EndInterval.IsZero() {
+		addFilter("r.time_start < $%d", sqlite.FormatTime(filterRecords.EndInterval))
+	}
+	if filterRecords.InProgress {
+		filters = append(filters, "r.time_end IS NULL")
+	}
+	if filterRecords.ExcludeInProgress {
+		filters = append(filters, "r.time_end IS NOT NULL")
+	}
+
+	if len(filters) > 0 {
+		query += " WHERE " + strings.Join(filters, " AND ")
+	}
+
+	query += " ORDER BY r.time_start ASC"
+
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite RecordsWithTasks Query: %w", err)
+	}
+	defer rows.Close()
+
+	taskMap := make(map[int]*Task)
+	for rows.Next() {
+		var record Record
+		var task Task
+
+		err := rows.Scan(
+			&record.ID, &record.TaskID, &record.TimeStart, &record.TimeEnd, &record.Comment,
+			&task.ID, &task.UserID, &task.Title, &task.Description, &task.Color, &task.SortOrder, &task.IsCompleted,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("DashboardRepositorySQLite RecordsWithTasks Scan: %w", err)
+		}
+
+		if existingTask, exists := taskMap[task.ID]; exists {
+			record.Task = existingTask
+		} else {
+			taskCopy := task
+			record.Task = &taskCopy
+			taskMap[task.ID] = &taskCopy
+		}
+
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite RecordsWithTasks Rows: %w", err)
+	}
+
+	return records, nil
+}
+
+func (r *DashboardRepositorySQLite) RecordByIDWithTask(ctx context.Context, recordID int) (*Record, error) {
+	records, err := r.RecordsWithTasks(ctx, FilterRecords{
+		RecordID: recordID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("record %d: %w", recordID, utils.ErrNotFound)
+	}
+	return records[0], nil
+}
+
+func (r *DashboardRepositorySQLite) CreateRecord(ctx context.Context, record *Record) (newRecordID int, error error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO records (task_id, time_start, time_end, comment, user_id)
+		VALUES ($1, $2, $3, $4, (SELECT user_id FROM tasks WHERE id = $1))
+		RETURNING id
+	`, record.TaskID, sqlite.FormatTime(record.TimeStart), sqlite.FormatTimePtr(record.TimeEnd), record.Comment).Scan(&newRecordID)
+	if err != nil {
+		if constraintErr := recordConstraintErrorSQLite(err); constraintErr != nil {
+			return 0, constraintErr
+		}
+		return 0, fmt.Errorf("DashboardRepositorySQLite CreateRecord QueryRow: %w", err)
+	}
+	return newRecordID, nil
+}
+
+func (r *DashboardRepositorySQLite) UpdateRecord(ctx context.Context, record *Record) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE records
+		SET task_id = $1, time_start = $2, time_end = $3, comment = $4, user_id = (SELECT user_id FROM tasks WHERE id = $1)
+		WHERE id = $5
+	`, record.TaskID, sqlite.FormatTime(record.TimeStart), sqlite.FormatTimePtr(record.TimeEnd), record.Comment, record.ID)
+	if err != nil {
+		if constraintErr := recordConstraintErrorSQLite(err); constraintErr != nil {
+			return constraintErr
+		}
+		return fmt.Errorf("DashboardRepositorySQLite UpdateRecord Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("record %d", record.ID))
+}
+
+// Maps the errors of the triggers of the records, the same errors as recordConstraintError
+func recordConstraintErrorSQLite(err error) error {
+	switch {
+	case sqlite.IsConstraintError(err, "records_no_overlap"):
+		return fmt.Errorf("%w: %s", ErrRecordsOverlap, err)
+	case sqlite.IsConstraintError(err, "records_one_in_progress_per_user"):
+		return fmt.Errorf("%w: %s", ErrRecordInProgressExists, err)
+	}
+	return nil
+}
+
+func (r *DashboardRepositorySQLite) DeleteRecord(ctx context.Context, recordID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM records WHERE id = $1
+	`, recordID)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite DeleteRecord Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("record %d", recordID))
+}
+
+func (r *DashboardRepositorySQLite) CountRecordsInProgress(ctx context.Context) (count int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM records WHERE time_end IS NULL
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("DashboardRepositorySQLite CountRecordsInProgress QueryRow: %w", err)
+	}
+	return
+}
+
+func (r *DashboardRepositorySQLite) DailyRecords(ctx context.Context, filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords, err error) {
+	records, err := r.RecordsWithTasks(ctx, filterRecords)
+	if err != nil {
+		return nil, err
+	}
+	return groupDailyRecords(records, filterRecords, nowWithTimezone), nil
+}
+
+func (r *DashboardRepositorySQLite) Reports(
+	ctx context.Context,
+	userID int,
+	startInterval time.Time,
+	endInterval time.Time,
+	nowWithTimezone time.Time,
+) (ReportData, error) {
+	recordsFilter := FilterRecords{
+		UserID:        userID,
+		StartInterval: startInterval,
+		EndInterval:   endInterval,
+	}
+	dailyRecords, err := r.DailyRecords(ctx, recordsFilter, nowWithTimezone)
+	if err != nil {
+		return ReportData{}, err
+	}
+	return buildReportData(dailyRecords), nil
+}
+
+// item is the text of the error, e.g. "task 1"
+func notFoundIfNoRows(result sql.Result, item string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s RowsAffected: %w", item, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", item, utils.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+)
+
+const recurringRecordsQuerySQLite = `
+		SELECT
+			rr.id, rr.task_id, rr.user_id, rr.start_minute, rr.duration_minutes, rr.rule,
+			rr.date_start, rr.materialized_until, rr.comment,
+			t.id, t.user_id, t.title, t.description, t.color, t.sort_order, t.is_completed
+		FROM recurring_records rr
+		JOIN tasks t ON rr.task_id = t.id
+	`
+
+func (r *DashboardRepositorySQLite) RecurringRecords(ctx context.Context, userID int) (recurringRecords []*RecurringRecord, err error) {
+	query := recurringRecordsQuerySQLite
+	args := []interface{}{}
+	if userID > 0 {
+		query += " WHERE rr.user_id = $1"
+		args = append(args, userID)
+	}
+	query += " ORDER BY rr.start_minute ASC, rr.id ASC"
+
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite RecurringRecords Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		recurringRecord, err := scanRecurringRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("DashboardRepositorySQLite RecurringRecords Scan: %w", err)
+		}
+		recurringRecords = append(recurringRecords, recurringRecord)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite RecurringRecords Rows: %w", err)
+	}
+	return recurringRecords, nil
+}
+
+func (r *DashboardRepositorySQLite) RecurringRecordByID(ctx context.Context, id int) (*RecurringRecord, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	recurringRecord, err := scanRecurringRecord(r.db.QueryRowContext(ctx, recurringRecordsQuerySQLite+" WHERE rr.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("recurring record %d: %w", id, utils.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("DashboardRepositorySQLite RecurringRecordByID QueryRow: %w", err)
+	}
+	return recurringRecord, nil
+}
+
+func (r *DashboardRepositorySQLite) CreateRecurringRecord(ctx context.Context, recurringRecord *RecurringRecord) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO recurring_records (task_id, start_minute, duration_minutes, rule, date_start, comment, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT user_id FROM tasks WHERE id = $1))
+		RETURNING id
+	`, recurringRecord.TaskID, minutes(recurringRecord.TimeOfDay), minutes(recurringRecord.Duration),
+		recurringRecord.Rule, sqlite.FormatTime(recurringRecord.DateStart), recurringRecord.Comment).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("DashboardRepositorySQLite CreateRecurringRecord QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *DashboardRepositorySQLite) UpdateRecurringRecord(ctx context.Context, recurringRecord *RecurringRecord) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_records
+		SET task_id = $1, start_minute = $2, duration_minutes = $3, rule = $4, date_start = $5, comment = $6,
+			user_id = (SELECT user_id FROM tasks WHERE id = $1)
+		WHERE id = $7
+	`, recurringRecord.TaskID, minutes(recurringRecord.TimeOfDay), minutes(recurringRecord.Duration),
+		recurringRecord.Rule, sqlite.FormatTime(recurringRecord.DateStart), recurringRecord.Comment, recurringRecord.ID)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite UpdateRecurringRecord Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("recurring record %d", recurringRecord.ID))
+}
+
+func (r *DashboardRepositorySQLite) DeleteRecurringRecord(ctx context.Context, id int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM recurring_records WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite DeleteRecurringRecord Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("recurring record %d", id))
+}
+
+func (r *DashboardRepositorySQLite) SetRecurringRecordMaterialized(ctx context.Context, id int, materializedUntil time.Time) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_records SET materialized_until = $1 WHERE id = $2
+	`, sqlite.FormatTime(materializedUntil), id)
+	if err != nil {
+		return fmt.Errorf("DashboardRepositorySQLite SetRecurringRecordMaterialized Exec: %w", err)
+	}
+	return notFoundIfNoRows(result, fmt.Sprintf("recurring record %d", id))
+}
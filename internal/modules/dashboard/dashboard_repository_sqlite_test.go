@@ -0,0 +1,33 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestDashboardRepositorySQLite
+package dashboard
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/internal/utils/sqlite"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardRepositorySQLite_Contract(t *testing.T) {
+	DashboardRepositoryContract(t, func(t *testing.T) (DashboardRepository, int, int) {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		userIDs := []int{}
+		for i := 1; i <= 2; i++ {
+			var userID int
+			err := db.QueryRow(`
+				INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id
+			`, "Contract", fmt.Sprintf("contract-%d@example.com", i), "password").Scan(&userID)
+			require.NoError(t, err)
+			userIDs = append(userIDs, userID)
+		}
+		return NewDashboardRepositorySQLite(db, time.Second), userIDs[0], userIDs[1]
+	})
+}
@@ -3,10 +3,12 @@
 package dashboard
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"testing"
 	"time"
 
 	"github.com/stretchr/testify/mock"
@@ -16,6 +18,12 @@ func SetAppDir() {
 	os.Chdir("/app")
 }
 
+func TShort(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode.")
+	}
+}
+
 func BadRequestPost(url string) *http.Request {
 	req := httptest.NewRequest(http.MethodPost, url, strings.NewReader("%%%"))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -26,78 +34,128 @@ type MockDashboardRepository struct {
 	mock.Mock
 }
 
-func (m *MockDashboardRepository) Tasks(userID int, taskCompleted string) (tasks []*Task) {
+func (m *MockDashboardRepository) Tasks(_ context.Context, userID int, taskCompleted string) (tasks []*Task, err error) {
 	args := m.Called(userID, taskCompleted)
-	return args.Get(0).([]*Task)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Task), args.Error(1)
 }
 
-func (m *MockDashboardRepository) TaskByID(id int) *Task {
+func (m *MockDashboardRepository) TaskByID(_ context.Context, id int) (*Task, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
-		return nil
+		return nil, args.Error(1)
 	}
-	return args.Get(0).(*Task)
+	return args.Get(0).(*Task), args.Error(1)
 }
 
-func (m *MockDashboardRepository) CreateTask(task *Task) (int, error) {
+func (m *MockDashboardRepository) CreateTask(_ context.Context, task *Task) (int, error) {
 	args := m.Called(task)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockDashboardRepository) UpdateTask(task *Task) error {
+func (m *MockDashboardRepository) UpdateTask(_ context.Context, task *Task) error {
 	args := m.Called(task)
 	return args.Error(0)
 }
 
-func (m *MockDashboardRepository) DeleteTask(id int) error {
+func (m *MockDashboardRepository) DeleteTask(_ context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockDashboardRepository) GetMaxSortOrder(userId int, isCompleted bool) (maxSortOrder int) {
+func (m *MockDashboardRepository) GetMaxSortOrder(_ context.Context, userId int, isCompleted bool) (maxSortOrder int, err error) {
 	args := m.Called(userId, isCompleted)
-	return args.Int(0)
+	return args.Int(0), args.Error(1)
 }
 
-func (m *MockDashboardRepository) UpdateTaskSortOrder(taskID, userID, sortOrder int) error {
+func (m *MockDashboardRepository) UpdateTaskSortOrder(_ context.Context, taskID, userID, sortOrder int) error {
 	args := m.Called(taskID, userID, sortOrder)
 	return args.Error(0)
 }
 
-func (m *MockDashboardRepository) RecordsWithTasks(filterRecords FilterRecords) (records []*Record) {
+func (m *MockDashboardRepository) RecordsWithTasks(_ context.Context, filterRecords FilterRecords) (records []*Record, err error) {
 	args := m.Called(filterRecords)
-	return args.Get(0).([]*Record)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Record), args.Error(1)
 }
 
-func (m *MockDashboardRepository) RecordByIDWithTask(recordID int) *Record {
+func (m *MockDashboardRepository) RecordByIDWithTask(_ context.Context, recordID int) (*Record, error) {
 	args := m.Called(recordID)
 	if args.Get(0) == nil {
-		return nil
+		return nil, args.Error(1)
 	}
-	return args.Get(0).(*Record)
+	return args.Get(0).(*Record), args.Error(1)
 }
 
-func (m *MockDashboardRepository) CreateRecord(record *Record) (newRecordID int, error error) {
+func (m *MockDashboardRepository) CreateRecord(_ context.Context, record *Record) (newRecordID int, error error) {
 	args := m.Called(record)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockDashboardRepository) UpdateRecord(record *Record) error {
+func (m *MockDashboardRepository) UpdateRecord(_ context.Context, record *Record) error {
 	args := m.Called(record)
 	return args.Error(0)
 }
 
-func (m *MockDashboardRepository) DeleteRecord(recordID int) error {
+func (m *MockDashboardRepository) DeleteRecord(_ context.Context, recordID int) error {
 	args := m.Called(recordID)
 	return args.Error(0)
 }
 
-func (m *MockDashboardRepository) DailyRecords(filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords) {
+func (m *MockDashboardRepository) CountRecordsInProgress(_ context.Context) (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDashboardRepository) DailyRecords(_ context.Context, filterRecords FilterRecords, nowWithTimezone time.Time) (dailyRecords []DailyRecords, err error) {
 	args := m.Called(filterRecords, nowWithTimezone)
-	return args.Get(0).([]DailyRecords)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DailyRecords), args.Error(1)
 }
 
-func (m *MockDashboardRepository) Reports(userID int, startInterval time.Time, endInterval time.Time, nowWithTimezone time.Time) ReportData {
+func (m *MockDashboardRepository) Reports(_ context.Context, userID int, startInterval time.Time, endInterval time.Time, nowWithTimezone time.Time) (ReportData, error) {
 	args := m.Called(userID, startInterval, endInterval, nowWithTimezone)
-	return args.Get(0).(ReportData)
+	return args.Get(0).(ReportData), args.Error(1)
+}
+
+func (m *MockDashboardRepository) RecurringRecords(_ context.Context, userID int) ([]*RecurringRecord, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*RecurringRecord), args.Error(1)
+}
+
+func (m *MockDashboardRepository) RecurringRecordByID(_ context.Context, id int) (*RecurringRecord, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RecurringRecord), args.Error(1)
+}
+
+func (m *MockDashboardRepository) CreateRecurringRecord(_ context.Context, recurringRecord *RecurringRecord) (int, error) {
+	args := m.Called(recurringRecord)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDashboardRepository) UpdateRecurringRecord(_ context.Context, recurringRecord *RecurringRecord) error {
+	args := m.Called(recurringRecord)
+	return args.Error(0)
+}
+
+func (m *MockDashboardRepository) DeleteRecurringRecord(_ context.Context, id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDashboardRepository) SetRecurringRecordMaterialized(_ context.Context, id int, materializedUntil time.Time) error {
+	args := m.Called(id, materializedUntil)
+	return args.Error(0)
 }
@@ -0,0 +1,197 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+// RecurringRecordsService creates the records of the recurring records when their occurrences end
+type RecurringRecordsService struct {
+	repo      DashboardRepository
+	usersRepo users.UsersRepository
+}
+
+func NewRecurringRecordsService(repo DashboardRepository, usersRepo users.UsersRepository) *RecurringRecordsService {
+	return &RecurringRecordsService{repo: repo, usersRepo: usersRepo}
+}
+
+// MaterializeRecurringRecords creates the records of the occurrences that ended before now in the time zones of their users.
+// An occurrence that overlaps an existing record is skipped, the user has already tracked that time.
+// The errors of one recurring record do not stop the others, they are joined.
+func (s *RecurringRecordsService) MaterializeRecurringRecords(ctx context.Context, now time.Time) (created int, skipped int, err error) {
+	recurringRecords, err := s.repo.RecurringRecords(ctx, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	timezones := make(map[int]string)
+	var errs []error
+	for _, recurringRecord := range recurringRecords {
+		timezone, ok := timezones[recurringRecord.UserID]
+		if !ok {
+			user, err := s.usersRepo.GetByID(ctx, recurringRecord.UserID)
+			if errors.Is(err, utils.ErrNotFound) {
+				// Deleted between the queries, the recurring record is deleted by ON DELETE CASCADE
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("recurring record %d: %w", recurringRecord.ID, err))
+				continue
+			}
+			timezone = user.TimeZone
+			timezones[recurringRecord.UserID] = timezone
+		}
+		wallClock, _ := utils.WallClock(now, timezone)
+
+		c, sk, err := s.materialize(ctx, recurringRecord, wallClock)
+		created += c
+		skipped += sk
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring record %d: %w", recurringRecord.ID, err))
+		}
+	}
+	return created, skipped, errors.Join(errs...)
+}
+
+// Creates the records of the days after MaterializedUntil up to the first occurrence that has not ended by nowWithTimezone
+func (s *RecurringRecordsService) materialize(ctx context.Context, recurringRecord *RecurringRecord, nowWithTimezone time.Time) (created int, skipped int, err error) {
+	schedule, err := ParseSchedule(recurringRecord.Rule)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	day := recurringRecord.DateStart
+	if recurringRecord.MaterializedUntil != nil {
+		day = recurringRecord.MaterializedUntil.AddDate(0, 0, 1)
+	}
+	today := nowWithTimezone.Truncate(24 * time.Hour)
+	var materializedUntil *time.Time
+	for ; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if schedule.OccursOn(recurringRecord.DateStart, day) {
+			timeStart, timeEnd := recurringRecord.Occurrence(day)
+			if timeEnd.After(nowWithTimezone) {
+				break
+			}
+			var ok bool
+			ok, err = s.createOccurrence(ctx, recurringRecord, timeStart, timeEnd)
+			if err != nil {
+				break
+			}
+			if ok {
+				created++
+			} else {
+				skipped++
+			}
+		}
+		processed := day
+		materializedUntil = &processed
+	}
+
+	if materializedUntil != nil {
+		if setErr := s.repo.SetRecurringRecordMaterialized(ctx, recurringRecord.ID, *materializedUntil); setErr != nil {
+			err = errors.Join(err, setErr)
+		}
+	}
+	return created, skipped, err
+}
+
+// materializedAfterEdit returns MaterializedUntil of the recurring record edited at nowWithTimezone.
+// The progress is kept and moved to the edit: the occurrences of the new schedule that ended before the edit
+// are not created, the user has tracked that time already or has deleted the records on purpose.
+func materializedAfterEdit(recurringRecord *RecurringRecord, materializedUntil *time.Time, nowWithTimezone time.Time) time.Time {
+	today := nowWithTimezone.Truncate(24 * time.Hour)
+	edited := today.AddDate(0, 0, -1)
+	if _, timeEnd := recurringRecord.Occurrence(today); !timeEnd.After(nowWithTimezone) {
+		edited = today
+	}
+	if materializedUntil != nil && materializedUntil.After(edited) {
+		return *materializedUntil
+	}
+	return edited
+}
+
+// ok is false if the occurrence overlaps another record of the user.
+// Unlike the constraint of the storage, a record in progress overlaps everything after its start,
+// like in validateIntersectingRecords, otherwise the user could not stop it.
+func (s *RecurringRecordsService) createOccurrence(ctx context.Context, recurringRecord *RecurringRecord, timeStart, timeEnd time.Time) (ok bool, err error) {
+	intersectingRecords, err := s.repo.RecordsWithTasks(ctx, FilterRecords{
+		UserID:        recurringRecord.UserID,
+		StartInterval: timeStart,
+		EndInterval:   timeEnd,
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(intersectingRecords) > 0 {
+		return false, nil
+	}
+	_, err = s.repo.CreateRecord(ctx, &Record{
+		TaskID:    recurringRecord.TaskID,
+		TimeStart: timeStart,
+		TimeEnd:   &timeEnd,
+		Comment:   recurringRecord.Comment,
+	})
+	// A record saved by the user after the check
+	if errors.Is(err, ErrRecordsOverlap) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Calls MaterializeRecurringRecords every interval until ctx is done
+func (s *RecurringRecordsService) RunRecurringRecordsJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		created, skipped, err := s.MaterializeRecurringRecords(ctx, time.Now())
+		if err != nil {
+			slog.Error("RunRecurringRecordsJob MaterializeRecurringRecords", "err", err)
+		}
+		if created > 0 || skipped > 0 {
+			slog.Info("RunRecurringRecordsJob records created", "created", created, "skipped", skipped)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Adds the occurrences of the recurring records after their MaterializedUntil to the days of dailyRecords
+func addPendingRecords(dailyRecords []DailyRecords, recurringRecords []*RecurringRecord) {
+	const totalDaySeconds = float32(86400)
+	for _, recurringRecord := range recurringRecords {
+		schedule, err := ParseSchedule(recurringRecord.Rule)
+		if err != nil {
+			continue
+		}
+		for i := range dailyRecords {
+			day := dailyRecords[i].Day
+			if recurringRecord.MaterializedUntil != nil && !day.After(*recurringRecord.MaterializedUntil) {
+				continue
+			}
+			if !schedule.OccursOn(recurringRecord.DateStart, day) {
+				continue
+			}
+			timeStart, timeEnd := recurringRecord.Occurrence(day)
+			// The bar ends at midnight, as the bars of the records
+			barEnd := min(timeEnd.Sub(day), 24*time.Hour)
+			dailyRecords[i].Pending = append(dailyRecords[i].Pending, PendingRecord{
+				RecurringRecord: recurringRecord,
+				TimeStart:       timeStart,
+				TimeEnd:         timeEnd,
+				StartPercent:    float32(recurringRecord.TimeOfDay/time.Second) / totalDaySeconds * 100,
+				DurationPercent: float32((barEnd-recurringRecord.TimeOfDay)/time.Second) / totalDaySeconds * 100,
+			})
+		}
+	}
+}
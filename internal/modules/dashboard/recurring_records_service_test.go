@@ -0,0 +1,194 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestRecurringRecordsService.*
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/users"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurringRecordsService_MaterializeRecurringRecords(t *testing.T) {
+	ctx := context.Background()
+	// 2024-12-02 is Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 12, day, hour, minute, 0, 0, time.UTC)
+	}
+	atPtr := func(day, hour, minute int) *time.Time {
+		tm := at(day, hour, minute)
+		return &tm
+	}
+	// The repositories with a user in Moscow (UTC+3) and the recurring record 10:00-11:00 on weekdays from 2 Dec
+	newService := func(t *testing.T) (*RecurringRecordsService, *DashboardRepositoryMem, *RecurringRecord) {
+		usersRepo := users.NewUsersRepositoryMem()
+		user := &users.User{Name: "User", Email: "user@example.com", TimeZone: "Europe/Moscow"}
+		require.NoError(t, usersRepo.Create(ctx, user))
+		repo := NewDashboardRepositoryMem()
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: user.ID, Title: "Standup"})
+		require.NoError(t, err)
+		recurringRecord := &RecurringRecord{
+			TaskID:    taskID,
+			TimeOfDay: 10 * time.Hour,
+			Duration:  time.Hour,
+			Rule:      "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			DateStart: at(2, 0, 0),
+			Comment:   "daily",
+		}
+		recurringRecord.ID, err = repo.CreateRecurringRecord(ctx, recurringRecord)
+		require.NoError(t, err)
+		return NewRecurringRecordsService(repo, usersRepo), repo, recurringRecord
+	}
+	recordStarts := func(t *testing.T, repo *DashboardRepositoryMem) (starts []time.Time) {
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{})
+		require.NoError(t, err)
+		for _, record := range records {
+			starts = append(starts, record.TimeStart)
+		}
+		return
+	}
+
+	t.Run("CreatesEndedOccurrences", func(t *testing.T) {
+		service, repo, recurringRecord := newService(t)
+
+		// Wednesday 10:59 in Moscow, the occurrence of Wednesday has not ended
+		created, skipped, err := service.MaterializeRecurringRecords(ctx, at(4, 7, 59))
+		require.NoError(t, err)
+		assert.Equal(t, 2, created)
+		assert.Equal(t, 0, skipped)
+		assert.Equal(t, []time.Time{at(2, 10, 0), at(3, 10, 0)}, recordStarts(t, repo))
+
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{})
+		require.NoError(t, err)
+		assert.Equal(t, recurringRecord.TaskID, records[0].TaskID)
+		assert.Equal(t, at(2, 11, 0), *records[0].TimeEnd)
+		assert.Equal(t, "daily", records[0].Comment)
+
+		stored, err := repo.RecurringRecordByID(ctx, recurringRecord.ID)
+		require.NoError(t, err)
+		assert.Equal(t, at(3, 0, 0), *stored.MaterializedUntil)
+
+		// 11:00 in Moscow, the next run creates only the new occurrence
+		created, _, err = service.MaterializeRecurringRecords(ctx, at(4, 8, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+		assert.Equal(t, []time.Time{at(2, 10, 0), at(3, 10, 0), at(4, 10, 0)}, recordStarts(t, repo))
+
+		// The weekend has no occurrences, the processed days are remembered
+		created, _, err = service.MaterializeRecurringRecords(ctx, at(8, 20, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 2, created)
+		stored, err = repo.RecurringRecordByID(ctx, recurringRecord.ID)
+		require.NoError(t, err)
+		assert.Equal(t, at(8, 0, 0), *stored.MaterializedUntil)
+	})
+
+	t.Run("EditCreatesNothingBeforeTheEdit", func(t *testing.T) {
+		service, repo, recurringRecord := newService(t)
+		created, _, err := service.MaterializeRecurringRecords(ctx, at(4, 8, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 3, created)
+		// The user deletes Tuesday on purpose
+		records, err := repo.RecordsWithTasks(ctx, FilterRecords{})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteRecord(ctx, records[1].ID))
+
+		// Sunday 13:00 in Moscow the schedule becomes daily from November at 12:00
+		stored, err := repo.RecurringRecordByID(ctx, recurringRecord.ID)
+		require.NoError(t, err)
+		recurringRecord.Rule = "FREQ=DAILY"
+		recurringRecord.DateStart = at(1, 0, 0).AddDate(0, -1, 0)
+		recurringRecord.TimeOfDay = 12 * time.Hour
+		require.NoError(t, repo.UpdateRecurringRecord(ctx, recurringRecord))
+		require.NoError(t, repo.SetRecurringRecordMaterialized(ctx, recurringRecord.ID, materializedAfterEdit(recurringRecord, stored.MaterializedUntil, at(8, 13, 0))))
+
+		created, skipped, err := service.MaterializeRecurringRecords(ctx, at(9, 8, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 0, created)
+		assert.Equal(t, 0, skipped)
+		created, _, err = service.MaterializeRecurringRecords(ctx, at(9, 20, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+		assert.Equal(t, []time.Time{at(2, 10, 0), at(4, 10, 0), at(9, 12, 0)}, recordStarts(t, repo))
+	})
+
+	t.Run("SkipsOverlaps", func(t *testing.T) {
+		service, repo, _ := newService(t)
+		taskID, err := repo.CreateTask(ctx, &Task{UserID: 1, Title: "Meeting"})
+		require.NoError(t, err)
+		// Overlaps Monday
+		_, err = repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: at(2, 10, 30), TimeEnd: atPtr(2, 12, 0)})
+		require.NoError(t, err)
+		// Runs since Tuesday 9:00, so Tuesday is skipped although the storage would accept it
+		_, err = repo.CreateRecord(ctx, &Record{TaskID: taskID, TimeStart: at(3, 9, 0)})
+		require.NoError(t, err)
+
+		created, skipped, err := service.MaterializeRecurringRecords(ctx, at(3, 12, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 0, created)
+		assert.Equal(t, 2, skipped)
+		assert.Equal(t, []time.Time{at(2, 10, 30), at(3, 9, 0)}, recordStarts(t, repo))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		repo := new(MockDashboardRepository)
+		usersRepo := users.NewUsersRepositoryMem()
+		user := &users.User{TimeZone: "UTC"}
+		require.NoError(t, usersRepo.Create(ctx, user))
+		service := NewRecurringRecordsService(repo, usersRepo)
+
+		broken := &RecurringRecord{ID: 1, UserID: user.ID, Rule: "FREQ=HOURLY", DateStart: at(2, 0, 0)}
+		failing := &RecurringRecord{ID: 2, TaskID: 3, UserID: user.ID, TimeOfDay: 9 * time.Hour, Duration: time.Hour, Rule: "FREQ=DAILY", DateStart: at(2, 0, 0)}
+		// The user was deleted
+		orphan := &RecurringRecord{ID: 3, UserID: user.ID + 1, Rule: "FREQ=DAILY", DateStart: at(2, 0, 0)}
+		repo.On("RecurringRecords", 0).Return([]*RecurringRecord{broken, failing, orphan}, nil)
+		repo.On("RecordsWithTasks", mock.Anything).Return([]*Record{}, nil)
+		repo.On("CreateRecord", mock.MatchedBy(func(record *Record) bool { return record.TimeStart.Equal(at(2, 9, 0)) })).Return(1, nil)
+		repo.On("CreateRecord", mock.Anything).Return(0, errors.New("connection refused"))
+		// The day before the failed one
+		repo.On("SetRecurringRecordMaterialized", failing.ID, at(2, 0, 0)).Return(nil)
+
+		created, _, err := service.MaterializeRecurringRecords(ctx, at(4, 12, 0))
+		assert.Equal(t, 1, created)
+		assert.ErrorContains(t, err, "recurring record 1: FREQ")
+		assert.ErrorContains(t, err, "recurring record 2: connection refused")
+		assert.NotContains(t, err.Error(), "recurring record 3")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRecurringRecordsService_addPendingRecords(t *testing.T) {
+	materializedUntil := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+	recurringRecord := &RecurringRecord{
+		Task:              &Task{Title: "Standup"},
+		TimeOfDay:         18 * time.Hour,
+		Duration:          8 * time.Hour,
+		Rule:              "FREQ=DAILY",
+		DateStart:         time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		MaterializedUntil: &materializedUntil,
+	}
+	invalid := &RecurringRecord{Rule: "FREQ=HOURLY", DateStart: recurringRecord.DateStart}
+	dailyRecords := []DailyRecords{
+		{Day: time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)},
+		{Day: time.Date(2024, 12, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	addPendingRecords(dailyRecords, []*RecurringRecord{recurringRecord, invalid})
+
+	// The first day is created by the job
+	assert.Empty(t, dailyRecords[0].Pending)
+	require.Len(t, dailyRecords[1].Pending, 1)
+	pending := dailyRecords[1].Pending[0]
+	assert.Same(t, recurringRecord, pending.RecurringRecord)
+	assert.Equal(t, time.Date(2024, 12, 3, 18, 0, 0, 0, time.UTC), pending.TimeStart)
+	assert.Equal(t, time.Date(2024, 12, 4, 2, 0, 0, 0, time.UTC), pending.TimeEnd)
+	assert.InDelta(t, 75.0, pending.StartPercent, 0.001)
+	// The bar ends at midnight
+	assert.InDelta(t, 25.0, pending.DurationPercent, 0.001)
+}
@@ -0,0 +1,151 @@
+package dashboard
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// Values of Schedule.Freq
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+var weekdaysRRule = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Schedule is the subset of RRULE of RFC 5545 that fits the records repeated once a day at most:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY without numbers, BYMONTHDAY and UNTIL.
+//
+//	FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+//	FREQ=WEEKLY;INTERVAL=2;BYDAY=FR;UNTIL=20251231
+//	FREQ=MONTHLY;BYMONTHDAY=1,-1
+type Schedule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	// 1 to 31 or -31 to -1 counted from the end of the month
+	ByMonthDay []int
+	// The last day, zero if the schedule does not end
+	Until time.Time
+}
+
+// ParseSchedule parses the rule, an error is utils.ErrInvalidInput
+func ParseSchedule(rule string) (Schedule, error) {
+	schedule := Schedule{Interval: 1}
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return Schedule{}, fmt.Errorf("empty schedule: %w", utils.ErrInvalidInput)
+	}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return Schedule{}, fmt.Errorf("schedule part %q: %w", part, utils.ErrInvalidInput)
+		}
+		switch name {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return Schedule{}, fmt.Errorf("FREQ %q, expected %s, %s or %s: %w", value, FreqDaily, FreqWeekly, FreqMonthly, utils.ErrInvalidInput)
+			}
+			schedule.Freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > 366 {
+				return Schedule{}, fmt.Errorf("INTERVAL %q: %w", value, utils.ErrInvalidInput)
+			}
+			schedule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdaysRRule[day]
+				if !ok {
+					return Schedule{}, fmt.Errorf("BYDAY %q: %w", day, utils.ErrInvalidInput)
+				}
+				schedule.ByDay = append(schedule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return Schedule{}, fmt.Errorf("BYMONTHDAY %q: %w", day, utils.ErrInvalidInput)
+				}
+				schedule.ByMonthDay = append(schedule.ByMonthDay, monthDay)
+			}
+		case "UNTIL":
+			// The date of UNTIL=20251231T235959Z is enough, the records are created for whole days
+			until, err := time.Parse("20060102", value[:min(len(value), 8)])
+			if err != nil {
+				return Schedule{}, fmt.Errorf("UNTIL %q: %w", value, utils.ErrInvalidInput)
+			}
+			schedule.Until = until
+		default:
+			return Schedule{}, fmt.Errorf("schedule part %s is not supported: %w", name, utils.ErrInvalidInput)
+		}
+	}
+	if schedule.Freq == "" {
+		return Schedule{}, fmt.Errorf("FREQ is required: %w", utils.ErrInvalidInput)
+	}
+	if len(schedule.ByMonthDay) > 0 && schedule.Freq != FreqMonthly {
+		return Schedule{}, fmt.Errorf("BYMONTHDAY requires FREQ=%s: %w", FreqMonthly, utils.ErrInvalidInput)
+	}
+	return schedule, nil
+}
+
+// OccursOn reports whether the schedule that starts on dateStart has an occurrence on the day.
+// Both are dates at midnight. As in RRULE, a weekly schedule without BYDAY repeats the weekday of dateStart
+// and a monthly one repeats the day of the month of dateStart. Weeks start on Monday.
+func (s Schedule) OccursOn(dateStart time.Time, day time.Time) bool {
+	if day.Before(dateStart) || (!s.Until.IsZero() && day.After(s.Until)) {
+		return false
+	}
+	if len(s.ByDay) > 0 && !slices.Contains(s.ByDay, day.Weekday()) {
+		return false
+	}
+
+	switch s.Freq {
+	case FreqDaily:
+		return daysBetween(dateStart, day)%s.Interval == 0
+	case FreqWeekly:
+		if len(s.ByDay) == 0 && day.Weekday() != dateStart.Weekday() {
+			return false
+		}
+		weeks := daysBetween(mondayOf(dateStart), mondayOf(day)) / 7
+		return weeks%s.Interval == 0
+	case FreqMonthly:
+		months := (day.Year()-dateStart.Year())*12 + int(day.Month()-dateStart.Month())
+		if months%s.Interval != 0 {
+			return false
+		}
+		if len(s.ByMonthDay) == 0 {
+			return len(s.ByDay) > 0 || day.Day() == dateStart.Day()
+		}
+		daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		for _, monthDay := range s.ByMonthDay {
+			if monthDay == day.Day() || daysInMonth+monthDay+1 == day.Day() {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// The number of calendar days, the dates are in UTC so there is no DST
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func mondayOf(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
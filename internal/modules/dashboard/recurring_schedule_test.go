@@ -0,0 +1,89 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/dashboard --tags=unit -cover -run TestSchedule.*
+package dashboard
+
+import (
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_ParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("RRULE:freq=weekly;INTERVAL=2;BYDAY=MO,FR;UNTIL=20251231T235959Z")
+	require.NoError(t, err)
+	assert.Equal(t, Schedule{
+		Freq:     FreqWeekly,
+		Interval: 2,
+		ByDay:    []time.Weekday{time.Monday, time.Friday},
+		Until:    time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+	}, schedule)
+
+	schedule, err = ParseSchedule("FREQ=MONTHLY;BYMONTHDAY=1,-1")
+	require.NoError(t, err)
+	assert.Equal(t, Schedule{Freq: FreqMonthly, Interval: 1, ByMonthDay: []int{1, -1}}, schedule)
+
+	for _, rule := range []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=DAILY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=DAILY;UNTIL=2025",
+		"FREQ=DAILY;COUNT=10",
+		"FREQ=DAILY;",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			_, err := ParseSchedule(rule)
+			assert.ErrorIs(t, err, utils.ErrInvalidInput)
+		})
+	}
+}
+
+func TestSchedule_OccursOn(t *testing.T) {
+	// 2024-12-02 is Monday
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	occurrences := func(t *testing.T, rule string, dateStart time.Time, last time.Time) (days []string) {
+		schedule, err := ParseSchedule(rule)
+		require.NoError(t, err)
+		for d := dateStart.AddDate(0, 0, -3); !d.After(last); d = d.AddDate(0, 0, 1) {
+			if schedule.OccursOn(dateStart, d) {
+				days = append(days, d.Format("01-02"))
+			}
+		}
+		return
+	}
+
+	testCases := []struct {
+		rule      string
+		dateStart time.Time
+		last      time.Time
+		expected  []string
+	}{
+		{"FREQ=DAILY", day(12, 2), day(12, 5), []string{"12-02", "12-03", "12-04", "12-05"}},
+		{"FREQ=DAILY;INTERVAL=3", day(12, 2), day(12, 10), []string{"12-02", "12-05", "12-08"}},
+		{"FREQ=DAILY;BYDAY=SA,SU", day(12, 2), day(12, 15), []string{"12-07", "12-08", "12-14", "12-15"}},
+		{"FREQ=DAILY;UNTIL=20241204", day(12, 2), day(12, 10), []string{"12-02", "12-03", "12-04"}},
+		// The weekday of the first day
+		{"FREQ=WEEKLY", day(12, 4), day(12, 25), []string{"12-04", "12-11", "12-18", "12-25"}},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", day(12, 4), day(12, 16), []string{"12-06", "12-09", "12-13", "12-16"}},
+		// The weeks start on Monday, the first week is the week of the first day
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU", day(12, 4), day(12, 30), []string{"12-08", "12-16", "12-22", "12-30"}},
+		{"FREQ=MONTHLY", day(1, 31), day(5, 31), []string{"01-31", "03-31", "05-31"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", day(1, 15), day(3, 1), []string{"01-31", "02-01", "02-29", "03-01"}},
+		{"FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=10", day(1, 1), day(6, 30), []string{"01-10", "03-10", "05-10"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			assert.Equal(t, tc.expected, occurrences(t, tc.rule, tc.dateStart, tc.last))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package health serves the probes of the load balancer and of the container orchestrator
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// Check returns an error if the dependency is not available, e.g. the Ping of the database
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type HealthHandlers struct {
+	checks  []namedCheck
+	timeout time.Duration
+}
+
+// timeout limits all checks of one /readyz request
+func NewHealthHandlers(timeout time.Duration) *HealthHandlers {
+	return &HealthHandlers{timeout: timeout}
+}
+
+// AddCheck adds the dependency to /readyz. Call it on start, before the server handles requests.
+func (h *HealthHandlers) AddCheck(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// GET /healthz
+// Liveness: the process serves requests. The dependencies are not checked,
+// so a database outage does not restart every container.
+func (h *HealthHandlers) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok"))
+}
+
+type readyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// GET /readyz
+// Readiness: all dependencies respond. 503 takes the instance out of the load balancer.
+// The errors are logged, the response has only "ok" or "error" for every check.
+func (h *HealthHandlers) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	errs := make([]error, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = runCheck(ctx, c.check)
+		}()
+	}
+	wg.Wait()
+
+	response := readyResponse{Status: "ok", Checks: make(map[string]string, len(h.checks))}
+	status := http.StatusOK
+	for i, c := range h.checks {
+		if errs[i] != nil {
+			utils.Logger(r.Context()).Error("HandleReadyz check failed", "check", c.name, "err", errs[i])
+			response.Checks[c.name] = "error"
+			response.Status = "error"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[c.name] = "ok"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// runCheck returns on the timeout of ctx even if the check ignores ctx, e.g. the Ping of the mail service
+func runCheck(ctx context.Context, check Check) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
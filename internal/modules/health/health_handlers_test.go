@@ -0,0 +1,92 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/health --tags=unit -cover -run TestHealthHandlers_.*
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okCheck(ctx context.Context) error {
+	return nil
+}
+
+func readyz(t *testing.T, handlers *HealthHandlers) (int, readyResponse) {
+	w := httptest.NewRecorder()
+	handlers.HandleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var response readyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestHealthHandlers_HandleHealthz(t *testing.T) {
+	handlers := NewHealthHandlers(time.Second)
+	handlers.AddCheck("postgres", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	w := httptest.NewRecorder()
+	handlers.HandleHealthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
+
+func TestHealthHandlers_HandleReadyz(t *testing.T) {
+	t.Run("AllChecksPass", func(t *testing.T) {
+		handlers := NewHealthHandlers(time.Second)
+		handlers.AddCheck("postgres", okCheck)
+		handlers.AddCheck("redis", okCheck)
+
+		status, response := readyz(t, handlers)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, readyResponse{Status: "ok", Checks: map[string]string{"postgres": "ok", "redis": "ok"}}, response)
+	})
+
+	t.Run("NoChecks", func(t *testing.T) {
+		status, response := readyz(t, NewHealthHandlers(time.Second))
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", response.Status)
+	})
+
+	t.Run("FailedCheck", func(t *testing.T) {
+		handlers := NewHealthHandlers(time.Second)
+		handlers.AddCheck("postgres", okCheck)
+		handlers.AddCheck("redis", func(ctx context.Context) error { return errors.New("dial tcp: connection refused") })
+
+		w := httptest.NewRecorder()
+		handlers.HandleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.NotContains(t, w.Body.String(), "connection refused")
+		var response readyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, readyResponse{Status: "error", Checks: map[string]string{"postgres": "ok", "redis": "error"}}, response)
+	})
+
+	t.Run("CheckIgnoresTimeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		handlers := NewHealthHandlers(50 * time.Millisecond)
+		handlers.AddCheck("mail", func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+
+		start := time.Now()
+		status, response := readyz(t, handlers)
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "error", response.Checks["mail"])
+	})
+}
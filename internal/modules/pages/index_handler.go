@@ -7,7 +7,7 @@ import (
 )
 
 func IndexHandler(w http.ResponseWriter, r *http.Request) {
-	utils.RenderTemplate(w, []string{"index"}, utils.TplData{
+	utils.RenderTemplate(w, "index", utils.TplData{
 		"Title": "Dashboard",
 		"User":  users.GetUserFromRequest(r),
 	})
@@ -3,5 +3,9 @@ package users
 type ContextKey string
 
 const ContextUserKey ContextKey = "user"
+const ContextSessionKey ContextKey = "session"
 
 const sessionCookieName = "session_id"
+
+const csrfHeaderName = "X-CSRF-Token"
+const csrfFormField = "csrf_token"
@@ -0,0 +1,104 @@
+package users
+
+import (
+	"crypto/subtle"
+	"mime"
+	"net/http"
+	"time-tracker/internal/utils"
+)
+
+// Checks the CSRF token of state-changing requests (POST, PUT, PATCH, DELETE) of logged in users.
+// The token is bound to the session and is taken from the X-CSRF-Token header (htmx, fetch)
+// or from the csrf_token form field. utils.RenderTemplate adds it to TplData as "CSRFToken".
+// Must be wrapped by SessionMiddleware.
+//
+// Requests without a session are not checked: there is no authenticated state to forge.
+//
+// exempt contains "METHOD /path" of routes that opt out, e.g. "POST /tasks/update-sort-order".
+// A route may opt out only if it accepts nothing but a JSON body:
+// the exemption applies only to requests with Content-Type: application/json, which a cross-site
+// form cannot send and fetch cannot send without a CORS preflight. Requests with any other
+// Content-Type to an exempt route are still checked.
+func CSRFMiddleware(next http.Handler, sessionsRepo SessionsRepository, exempt ...string) http.Handler {
+	exemptRoutes := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		exemptRoutes[route] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := GetSessionFromRequest(r)
+		if session == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if session.CSRFToken == "" {
+			// Sessions created before CSRF protection
+			csrfToken, err := generateRandomToken()
+			if err == nil {
+				session.CSRFToken = csrfToken
+				err = sessionsRepo.Create(r.Context(), session.SessionID, session)
+			}
+			if err != nil {
+				utils.Logger(r.Context()).Error("CSRFMiddleware generate token", "err", err)
+				http.Error(w, "Error. Please try again later.", http.StatusBadGateway)
+				return
+			}
+		}
+
+		if isStateChangingMethod(r.Method) && !(exemptRoutes[r.Method+" "+r.URL.Path] && isJSONRequest(r)) {
+			token := r.Header.Get(csrfHeaderName)
+			if token == "" {
+				token = r.PostFormValue(csrfFormField)
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) != 1 {
+				utils.Logger(r.Context()).Warn("CSRFMiddleware invalid token", "method", r.Method, "path", r.URL.Path, "userID", session.UserID)
+				renderInvalidCSRFToken(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(&csrfResponseWriter{ResponseWriter: w, csrfToken: session.CSRFToken}, r)
+	})
+}
+
+// Passes the token to utils.RenderTemplate
+type csrfResponseWriter struct {
+	http.ResponseWriter
+	csrfToken string
+}
+
+func (w *csrfResponseWriter) CSRFToken() string {
+	return w.csrfToken
+}
+
+// For http.ResponseController
+func (w *csrfResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func isStateChangingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+func renderInvalidCSRFToken(w http.ResponseWriter, r *http.Request) {
+	message := "The form has expired. Please reload the page and try again."
+	if r.Header.Get("HX-Request") == "true" {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusForbidden)
+	utils.RenderTemplate(w, "error", utils.TplData{
+		"Title":   "Forbidden",
+		"Message": message,
+		"User":    GetUserFromRequest(r),
+	})
+}
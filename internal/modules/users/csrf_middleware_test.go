@@ -0,0 +1,153 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestCSRFMiddleware
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func csrfRequest(method, target string, body string, contentType string, session *Session) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if session != nil {
+		ctx := context.WithValue(req.Context(), ContextSessionKey, session)
+		req = req.WithContext(ctx)
+	}
+	return req
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	SetAppDir()
+	var tokenInHandler string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenInHandler = ""
+		if cw, ok := w.(interface{ CSRFToken() string }); ok {
+			tokenInHandler = cw.CSRFToken()
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	sessionsRepo := new(MockSessionsRepo)
+	middleware := CSRFMiddleware(next, sessionsRepo, "POST /tasks/update-sort-order")
+	newSession := func() *Session {
+		return &Session{SessionID: "session-id", UserID: 1, CSRFToken: "token"}
+	}
+	formContentType := "application/x-www-form-urlencoded"
+
+	tests := []struct {
+		name         string
+		req          *http.Request
+		header       string
+		expectedCode int
+	}{
+		{
+			name:         "no session",
+			req:          csrfRequest(http.MethodPost, "/login", "email=a", formContentType, nil),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "GET without token",
+			req:          csrfRequest(http.MethodGet, "/dashboard", "", "", newSession()),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "POST without token",
+			req:          csrfRequest(http.MethodPost, "/settings", "name=a", formContentType, newSession()),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "POST with wrong token",
+			req:          csrfRequest(http.MethodPost, "/settings", url.Values{"csrf_token": {"wrong"}}.Encode(), formContentType, newSession()),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "POST with form token",
+			req:          csrfRequest(http.MethodPost, "/settings", url.Values{"csrf_token": {"token"}}.Encode(), formContentType, newSession()),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "DELETE with header token",
+			req:          csrfRequest(http.MethodDelete, "/tasks/1", "", "", newSession()),
+			header:       "token",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "DELETE with wrong header token",
+			req:          csrfRequest(http.MethodDelete, "/tasks/1", "", "", newSession()),
+			header:       "wrong",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "exempt route with JSON",
+			req:          csrfRequest(http.MethodPost, "/tasks/update-sort-order", "[]", "application/json; charset=utf-8", newSession()),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "exempt route with form content type",
+			req:          csrfRequest(http.MethodPost, "/tasks/update-sort-order", "a=b", formContentType, newSession()),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "not exempt route with JSON",
+			req:          csrfRequest(http.MethodPost, "/tasks", "[]", "application/json", newSession()),
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.header != "" {
+				tt.req.Header.Set(csrfHeaderName, tt.header)
+			}
+			w := httptest.NewRecorder()
+			middleware.ServeHTTP(w, tt.req)
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+
+	t.Run("token is passed to handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, csrfRequest(http.MethodGet, "/dashboard", "", "", newSession()))
+		assert.Equal(t, "token", tokenInHandler)
+	})
+
+	t.Run("htmx request gets plain error", func(t *testing.T) {
+		req := csrfRequest(http.MethodPost, "/records", "", formContentType, newSession())
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "<!doctype html>")
+	})
+
+	t.Run("session without token gets a new one", func(t *testing.T) {
+		session := &Session{SessionID: "old-session", UserID: 1}
+		sessionsRepo.On("Create", "old-session", session).Return(nil).Once()
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, csrfRequest(http.MethodGet, "/dashboard", "", "", session))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, session.CSRFToken, 64)
+		assert.Equal(t, session.CSRFToken, tokenInHandler)
+		sessionsRepo.AssertExpectations(t)
+	})
+
+	t.Run("session without token and repo error", func(t *testing.T) {
+		session := &Session{SessionID: "old-session", UserID: 1}
+		sessionsRepo.On("Create", "old-session", mock.Anything).Return(errors.New("redis error")).Once()
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, csrfRequest(http.MethodGet, "/dashboard", "", "", session))
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		sessionsRepo.AssertExpectations(t)
+	})
+}
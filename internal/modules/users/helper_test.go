@@ -3,11 +3,14 @@
 package users
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"time"
+	"time-tracker/internal/utils/oauth"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -44,45 +47,43 @@ type MockUsersService struct {
 	mock.Mock
 }
 
-func (m *MockUsersService) ActivateUser(activationHash string) (*Session, error) {
+func (m *MockUsersService) ActivateUser(_ context.Context, activationHash string) (*Session, error) {
 	args := m.Called(activationHash)
 	session, _ := args.Get(0).(*Session)
 	return session, args.Error(1)
 }
-func (m *MockUsersService) RegisterUser(registerUserData RegisterUserData) error {
+func (m *MockUsersService) RegisterUser(_ context.Context, registerUserData RegisterUserData) error {
 	args := m.Called(registerUserData)
 	return args.Error(0)
 }
-func (m *MockUsersService) LoginWithToken(token string) (*Session, error) {
+func (m *MockUsersService) LoginWithToken(_ context.Context, token string) (*Session, error) {
 	args := m.Called(token)
 	session, _ := args.Get(0).(*Session)
 	return session, args.Error(1)
 }
-func (m *MockUsersService) LoginUser(email, password string) (*Session, error) {
+func (m *MockUsersService) LoginUser(_ context.Context, email, password string) (*Session, error) {
 	args := m.Called(email, password)
 	session, _ := args.Get(0).(*Session)
 	return session, args.Error(1)
 }
-func (m *MockUsersService) LogoutUser(sessionID string) error {
+func (m *MockUsersService) LogoutUser(_ context.Context, sessionID string) error {
 	args := m.Called(sessionID)
 	return args.Error(0)
 }
-func (m *MockUsersService) SendLinkToLogin(email string) (int, error) {
+func (m *MockUsersService) SendLinkToLogin(_ context.Context, email string) (int, error) {
 	args := m.Called(email)
 	return args.Int(0), args.Error(1)
 }
-func (m *MockUsersService) ReSendActivationEmail(user *User) error {
+func (m *MockUsersService) ReSendActivationEmail(_ context.Context, user *User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
-func (m *MockUsersService) UserGetByEmail(email string) *User {
+func (m *MockUsersService) UserGetByEmail(_ context.Context, email string) (*User, error) {
 	args := m.Called(email)
-	if user, ok := args.Get(0).(*User); ok {
-		return user
-	}
-	return nil
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
-func (m *MockUsersService) UserUpdate(user *User) error {
+func (m *MockUsersService) UserUpdate(_ context.Context, user *User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
@@ -90,64 +91,119 @@ func (m *MockUsersService) HashPassword(password string) (string, error) {
 	args := m.Called(password)
 	return args.String(0), args.Error(1)
 }
+func (m *MockUsersService) LoginWithOAuth(_ context.Context, provider string, userInfo *oauth.UserInfo) (*Session, error) {
+	args := m.Called(provider, userInfo)
+	session, _ := args.Get(0).(*Session)
+	return session, args.Error(1)
+}
+func (m *MockUsersService) RequestEmailChange(_ context.Context, user *User, newEmail, password string) error {
+	args := m.Called(user, newEmail, password)
+	return args.Error(0)
+}
+func (m *MockUsersService) ConfirmEmailChange(_ context.Context, activationHash string) (*User, error) {
+	args := m.Called(activationHash)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
+}
+func (m *MockUsersService) RequestAccountDeletion(_ context.Context, user *User, password string) error {
+	args := m.Called(user, password)
+	return args.Error(0)
+}
+func (m *MockUsersService) CancelAccountDeletion(_ context.Context, user *User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+func (m *MockUsersService) RotateCalendarToken(_ context.Context, user *User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+func (m *MockUsersService) DisableCalendarFeed(_ context.Context, user *User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+func (m *MockUsersService) CalendarFeedLink(user *User) string {
+	args := m.Called(user)
+	return args.String(0)
+}
 
 type MockUsersRepo struct {
 	mock.Mock
 }
 
-func (m *MockUsersRepo) GetByID(id int) *User {
+func (m *MockUsersRepo) GetByID(_ context.Context, id int) (*User, error) {
 	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil
-	}
-	return args.Get(0).(*User)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
-func (m *MockUsersRepo) GetByEmail(email string) *User {
+func (m *MockUsersRepo) GetByEmail(_ context.Context, email string) (*User, error) {
 	args := m.Called(email)
-	if args.Get(0) == nil {
-		return nil
-	}
-	return args.Get(0).(*User)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
 
-func (m *MockUsersRepo) Create(user *User) error {
+func (m *MockUsersRepo) Create(_ context.Context, user *User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUsersRepo) GetByActivationHash(hash string) *User {
+func (m *MockUsersRepo) GetByActivationHash(_ context.Context, hash string) (*User, error) {
 	args := m.Called(hash)
-	if args.Get(0) == nil {
-		return nil
-	}
-	return args.Get(0).(*User)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
 
-func (m *MockUsersRepo) Update(user *User) error {
+func (m *MockUsersRepo) GetByCalendarToken(_ context.Context, calendarToken string) (*User, error) {
+	args := m.Called(calendarToken)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
+}
+
+func (m *MockUsersRepo) Update(_ context.Context, user *User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUsersRepo) Delete(id int) error {
+func (m *MockUsersRepo) Delete(_ context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockUsersRepo) GetByIdentity(_ context.Context, provider, subject string) (*User, error) {
+	args := m.Called(provider, subject)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
+}
+
+func (m *MockUsersRepo) CreateIdentity(_ context.Context, identity *Identity) error {
+	args := m.Called(identity)
+	return args.Error(0)
+}
+
+func (m *MockUsersRepo) DeleteRequestedBefore(_ context.Context, date time.Time) (int, error) {
+	args := m.Called(date)
+	return args.Int(0), args.Error(1)
+}
+
 type MockSessionsRepo struct {
 	mock.Mock
 }
 
-func (m *MockSessionsRepo) Create(sessionID string, session *Session) error {
+func (m *MockSessionsRepo) Create(_ context.Context, sessionID string, session *Session) error {
 	args := m.Called(sessionID, session)
 	return args.Error(0)
 }
 
-func (m *MockSessionsRepo) Delete(sessionID string) error {
+func (m *MockSessionsRepo) Delete(_ context.Context, sessionID string) error {
 	args := m.Called(sessionID)
 	return args.Error(0)
 }
 
-func (m *MockSessionsRepo) Get(sessionID string) (*Session, error) {
+func (m *MockSessionsRepo) DeleteByUserID(_ context.Context, userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionsRepo) Get(_ context.Context, sessionID string) (*Session, error) {
 	args := m.Called(sessionID)
 	session, _ := args.Get(0).(*Session)
 	return session, args.Error(1)
@@ -157,12 +213,44 @@ type MockMailService struct {
 	mock.Mock
 }
 
-func (m *MockMailService) SendActivationEmail(email, name, link string) error {
+func (m *MockMailService) SendActivationEmail(_ context.Context, email, name, link string) error {
 	args := m.Called(email, name, link)
 	return args.Error(0)
 }
 
-func (m *MockMailService) SendLoginWithTokenEmail(email, name, link string) error {
+func (m *MockMailService) SendLoginWithTokenEmail(_ context.Context, email, name, link string) error {
 	args := m.Called(email, name, link)
 	return args.Error(0)
 }
+
+func (m *MockMailService) SendEmailChangeEmail(_ context.Context, email, name, link string) error {
+	args := m.Called(email, name, link)
+	return args.Error(0)
+}
+
+func (m *MockMailService) SendEmailChangedEmail(_ context.Context, email, name, newEmail string) error {
+	args := m.Called(email, name, newEmail)
+	return args.Error(0)
+}
+
+type MockOAuthProvider struct {
+	mock.Mock
+}
+
+func (m *MockOAuthProvider) Name() string {
+	return "mock"
+}
+
+func (m *MockOAuthProvider) Title() string {
+	return "Mock"
+}
+
+func (m *MockOAuthProvider) AuthCodeURL(state, nonce string) string {
+	return "https://provider.example.com/auth?state=" + state + "&nonce=" + nonce
+}
+
+func (m *MockOAuthProvider) Exchange(ctx context.Context, code, nonce string) (*oauth.UserInfo, error) {
+	args := m.Called(code, nonce)
+	userInfo, _ := args.Get(0).(*oauth.UserInfo)
+	return userInfo, args.Error(1)
+}
@@ -1,6 +1,88 @@
 package users
 
+import (
+	"context"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/metrics"
+	"time-tracker/internal/utils/tracing"
+)
+
 type MailService interface {
-	SendActivationEmail(email, name, link string) error
-	SendLoginWithTokenEmail(email, name, link string) error
+	SendActivationEmail(ctx context.Context, email, name, link string) error
+	SendLoginWithTokenEmail(ctx context.Context, email, name, link string) error
+	// Sent to the new address to confirm the email change
+	SendEmailChangeEmail(ctx context.Context, email, name, link string) error
+	// Sent to the old address after the email change
+	SendEmailChangedEmail(ctx context.Context, email, name, newEmail string) error
+}
+
+// logMailService writes the emails to the log instead of sending them, e.g. for self-hosting without Mailgun:
+// the links of the activation and the login are taken from the log
+type logMailService struct{}
+
+// NewLogMailService returns the mail service without a mail provider
+func NewLogMailService() MailService {
+	return logMailService{}
+}
+
+func (logMailService) SendActivationEmail(ctx context.Context, email, name, link string) error {
+	utils.Logger(ctx).Info("Email not sent, the mail is not configured", "type", "activation", "email", email, "link", link)
+	return nil
+}
+
+func (logMailService) SendLoginWithTokenEmail(ctx context.Context, email, name, link string) error {
+	utils.Logger(ctx).Info("Email not sent, the mail is not configured", "type", "login_with_token", "email", email, "link", link)
+	return nil
+}
+
+func (logMailService) SendEmailChangeEmail(ctx context.Context, email, name, link string) error {
+	utils.Logger(ctx).Info("Email not sent, the mail is not configured", "type", "email_change", "email", email, "link", link)
+	return nil
+}
+
+func (logMailService) SendEmailChangedEmail(ctx context.Context, email, name, newEmail string) error {
+	utils.Logger(ctx).Info("Email not sent, the mail is not configured", "type", "email_changed", "email", email, "new_email", newEmail)
+	return nil
+}
+
+// instrumentedMailService adds a span and the metrics of the result to every email
+type instrumentedMailService struct {
+	MailService
+}
+
+// NewInstrumentedMailService wraps the mail service, e.g. mailgun, with the tracing and the metrics of the sent emails
+func NewInstrumentedMailService(mailService MailService) MailService {
+	return &instrumentedMailService{MailService: mailService}
+}
+
+func (m *instrumentedMailService) SendActivationEmail(ctx context.Context, email, name, link string) error {
+	return m.observe(ctx, "activation", func(ctx context.Context) error {
+		return m.MailService.SendActivationEmail(ctx, email, name, link)
+	})
+}
+
+func (m *instrumentedMailService) SendLoginWithTokenEmail(ctx context.Context, email, name, link string) error {
+	return m.observe(ctx, "login_with_token", func(ctx context.Context) error {
+		return m.MailService.SendLoginWithTokenEmail(ctx, email, name, link)
+	})
+}
+
+func (m *instrumentedMailService) SendEmailChangeEmail(ctx context.Context, email, name, link string) error {
+	return m.observe(ctx, "email_change", func(ctx context.Context) error {
+		return m.MailService.SendEmailChangeEmail(ctx, email, name, link)
+	})
+}
+
+func (m *instrumentedMailService) SendEmailChangedEmail(ctx context.Context, email, name, newEmail string) error {
+	return m.observe(ctx, "email_changed", func(ctx context.Context) error {
+		return m.MailService.SendEmailChangedEmail(ctx, email, name, newEmail)
+	})
+}
+
+func (m *instrumentedMailService) observe(ctx context.Context, mailType string, send func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, "mail.Send "+mailType)
+	err := send(ctx)
+	tracing.End(span, err)
+	metrics.MailSent(mailType, err)
+	return err
 }
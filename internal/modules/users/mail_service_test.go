@@ -0,0 +1,83 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestInstrumentedMailService
+package users
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time-tracker/internal/utils/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentedMailService(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	defer otel.SetTracerProvider(prevProvider)
+
+	mailService := new(MockMailService)
+	service := NewInstrumentedMailService(mailService)
+	sendErr := errors.New("mailgun: 500")
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "POST /signup")
+
+	mailService.On("SendActivationEmail", email, "Test", "link").Return(nil).Once()
+	mailService.On("SendLoginWithTokenEmail", email, "Test", "link").Return(sendErr).Once()
+	mailService.On("SendEmailChangeEmail", email, "Test", "link").Return(nil).Once()
+	mailService.On("SendEmailChangedEmail", email, "Test", "new@example.com").Return(nil).Once()
+
+	require.NoError(t, service.SendActivationEmail(ctx, email, "Test", "link"))
+	require.ErrorIs(t, service.SendLoginWithTokenEmail(ctx, email, "Test", "link"), sendErr)
+	require.NoError(t, service.SendEmailChangeEmail(ctx, email, "Test", "link"))
+	require.NoError(t, service.SendEmailChangedEmail(ctx, email, "Test", "new@example.com"))
+	parent.End()
+
+	mailService.AssertExpectations(t)
+	expected := `
+# HELP time_tracker_mail_sent_total Emails sent by the type and the result, success or failure.
+# TYPE time_tracker_mail_sent_total counter
+time_tracker_mail_sent_total{result="failure",type="login_with_token"} 1
+time_tracker_mail_sent_total{result="success",type="activation"} 1
+time_tracker_mail_sent_total{result="success",type="email_change"} 1
+time_tracker_mail_sent_total{result="success",type="email_changed"} 1
+`
+	err := testutil.GatherAndCompare(metrics.Registry, strings.NewReader(expected), "time_tracker_mail_sent_total")
+	assert.NoError(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 5)
+	for _, span := range ended[:4] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+	}
+	assert.Equal(t, "mail.Send activation", ended[0].Name())
+	assert.Equal(t, "mail.Send login_with_token", ended[1].Name())
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	assert.Equal(t, codes.Unset, ended[0].Status().Code)
+}
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestLogMailService
+func TestLogMailService(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	service := NewLogMailService()
+
+	require.NoError(t, service.SendActivationEmail(context.Background(), "john@example.com", "John", "https://example.com/activation?hash=abc"))
+	require.NoError(t, service.SendEmailChangedEmail(context.Background(), "john@example.com", "John", "new@example.com"))
+
+	assert.Contains(t, buf.String(), `"type":"activation","email":"john@example.com","link":"https://example.com/activation?hash=abc"`)
+	assert.Contains(t, buf.String(), `"type":"email_changed","email":"john@example.com","new_email":"new@example.com"`)
+}
@@ -0,0 +1,14 @@
+package users
+
+import (
+	"context"
+	"time-tracker/internal/utils/oauth"
+)
+
+// Implemented by oauth.OIDCProvider and oauth.GitHubProvider
+type OAuthProvider interface {
+	Name() string
+	Title() string
+	AuthCodeURL(state, nonce string) string
+	Exchange(ctx context.Context, code, nonce string) (*oauth.UserInfo, error)
+}
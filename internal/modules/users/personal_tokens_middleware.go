@@ -0,0 +1,61 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// last_used_at is updated at most once a minute per token, not on every request
+const personalTokenUsedInterval = time.Minute
+
+// PersonalTokenMiddleware authenticates the requests of the API by "Authorization: Bearer <token>".
+// Without a valid token of an active user it responds 401, the handlers always have the user.
+func PersonalTokenMiddleware(next http.Handler, tokensRepo PersonalTokensRepository, usersRepo UsersRepository) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			renderUnauthorized(w, "Missing API token")
+			return
+		}
+
+		personalToken, err := tokensRepo.PersonalTokenByHash(r.Context(), hashPersonalToken(token))
+		if errors.Is(err, utils.ErrNotFound) {
+			renderUnauthorized(w, "Invalid API token")
+			return
+		}
+		if err != nil {
+			utils.RenderErrorJSON(w, r, err)
+			return
+		}
+
+		user, err := usersRepo.GetByID(r.Context(), personalToken.UserID)
+		if errors.Is(err, utils.ErrNotFound) || (err == nil && !user.IsActive) {
+			renderUnauthorized(w, "Invalid API token")
+			return
+		}
+		if err != nil {
+			utils.RenderErrorJSON(w, r, err)
+			return
+		}
+
+		now := time.Now().UTC()
+		if personalToken.LastUsedAt == nil || now.Sub(*personalToken.LastUsedAt) >= personalTokenUsedInterval {
+			if err := tokensRepo.SetPersonalTokenUsed(r.Context(), personalToken.ID, now); err != nil {
+				utils.Logger(r.Context()).Error("PersonalTokenMiddleware SetPersonalTokenUsed()", "err", err)
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), ContextUserKey, user)
+		utils.AddLogAttrs(ctx, "user_id", user.ID, "personal_token_id", personalToken.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func renderUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	utils.RenderJSON(w, http.StatusUnauthorized, map[string]string{"error": message})
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestPersonalTokenMiddleware
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalTokenMiddleware(t *testing.T) {
+	ctx := context.Background()
+	usersRepo := NewUsersRepositoryMem()
+	user := &User{Name: "User", Email: "user@example.com", IsActive: true}
+	require.NoError(t, usersRepo.Create(ctx, user))
+	inactive := &User{Name: "Inactive", Email: "inactive@example.com"}
+	require.NoError(t, usersRepo.Create(ctx, inactive))
+
+	tokensRepo := NewPersonalTokensRepositoryMem()
+	createToken := func(userID int) (string, int) {
+		token, tokenHash, err := generatePersonalToken()
+		require.NoError(t, err)
+		id, err := tokensRepo.CreatePersonalToken(ctx, &PersonalToken{UserID: userID, Name: "Laptop", TokenHash: tokenHash, CreatedAt: time.Now().UTC()})
+		require.NoError(t, err)
+		return token, id
+	}
+	token, tokenID := createToken(user.ID)
+	inactiveToken, _ := createToken(inactive.ID)
+
+	var gotUser *User
+	handler := PersonalTokenMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = GetUserFromRequest(r)
+		w.WriteHeader(http.StatusNoContent)
+	}), tokensRepo, usersRepo)
+	request := func(authorization string) *httptest.ResponseRecorder {
+		gotUser = nil
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			authorization string
+			message       string
+		}{
+			{"NoHeader", "", "Missing API token"},
+			{"NotBearer", "Basic dXNlcjpwYXNz", "Missing API token"},
+			{"Unknown", "Bearer tt_unknown", "Invalid API token"},
+			{"InactiveUser", "Bearer " + inactiveToken, "Invalid API token"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := request(tt.authorization)
+
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+				assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+				assert.JSONEq(t, `{"error":"`+tt.message+`"}`, w.Body.String())
+				assert.Nil(t, gotUser)
+			})
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		w := request("Bearer " + token)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		require.NotNil(t, gotUser)
+		assert.Equal(t, user.ID, gotUser.ID)
+		tokens, err := tokensRepo.PersonalTokens(ctx, user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, tokens[0].LastUsedAt)
+		assert.WithinDuration(t, time.Now(), *tokens[0].LastUsedAt, time.Minute)
+
+		// Not updated on every request
+		usedAt := time.Now().UTC().Add(-10 * time.Second)
+		require.NoError(t, tokensRepo.SetPersonalTokenUsed(ctx, tokenID, usedAt))
+		request("Bearer " + token)
+		tokens, err = tokensRepo.PersonalTokens(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, usedAt, *tokens[0].LastUsedAt)
+	})
+}
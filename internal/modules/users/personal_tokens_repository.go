@@ -0,0 +1,56 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The prefix of the tokens, so they are recognized, e.g. by the secret scanners
+const personalTokenPrefix = "tt_"
+
+// PersonalToken is a token of the API, e.g. for the tt command-line client.
+// The token itself is shown once on creation, only its SHA-256 is stored.
+type PersonalToken struct {
+	ID     int
+	UserID int
+	// Where the token is used, e.g. "Laptop"
+	Name      string
+	TokenHash string
+	// UTC
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// Methods that change one token by ID return utils.ErrNotFound if it does not exist.
+// PersonalTokensRepositoryContract in the tests describes the behavior that every implementation must follow.
+type PersonalTokensRepository interface {
+	// Oldest first
+	PersonalTokens(ctx context.Context, userID int) ([]*PersonalToken, error)
+	CreatePersonalToken(ctx context.Context, token *PersonalToken) (int, error)
+	// utils.ErrNotFound if there is no token with the hash
+	PersonalTokenByHash(ctx context.Context, tokenHash string) (*PersonalToken, error)
+	// Deletes the token of the user
+	DeletePersonalToken(ctx context.Context, id int, userID int) error
+	// Deletes all the tokens of the user, e.g. on the deletion of the account
+	DeletePersonalTokens(ctx context.Context, userID int) error
+	SetPersonalTokenUsed(ctx context.Context, id int, usedAt time.Time) error
+}
+
+// Returns the token for the user and its hash for the storage
+func generatePersonalToken() (token string, tokenHash string, err error) {
+	random, err := generateRandomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("generatePersonalToken: %w", err)
+	}
+	token = personalTokenPrefix + random
+	return token, hashPersonalToken(token), nil
+}
+
+func hashPersonalToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
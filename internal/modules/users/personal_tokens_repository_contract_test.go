@@ -0,0 +1,120 @@
+// For all go:build
+// The contract of PersonalTokensRepository. Every implementation must pass it:
+// PersonalTokensRepositoryMem and PersonalTokensRepositorySQLite in the unit tests.
+package users
+
+import (
+	"context"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns an empty repository and two users
+type PersonalTokensRepositoryFactory func(t *testing.T) (repo PersonalTokensRepository, userID int, otherUserID int)
+
+func PersonalTokensRepositoryContract(t *testing.T, newRepo PersonalTokensRepositoryFactory) {
+	ctx := context.Background()
+	// The databases keep microseconds
+	now := time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC)
+
+	createToken := func(t *testing.T, repo PersonalTokensRepository, userID int, name string) *PersonalToken {
+		token := &PersonalToken{UserID: userID, Name: name, TokenHash: hashPersonalToken(name), CreatedAt: now}
+		id, err := repo.CreatePersonalToken(ctx, token)
+		require.NoError(t, err)
+		token.ID = id
+		return token
+	}
+
+	t.Run("PersonalTokens", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		first := createToken(t, repo, userID, "Laptop")
+		second := createToken(t, repo, userID, "CI")
+		createToken(t, repo, otherUserID, "Other")
+
+		tokens, err := repo.PersonalTokens(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, tokens, 2)
+		assert.Equal(t, first.ID, tokens[0].ID)
+		assert.Equal(t, second.ID, tokens[1].ID)
+		assert.Equal(t, userID, tokens[0].UserID)
+		assert.Equal(t, "Laptop", tokens[0].Name)
+		assert.Equal(t, hashPersonalToken("Laptop"), tokens[0].TokenHash)
+		assert.True(t, tokens[0].CreatedAt.Equal(now))
+		assert.Nil(t, tokens[0].LastUsedAt)
+	})
+
+	t.Run("DuplicateHash", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		createToken(t, repo, userID, "Laptop")
+
+		_, err := repo.CreatePersonalToken(ctx, &PersonalToken{UserID: userID, Name: "Laptop", TokenHash: hashPersonalToken("Laptop"), CreatedAt: now})
+		assert.Error(t, err)
+	})
+
+	t.Run("PersonalTokenByHash", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		token := createToken(t, repo, userID, "Laptop")
+
+		found, err := repo.PersonalTokenByHash(ctx, token.TokenHash)
+		require.NoError(t, err)
+		assert.Equal(t, token.ID, found.ID)
+		assert.Equal(t, userID, found.UserID)
+
+		_, err = repo.PersonalTokenByHash(ctx, hashPersonalToken("unknown"))
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("SetPersonalTokenUsed", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		token := createToken(t, repo, userID, "Laptop")
+
+		usedAt := now.Add(time.Hour)
+		require.NoError(t, repo.SetPersonalTokenUsed(ctx, token.ID, usedAt))
+		found, err := repo.PersonalTokenByHash(ctx, token.TokenHash)
+		require.NoError(t, err)
+		require.NotNil(t, found.LastUsedAt)
+		assert.True(t, found.LastUsedAt.Equal(usedAt))
+
+		err = repo.SetPersonalTokenUsed(ctx, token.ID+1000, usedAt)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("DeletePersonalToken", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		token := createToken(t, repo, userID, "Laptop")
+
+		err := repo.DeletePersonalToken(ctx, token.ID, otherUserID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+
+		require.NoError(t, repo.DeletePersonalToken(ctx, token.ID, userID))
+		tokens, err := repo.PersonalTokens(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+		_, err = repo.PersonalTokenByHash(ctx, token.TokenHash)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+
+		err = repo.DeletePersonalToken(ctx, token.ID, userID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("DeletePersonalTokens", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		createToken(t, repo, userID, "Laptop")
+		createToken(t, repo, userID, "CI")
+		other := createToken(t, repo, otherUserID, "Other")
+
+		require.NoError(t, repo.DeletePersonalTokens(ctx, userID))
+		tokens, err := repo.PersonalTokens(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+		_, err = repo.PersonalTokenByHash(ctx, other.TokenHash)
+		assert.NoError(t, err)
+
+		// No tokens is not an error
+		require.NoError(t, repo.DeletePersonalTokens(ctx, userID))
+	})
+}
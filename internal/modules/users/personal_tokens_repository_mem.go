@@ -0,0 +1,102 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// PersonalTokensRepositoryMem has the same semantics as PersonalTokensRepositoryPostgres, for the tests.
+// Returned tokens are copies, changing them does not change the repository.
+type PersonalTokensRepositoryMem struct {
+	mu     sync.Mutex
+	tokens map[int]*PersonalToken
+	nextID int
+}
+
+func NewPersonalTokensRepositoryMem() *PersonalTokensRepositoryMem {
+	return &PersonalTokensRepositoryMem{tokens: make(map[int]*PersonalToken), nextID: 1}
+}
+
+func (repo *PersonalTokensRepositoryMem) PersonalTokens(_ context.Context, userID int) (tokens []*PersonalToken, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, token := range repo.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, copyPersonalToken(token))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+func (repo *PersonalTokensRepositoryMem) CreatePersonalToken(_ context.Context, token *PersonalToken) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, existing := range repo.tokens {
+		if existing.TokenHash == token.TokenHash {
+			return 0, fmt.Errorf("personal token: duplicate token_hash")
+		}
+	}
+	created := copyPersonalToken(token)
+	created.ID = repo.nextID
+	repo.nextID++
+	repo.tokens[created.ID] = created
+	return created.ID, nil
+}
+
+func (repo *PersonalTokensRepositoryMem) PersonalTokenByHash(_ context.Context, tokenHash string) (*PersonalToken, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, token := range repo.tokens {
+		if token.TokenHash == tokenHash {
+			return copyPersonalToken(token), nil
+		}
+	}
+	return nil, fmt.Errorf("personal token: %w", utils.ErrNotFound)
+}
+
+func (repo *PersonalTokensRepositoryMem) DeletePersonalToken(_ context.Context, id int, userID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	token, ok := repo.tokens[id]
+	if !ok || token.UserID != userID {
+		return fmt.Errorf("personal token %d: %w", id, utils.ErrNotFound)
+	}
+	delete(repo.tokens, id)
+	return nil
+}
+
+func (repo *PersonalTokensRepositoryMem) DeletePersonalTokens(_ context.Context, userID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for id, token := range repo.tokens {
+		if token.UserID == userID {
+			delete(repo.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (repo *PersonalTokensRepositoryMem) SetPersonalTokenUsed(_ context.Context, id int, usedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	token, ok := repo.tokens[id]
+	if !ok {
+		return fmt.Errorf("personal token %d: %w", id, utils.ErrNotFound)
+	}
+	token.LastUsedAt = &usedAt
+	return nil
+}
+
+func copyPersonalToken(token *PersonalToken) *PersonalToken {
+	t := *token
+	if token.LastUsedAt != nil {
+		lastUsedAt := *token.LastUsedAt
+		t.LastUsedAt = &lastUsedAt
+	}
+	return &t
+}
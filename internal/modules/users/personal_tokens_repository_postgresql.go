@@ -0,0 +1,132 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PersonalTokensRepositoryPostgres struct {
+	db           PgxPool
+	queryTimeout time.Duration
+}
+
+// queryTimeout limits every query, 0 means no limit
+func NewPersonalTokensRepositoryPostgres(db PgxPool, queryTimeout time.Duration) *PersonalTokensRepositoryPostgres {
+	return &PersonalTokensRepositoryPostgres{db: db, queryTimeout: queryTimeout}
+}
+
+const personalTokensSelectFields = "SELECT id, user_id, name, token_hash, created_at, last_used_at FROM personal_tokens"
+
+func (r *PersonalTokensRepositoryPostgres) PersonalTokens(ctx context.Context, userID int) (tokens []*PersonalToken, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, personalTokensSelectFields+` WHERE user_id = $1 ORDER BY id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositoryPostgres PersonalTokens Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PersonalTokensRepositoryPostgres PersonalTokens Scan: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositoryPostgres PersonalTokens Rows: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *PersonalTokensRepositoryPostgres) CreatePersonalToken(ctx context.Context, token *PersonalToken) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO personal_tokens (user_id, name, token_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, token.UserID, token.Name, token.TokenHash, token.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("PersonalTokensRepositoryPostgres CreatePersonalToken Query: %w", err)
+	}
+	newID, err = pgx.CollectExactlyOneRow(rows, pgx.RowTo[int])
+	if err != nil {
+		return 0, fmt.Errorf("PersonalTokensRepositoryPostgres CreatePersonalToken Scan: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *PersonalTokensRepositoryPostgres) PersonalTokenByHash(ctx context.Context, tokenHash string) (*PersonalToken, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, personalTokensSelectFields+` WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositoryPostgres PersonalTokenByHash Query: %w", err)
+	}
+	token, err := pgx.CollectExactlyOneRow(rows, func(row pgx.CollectableRow) (*PersonalToken, error) {
+		return scanPersonalToken(row)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("personal token: %w", utils.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositoryPostgres PersonalTokenByHash Scan: %w", err)
+	}
+	return token, nil
+}
+
+func (r *PersonalTokensRepositoryPostgres) DeletePersonalToken(ctx context.Context, id int, userID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	tag, err := r.db.Exec(ctx, `DELETE FROM personal_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("PersonalTokensRepositoryPostgres DeletePersonalToken Exec: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("personal token %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *PersonalTokensRepositoryPostgres) DeletePersonalTokens(ctx context.Context, userID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.Exec(ctx, `DELETE FROM personal_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("PersonalTokensRepositoryPostgres DeletePersonalTokens Exec: %w", err)
+	}
+	return nil
+}
+
+func (r *PersonalTokensRepositoryPostgres) SetPersonalTokenUsed(ctx context.Context, id int, usedAt time.Time) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	tag, err := r.db.Exec(ctx, `UPDATE personal_tokens SET last_used_at = $1 WHERE id = $2`, usedAt, id)
+	if err != nil {
+		return fmt.Errorf("PersonalTokensRepositoryPostgres SetPersonalTokenUsed Exec: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("personal token %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+// pgx.Row and *sql.Row
+type personalTokenScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPersonalToken(row personalTokenScanner) (*PersonalToken, error) {
+	var token PersonalToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.CreatedAt, &token.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
@@ -0,0 +1,106 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+)
+
+type PersonalTokensRepositorySQLite struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// db is opened by sqlite.Open, queryTimeout limits every query, 0 means no limit
+func NewPersonalTokensRepositorySQLite(db *sql.DB, queryTimeout time.Duration) *PersonalTokensRepositorySQLite {
+	return &PersonalTokensRepositorySQLite{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *PersonalTokensRepositorySQLite) PersonalTokens(ctx context.Context, userID int) (tokens []*PersonalToken, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, personalTokensSelectFields+` WHERE user_id = $1 ORDER BY id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositorySQLite PersonalTokens Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("PersonalTokensRepositorySQLite PersonalTokens Scan: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositorySQLite PersonalTokens Rows: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *PersonalTokensRepositorySQLite) CreatePersonalToken(ctx context.Context, token *PersonalToken) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO personal_tokens (user_id, name, token_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, token.UserID, token.Name, token.TokenHash, sqlite.FormatTime(token.CreatedAt)).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("PersonalTokensRepositorySQLite CreatePersonalToken QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *PersonalTokensRepositorySQLite) PersonalTokenByHash(ctx context.Context, tokenHash string) (*PersonalToken, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	token, err := scanPersonalToken(r.db.QueryRowContext(ctx, personalTokensSelectFields+` WHERE token_hash = $1`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("personal token: %w", utils.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("PersonalTokensRepositorySQLite PersonalTokenByHash QueryRow: %w", err)
+	}
+	return token, nil
+}
+
+func (r *PersonalTokensRepositorySQLite) DeletePersonalToken(ctx context.Context, id int, userID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `DELETE FROM personal_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("PersonalTokensRepositorySQLite DeletePersonalToken Exec: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("personal token %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *PersonalTokensRepositorySQLite) DeletePersonalTokens(ctx context.Context, userID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, `DELETE FROM personal_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("PersonalTokensRepositorySQLite DeletePersonalTokens Exec: %w", err)
+	}
+	return nil
+}
+
+func (r *PersonalTokensRepositorySQLite) SetPersonalTokenUsed(ctx context.Context, id int, usedAt time.Time) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `UPDATE personal_tokens SET last_used_at = $1 WHERE id = $2`, sqlite.FormatTime(usedAt), id)
+	if err != nil {
+		return fmt.Errorf("PersonalTokensRepositorySQLite SetPersonalTokenUsed Exec: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("personal token %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestPersonalTokensRepository.*
+package users
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalTokensRepositoryMem_Contract(t *testing.T) {
+	PersonalTokensRepositoryContract(t, func(t *testing.T) (PersonalTokensRepository, int, int) {
+		return NewPersonalTokensRepositoryMem(), 1, 2
+	})
+}
+
+func TestPersonalTokensRepositorySQLite_Contract(t *testing.T) {
+	PersonalTokensRepositoryContract(t, func(t *testing.T) (PersonalTokensRepository, int, int) {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		userIDs := []int{}
+		for i := 1; i <= 2; i++ {
+			var userID int
+			err := db.QueryRow(`
+				INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id
+			`, "Contract", fmt.Sprintf("contract-%d@example.com", i), "password").Scan(&userID)
+			require.NoError(t, err)
+			userIDs = append(userIDs, userID)
+		}
+		return NewPersonalTokensRepositorySQLite(db, time.Second), userIDs[0], userIDs[1]
+	})
+}
+
+func TestPersonalTokensRepositoryPostgres_PersonalTokenByHash(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo := NewPersonalTokensRepositoryPostgres(mock, 0)
+	query := `SELECT id, user_id, name, token_hash, created_at, last_used_at FROM personal_tokens WHERE token_hash = \$1`
+	columns := []string{"id", "user_id", "name", "token_hash", "created_at", "last_used_at"}
+	createdAt := time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(query).WithArgs("hash").
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(1, 2, "Laptop", "hash", createdAt, nil))
+	token, err := repo.PersonalTokenByHash(context.Background(), "hash")
+	require.NoError(t, err)
+	assert.Equal(t, &PersonalToken{ID: 1, UserID: 2, Name: "Laptop", TokenHash: "hash", CreatedAt: createdAt}, token)
+
+	mock.ExpectQuery(query).WithArgs("unknown").WillReturnRows(pgxmock.NewRows(columns))
+	_, err = repo.PersonalTokenByHash(context.Background(), "unknown")
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPersonalTokensRepository_GeneratePersonalToken(t *testing.T) {
+	token, tokenHash, err := generatePersonalToken()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, personalTokenPrefix))
+	assert.Len(t, token, len(personalTokenPrefix)+64)
+	assert.Len(t, tokenHash, 64)
+	assert.Equal(t, tokenHash, hashPersonalToken(token))
+	// Copied with a newline
+	assert.Equal(t, tokenHash, hashPersonalToken(token+"\n"))
+
+	other, _, err := generatePersonalToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
@@ -0,0 +1,186 @@
+package users
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+type RateLimitRule struct {
+	Limit  int // Attempts allowed within Window
+	Window time.Duration
+	// The first lockout lasts Lockout, each next one within StrikesWindow lasts twice as long, up to MaxLockout.
+	Lockout       time.Duration
+	MaxLockout    time.Duration
+	StrikesWindow time.Duration
+}
+
+type RateLimiter struct {
+	repo      RateLimitRepository
+	ipRule    RateLimitRule
+	emailRule RateLimitRule
+	// The load balancers and proxies whose X-Forwarded-For is trusted, see clientIP
+	trustedProxies []netip.Prefix
+}
+
+func NewRateLimiter(repo RateLimitRepository, trustedProxies []netip.Prefix) *RateLimiter {
+	return &RateLimiter{
+		repo:           repo,
+		trustedProxies: trustedProxies,
+		ipRule: RateLimitRule{
+			Limit:         30,
+			Window:        15 * time.Minute,
+			Lockout:       15 * time.Minute,
+			MaxLockout:    24 * time.Hour,
+			StrikesWindow: 24 * time.Hour,
+		},
+		emailRule: RateLimitRule{
+			Limit:         10,
+			Window:        15 * time.Minute,
+			Lockout:       5 * time.Minute,
+			MaxLockout:    24 * time.Hour,
+			StrikesWindow: 24 * time.Hour,
+		},
+	}
+}
+
+// Limits POST requests per IP and per "email" form field.
+// action separates buckets of different forms, e.g. "login", "signup".
+// Example:
+// mux.Handle("/login", users.RateLimitMiddleware(http.HandlerFunc(usersHandlers.HandleLogin), rateLimiter, "login"))
+func RateLimitMiddleware(next http.Handler, limiter *RateLimiter, action string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// The handler will respond with "Bad Request"
+		if err := r.ParseForm(); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := clientIP(r, limiter.trustedProxies)
+		email := strings.ToLower(strings.TrimSpace(r.PostForm.Get("email")))
+
+		lockedFor := limiter.check("ip:"+action+":"+ip, limiter.ipRule, action, ip, email)
+		// A locked IP does not count against the email, so it cannot keep the email of someone else locked
+		if email != "" && lockedFor == 0 {
+			lockedFor = max(lockedFor, limiter.check("email:"+action+":"+email, limiter.emailRule, action, ip, email))
+		}
+		if lockedFor > 0 {
+			renderTooManyAttempts(w, lockedFor)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Registers an attempt in the bucket and returns the remaining lockout time if the bucket is locked.
+// Repository errors are logged and the request is allowed, so that Redis outage does not block logins.
+func (l *RateLimiter) check(bucket string, rule RateLimitRule, action, ip, email string) time.Duration {
+	lockedFor, err := l.repo.LockedFor(bucket)
+	if err != nil {
+		slog.Error("RateLimiter LockedFor", "bucket", bucket, "err", err)
+		return 0
+	}
+	if lockedFor > 0 {
+		return lockedFor
+	}
+
+	hits, err := l.repo.Hit(bucket, rule.Window)
+	if err != nil {
+		slog.Error("RateLimiter Hit", "bucket", bucket, "err", err)
+		return 0
+	}
+	if hits <= rule.Limit {
+		return 0
+	}
+
+	strikes, err := l.repo.Hit("strikes:"+bucket, rule.StrikesWindow)
+	if err != nil {
+		slog.Error("RateLimiter Hit strikes", "bucket", bucket, "err", err)
+		strikes = 1
+	}
+	lockout := lockoutDuration(rule, strikes)
+	if err := l.repo.Lock(bucket, lockout); err != nil {
+		slog.Error("RateLimiter Lock", "bucket", bucket, "err", err)
+	}
+	slog.Warn("RateLimiter lockout",
+		"event", "rate_limit_lockout",
+		"action", action,
+		"bucket", bucket,
+		"ip", ip,
+		"email", email,
+		"hits", hits,
+		"strikes", strikes,
+		"lockout", lockout.String(),
+	)
+	return lockout
+}
+
+func lockoutDuration(rule RateLimitRule, strikes int) time.Duration {
+	multiplier := math.Pow(2, float64(strikes-1))
+	lockout := time.Duration(float64(rule.Lockout) * multiplier)
+	if lockout > rule.MaxLockout || lockout <= 0 {
+		lockout = rule.MaxLockout
+	}
+	return lockout
+}
+
+// The address of the connection, or the client in X-Forwarded-For if the connection is from a trusted proxy.
+// The client can send any X-Forwarded-For, the proxies append to it, so it is read from the right
+// up to the first address that is not a trusted proxy. X-Real-IP is not used,
+// the load balancers pass the one of the client unchanged.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			// Not written by a trusted proxy
+			break
+		}
+		if !isTrustedProxy(addr.String(), trustedProxies) {
+			return addr.String()
+		}
+	}
+	return host
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func renderTooManyAttempts(w http.ResponseWriter, lockedFor time.Duration) {
+	minutes := int(math.Ceil(lockedFor.Minutes()))
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(lockedFor.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	utils.RenderTemplate(w, "error", utils.TplData{
+		"Title":   "Too Many Attempts",
+		"Message": fmt.Sprintf("Too many attempts. Please try again in %d min.", minutes),
+	})
+}
@@ -0,0 +1,158 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestRateLimitMiddleware
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rateLimitRequest(method, ip, email string) *http.Request {
+	form := url.Values{"email": {email}, "password": {"password"}}
+	req := httptest.NewRequest(method, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = ip + ":1234"
+	return req
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	SetAppDir()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newLimiter := func() *RateLimiter {
+		limiter := NewRateLimiter(NewRateLimitRepositoryMem(), nil)
+		limiter.ipRule.Limit = 5
+		limiter.emailRule.Limit = 2
+		return limiter
+	}
+
+	t.Run("GET is not limited", func(t *testing.T) {
+		handler := RateLimitMiddleware(next, newLimiter(), "login")
+		for i := 0; i < 10; i++ {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("email bucket locks across IPs", func(t *testing.T) {
+		handler := RateLimitMiddleware(next, newLimiter(), "login")
+		codes := []int{}
+		for _, ip := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, rateLimitRequest(http.MethodPost, ip, "Test@Example.com "))
+			codes = append(codes, w.Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+
+		// Another email is not locked
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, rateLimitRequest(http.MethodPost, "1.1.1.1", "other@example.com"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("IP bucket locks across emails", func(t *testing.T) {
+		handler := RateLimitMiddleware(next, newLimiter(), "login")
+		var w *httptest.ResponseRecorder
+		for i := 0; i < 6; i++ {
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, rateLimitRequest(http.MethodPost, "1.1.1.1", strings.Repeat("a", i+1)+"@example.com"))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "900", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("locked IP does not lock the email", func(t *testing.T) {
+		limiter := newLimiter()
+		limiter.emailRule.Limit = 6
+		handler := RateLimitMiddleware(next, limiter, "login")
+		// 5 attempts are counted for the email, the next ones are stopped by the lockout of the IP
+		for i := 0; i < 10; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), rateLimitRequest(http.MethodPost, "6.6.6.6", "victim@example.com"))
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, rateLimitRequest(http.MethodPost, "1.1.1.1", "victim@example.com"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("spoofed headers do not make new buckets", func(t *testing.T) {
+		handler := RateLimitMiddleware(next, newLimiter(), "login")
+		var w *httptest.ResponseRecorder
+		for i := 0; i < 6; i++ {
+			req := rateLimitRequest(http.MethodPost, "1.1.1.1", strings.Repeat("a", i+1)+"@example.com")
+			req.Header.Set("X-Real-IP", "9.9.9."+strconv.Itoa(i))
+			req.Header.Set("X-Forwarded-For", "8.8.8."+strconv.Itoa(i))
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("actions have separate buckets", func(t *testing.T) {
+		limiter := newLimiter()
+		login := RateLimitMiddleware(next, limiter, "login")
+		signup := RateLimitMiddleware(next, limiter, "signup")
+		for i := 0; i < 3; i++ {
+			login.ServeHTTP(httptest.NewRecorder(), rateLimitRequest(http.MethodPost, "1.1.1.1", "test@example.com"))
+		}
+		w := httptest.NewRecorder()
+		signup.ServeHTTP(w, rateLimitRequest(http.MethodPost, "1.1.1.1", "test@example.com"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestRateLimitMiddleware_LockoutDuration(t *testing.T) {
+	rule := RateLimitRule{Lockout: 5 * time.Minute, MaxLockout: time.Hour}
+	assert.Equal(t, 5*time.Minute, lockoutDuration(rule, 1))
+	assert.Equal(t, 10*time.Minute, lockoutDuration(rule, 2))
+	assert.Equal(t, 40*time.Minute, lockoutDuration(rule, 4))
+	assert.Equal(t, time.Hour, lockoutDuration(rule, 5))
+	assert.Equal(t, time.Hour, lockoutDuration(rule, 100))
+}
+
+func TestRateLimitMiddleware_ClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/16"), netip.MustParsePrefix("192.168.1.5/32")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"NoProxy", "1.2.3.4:1234", nil, "", "1.2.3.4"},
+		{"UntrustedHeaders", "1.2.3.4:1234", []string{"5.6.7.8"}, "9.9.9.9", "1.2.3.4"},
+		{"TrustedProxy", "10.0.0.1:1234", []string{"5.6.7.8"}, "", "5.6.7.8"},
+		{"RealIPIsIgnored", "10.0.0.1:1234", nil, "9.9.9.9", "10.0.0.1"},
+		{"SpoofedByClient", "10.0.0.1:1234", []string{"6.6.6.6, 5.6.7.8"}, "", "5.6.7.8"},
+		{"ChainOfProxies", "10.0.0.1:1234", []string{"6.6.6.6, 5.6.7.8", "192.168.1.5"}, "", "5.6.7.8"},
+		{"InvalidEntry", "10.0.0.1:1234", []string{"5.6.7.8, garbage"}, "", "10.0.0.1"},
+		{"OnlyProxies", "10.0.0.1:1234", []string{"10.0.0.2"}, "", "10.0.0.1"},
+		{"IPv6", "[2001:db8::1]:1234", []string{"5.6.7.8"}, "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, clientIP(req, trusted))
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package users
+
+import "time"
+
+type RateLimitRepository interface {
+	// Increments the counter of the key. The window starts with the first hit.
+	Hit(key string, window time.Duration) (hits int, err error)
+	Lock(key string, duration time.Duration) error
+	// Returns 0 if the key is not locked.
+	LockedFor(key string) (time.Duration, error)
+}
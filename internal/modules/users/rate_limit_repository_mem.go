@@ -0,0 +1,58 @@
+package users
+
+import (
+	"sync"
+	"time"
+)
+
+type rateLimitCounter struct {
+	hits   int
+	expiry time.Time
+}
+
+type RateLimitRepositoryMem struct {
+	mu       sync.Mutex
+	counters map[string]*rateLimitCounter
+	locks    map[string]time.Time
+}
+
+func NewRateLimitRepositoryMem() *RateLimitRepositoryMem {
+	return &RateLimitRepositoryMem{
+		counters: make(map[string]*rateLimitCounter),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+func (repo *RateLimitRepositoryMem) Hit(key string, window time.Duration) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	counter, exists := repo.counters[key]
+	if !exists || counter.expiry.Before(time.Now()) {
+		counter = &rateLimitCounter{expiry: time.Now().Add(window)}
+		repo.counters[key] = counter
+	}
+	counter.hits++
+	return counter.hits, nil
+}
+
+func (repo *RateLimitRepositoryMem) Lock(key string, duration time.Duration) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.locks[key] = time.Now().Add(duration)
+	return nil
+}
+
+func (repo *RateLimitRepositoryMem) LockedFor(key string) (time.Duration, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	expiry, exists := repo.locks[key]
+	if !exists {
+		return 0, nil
+	}
+	lockedFor := time.Until(expiry)
+	if lockedFor <= 0 {
+		delete(repo.locks, key)
+		return 0, nil
+	}
+	return lockedFor, nil
+}
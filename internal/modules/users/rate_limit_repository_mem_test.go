@@ -0,0 +1,56 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestRateLimitRepositoryMem.*
+package users
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRepositoryMem_Hit(t *testing.T) {
+	repo := NewRateLimitRepositoryMem()
+
+	t.Run("counts hits within window", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			hits, err := repo.Hit("key", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, i, hits)
+		}
+	})
+
+	t.Run("starts new window after expiry", func(t *testing.T) {
+		_, err := repo.Hit("expired", -time.Second)
+		require.NoError(t, err)
+		hits, err := repo.Hit("expired", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 1, hits)
+	})
+}
+
+func TestRateLimitRepositoryMem_Lock(t *testing.T) {
+	repo := NewRateLimitRepositoryMem()
+
+	t.Run("not locked", func(t *testing.T) {
+		lockedFor, err := repo.LockedFor("key")
+		require.NoError(t, err)
+		assert.Zero(t, lockedFor)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		require.NoError(t, repo.Lock("key", time.Minute))
+		lockedFor, err := repo.LockedFor("key")
+		require.NoError(t, err)
+		assert.InDelta(t, time.Minute, lockedFor, float64(time.Second))
+	})
+
+	t.Run("lock expired", func(t *testing.T) {
+		require.NoError(t, repo.Lock("expired", -time.Second))
+		lockedFor, err := repo.LockedFor("expired")
+		require.NoError(t, err)
+		assert.Zero(t, lockedFor)
+	})
+}
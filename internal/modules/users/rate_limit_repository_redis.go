@@ -0,0 +1,57 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+type RateLimitRepositoryRedis struct {
+	client *redis.Client
+}
+
+func NewRateLimitRepositoryRedis(client *redis.Client) *RateLimitRepositoryRedis {
+	return &RateLimitRepositoryRedis{client: client}
+}
+
+func (repo *RateLimitRepositoryRedis) Hit(key string, window time.Duration) (int, error) {
+	ctx := context.Background()
+	key = rateLimitKeyPrefix + "hits:" + key
+	var incr *redis.IntCmd
+	// EXPIRE NX keeps the TTL set by the first hit of the window
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to hit rate limit in Redis: %w", err)
+	}
+	return int(incr.Val()), nil
+}
+
+func (repo *RateLimitRepositoryRedis) Lock(key string, duration time.Duration) error {
+	ctx := context.Background()
+	err := repo.client.Set(ctx, rateLimitKeyPrefix+"lock:"+key, 1, duration).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set rate limit lock in Redis: %w", err)
+	}
+	return nil
+}
+
+func (repo *RateLimitRepositoryRedis) LockedFor(key string) (time.Duration, error) {
+	ctx := context.Background()
+	ttl, err := repo.client.TTL(ctx, rateLimitKeyPrefix+"lock:"+key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rate limit lock from Redis: %w", err)
+	}
+	// TTL returns negative values if the key does not exist or has no expiration
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
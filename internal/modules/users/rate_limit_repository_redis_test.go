@@ -0,0 +1,97 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestRateLimitRepositoryRedis.*
+package users
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRepositoryRedis_Hit(t *testing.T) {
+	t.Run("successful hit", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectTxPipeline()
+		mock.ExpectIncr("ratelimit:hits:key").SetVal(3)
+		mock.ExpectExpireNX("ratelimit:hits:key", time.Minute).SetVal(false)
+		mock.ExpectTxPipelineExec()
+
+		hits, err := repo.Hit("key", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 3, hits)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectTxPipeline()
+		mock.ExpectIncr("ratelimit:hits:key").SetErr(errors.New("redis error"))
+
+		_, err := repo.Hit("key", time.Minute)
+		assert.Error(t, err)
+	})
+}
+
+func TestRateLimitRepositoryRedis_Lock(t *testing.T) {
+	t.Run("successful lock", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectSet("ratelimit:lock:key", 1, time.Minute).SetVal("OK")
+
+		assert.NoError(t, repo.Lock("key", time.Minute))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectSet("ratelimit:lock:key", 1, time.Minute).SetErr(errors.New("redis error"))
+
+		assert.Error(t, repo.Lock("key", time.Minute))
+	})
+}
+
+func TestRateLimitRepositoryRedis_LockedFor(t *testing.T) {
+	t.Run("locked", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectTTL("ratelimit:lock:key").SetVal(time.Minute)
+
+		lockedFor, err := repo.LockedFor("key")
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, lockedFor)
+	})
+
+	t.Run("not locked", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectTTL("ratelimit:lock:key").SetVal(-2)
+
+		lockedFor, err := repo.LockedFor("key")
+		require.NoError(t, err)
+		assert.Zero(t, lockedFor)
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewRateLimitRepositoryRedis(client)
+
+		mock.ExpectTTL("ratelimit:lock:key").SetErr(errors.New("redis error"))
+
+		_, err := repo.LockedFor("key")
+		assert.Error(t, err)
+	})
+}
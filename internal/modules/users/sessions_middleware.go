@@ -2,11 +2,19 @@ package users
 
 import (
 	"context"
-	"log/slog"
+	"errors"
 	"net/http"
 	"time"
+	"time-tracker/internal/utils"
 )
 
+// The error pages of utils.RenderError show the navigation of the logged in user
+func init() {
+	utils.ErrorPageData = func(r *http.Request) utils.TplData {
+		return utils.TplData{"User": GetUserFromRequest(r)}
+	}
+}
+
 func SessionMiddleware(next http.Handler, sessionsRepo SessionsRepository, usersRepo UsersRepository) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// D("SessionMiddleware", r.URL)
@@ -17,18 +25,25 @@ func SessionMiddleware(next http.Handler, sessionsRepo SessionsRepository, users
 			return
 		}
 
-		session, err := sessionsRepo.Get(cookie.Value)
+		session, err := sessionsRepo.Get(r.Context(), cookie.Value)
 		// slog.Debug("SessionMiddleware", "session", session)
 		if err != nil || session == nil || session.Expiry.Before(time.Now()) {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		user := usersRepo.GetByID(session.UserID)
+		user, err := usersRepo.GetByID(r.Context(), session.UserID)
 		// slog.Debug("SessionMiddleware", "user", user)
-		if user != nil && user.IsActive {
+		if err != nil && !errors.Is(err, utils.ErrNotFound) {
+			// Not "logged out", the user would be redirected to the login page during a database outage
+			utils.RenderError(w, r, err)
+			return
+		}
+		if err == nil && user.IsActive {
 			ctx := context.WithValue(r.Context(), ContextUserKey, user)
+			ctx = context.WithValue(ctx, ContextSessionKey, session)
 			r = r.WithContext(ctx)
+			utils.AddLogAttrs(ctx, "user_id", user.ID)
 		}
 
 		next.ServeHTTP(w, r)
@@ -42,8 +57,13 @@ func GetUserFromRequest(r *http.Request) *User {
 	}
 	user, ok := userAny.(*User)
 	if !ok {
-		slog.Error("GetUserFromRequest not *User", "user", user)
+		utils.Logger(r.Context()).Error("GetUserFromRequest not *User", "user", user)
 		return nil
 	}
 	return user
 }
+
+func GetSessionFromRequest(r *http.Request) *Session {
+	session, _ := r.Context().Value(ContextSessionKey).(*Session)
+	return session
+}
@@ -5,10 +5,12 @@ package users
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+	"time-tracker/internal/utils"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -18,56 +20,86 @@ type MockSessionsRepository struct {
 	mock.Mock
 }
 
-func (m *MockSessionsRepository) Create(sessionID string, session *Session) error {
+func (m *MockSessionsRepository) Create(_ context.Context, sessionID string, session *Session) error {
 	args := m.Called(sessionID, session)
 	return args.Error(0)
 }
 
-func (m *MockSessionsRepository) Get(sessionID string) (*Session, error) {
+func (m *MockSessionsRepository) Get(_ context.Context, sessionID string) (*Session, error) {
 	args := m.Called(sessionID)
 	session, _ := args.Get(0).(*Session)
 	return session, args.Error(1)
 }
 
-func (m *MockSessionsRepository) Delete(sessionID string) error {
+func (m *MockSessionsRepository) Delete(_ context.Context, sessionID string) error {
 	args := m.Called(sessionID)
 	return args.Error(0)
 }
 
+func (m *MockSessionsRepository) DeleteByUserID(_ context.Context, userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
 type MockUsersRepository struct {
 	mock.Mock
 }
 
-func (m *MockUsersRepository) Create(user *User) error {
+func (m *MockUsersRepository) Create(_ context.Context, user *User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUsersRepository) GetByID(id int) *User {
+func (m *MockUsersRepository) GetByID(_ context.Context, id int) (*User, error) {
 	args := m.Called(id)
-	return args.Get(0).(*User)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
 
-func (m *MockUsersRepository) GetByEmail(email string) *User {
+func (m *MockUsersRepository) GetByEmail(_ context.Context, email string) (*User, error) {
 	args := m.Called(email)
-	return args.Get(0).(*User)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
 
-func (m *MockUsersRepository) GetByActivationHash(activationHash string) *User {
+func (m *MockUsersRepository) GetByActivationHash(_ context.Context, activationHash string) (*User, error) {
 	args := m.Called(activationHash)
-	return args.Get(0).(*User)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
+}
+
+func (m *MockUsersRepository) GetByCalendarToken(_ context.Context, calendarToken string) (*User, error) {
+	args := m.Called(calendarToken)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
 
-func (m *MockUsersRepository) Update(user *User) error {
+func (m *MockUsersRepository) Update(_ context.Context, user *User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUsersRepository) Delete(id int) error {
+func (m *MockUsersRepository) Delete(_ context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockUsersRepository) GetByIdentity(_ context.Context, provider, subject string) (*User, error) {
+	args := m.Called(provider, subject)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
+}
+
+func (m *MockUsersRepository) CreateIdentity(_ context.Context, identity *Identity) error {
+	args := m.Called(identity)
+	return args.Error(0)
+}
+
+func (m *MockUsersRepository) DeleteRequestedBefore(_ context.Context, date time.Time) (int, error) {
+	args := m.Called(date)
+	return args.Int(0), args.Error(1)
+}
+
 func TestSessionMiddleware(t *testing.T) {
 	mockSessionsRepo := new(MockSessionsRepository)
 	mockUsersRepo := new(MockUsersRepository)
@@ -115,7 +147,7 @@ func TestSessionMiddleware(t *testing.T) {
 
 		session := &Session{SessionID: sessionValue, UserID: 1, Expiry: time.Now().Add(1 * time.Hour)}
 		mockSessionsRepo.On("Get", sessionValue).Return(session, nil)
-		mockUsersRepo.On("GetByID", 1).Return(&User{ID: 1, IsActive: false})
+		mockUsersRepo.On("GetByID", 1).Return(&User{ID: 1, IsActive: false}, nil)
 
 		middleware.ServeHTTP(resp, req)
 
@@ -132,12 +164,13 @@ func TestSessionMiddleware(t *testing.T) {
 
 		session := &Session{SessionID: sessionValue, UserID: 2, Expiry: time.Now().Add(1 * time.Hour)}
 		mockSessionsRepo.On("Get", sessionValue).Return(session, nil)
-		mockUsersRepo.On("GetByID", 2).Return(&User{ID: 2, IsActive: true})
+		mockUsersRepo.On("GetByID", 2).Return(&User{ID: 2, IsActive: true}, nil)
 
 		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user := GetUserFromRequest(r)
 			require.NotNil(t, user)
 			require.Equal(t, 2, user.ID)
+			require.Equal(t, session, GetSessionFromRequest(r))
 		})
 
 		middleware := SessionMiddleware(handler, mockSessionsRepo, mockUsersRepo)
@@ -147,6 +180,37 @@ func TestSessionMiddleware(t *testing.T) {
 		mockSessionsRepo.AssertCalled(t, "Get", sessionValue)
 		mockUsersRepo.AssertCalled(t, "GetByID", 2)
 	})
+
+	t.Run("valid session but user deleted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		sessionValue := "valid-session3"
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: sessionValue})
+		resp := httptest.NewRecorder()
+
+		session := &Session{SessionID: sessionValue, UserID: 3, Expiry: time.Now().Add(1 * time.Hour)}
+		mockSessionsRepo.On("Get", sessionValue).Return(session, nil)
+		mockUsersRepo.On("GetByID", 3).Return(nil, utils.ErrNotFound)
+
+		middleware.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "No user in context", resp.Body.String())
+	})
+
+	t.Run("database error is not a logout", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		sessionValue := "valid-session4"
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: sessionValue})
+		resp := httptest.NewRecorder()
+
+		session := &Session{SessionID: sessionValue, UserID: 4, Expiry: time.Now().Add(1 * time.Hour)}
+		mockSessionsRepo.On("Get", sessionValue).Return(session, nil)
+		mockUsersRepo.On("GetByID", 4).Return(nil, errors.New("db error"))
+
+		middleware.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
 }
 
 // docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestGetUserFromRequest
@@ -178,3 +242,11 @@ func TestGetUserFromRequest(t *testing.T) {
 		require.Nil(t, retrievedUser)
 	})
 }
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestErrorPageData
+func TestErrorPageData(t *testing.T) {
+	user := &User{ID: 1, Name: "John Doe", IsActive: true}
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(context.WithValue(context.Background(), ContextUserKey, user))
+
+	require.Equal(t, user, utils.ErrorPageData(req)["User"])
+}
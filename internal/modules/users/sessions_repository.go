@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"time"
 )
 
@@ -8,10 +9,13 @@ type Session struct {
 	SessionID string
 	UserID    int
 	Expiry    time.Time
+	CSRFToken string
 }
 
 type SessionsRepository interface {
-	Create(sessionID string, session *Session) error
-	Get(sessionID string) (*Session, error)
-	Delete(sessionID string) error
+	Create(ctx context.Context, sessionID string, session *Session) error
+	Get(ctx context.Context, sessionID string) (*Session, error)
+	Delete(ctx context.Context, sessionID string) error
+	// Deletes all the sessions of the user, e.g. on the deletion of the account
+	DeleteByUserID(ctx context.Context, userID int) error
 }
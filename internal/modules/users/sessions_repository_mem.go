@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -16,14 +17,14 @@ func NewSessionsRepositoryMem() *SessionsRepositoryMem {
 	}
 }
 
-func (repo *SessionsRepositoryMem) Create(sessionID string, session *Session) error {
+func (repo *SessionsRepositoryMem) Create(ctx context.Context, sessionID string, session *Session) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 	repo.sessions[sessionID] = session
 	return nil
 }
 
-func (repo *SessionsRepositoryMem) Get(sessionID string) (*Session, error) {
+func (repo *SessionsRepositoryMem) Get(ctx context.Context, sessionID string) (*Session, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 	session, exists := repo.sessions[sessionID]
@@ -33,9 +34,20 @@ func (repo *SessionsRepositoryMem) Get(sessionID string) (*Session, error) {
 	return session, nil
 }
 
-func (repo *SessionsRepositoryMem) Delete(sessionID string) error {
+func (repo *SessionsRepositoryMem) Delete(ctx context.Context, sessionID string) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 	delete(repo.sessions, sessionID)
 	return nil
 }
+
+func (repo *SessionsRepositoryMem) DeleteByUserID(ctx context.Context, userID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for sessionID, session := range repo.sessions {
+		if session.UserID == userID {
+			delete(repo.sessions, sessionID)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@
 package users
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -21,10 +22,10 @@ func TestSessionsRepositoryMem(t *testing.T) {
 			Expiry:    time.Now().Add(time.Hour),
 		}
 
-		err := repo.Create(sessionID, session)
+		err := repo.Create(context.Background(), sessionID, session)
 		assert.NoError(t, err)
 
-		got, err := repo.Get(sessionID)
+		got, err := repo.Get(context.Background(), sessionID)
 		assert.NoError(t, err)
 		assert.NotNil(t, got)
 		assert.Equal(t, session, got)
@@ -38,11 +39,11 @@ func TestSessionsRepositoryMem(t *testing.T) {
 			Expiry:    time.Now().Add(-time.Hour), // Expired session
 		}
 
-		err := repo.Create(sessionID, session)
+		err := repo.Create(context.Background(), sessionID, session)
 		assert.NoError(t, err)
 
 		// Check that the expired session is not available
-		got, err := repo.Get(sessionID)
+		got, err := repo.Get(context.Background(), sessionID)
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 	})
@@ -55,20 +56,38 @@ func TestSessionsRepositoryMem(t *testing.T) {
 			Expiry:    time.Now().Add(time.Hour),
 		}
 
-		err := repo.Create(sessionID, session)
+		err := repo.Create(context.Background(), sessionID, session)
 		assert.NoError(t, err)
 
-		err = repo.Delete(sessionID)
+		err = repo.Delete(context.Background(), sessionID)
 		assert.NoError(t, err)
 
-		got, err := repo.Get(sessionID)
+		got, err := repo.Get(context.Background(), sessionID)
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 	})
 
 	t.Run("delete non-existent session", func(t *testing.T) {
 		sessionID := "nonExistent"
-		err := repo.Delete(sessionID)
+		err := repo.Delete(context.Background(), sessionID)
 		assert.NoError(t, err)
 	})
+
+	t.Run("delete sessions of user", func(t *testing.T) {
+		for _, sessionID := range []string{"userSession1", "userSession2"} {
+			assert.NoError(t, repo.Create(context.Background(), sessionID, &Session{SessionID: sessionID, UserID: 10, Expiry: time.Now().Add(time.Hour)}))
+		}
+		assert.NoError(t, repo.Create(context.Background(), "otherUserSession", &Session{SessionID: "otherUserSession", UserID: 11, Expiry: time.Now().Add(time.Hour)}))
+
+		assert.NoError(t, repo.DeleteByUserID(context.Background(), 10))
+
+		for _, sessionID := range []string{"userSession1", "userSession2"} {
+			got, err := repo.Get(context.Background(), sessionID)
+			assert.NoError(t, err)
+			assert.Nil(t, got)
+		}
+		got, err := repo.Get(context.Background(), "otherUserSession")
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+	})
 }
@@ -21,8 +21,7 @@ func NewSessionsRepositoryRedis(client *redis.Client) *SessionsRepositoryRedis {
 	}
 }
 
-func (repo *SessionsRepositoryRedis) Create(sessionID string, session *Session) error {
-	ctx := context.Background()
+func (repo *SessionsRepositoryRedis) Create(ctx context.Context, sessionID string, session *Session) error {
 	data, err := repo.jsonMarshal(session)
 	if err != nil {
 		return fmt.Errorf("failed to marshal session: %w", err)
@@ -32,11 +31,22 @@ func (repo *SessionsRepositoryRedis) Create(sessionID string, session *Session)
 	if err != nil {
 		return fmt.Errorf("failed to set session in Redis: %w", err)
 	}
+	// The set of the sessions of the user expires with the last of them: NX for a new set, GT extends it
+	key := userSessionsKey(session.UserID)
+	err = repo.client.SAdd(ctx, key, sessionID).Err()
+	if err == nil {
+		err = repo.client.ExpireNX(ctx, key, expiration).Err()
+	}
+	if err == nil {
+		err = repo.client.ExpireGT(ctx, key, expiration).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add session to the sessions of user in Redis: %w", err)
+	}
 	return nil
 }
 
-func (repo *SessionsRepositoryRedis) Get(sessionID string) (*Session, error) {
-	ctx := context.Background()
+func (repo *SessionsRepositoryRedis) Get(ctx context.Context, sessionID string) (*Session, error) {
 	data, err := repo.client.Get(ctx, sessionID).Result()
 	if err == redis.Nil {
 		return nil, nil
@@ -57,11 +67,28 @@ func (repo *SessionsRepositoryRedis) Get(sessionID string) (*Session, error) {
 	return &session, nil
 }
 
-func (repo *SessionsRepositoryRedis) Delete(sessionID string) error {
-	ctx := context.Background()
+func (repo *SessionsRepositoryRedis) Delete(ctx context.Context, sessionID string) error {
 	err := repo.client.Del(ctx, sessionID).Err()
 	if err != nil {
 		return fmt.Errorf("failed to delete session from Redis: %w", err)
 	}
 	return nil
 }
+
+// The set of the sessions may have the IDs of the deleted and the expired sessions, deleting them again is harmless
+func (repo *SessionsRepositoryRedis) DeleteByUserID(ctx context.Context, userID int) error {
+	key := userSessionsKey(userID)
+	sessionIDs, err := repo.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get sessions of user from Redis: %w", err)
+	}
+	err = repo.client.Del(ctx, append(sessionIDs, key)...).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions of user from Redis: %w", err)
+	}
+	return nil
+}
+
+func userSessionsKey(userID int) string {
+	return fmt.Sprintf("user_sessions:%d", userID)
+}
@@ -4,6 +4,7 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -34,8 +35,11 @@ func TestSessionsRepositoryRedis_Create(t *testing.T) {
 			data,
 			time.Until(session.Expiry),
 		).SetVal("OK")
+		mock.ExpectSAdd("user_sessions:1", "session123").SetVal(1)
+		mock.ExpectExpireNX("user_sessions:1", time.Until(session.Expiry)).SetVal(true)
+		mock.ExpectExpireGT("user_sessions:1", time.Until(session.Expiry)).SetVal(false)
 
-		err = repo.Create(session.SessionID, session)
+		err = repo.Create(context.Background(), session.SessionID, session)
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -59,7 +63,7 @@ func TestSessionsRepositoryRedis_Create(t *testing.T) {
 			time.Until(session.Expiry),
 		).SetErr(errors.New("redis error"))
 
-		err = repo.Create(session.SessionID, session)
+		err = repo.Create(context.Background(), session.SessionID, session)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to set session in Redis")
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -79,7 +83,7 @@ func TestSessionsRepositoryRedis_Create(t *testing.T) {
 			Expiry:    time.Now().Add(time.Hour).UTC(),
 		}
 
-		err := repo.Create(session.SessionID, session)
+		err := repo.Create(context.Background(), session.SessionID, session)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to marshal session")
 	})
@@ -101,7 +105,7 @@ func TestSessionsRepositoryRedis_Get(t *testing.T) {
 
 		mock.ExpectGet("session123").SetVal(string(data))
 
-		result, err := repo.Get("session123")
+		result, err := repo.Get(context.Background(), "session123")
 		assert.NoError(t, err)
 		assert.Equal(t, session.UserID, result.UserID)
 		assert.Equal(t, session.SessionID, result.SessionID)
@@ -115,7 +119,7 @@ func TestSessionsRepositoryRedis_Get(t *testing.T) {
 
 		mock.ExpectGet("session123").SetErr(redis.Nil)
 
-		result, err := repo.Get("session123")
+		result, err := repo.Get(context.Background(), "session123")
 		assert.NoError(t, err)
 		assert.Nil(t, result)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -127,7 +131,7 @@ func TestSessionsRepositoryRedis_Get(t *testing.T) {
 
 		mock.ExpectGet("session123").SetErr(errors.New("redis error"))
 
-		result, err := repo.Get("session123")
+		result, err := repo.Get(context.Background(), "session123")
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to get session from Redis")
@@ -140,7 +144,7 @@ func TestSessionsRepositoryRedis_Get(t *testing.T) {
 
 		mock.ExpectGet("session123").SetVal("invalid json")
 
-		result, err := repo.Get("session123")
+		result, err := repo.Get(context.Background(), "session123")
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to unmarshal session")
@@ -162,7 +166,7 @@ func TestSessionsRepositoryRedis_Get(t *testing.T) {
 
 		mock.ExpectGet("session123").SetVal(string(data))
 
-		result, err := repo.Get("session123")
+		result, err := repo.Get(context.Background(), "session123")
 		assert.NoError(t, err)
 		assert.Nil(t, result)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -176,7 +180,7 @@ func TestSessionsRepositoryRedis_Delete(t *testing.T) {
 
 		mock.ExpectDel("session123").SetVal(1)
 
-		err := repo.Delete("session123")
+		err := repo.Delete(context.Background(), "session123")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -187,9 +191,35 @@ func TestSessionsRepositoryRedis_Delete(t *testing.T) {
 
 		mock.ExpectDel("session123").SetErr(errors.New("redis error"))
 
-		err := repo.Delete("session123")
+		err := repo.Delete(context.Background(), "session123")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to delete session from Redis")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestSessionsRepositoryRedis_DeleteByUserID(t *testing.T) {
+	t.Run("successful delete", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewSessionsRepositoryRedis(client)
+
+		mock.ExpectSMembers("user_sessions:1").SetVal([]string{"session1", "session2"})
+		mock.ExpectDel("session1", "session2", "user_sessions:1").SetVal(3)
+
+		err := repo.DeleteByUserID(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		client, mock := redismock.NewClientMock()
+		repo := NewSessionsRepositoryRedis(client)
+
+		mock.ExpectSMembers("user_sessions:1").SetErr(errors.New("redis error"))
+
+		err := repo.DeleteByUserID(context.Background(), 1)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get sessions of user from Redis")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,72 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils/sqlite"
+)
+
+// SessionsRepositorySQLite keeps the sessions in the sessions table of sqlite.Open,
+// so they survive restarts without Redis
+type SessionsRepositorySQLite struct {
+	db *sql.DB
+}
+
+func NewSessionsRepositorySQLite(db *sql.DB) *SessionsRepositorySQLite {
+	return &SessionsRepositorySQLite{db: db}
+}
+
+// Create also replaces the session, e.g. when CSRFMiddleware adds the token.
+// Expired sessions are deleted here, Redis does it by the expiration of the keys.
+func (repo *SessionsRepositorySQLite) Create(ctx context.Context, sessionID string, session *Session) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM sessions WHERE expiry < $1`, sqlite.FormatTime(time.Now().UTC()))
+	if err != nil {
+		return fmt.Errorf("failed to delete expired sessions from SQLite: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `
+		INSERT INTO sessions (session_id, user_id, expiry, csrf_token)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (session_id) DO UPDATE SET user_id = excluded.user_id, expiry = excluded.expiry, csrf_token = excluded.csrf_token
+	`, sessionID, session.UserID, sqlite.FormatTime(session.Expiry.UTC()), session.CSRFToken)
+	if err != nil {
+		return fmt.Errorf("failed to set session in SQLite: %w", err)
+	}
+	return nil
+}
+
+func (repo *SessionsRepositorySQLite) Get(ctx context.Context, sessionID string) (*Session, error) {
+	session := Session{SessionID: sessionID}
+	err := repo.db.QueryRowContext(ctx, `
+		SELECT user_id, expiry, csrf_token FROM sessions WHERE session_id = $1
+	`, sessionID).Scan(&session.UserID, &session.Expiry, &session.CSRFToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get session from SQLite: %w", err)
+	}
+
+	if session.Expiry.Before(time.Now()) {
+		return nil, nil
+	}
+
+	return &session, nil
+}
+
+func (repo *SessionsRepositorySQLite) Delete(ctx context.Context, sessionID string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM sessions WHERE session_id = $1`, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session from SQLite: %w", err)
+	}
+	return nil
+}
+
+func (repo *SessionsRepositorySQLite) DeleteByUserID(ctx context.Context, userID int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions of user from SQLite: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestSessionsRepositorySQLite
+package users
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/internal/utils/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionsRepositorySQLite(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewSessionsRepositorySQLite(db)
+
+	t.Run("create and get session", func(t *testing.T) {
+		sessionID := "session123"
+		session := &Session{
+			SessionID: sessionID,
+			UserID:    1,
+			Expiry:    time.Now().Add(time.Hour).Truncate(time.Microsecond),
+		}
+
+		err := repo.Create(context.Background(), sessionID, session)
+		assert.NoError(t, err)
+
+		got, err := repo.Get(context.Background(), sessionID)
+		assert.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, sessionID, got.SessionID)
+		assert.Equal(t, 1, got.UserID)
+		assert.True(t, session.Expiry.Equal(got.Expiry))
+	})
+
+	t.Run("replace session", func(t *testing.T) {
+		sessionID := "sessionWithCSRF"
+		session := &Session{
+			SessionID: sessionID,
+			UserID:    1,
+			Expiry:    time.Now().Add(time.Hour),
+		}
+		assert.NoError(t, repo.Create(context.Background(), sessionID, session))
+
+		session.CSRFToken = "token"
+		assert.NoError(t, repo.Create(context.Background(), sessionID, session))
+
+		got, err := repo.Get(context.Background(), sessionID)
+		assert.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, "token", got.CSRFToken)
+	})
+
+	t.Run("get expired session", func(t *testing.T) {
+		sessionID := "expiredSession"
+		session := &Session{
+			SessionID: sessionID,
+			UserID:    2,
+			Expiry:    time.Now().Add(-time.Hour), // Expired session
+		}
+
+		err := repo.Create(context.Background(), sessionID, session)
+		assert.NoError(t, err)
+
+		// Check that the expired session is not available
+		got, err := repo.Get(context.Background(), sessionID)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("delete session", func(t *testing.T) {
+		sessionID := "sessionToDelete"
+		session := &Session{
+			SessionID: sessionID,
+			UserID:    3,
+			Expiry:    time.Now().Add(time.Hour),
+		}
+
+		err := repo.Create(context.Background(), sessionID, session)
+		assert.NoError(t, err)
+
+		err = repo.Delete(context.Background(), sessionID)
+		assert.NoError(t, err)
+
+		got, err := repo.Get(context.Background(), sessionID)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("delete non-existent session", func(t *testing.T) {
+		sessionID := "nonExistent"
+		err := repo.Delete(context.Background(), sessionID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("delete sessions of user", func(t *testing.T) {
+		for _, sessionID := range []string{"userSession1", "userSession2"} {
+			assert.NoError(t, repo.Create(context.Background(), sessionID, &Session{SessionID: sessionID, UserID: 10, Expiry: time.Now().Add(time.Hour)}))
+		}
+		assert.NoError(t, repo.Create(context.Background(), "otherUserSession", &Session{SessionID: "otherUserSession", UserID: 11, Expiry: time.Now().Add(time.Hour)}))
+
+		assert.NoError(t, repo.DeleteByUserID(context.Background(), 10))
+
+		for _, sessionID := range []string{"userSession1", "userSession2"} {
+			got, err := repo.Get(context.Background(), sessionID)
+			assert.NoError(t, err)
+			assert.Nil(t, got)
+		}
+		got, err := repo.Get(context.Background(), "otherUserSession")
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+	})
+}
@@ -15,7 +15,7 @@ func (h *UsersHandler) HandleActivation(w http.ResponseWriter, r *http.Request)
 
 	activationHash := r.URL.Query().Get("hash")
 	if activationHash == "" {
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Error",
 			"Message": "Invalid activation link",
 			"User":    user,
@@ -23,9 +23,9 @@ func (h *UsersHandler) HandleActivation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	session, err := h.usersService.ActivateUser(activationHash)
+	session, err := h.usersService.ActivateUser(r.Context(), activationHash)
 	if err != nil {
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Activation Failed",
 			"Message": "Failed to activate the account. The activation link might be expired or invalid.",
 			"User":    user,
@@ -34,7 +34,7 @@ func (h *UsersHandler) HandleActivation(w http.ResponseWriter, r *http.Request)
 	}
 	setSessionCookie(w, session.SessionID, session.Expiry)
 
-	utils.RenderTemplate(w, []string{"activation-success"}, utils.TplData{
+	utils.RenderTemplate(w, "activation-success", utils.TplData{
 		"Title": "Activation Successful - Logged In",
 		"User":  user,
 	})
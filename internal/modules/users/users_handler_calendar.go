@@ -0,0 +1,53 @@
+package users
+
+import (
+	"net/http"
+	"time-tracker/internal/utils"
+)
+
+// "POST /settings/calendar"
+// Enables the calendar feed or replaces its URL if it was shared by mistake.
+func (h *UsersHandler) HandleCalendarTokenRotate(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	err := h.usersService.RotateCalendarToken(r.Context(), user)
+	if err != nil {
+		utils.Logger(r.Context()).Error("HandleCalendarTokenRotate RotateCalendarToken()", "err", err)
+		w.WriteHeader(http.StatusBadGateway)
+		utils.RenderTemplate(w, "error", utils.TplData{
+			"Title":   "Error",
+			"Message": "Error. Please try again later.",
+			"User":    user,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// "POST /settings/calendar/disable"
+func (h *UsersHandler) HandleCalendarFeedDisable(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	err := h.usersService.DisableCalendarFeed(r.Context(), user)
+	if err != nil {
+		utils.Logger(r.Context()).Error("HandleCalendarFeedDisable DisableCalendarFeed()", "err", err)
+		w.WriteHeader(http.StatusBadGateway)
+		utils.RenderTemplate(w, "error", utils.TplData{
+			"Title":   "Error",
+			"Message": "Error. Please try again later.",
+			"User":    user,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
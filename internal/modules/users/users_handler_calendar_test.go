@@ -0,0 +1,96 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestHandleCalendar.*
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCalendarRequest(path string, user *User) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, user))
+	}
+	return req
+}
+
+func TestHandleCalendarTokenRotate(t *testing.T) {
+	SetAppDir()
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		user := &User{ID: 1}
+		mockService.On("RotateCalendarToken", user).Return(nil)
+
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleCalendarTokenRotate(w, newCalendarRequest("/settings/calendar", user))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/settings", w.Header().Get("Location"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		user := &User{ID: 1}
+		mockService.On("RotateCalendarToken", user).Return(errors.New("db error"))
+
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleCalendarTokenRotate(w, newCalendarRequest("/settings/calendar", user))
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		mockService := new(MockUsersService)
+
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleCalendarTokenRotate(w, newCalendarRequest("/settings/calendar", nil))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+		mockService.AssertNotCalled(t, "RotateCalendarToken")
+	})
+}
+
+func TestHandleCalendarFeedDisable(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	user := &User{ID: 1, CalendarToken: "token"}
+	mockService.On("DisableCalendarFeed", user).Return(nil)
+
+	w := httptest.NewRecorder()
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleCalendarFeedDisable(w, newCalendarRequest("/settings/calendar/disable", user))
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/settings", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleSettings_CalendarFeedLink(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	user := &User{ID: 1, Name: "John Doe", TimeZone: "UTC", CalendarToken: "token"}
+	mockService.On("CalendarFeedLink", user).Return("https://example.com/calendar.ics?token=token")
+
+	req := httptest.NewRequest(http.MethodGet, "/settings", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, user))
+	w := httptest.NewRecorder()
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleSettings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `value="https://example.com/calendar.ics?token=token"`)
+	assert.Contains(t, w.Body.String(), `action="/settings/calendar/disable"`)
+}
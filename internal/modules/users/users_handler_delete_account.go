@@ -0,0 +1,82 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+type deleteAccountForm struct {
+	Password string `form:"delete_password" validate:"required" label:"Password"`
+}
+
+// "POST /settings/delete"
+func (h *UsersHandler) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	form := deleteAccountForm{}
+	err := utils.ParseFormToStruct(r, &form)
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	formErrors := utils.NewValidator(&form).Validate()
+	if !formErrors.HasErrors() {
+		err = h.usersService.RequestAccountDeletion(r.Context(), user, form.Password)
+		if errors.Is(err, ErrInvalidPassword) {
+			formErrors.Add("Password", "Invalid password")
+		} else if errors.Is(err, ErrNoPassword) {
+			formErrors.Add("Password", "Set a password in the settings above first")
+		} else if err != nil {
+			utils.Logger(r.Context()).Error("HandleDeleteAccount RequestAccountDeletion()", "err", err)
+			w.WriteHeader(http.StatusBadGateway)
+			utils.RenderTemplate(w, "error", utils.TplData{
+				"Title":   "Error",
+				"Message": "Error. Please try again later.",
+				"User":    user,
+			})
+			return
+		}
+	}
+	if formErrors.HasErrors() {
+		h.renderSettings(w, utils.FormErrors{"DeletePassword": formErrors["Password"]}, settingsFormFromUser(user), user, false)
+		return
+	}
+
+	// RequestAccountDeletion deleted all the sessions of the user, this one included
+	setSessionCookie(w, "", time.Unix(0, 0))
+
+	utils.RenderTemplate(w, "account-deletion-scheduled", utils.TplData{
+		"Title":        "Account Deletion Scheduled",
+		"DeletionDate": user.DeletionDate(),
+	})
+}
+
+// "POST /settings/delete/cancel"
+func (h *UsersHandler) HandleCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	err := h.usersService.CancelAccountDeletion(r.Context(), user)
+	if err != nil {
+		utils.Logger(r.Context()).Error("HandleCancelAccountDeletion CancelAccountDeletion()", "err", err)
+		w.WriteHeader(http.StatusBadGateway)
+		utils.RenderTemplate(w, "error", utils.TplData{
+			"Title":   "Error",
+			"Message": "Error. Please try again later.",
+			"User":    user,
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
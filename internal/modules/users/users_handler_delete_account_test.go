@@ -0,0 +1,167 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestHandle.*AccountDeletion
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newDeleteAccountRequest(t *testing.T, path string, formData url.Values, user *User) *http.Request {
+	req, err := http.NewRequest("POST", path, strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, user))
+	}
+	return req
+}
+
+func TestHandleDeleteAccount_Success(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	user := &User{ID: 1}
+	mockService.On("RequestAccountDeletion", user, "password123").Run(func(args mock.Arguments) {
+		requestedAt := time.Now()
+		args.Get(0).(*User).DeleteRequestedAt = &requestedAt
+	}).Return(nil)
+
+	req := newDeleteAccountRequest(t, "/settings/delete", url.Values{"delete_password": {"password123"}}, user)
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "session-id"})
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleDeleteAccount(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "will be permanently deleted on")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), sessionCookieName+"=;")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleDeleteAccount_InvalidPassword(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
+	user := &User{ID: 1, Name: "John Doe", TimeZone: "UTC"}
+	mockService.On("RequestAccountDeletion", user, "wrong").Return(ErrInvalidPassword)
+
+	req := newDeleteAccountRequest(t, "/settings/delete", url.Values{"delete_password": {"wrong"}}, user)
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleDeleteAccount(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid password")
+	mockService.AssertNotCalled(t, "LogoutUser", mock.Anything)
+}
+
+func TestHandleDeleteAccount_NoPassword(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
+	user := &User{ID: 1, Name: "John Doe", TimeZone: "UTC", NoPassword: true}
+	mockService.On("RequestAccountDeletion", user, "guess").Return(ErrNoPassword)
+
+	req := newDeleteAccountRequest(t, "/settings/delete", url.Values{"delete_password": {"guess"}}, user)
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleDeleteAccount(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Set one in Change Password above")
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+}
+
+func TestHandleDeleteAccount_EmptyPassword(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
+	user := &User{ID: 1, Name: "John Doe", TimeZone: "UTC"}
+
+	req := newDeleteAccountRequest(t, "/settings/delete", url.Values{"delete_password": {""}}, user)
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleDeleteAccount(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertNotCalled(t, "RequestAccountDeletion", mock.Anything, mock.Anything)
+}
+
+func TestHandleDeleteAccount_Error(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	user := &User{ID: 1}
+	mockService.On("RequestAccountDeletion", user, "password123").Return(errors.New("db error"))
+
+	req := newDeleteAccountRequest(t, "/settings/delete", url.Values{"delete_password": {"password123"}}, user)
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleDeleteAccount(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestHandleDeleteAccount_Unauthenticated(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+
+	req := newDeleteAccountRequest(t, "/settings/delete", url.Values{"delete_password": {"password123"}}, nil)
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleDeleteAccount(w, req)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login", w.Header().Get("Location"))
+}
+
+func TestHandleCancelAccountDeletion(t *testing.T) {
+	SetAppDir()
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		user := &User{ID: 1}
+		mockService.On("CancelAccountDeletion", user).Return(nil)
+
+		req := newDeleteAccountRequest(t, "/settings/delete/cancel", url.Values{}, user)
+		w := httptest.NewRecorder()
+
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleCancelAccountDeletion(w, req)
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/settings", w.Header().Get("Location"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		user := &User{ID: 1}
+		mockService.On("CancelAccountDeletion", user).Return(errors.New("db error"))
+
+		req := newDeleteAccountRequest(t, "/settings/delete/cancel", url.Values{}, user)
+		w := httptest.NewRecorder()
+
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleCancelAccountDeletion(w, req)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
@@ -0,0 +1,98 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time-tracker/internal/utils"
+)
+
+type emailChangeForm struct {
+	NewEmail      string `form:"new_email" validate:"required,email,max=100" label:"New Email"`
+	EmailPassword string `form:"email_password" validate:"required" label:"Password"`
+}
+
+// "POST /settings/email"
+func (h *UsersHandler) HandleEmailChange(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	form := emailChangeForm{}
+	err := utils.ParseFormToStruct(r, &form)
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	formErrors := utils.NewValidator(&form).Validate()
+	if !formErrors.HasErrors() {
+		err = h.usersService.RequestEmailChange(r.Context(), user, form.NewEmail, form.EmailPassword)
+		switch {
+		case errors.Is(err, ErrInvalidPassword):
+			formErrors.Add("EmailPassword", "Invalid password")
+		case errors.Is(err, ErrNoPassword):
+			formErrors.Add("EmailPassword", "Set a password in the settings above first")
+		case errors.Is(err, ErrSameEmail):
+			formErrors.Add("NewEmail", "This is your current email")
+		case errors.Is(err, ErrEmailExists):
+			formErrors.Add("NewEmail", "This email is already in use")
+		case err != nil:
+			utils.Logger(r.Context()).Error("HandleEmailChange RequestEmailChange()", "err", err)
+			w.WriteHeader(http.StatusBadGateway)
+			utils.RenderTemplate(w, "error", utils.TplData{
+				"Title":   "Error",
+				"Message": "Error. Please try again later.",
+				"User":    user,
+			})
+			return
+		}
+	}
+	if formErrors.HasErrors() {
+		h.renderSettings(w, formErrors, settingsFormFromUser(user), user, false)
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// http://localhost:8080/confirm-email?hash=95c0f0bfec4376d2192bac0239b3d050ea962de312a3090bf09c60f70e51f95f
+func (h *UsersHandler) HandleConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	sessionUser := GetUserFromRequest(r)
+
+	activationHash := r.URL.Query().Get("hash")
+	if activationHash == "" {
+		utils.RenderTemplate(w, "error", utils.TplData{
+			"Title":   "Error",
+			"Message": "Invalid confirmation link",
+			"User":    sessionUser,
+		})
+		return
+	}
+
+	user, err := h.usersService.ConfirmEmailChange(r.Context(), activationHash)
+	if err != nil {
+		message := "Failed to change the email. The confirmation link might be expired or invalid."
+		if errors.Is(err, ErrEmailExists) {
+			message = "Failed to change the email. This email is already in use."
+		} else if !errors.Is(err, ErrUserNotFoundOrActivationHashIsInvalid) {
+			utils.Logger(r.Context()).Error("HandleConfirmEmail ConfirmEmailChange()", "err", err)
+		}
+		utils.RenderTemplate(w, "error", utils.TplData{
+			"Title":   "Email Change Failed",
+			"Message": message,
+			"User":    sessionUser,
+		})
+		return
+	}
+
+	if sessionUser != nil && sessionUser.ID == user.ID {
+		sessionUser = user
+	}
+	utils.RenderTemplate(w, "email-change-success", utils.TplData{
+		"Title": "Email Changed",
+		"User":  sessionUser,
+		"Email": user.Email,
+	})
+}
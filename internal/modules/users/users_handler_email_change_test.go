@@ -0,0 +1,153 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestHandle.*Email
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newEmailChangeRequest(t *testing.T, newEmail, password string, user *User) *http.Request {
+	formData := url.Values{"new_email": {newEmail}, "email_password": {password}}
+	req, err := http.NewRequest("POST", "/settings/email", strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, user))
+	}
+	return req
+}
+
+func TestHandleEmailChange_Success(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	user := &User{ID: 1, Email: "old@example.com"}
+	mockService.On("RequestEmailChange", user, "new@example.com", "password123").Return(nil)
+
+	w := httptest.NewRecorder()
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleEmailChange(w, newEmailChangeRequest(t, "new@example.com", "password123", user))
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/settings", w.Header().Get("Location"))
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleEmailChange_FormErrors(t *testing.T) {
+	SetAppDir()
+
+	tests := []struct {
+		name       string
+		newEmail   string
+		password   string
+		serviceErr error
+		message    string
+	}{
+		{"InvalidEmail", "not-an-email", "password123", nil, "New Email"},
+		{"EmptyPassword", "new@example.com", "", nil, "Password"},
+		{"InvalidPassword", "new@example.com", "wrong", ErrInvalidPassword, "Invalid password"},
+		{"SameEmail", "old@example.com", "password123", ErrSameEmail, "This is your current email"},
+		{"EmailExists", "taken@example.com", "password123", ErrEmailExists, "This email is already in use"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUsersService)
+			mockService.On("CalendarFeedLink", mock.Anything).Return("")
+			user := &User{ID: 1, Email: "old@example.com", TimeZone: "UTC"}
+			if tt.serviceErr != nil {
+				mockService.On("RequestEmailChange", user, tt.newEmail, tt.password).Return(tt.serviceErr)
+			}
+
+			w := httptest.NewRecorder()
+			handler := &UsersHandler{usersService: mockService}
+			handler.HandleEmailChange(w, newEmailChangeRequest(t, tt.newEmail, tt.password, user))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tt.message)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleEmailChange_Error(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	user := &User{ID: 1, Email: "old@example.com"}
+	mockService.On("RequestEmailChange", user, "new@example.com", "password123").Return(errors.New("db error"))
+
+	w := httptest.NewRecorder()
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleEmailChange(w, newEmailChangeRequest(t, "new@example.com", "password123", user))
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestHandleEmailChange_Unauthenticated(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+
+	w := httptest.NewRecorder()
+	handler := &UsersHandler{usersService: mockService}
+	handler.HandleEmailChange(w, newEmailChangeRequest(t, "new@example.com", "password123", nil))
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login", w.Header().Get("Location"))
+	mockService.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleConfirmEmail(t *testing.T) {
+	SetAppDir()
+
+	t.Run("MissingHash", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleConfirmEmail(w, httptest.NewRequest("GET", "/confirm-email", nil))
+
+		assert.Contains(t, w.Body.String(), "Invalid confirmation link")
+		mockService.AssertNotCalled(t, "ConfirmEmailChange", mock.Anything)
+	})
+
+	t.Run("InvalidHash", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		mockService.On("ConfirmEmailChange", "invalid").Return(nil, ErrUserNotFoundOrActivationHashIsInvalid)
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleConfirmEmail(w, httptest.NewRequest("GET", "/confirm-email?hash=invalid", nil))
+
+		assert.Contains(t, w.Body.String(), "expired or invalid")
+	})
+
+	t.Run("EmailExists", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		mockService.On("ConfirmEmailChange", "hash").Return(nil, ErrEmailExists)
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleConfirmEmail(w, httptest.NewRequest("GET", "/confirm-email?hash=hash", nil))
+
+		assert.Contains(t, w.Body.String(), "already in use")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUsersService)
+		mockService.On("ConfirmEmailChange", "hash").Return(&User{ID: 1, Email: "new@example.com"}, nil)
+		w := httptest.NewRecorder()
+		handler := &UsersHandler{usersService: mockService}
+		handler.HandleConfirmEmail(w, httptest.NewRequest("GET", "/confirm-email?hash=hash", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "new@example.com")
+		mockService.AssertExpectations(t)
+	})
+}
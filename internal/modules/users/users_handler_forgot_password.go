@@ -34,7 +34,7 @@ func (h *UsersHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		timeUntilResend, err = h.usersService.SendLinkToLogin(form.Email)
+		timeUntilResend, err = h.usersService.SendLinkToLogin(r.Context(), form.Email)
 		if err == nil {
 			renderForgotPassword(w, formErrors, form, timeUntilResend, true)
 			return
@@ -43,7 +43,7 @@ func (h *UsersHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Reque
 		if err == ErrUserNotFound {
 			formErrors.Add("Email", "Email not found")
 		} else if err == ErrAccountNotActivated {
-			formErrors.Add("Email", getNotActivatedMessage(form.Email))
+			formErrors.AddHTML("Email", getNotActivatedMessage(form.Email))
 		} else if err == ErrTimeUntilResend {
 			// see ErrorMessage
 		} else {
@@ -60,7 +60,7 @@ func renderForgotPassword(w http.ResponseWriter, formErrors utils.FormErrors, fo
 	if timeUntilResend > 0 {
 		errorMessage = fmt.Sprintf("Wait %d sec.", timeUntilResend)
 	}
-	utils.RenderTemplate(w, []string{"forgot-password"}, utils.TplData{
+	utils.RenderTemplate(w, "forgot-password", utils.TplData{
 		"Title":           "Forgot Password?",
 		"Errors":          formErrors,
 		"Form":            form,
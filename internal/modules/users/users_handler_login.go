@@ -29,12 +29,12 @@ func (h *UsersHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 
 		formErrors = utils.NewValidator(&form).Validate()
 		if formErrors.HasErrors() {
-			renderLogin(w, formErrors, form)
+			h.renderLogin(w, formErrors, form)
 			return
 		}
 
 		var session *Session
-		session, err = h.usersService.LoginUser(form.Email, form.Password)
+		session, err = h.usersService.LoginUser(r.Context(), form.Email, form.Password)
 		if err == nil {
 			setSessionCookie(w, session.SessionID, session.Expiry)
 			utils.RedirectDashboard(w, r)
@@ -44,19 +44,20 @@ func (h *UsersHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		if err == ErrInvalidEmailOrPassword {
 			formErrors.Add("Common", "Invalid email or password")
 		} else if err == ErrAccountNotActivated {
-			formErrors.Add("Common", getNotActivatedMessage(form.Email))
+			formErrors.AddHTML("Common", getNotActivatedMessage(form.Email))
 		} else {
 			formErrors.Add("Common", "Error. Please try again later.")
 		}
 
 	}
 
-	renderLogin(w, formErrors, form)
+	h.renderLogin(w, formErrors, form)
 }
-func renderLogin(w http.ResponseWriter, formErrors utils.FormErrors, form loginForm) {
-	utils.RenderTemplate(w, []string{"login"}, utils.TplData{
-		"Title":  "Log In",
-		"Errors": formErrors,
-		"Form":   form,
+func (h *UsersHandler) renderLogin(w http.ResponseWriter, formErrors utils.FormErrors, form loginForm) {
+	utils.RenderTemplate(w, "login", utils.TplData{
+		"Title":          "Log In",
+		"Errors":         formErrors,
+		"Form":           form,
+		"OAuthProviders": h.oauthProviders,
 	})
 }
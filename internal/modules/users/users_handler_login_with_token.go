@@ -1,6 +1,7 @@
 package users
 
 import (
+	"html/template"
 	"net/http"
 	"time-tracker/internal/utils"
 )
@@ -15,18 +16,18 @@ func (h *UsersHandler) HandleLoginWithToken(w http.ResponseWriter, r *http.Reque
 
 	token := r.URL.Query().Get("token")
 	if token == "" {
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Trouble Logging In?",
-			"Message": "The login link is invalid or expired. <a href=\"/forgot-password\" class=\"text-blue-500 hover:underline\">Please request a new one</a>.",
+			"Message": template.HTML("The login link is invalid or expired. <a href=\"/forgot-password\" class=\"text-blue-500 hover:underline\">Please request a new one</a>."),
 		})
 		return
 	}
 
-	session, err := h.usersService.LoginWithToken(token)
+	session, err := h.usersService.LoginWithToken(r.Context(), token)
 	if err != nil {
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Trouble Logging In?",
-			"Message": "The login link is invalid or expired. <a href=\"/forgot-password\" class=\"text-blue-500 hover:underline\">Please request a new one</a>.",
+			"Message": template.HTML("The login link is invalid or expired. <a href=\"/forgot-password\" class=\"text-blue-500 hover:underline\">Please request a new one</a>."),
 		})
 		return
 	}
@@ -14,9 +14,9 @@ func (h *UsersHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.usersService.LogoutUser(cookie.Value)
+	err = h.usersService.LogoutUser(r.Context(), cookie.Value)
 	if err != nil {
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Logout Failed",
 			"Message": "Failed to log out.",
 			"User":    GetUserFromRequest(r),
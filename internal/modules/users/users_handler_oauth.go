@@ -0,0 +1,117 @@
+package users
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+const oauthStateCookieName = "oauth_state"
+const oauthNonceCookieName = "oauth_nonce"
+
+// GET /oauth/{provider}
+func (h *UsersHandler) HandleOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	provider := h.getOAuthProvider(r.PathValue("provider"))
+	if provider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	state, err := generateRandomToken()
+	if err != nil {
+		h.renderOAuthError(w, r, provider, err)
+		return
+	}
+	nonce, err := generateRandomToken()
+	if err != nil {
+		h.renderOAuthError(w, r, provider, err)
+		return
+	}
+	setOAuthCookie(w, oauthStateCookieName, state, 10*time.Minute)
+	setOAuthCookie(w, oauthNonceCookieName, nonce, 10*time.Minute)
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, nonce), http.StatusSeeOther)
+}
+
+// GET /oauth/{provider}/callback
+func (h *UsersHandler) HandleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider := h.getOAuthProvider(r.PathValue("provider"))
+	if provider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	stateCookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil || stateCookie.Value == "" ||
+		subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(r.URL.Query().Get("state"))) != 1 {
+		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
+		return
+	}
+	nonceCookie, err := r.Cookie(oauthNonceCookieName)
+	if err != nil {
+		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
+		return
+	}
+	setOAuthCookie(w, oauthStateCookieName, "", -time.Second)
+	setOAuthCookie(w, oauthNonceCookieName, "", -time.Second)
+
+	// For example, the user has denied access
+	if errorCode := r.URL.Query().Get("error"); errorCode != "" {
+		utils.Logger(r.Context()).Warn("HandleOAuthCallback provider error", "provider", provider.Name(), "error", errorCode)
+		h.renderLoginError(w, "Log in with "+provider.Title()+" was cancelled.")
+		return
+	}
+
+	userInfo, err := provider.Exchange(r.Context(), r.URL.Query().Get("code"), nonceCookie.Value)
+	if err != nil {
+		h.renderOAuthError(w, r, provider, err)
+		return
+	}
+
+	session, err := h.usersService.LoginWithOAuth(r.Context(), provider.Name(), userInfo)
+	if err == ErrOAuthEmailNotVerified {
+		h.renderLoginError(w, "Your "+provider.Title()+" email is not verified.")
+		return
+	}
+	if err != nil {
+		h.renderOAuthError(w, r, provider, err)
+		return
+	}
+
+	setSessionCookie(w, session.SessionID, session.Expiry)
+	utils.RedirectDashboard(w, r)
+}
+
+func (h *UsersHandler) getOAuthProvider(name string) OAuthProvider {
+	for _, provider := range h.oauthProviders {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+func (h *UsersHandler) renderOAuthError(w http.ResponseWriter, r *http.Request, provider OAuthProvider, err error) {
+	utils.Logger(r.Context()).Error("OAuth login", "provider", provider.Name(), "err", err)
+	h.renderLoginError(w, "Log in with "+provider.Title()+" failed. Please try again later.")
+}
+
+func setOAuthCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		// Lax is required: the callback is a cross-site navigation from the provider
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/oauth/",
+	})
+}
+
+// renderLoginError shows the message above the login form, the message is escaped as a text
+func (h *UsersHandler) renderLoginError(w http.ResponseWriter, message string) {
+	formErrors := utils.FormErrors{}
+	formErrors.Add("Common", message)
+	h.renderLogin(w, formErrors, loginForm{})
+}
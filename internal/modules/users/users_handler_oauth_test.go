@@ -0,0 +1,147 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestHandleOAuth.*
+package users
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/utils/oauth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleOAuthLogin(t *testing.T) {
+	SetAppDir()
+	handler := NewUsersHandlers(new(MockUsersService), new(MockOAuthProvider))
+
+	t.Run("unknown provider", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/oauth/unknown", nil)
+		req.SetPathValue("provider", "unknown")
+		w := httptest.NewRecorder()
+		handler.HandleOAuthLogin(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("redirects to provider with state and nonce cookies", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/oauth/mock", nil)
+		req.SetPathValue("provider", "mock")
+		w := httptest.NewRecorder()
+		handler.HandleOAuthLogin(w, req)
+
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		cookies := map[string]string{}
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie.Value
+		}
+		require.Len(t, cookies[oauthStateCookieName], 64)
+		require.Len(t, cookies[oauthNonceCookieName], 64)
+		assert.Equal(t,
+			"https://provider.example.com/auth?state="+cookies[oauthStateCookieName]+"&nonce="+cookies[oauthNonceCookieName],
+			w.Header().Get("Location"),
+		)
+	})
+}
+
+func TestHandleOAuthCallback(t *testing.T) {
+	SetAppDir()
+	userInfo := &oauth.UserInfo{Subject: "sub", Email: "test@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name         string
+		query        string
+		stateCookie  string
+		setupMocks   func(*MockUsersService, *MockOAuthProvider)
+		expectedCode int
+		expectedPath string
+		expectedBody string
+	}{
+		{
+			name:         "missing state cookie",
+			query:        "?state=state&code=code",
+			setupMocks:   func(s *MockUsersService, p *MockOAuthProvider) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "wrong state",
+			query:        "?state=other&code=code",
+			stateCookie:  "state",
+			setupMocks:   func(s *MockUsersService, p *MockOAuthProvider) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "provider error",
+			query:        "?state=state&error=access_denied",
+			stateCookie:  "state",
+			setupMocks:   func(s *MockUsersService, p *MockOAuthProvider) {},
+			expectedCode: http.StatusOK,
+			expectedBody: "Log in with Mock was cancelled.",
+		},
+		{
+			name:        "exchange error",
+			query:       "?state=state&code=code",
+			stateCookie: "state",
+			setupMocks: func(s *MockUsersService, p *MockOAuthProvider) {
+				p.On("Exchange", "code", "nonce").Return(nil, errors.New("exchange error"))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: "Log in with Mock failed. Please try again later.",
+		},
+		{
+			name:        "email not verified",
+			query:       "?state=state&code=code",
+			stateCookie: "state",
+			setupMocks: func(s *MockUsersService, p *MockOAuthProvider) {
+				p.On("Exchange", "code", "nonce").Return(userInfo, nil)
+				s.On("LoginWithOAuth", "mock", userInfo).Return(nil, ErrOAuthEmailNotVerified)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: "Your Mock email is not verified.",
+		},
+		{
+			name:        "success",
+			query:       "?state=state&code=code",
+			stateCookie: "state",
+			setupMocks: func(s *MockUsersService, p *MockOAuthProvider) {
+				p.On("Exchange", "code", "nonce").Return(userInfo, nil)
+				s.On("LoginWithOAuth", "mock", userInfo).Return(&Session{SessionID: "session", Expiry: time.Now().Add(time.Hour)}, nil)
+			},
+			expectedCode: http.StatusSeeOther,
+			expectedPath: "/dashboard",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockUsersService)
+			provider := new(MockOAuthProvider)
+			tt.setupMocks(service, provider)
+			handler := NewUsersHandlers(service, provider)
+
+			req := httptest.NewRequest(http.MethodGet, "/oauth/mock/callback"+tt.query, nil)
+			req.SetPathValue("provider", "mock")
+			if tt.stateCookie != "" {
+				req.AddCookie(&http.Cookie{Name: oauthStateCookieName, Value: tt.stateCookie})
+				req.AddCookie(&http.Cookie{Name: oauthNonceCookieName, Value: "nonce"})
+			}
+			w := httptest.NewRecorder()
+			handler.HandleOAuthCallback(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedPath != "" {
+				assert.Equal(t, tt.expectedPath, w.Header().Get("Location"))
+				assert.True(t, strings.Contains(w.Header().Values("Set-Cookie")[2], sessionCookieName+"=session"))
+			}
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			service.AssertExpectations(t)
+			provider.AssertExpectations(t)
+		})
+	}
+}
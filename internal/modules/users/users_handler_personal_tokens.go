@@ -0,0 +1,114 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+const maxPersonalTokensPerUser = 10
+
+type personalTokenForm struct {
+	Name string `form:"name" validate:"required,max=100" label:"Name"`
+}
+
+type PersonalTokensHandlers struct {
+	tokensRepo PersonalTokensRepository
+}
+
+func NewPersonalTokensHandlers(tokensRepo PersonalTokensRepository) *PersonalTokensHandlers {
+	return &PersonalTokensHandlers{tokensRepo: tokensRepo}
+}
+
+// GET /settings/tokens
+func (h *PersonalTokensHandlers) HandlePersonalTokens(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+	h.renderPersonalTokens(w, r, user, personalTokenForm{}, utils.FormErrors{}, "")
+}
+
+// POST /settings/tokens
+// The new token is shown once on the rendered page, there is no redirect.
+func (h *PersonalTokensHandlers) HandlePersonalTokensCreate(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	var form personalTokenForm
+	if err := utils.ParseFormToStruct(r, &form); err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	formErrors := utils.NewValidator(&form).Validate()
+	tokens, err := h.tokensRepo.PersonalTokens(r.Context(), user.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	if len(tokens) >= maxPersonalTokensPerUser {
+		formErrors.Add("Common", fmt.Sprintf("You can have up to %d tokens", maxPersonalTokensPerUser))
+	}
+	if formErrors.HasErrors() {
+		h.renderPersonalTokens(w, r, user, form, formErrors, "")
+		return
+	}
+
+	token, tokenHash, err := generatePersonalToken()
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	_, err = h.tokensRepo.CreatePersonalToken(r.Context(), &PersonalToken{
+		UserID:    user.ID,
+		Name:      form.Name,
+		TokenHash: tokenHash,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	h.renderPersonalTokens(w, r, user, personalTokenForm{}, utils.FormErrors{}, token)
+}
+
+// POST /settings/tokens/{id}/delete
+func (h *PersonalTokensHandlers) HandlePersonalTokensDelete(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	if err := h.tokensRepo.DeletePersonalToken(r.Context(), id, user.ID); err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, "/settings/tokens", http.StatusSeeOther)
+}
+
+func (h *PersonalTokensHandlers) renderPersonalTokens(w http.ResponseWriter, r *http.Request, user *User, form personalTokenForm, formErrors utils.FormErrors, newToken string) {
+	tokens, err := h.tokensRepo.PersonalTokens(r.Context(), user.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	utils.RenderTemplate(w, "tokens", utils.TplData{
+		"Title":    "API Tokens",
+		"User":     user,
+		"Form":     form,
+		"Errors":   formErrors,
+		"Tokens":   tokens,
+		"NewToken": newToken,
+	})
+}
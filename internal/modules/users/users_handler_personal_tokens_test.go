@@ -0,0 +1,153 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestHandlePersonalTokens.*
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPersonalTokensRequest(method string, path string, form url.Values, user *User) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, user))
+	}
+	return req
+}
+
+func TestHandlePersonalTokens(t *testing.T) {
+	SetAppDir()
+	ctx := context.Background()
+	user := &User{ID: 1, Name: "User"}
+
+	t.Run("List", func(t *testing.T) {
+		tokensRepo := NewPersonalTokensRepositoryMem()
+		usedAt := time.Date(2024, 12, 2, 10, 30, 0, 0, time.UTC)
+		id, err := tokensRepo.CreatePersonalToken(ctx, &PersonalToken{UserID: user.ID, Name: "Laptop", TokenHash: "hash", CreatedAt: usedAt})
+		require.NoError(t, err)
+		require.NoError(t, tokensRepo.SetPersonalTokenUsed(ctx, id, usedAt))
+		_, err = tokensRepo.CreatePersonalToken(ctx, &PersonalToken{UserID: 2, Name: "Other", TokenHash: "other", CreatedAt: usedAt})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		NewPersonalTokensHandlers(tokensRepo).HandlePersonalTokens(w, newPersonalTokensRequest(http.MethodGet, "/settings/tokens", nil, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "Laptop")
+		assert.Contains(t, body, "last used 2024-12-02 10:30 UTC")
+		assert.Contains(t, body, fmt.Sprintf(`action="/settings/tokens/%d/delete"`, id))
+		assert.NotContains(t, body, "Other")
+		assert.NotContains(t, body, "hash")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewPersonalTokensHandlers(NewPersonalTokensRepositoryMem()).HandlePersonalTokens(w, newPersonalTokensRequest(http.MethodGet, "/settings/tokens", nil, nil))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+}
+
+func TestHandlePersonalTokensCreate(t *testing.T) {
+	SetAppDir()
+	ctx := context.Background()
+	user := &User{ID: 1, Name: "User"}
+
+	t.Run("Success", func(t *testing.T) {
+		tokensRepo := NewPersonalTokensRepositoryMem()
+
+		w := httptest.NewRecorder()
+		NewPersonalTokensHandlers(tokensRepo).HandlePersonalTokensCreate(w, newPersonalTokensRequest(http.MethodPost, "/settings/tokens", url.Values{"name": {"Laptop"}}, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		// Shown once, only the hash is stored
+		token := regexp.MustCompile(`tt_[0-9a-f]{64}`).FindString(w.Body.String())
+		require.NotEmpty(t, token)
+		tokens, err := tokensRepo.PersonalTokens(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Equal(t, "Laptop", tokens[0].Name)
+		assert.Equal(t, hashPersonalToken(token), tokens[0].TokenHash)
+	})
+
+	t.Run("NoName", func(t *testing.T) {
+		tokensRepo := NewPersonalTokensRepositoryMem()
+
+		w := httptest.NewRecorder()
+		NewPersonalTokensHandlers(tokensRepo).HandlePersonalTokensCreate(w, newPersonalTokensRequest(http.MethodPost, "/settings/tokens", url.Values{}, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Name is required")
+		tokens, err := tokensRepo.PersonalTokens(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("TooMany", func(t *testing.T) {
+		tokensRepo := NewPersonalTokensRepositoryMem()
+		for i := 0; i < maxPersonalTokensPerUser; i++ {
+			_, err := tokensRepo.CreatePersonalToken(ctx, &PersonalToken{UserID: user.ID, Name: "Token", TokenHash: fmt.Sprint(i)})
+			require.NoError(t, err)
+		}
+
+		w := httptest.NewRecorder()
+		NewPersonalTokensHandlers(tokensRepo).HandlePersonalTokensCreate(w, newPersonalTokensRequest(http.MethodPost, "/settings/tokens", url.Values{"name": {"Laptop"}}, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "You can have up to 10 tokens")
+	})
+}
+
+func TestHandlePersonalTokensDelete(t *testing.T) {
+	SetAppDir()
+	ctx := context.Background()
+	user := &User{ID: 1, Name: "User"}
+	tokensRepo := NewPersonalTokensRepositoryMem()
+	id, err := tokensRepo.CreatePersonalToken(ctx, &PersonalToken{UserID: user.ID, Name: "Laptop", TokenHash: "hash"})
+	require.NoError(t, err)
+	handlers := NewPersonalTokensHandlers(tokensRepo)
+	deleteRequest := func(id string, user *User) *http.Request {
+		req := newPersonalTokensRequest(http.MethodPost, "/settings/tokens/"+id+"/delete", url.Values{}, user)
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	t.Run("OtherUser", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandlePersonalTokensDelete(w, deleteRequest(fmt.Sprint(id), &User{ID: 2}))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandlePersonalTokensDelete(w, deleteRequest("abc", user))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandlePersonalTokensDelete(w, deleteRequest(fmt.Sprint(id), user))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/settings/tokens", w.Header().Get("Location"))
+		tokens, err := tokensRepo.PersonalTokens(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+}
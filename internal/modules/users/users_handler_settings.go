@@ -1,7 +1,6 @@
 package users
 
 import (
-	"log/slog"
 	"net/http"
 	"time-tracker/internal/utils"
 )
@@ -21,11 +20,7 @@ func (h *UsersHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	form := settingsForm{
-		Name:              user.Name,
-		TimeZone:          user.TimeZone,
-		IsWeekStartMonday: user.IsWeekStartMonday,
-	}
+	form := settingsFormFromUser(user)
 	formErrors := utils.FormErrors{}
 
 	saveOk := false
@@ -38,7 +33,7 @@ func (h *UsersHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
 
 		formErrors = utils.NewValidator(&form).Validate()
 		if formErrors.HasErrors() {
-			renderSettings(w, formErrors, form, user, saveOk)
+			h.renderSettings(w, formErrors, form, user, saveOk)
 			return
 		}
 
@@ -48,21 +43,22 @@ func (h *UsersHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
 		if form.Password != "" {
 			hashedPassword, err := h.usersService.HashPassword(form.Password)
 			if err != nil {
-				slog.Error("HandleSettings hashPassword()", "err", err)
+				utils.Logger(r.Context()).Error("HandleSettings hashPassword()", "err", err)
 				w.WriteHeader(http.StatusBadGateway)
-				utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+				utils.RenderTemplate(w, "error", utils.TplData{
 					"Title":   "Error",
 					"Message": "Error. Please try again later.",
 				})
 				return
 			}
 			user.Password = hashedPassword
+			user.NoPassword = false
 		}
-		err = h.usersService.UserUpdate(user)
+		err = h.usersService.UserUpdate(r.Context(), user)
 		if err != nil {
-			slog.Error("HandleSettings Update()", "err", err)
+			utils.Logger(r.Context()).Error("HandleSettings Update()", "err", err)
 			w.WriteHeader(http.StatusBadGateway)
-			utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+			utils.RenderTemplate(w, "error", utils.TplData{
 				"Title":   "Error",
 				"Message": "Error. Please try again later.",
 			})
@@ -73,15 +69,24 @@ func (h *UsersHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
 		saveOk = true
 	}
 
-	renderSettings(w, formErrors, form, user, saveOk)
+	h.renderSettings(w, formErrors, form, user, saveOk)
+}
+
+func settingsFormFromUser(user *User) settingsForm {
+	return settingsForm{
+		Name:              user.Name,
+		TimeZone:          user.TimeZone,
+		IsWeekStartMonday: user.IsWeekStartMonday,
+	}
 }
 
-func renderSettings(w http.ResponseWriter, formErrors utils.FormErrors, form settingsForm, user *User, saveOk bool) {
-	utils.RenderTemplate(w, []string{"settings"}, utils.TplData{
-		"Title":  "Settings",
-		"User":   user,
-		"Errors": formErrors,
-		"Form":   form,
-		"SaveOk": saveOk,
+func (h *UsersHandler) renderSettings(w http.ResponseWriter, formErrors utils.FormErrors, form settingsForm, user *User, saveOk bool) {
+	utils.RenderTemplate(w, "settings", utils.TplData{
+		"Title":            "Settings",
+		"User":             user,
+		"Errors":           formErrors,
+		"Form":             form,
+		"SaveOk":           saveOk,
+		"CalendarFeedLink": h.usersService.CalendarFeedLink(user),
 	})
 }
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +19,7 @@ import (
 func TestHandleSettings_Success(t *testing.T) {
 	SetAppDir()
 	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
 	mockService.On("UserUpdate", mock.Anything).Return(nil)
 
 	formData := url.Values{
@@ -76,6 +78,7 @@ func TestHandleSettings_Unauthenticated(t *testing.T) {
 func TestHandleSettings_ValidationError(t *testing.T) {
 	SetAppDir()
 	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
 	mockService.On("UserUpdate", mock.Anything).Return(nil)
 
 	formData := url.Values{
@@ -163,6 +166,7 @@ func TestHandleSettings_ParseFormError(t *testing.T) {
 func TestHandleSettings_SuccessfulPasswordHashing(t *testing.T) {
 	SetAppDir()
 	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
 	mockService.On("UserUpdate", mock.Anything).Return(nil)
 	mockService.On("HashPassword", "newpassword").Return("hashed_newpassword", nil)
 
@@ -179,8 +183,10 @@ func TestHandleSettings_SuccessfulPasswordHashing(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Created by OAuth, the password can now confirm the email change and the deletion
+	user := &User{NoPassword: true}
 	ctx := req.Context()
-	ctx = context.WithValue(ctx, ContextUserKey, &User{})
+	ctx = context.WithValue(ctx, ContextUserKey, user)
 	req = req.WithContext(ctx)
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -194,6 +200,9 @@ func TestHandleSettings_SuccessfulPasswordHashing(t *testing.T) {
 	handler.HandleSettings(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hashed_newpassword", user.Password)
+	assert.False(t, user.NoPassword)
+	assert.Contains(t, w.Body.String(), `name="delete_password"`)
 	mockService.AssertExpectations(t)
 }
 
@@ -233,3 +242,53 @@ func TestHandleSettings_PasswordHashingError(t *testing.T) {
 	assert.Equal(t, http.StatusBadGateway, w.Code)
 	mockService.AssertNotCalled(t, "UserUpdate")
 }
+
+func TestHandleSettings_DeleteRequested(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
+
+	req, err := http.NewRequest("GET", "/settings", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, &User{DeleteRequestedAt: &requestedAt}))
+
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{
+		usersService: mockService,
+	}
+
+	handler.HandleSettings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "January 31, 2026")
+	assert.Contains(t, w.Body.String(), "/settings/delete/cancel")
+}
+
+func TestHandleSettings_NoPassword(t *testing.T) {
+	SetAppDir()
+	mockService := new(MockUsersService)
+	mockService.On("CalendarFeedLink", mock.Anything).Return("")
+
+	req, err := http.NewRequest("GET", "/settings", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), ContextUserKey, &User{NoPassword: true}))
+
+	w := httptest.NewRecorder()
+
+	handler := &UsersHandler{
+		usersService: mockService,
+	}
+
+	handler.HandleSettings(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "have no password. Set one in Change Password above to delete the account.")
+	assert.NotContains(t, w.Body.String(), `name="delete_password"`)
+	assert.NotContains(t, w.Body.String(), `name="email_password"`)
+}
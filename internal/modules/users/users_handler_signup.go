@@ -1,7 +1,7 @@
 package users
 
 import (
-	"log/slog"
+	"errors"
 	"net/http"
 	"time-tracker/internal/utils"
 )
@@ -37,7 +37,7 @@ func (h *UsersHandler) HandleSignup(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = h.usersService.RegisterUser(RegisterUserData{
+		err = h.usersService.RegisterUser(r.Context(), RegisterUserData{
 			Name:              form.Name,
 			Email:             form.Email,
 			Password:          form.Password,
@@ -48,12 +48,12 @@ func (h *UsersHandler) HandleSignup(w http.ResponseWriter, r *http.Request) {
 			utils.RedirectSignupSuccess(w, r, form.Email)
 			return
 		} else {
-			if err == ErrEmailExists {
+			if errors.Is(err, ErrEmailExists) {
 				formErrors.Add("Email", "Email is already in use")
-			} else if err == ErrAccountNotActivated {
-				formErrors.Add("Common", getNotActivatedMessage(form.Email))
+			} else if errors.Is(err, ErrAccountNotActivated) {
+				formErrors.AddHTML("Common", getNotActivatedMessage(form.Email))
 			} else {
-				slog.Error("HandleSignup", "err", err)
+				utils.Logger(r.Context()).Error("HandleSignup", "err", err)
 				http.Error(w, "Error. Please try again later.", http.StatusBadGateway)
 				return
 			}
@@ -64,7 +64,7 @@ func (h *UsersHandler) HandleSignup(w http.ResponseWriter, r *http.Request) {
 }
 
 func renderSignup(w http.ResponseWriter, formErrors utils.FormErrors, form signupForm) {
-	utils.RenderTemplate(w, []string{"signup"}, utils.TplData{
+	utils.RenderTemplate(w, "signup", utils.TplData{
 		"Title":  "Sign Up",
 		"Errors": formErrors,
 		"Form":   form,
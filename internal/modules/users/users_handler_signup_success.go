@@ -1,6 +1,7 @@
 package users
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time-tracker/internal/utils"
@@ -18,22 +19,26 @@ func (h *UsersHandler) HandleSignupSuccess(w http.ResponseWriter, r *http.Reques
 	email := r.URL.Query().Get("email")
 	if email == "" {
 		w.WriteHeader(http.StatusNotFound)
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Error",
 			"Message": "Email not found",
 		})
 		return
 	}
 
-	notActiveUser := h.usersService.UserGetByEmail(email)
-	if notActiveUser == nil {
+	notActiveUser, err := h.usersService.UserGetByEmail(r.Context(), email)
+	if errors.Is(err, utils.ErrNotFound) {
 		w.WriteHeader(http.StatusNotFound)
-		utils.RenderTemplate(w, []string{"error"}, utils.TplData{
+		utils.RenderTemplate(w, "error", utils.TplData{
 			"Title":   "Error",
 			"Message": "User not found",
 		})
 		return
 	}
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
 
 	if notActiveUser.IsActive {
 		utils.RedirectLogin(w, r)
@@ -44,14 +49,14 @@ func (h *UsersHandler) HandleSignupSuccess(w http.ResponseWriter, r *http.Reques
 	saveOk := false
 	if r.Method == http.MethodPost {
 		if timeUntilResend := notActiveUser.TimeUntilResend(); timeUntilResend == 0 {
-			h.usersService.ReSendActivationEmail(notActiveUser) // will update TimeUntilResend
+			h.usersService.ReSendActivationEmail(r.Context(), notActiveUser) // will update TimeUntilResend
 			saveOk = true
 		} else {
 			errorMessage = fmt.Sprintf("Wait %d sec.", timeUntilResend)
 		}
 	}
 
-	utils.RenderTemplate(w, []string{"signup-success"}, utils.TplData{
+	utils.RenderTemplate(w, "signup-success", utils.TplData{
 		"Title":           "Sign Up Successful",
 		"Email":           email,
 		"TimeUntilResend": notActiveUser.TimeUntilResend(), // need new TimeUntilResend
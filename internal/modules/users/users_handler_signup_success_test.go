@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+	"time-tracker/internal/utils"
 
 	"github.com/stretchr/testify/require"
 )
@@ -48,7 +49,7 @@ func TestHandleSignupSuccess_UserNotFound(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	mockUsersService := &MockUsersService{}
-	mockUsersService.On("UserGetByEmail", "test@example.com").Return(nil)
+	mockUsersService.On("UserGetByEmail", "test@example.com").Return(nil, utils.ErrNotFound)
 	handler := &UsersHandler{usersService: mockUsersService}
 	handler.HandleSignupSuccess(rr, req)
 
@@ -62,7 +63,7 @@ func TestHandleSignupSuccess_UserIsActive(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	mockUsersService := &MockUsersService{}
-	mockUsersService.On("UserGetByEmail", "test@example.com").Return(&User{IsActive: true})
+	mockUsersService.On("UserGetByEmail", "test@example.com").Return(&User{IsActive: true}, nil)
 	handler := &UsersHandler{usersService: mockUsersService}
 	handler.HandleSignupSuccess(rr, req)
 
@@ -77,7 +78,7 @@ func TestHandleSignupSuccess_ResendConfirmation_Success(t *testing.T) {
 
 	user := &User{ActivationHashDate: time.Now().Add(-61 * time.Second)} // TimeUntilResend = 0
 	mockUsersService := &MockUsersService{}
-	mockUsersService.On("UserGetByEmail", "test@example.com").Return(user)
+	mockUsersService.On("UserGetByEmail", "test@example.com").Return(user, nil)
 	mockUsersService.On("ReSendActivationEmail", user).Return(nil)
 
 	handler := &UsersHandler{usersService: mockUsersService}
@@ -98,7 +99,7 @@ func TestHandleSignupSuccess_ResendConfirmation_Wait(t *testing.T) {
 
 	user := &User{ActivationHashDate: time.Now().Add(-30 * time.Second)} // TimeUntilResend = 30
 	mockUsersService := &MockUsersService{}
-	mockUsersService.On("UserGetByEmail", "test@example.com").Return(user)
+	mockUsersService.On("UserGetByEmail", "test@example.com").Return(user, nil)
 
 	handler := &UsersHandler{usersService: mockUsersService}
 	handler.HandleSignupSuccess(rr, req)
@@ -1,34 +1,46 @@
 package users
 
 import (
+	"context"
 	"fmt"
-	"html"
+	"html/template"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
+	"time-tracker/internal/utils/oauth"
 )
 
 type UsersServiceInterface interface {
-	ActivateUser(activationHash string) (*Session, error)
-	RegisterUser(registerUserData RegisterUserData) error
-	LoginWithToken(token string) (*Session, error)
-	LoginUser(email, password string) (*Session, error)
-	LogoutUser(sessionID string) error
-	SendLinkToLogin(email string) (timeUntilResend int, err error)
-	ReSendActivationEmail(user *User) error
-	UserGetByEmail(email string) *User
-	UserUpdate(user *User) error
+	ActivateUser(ctx context.Context, activationHash string) (*Session, error)
+	RegisterUser(ctx context.Context, registerUserData RegisterUserData) error
+	LoginWithToken(ctx context.Context, token string) (*Session, error)
+	LoginUser(ctx context.Context, email, password string) (*Session, error)
+	LogoutUser(ctx context.Context, sessionID string) error
+	SendLinkToLogin(ctx context.Context, email string) (timeUntilResend int, err error)
+	ReSendActivationEmail(ctx context.Context, user *User) error
+	UserGetByEmail(ctx context.Context, email string) (*User, error)
+	UserUpdate(ctx context.Context, user *User) error
 	HashPassword(password string) (string, error)
+	LoginWithOAuth(ctx context.Context, provider string, userInfo *oauth.UserInfo) (*Session, error)
+	RequestEmailChange(ctx context.Context, user *User, newEmail, password string) error
+	ConfirmEmailChange(ctx context.Context, activationHash string) (*User, error)
+	RequestAccountDeletion(ctx context.Context, user *User, password string) error
+	CancelAccountDeletion(ctx context.Context, user *User) error
+	RotateCalendarToken(ctx context.Context, user *User) error
+	DisableCalendarFeed(ctx context.Context, user *User) error
+	CalendarFeedLink(user *User) string
 }
 
 type UsersHandler struct {
-	usersService UsersServiceInterface
+	usersService   UsersServiceInterface
+	oauthProviders []OAuthProvider
 }
 
-func NewUsersHandlers(usersService UsersServiceInterface) *UsersHandler {
+func NewUsersHandlers(usersService UsersServiceInterface, oauthProviders ...OAuthProvider) *UsersHandler {
 	return &UsersHandler{
-		usersService: usersService,
+		usersService:   usersService,
+		oauthProviders: oauthProviders,
 	}
 }
 
@@ -43,12 +55,13 @@ func setSessionCookie(w http.ResponseWriter, sessionID string, expires time.Time
 	})
 }
 
-func getNotActivatedMessage(email string) string {
-	return fmt.Sprintf(
+// The form error with the link to resend the email, the email is escaped for the URL
+func getNotActivatedMessage(email string) template.HTML {
+	return template.HTML(fmt.Sprintf(
 		`Your account is not activated. Please check your email and follow the activation link. 
 				If you didn’t receive the email, <a href="/signup-success?email=%s">click here to resend it</a>.`,
-		url.QueryEscape(html.EscapeString(email)),
-	)
+		url.QueryEscape(email),
+	))
 }
 
 var D = slog.Debug
@@ -41,7 +41,13 @@ func TestGetNotActivatedMessage(t *testing.T) {
 
 	message := getNotActivatedMessage(email)
 
-	if !strings.Contains(message, expectedSubstring) {
+	if !strings.Contains(string(message), expectedSubstring) {
 		t.Errorf("expected message to contain '%s', but it didn't", expectedSubstring)
 	}
+
+	// The message is trusted HTML, so the email must not break out of the link
+	message = getNotActivatedMessage(`"><script>alert(1)</script>@example.com`)
+	if strings.Contains(string(message), "<script>") || strings.Contains(string(message), `"><`) {
+		t.Errorf("expected the email to be escaped, got %s", message)
+	}
 }
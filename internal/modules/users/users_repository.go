@@ -1,11 +1,16 @@
 package users
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type User struct {
-	ID                 int       `json:"id" db:"id"`
-	Name               string    `json:"name" db:"name"`
-	Email              string    `json:"email" db:"email"`
+	ID    int    `json:"id" db:"id"`
+	Name  string `json:"name" db:"name"`
+	Email string `json:"email" db:"email"`
+	// Email waiting for confirmation. It replaces Email after the user follows the link sent to it.
+	NewEmail           string    `json:"new_email" db:"new_email"`
 	Password           string    `json:"-" db:"password"`
 	TimeZone           string    `json:"timezone" db:"timezone"`
 	IsWeekStartMonday  bool      `json:"is_week_start_monday" db:"is_week_start_monday"`
@@ -13,6 +18,13 @@ type User struct {
 	DateAdd            time.Time `json:"date_add" db:"date_add"`
 	ActivationHash     string    `json:"activation_hash" db:"activation_hash"`
 	ActivationHashDate time.Time `json:"activation_hash_date" db:"activation_hash_date"`
+	// Set when the user asked to delete the account. The account is deleted after AccountDeletionGracePeriod.
+	DeleteRequestedAt *time.Time `json:"delete_requested_at" db:"delete_requested_at"`
+	// Secret of the calendar feed URL, empty if the feed is disabled
+	CalendarToken string `json:"-" db:"calendar_token"`
+	// Set for the users created by OAuth: Password is random until they set one in the settings,
+	// so they cannot confirm the changes of the account with it
+	NoPassword bool `json:"no_password" db:"no_password"`
 }
 
 func (u User) TimeUntilResend() int {
@@ -23,11 +35,40 @@ func (u User) TimeUntilResend() int {
 	return t
 }
 
+func (u User) IsDeleteRequested() bool {
+	return u.DeleteRequestedAt != nil
+}
+
+// The date after which the account will be deleted
+func (u User) DeletionDate() time.Time {
+	if u.DeleteRequestedAt == nil {
+		return time.Time{}
+	}
+	return u.DeleteRequestedAt.Add(AccountDeletionGracePeriod)
+}
+
+// Account of an external OAuth / OpenID Connect provider linked to the user
+type Identity struct {
+	ID       int       `json:"id" db:"id"`
+	UserID   int       `json:"user_id" db:"user_id"`
+	Provider string    `json:"provider" db:"provider"`
+	Subject  string    `json:"subject" db:"subject"`
+	Email    string    `json:"email" db:"email"`
+	DateAdd  time.Time `json:"date_add" db:"date_add"`
+}
+
 type UsersRepository interface {
-	Create(user *User) error
-	GetByID(id int) *User
-	GetByEmail(email string) *User
-	GetByActivationHash(activationHash string) *User
-	Update(user *User) error
-	Delete(id int) error
+	Create(ctx context.Context, user *User) error
+	// The getters return utils.ErrNotFound if there is no user
+	GetByID(ctx context.Context, id int) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByActivationHash(ctx context.Context, activationHash string) (*User, error)
+	// Returns utils.ErrNotFound for an empty token
+	GetByCalendarToken(ctx context.Context, calendarToken string) (*User, error)
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id int) error
+	GetByIdentity(ctx context.Context, provider, subject string) (*User, error)
+	CreateIdentity(ctx context.Context, identity *Identity) error
+	// Deletes users who requested deletion before the date. Tasks, records and identities are deleted by ON DELETE CASCADE.
+	DeleteRequestedBefore(ctx context.Context, date time.Time) (deleted int, err error)
 }
@@ -1,14 +1,22 @@
 package users
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"time"
+	"time-tracker/internal/utils"
 )
 
+var errUserNotFound = fmt.Errorf("user: %w", utils.ErrNotFound)
+
 type UsersRepositoryMem struct {
-	mu     sync.Mutex
-	users  map[int]*User
-	nextID int
+	mu         sync.Mutex
+	users      map[int]*User
+	nextID     int
+	identities []*Identity
 }
 
 func NewUsersRepositoryMem() *UsersRepositoryMem {
@@ -18,69 +26,149 @@ func NewUsersRepositoryMem() *UsersRepositoryMem {
 	}
 }
 
-func (repo *UsersRepositoryMem) Create(user *User) error {
+func (repo *UsersRepositoryMem) Create(_ context.Context, user *User) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
+	for _, existing := range repo.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return ErrEmailExists
+		}
+	}
 	user.ID = repo.nextID
 	repo.users[repo.nextID] = user
 	repo.nextID++
 	return nil
 }
 
-func (repo *UsersRepositoryMem) GetByID(id int) *User {
+func (repo *UsersRepositoryMem) GetByID(_ context.Context, id int) (*User, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	user, exists := repo.users[id]
 	if !exists {
-		return nil
+		return nil, errUserNotFound
 	}
-	return user
+	return user, nil
 }
 
-func (repo *UsersRepositoryMem) GetByEmail(email string) *User {
+func (repo *UsersRepositoryMem) GetByEmail(_ context.Context, email string) (*User, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	for _, user := range repo.users {
-		if user.Email == email {
-			return user
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
 		}
 	}
-	return nil
+	return nil, errUserNotFound
 }
 
-func (repo *UsersRepositoryMem) GetByActivationHash(activationHash string) *User {
+func (repo *UsersRepositoryMem) GetByActivationHash(_ context.Context, activationHash string) (*User, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	for _, user := range repo.users {
 		if user.ActivationHash == activationHash {
-			return user
+			return user, nil
 		}
 	}
-	return nil
+	return nil, errUserNotFound
+}
+
+func (repo *UsersRepositoryMem) GetByCalendarToken(_ context.Context, calendarToken string) (*User, error) {
+	if calendarToken == "" {
+		return nil, errUserNotFound
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, user := range repo.users {
+		if user.CalendarToken == calendarToken {
+			return user, nil
+		}
+	}
+	return nil, errUserNotFound
 }
 
-func (repo *UsersRepositoryMem) Update(user *User) error {
+func (repo *UsersRepositoryMem) Update(_ context.Context, user *User) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	if _, exists := repo.users[user.ID]; !exists {
 		return errors.New("user not found")
 	}
+	for id, existing := range repo.users {
+		if id != user.ID && strings.EqualFold(existing.Email, user.Email) {
+			return ErrEmailExists
+		}
+	}
 	repo.users[user.ID] = user
 	return nil
 }
 
-func (repo *UsersRepositoryMem) Delete(id int) error {
+func (repo *UsersRepositoryMem) Delete(_ context.Context, id int) error {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	if _, exists := repo.users[id]; !exists {
 		return errors.New("user not found")
 	}
+	repo.delete(id)
+	return nil
+}
+
+func (repo *UsersRepositoryMem) delete(id int) {
 	delete(repo.users, id)
+	// ON DELETE CASCADE
+	identities := repo.identities[:0]
+	for _, identity := range repo.identities {
+		if identity.UserID != id {
+			identities = append(identities, identity)
+		}
+	}
+	repo.identities = identities
+}
+
+func (repo *UsersRepositoryMem) GetByIdentity(_ context.Context, provider, subject string) (*User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, identity := range repo.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return repo.users[identity.UserID], nil
+		}
+	}
+	return nil, errUserNotFound
+}
+
+func (repo *UsersRepositoryMem) CreateIdentity(_ context.Context, identity *Identity) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.users[identity.UserID]; !exists {
+		return errors.New("user not found")
+	}
+	for _, existing := range repo.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return errors.New("identity already exists")
+		}
+	}
+	identity.ID = len(repo.identities) + 1
+	repo.identities = append(repo.identities, identity)
 	return nil
 }
+
+func (repo *UsersRepositoryMem) DeleteRequestedBefore(_ context.Context, date time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	deleted := 0
+	for id, user := range repo.users {
+		if user.DeleteRequestedAt != nil && user.DeleteRequestedAt.Before(date) {
+			repo.delete(id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -4,7 +4,10 @@
 package users
 
 import (
+	"context"
 	"testing"
+	"time"
+	"time-tracker/internal/utils"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,68 +16,97 @@ func TestUsersRepositoryMem_Create(t *testing.T) {
 	repo := NewUsersRepositoryMem()
 
 	user := &User{Email: "test@example.com"}
-	err := repo.Create(user)
+	err := repo.Create(context.Background(), user)
 	require.NoError(t, err)
 	require.Equal(t, 1, user.ID)
+
+	err = repo.Create(context.Background(), &User{Email: "Test@Example.com"})
+	require.ErrorIs(t, err, ErrEmailExists)
 }
 
 func TestUsersRepositoryMem_GetByID(t *testing.T) {
 	repo := NewUsersRepositoryMem()
 
 	user := &User{Email: "test@example.com"}
-	_ = repo.Create(user)
+	_ = repo.Create(context.Background(), user)
 
-	found := repo.GetByID(user.ID)
+	found, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
 	require.NotNil(t, found)
 	require.Equal(t, user.Email, found.Email)
 
-	notFound := repo.GetByID(999)
-	require.Nil(t, notFound)
+	_, err = repo.GetByID(context.Background(), 999)
+	require.ErrorIs(t, err, utils.ErrNotFound)
 }
 
 func TestUsersRepositoryMem_GetByEmail(t *testing.T) {
 	repo := NewUsersRepositoryMem()
 
 	user := &User{Email: "test@example.com"}
-	_ = repo.Create(user)
+	_ = repo.Create(context.Background(), user)
 
-	found := repo.GetByEmail(user.Email)
+	found, err := repo.GetByEmail(context.Background(), user.Email)
+	require.NoError(t, err)
 	require.NotNil(t, found)
 	require.Equal(t, user.ID, found.ID)
 
-	notFound := repo.GetByEmail("nonexistent@example.com")
-	require.Nil(t, notFound)
+	found, err = repo.GetByEmail(context.Background(), "Test@Example.com")
+	require.NoError(t, err)
+	require.Equal(t, user, found)
+
+	_, err = repo.GetByEmail(context.Background(), "nonexistent@example.com")
+	require.ErrorIs(t, err, utils.ErrNotFound)
 }
 
 func TestUsersRepositoryMem_GetByActivationHash(t *testing.T) {
 	repo := NewUsersRepositoryMem()
 
 	user := &User{ActivationHash: "hash123"}
-	_ = repo.Create(user)
+	_ = repo.Create(context.Background(), user)
 
-	found := repo.GetByActivationHash(user.ActivationHash)
+	found, err := repo.GetByActivationHash(context.Background(), user.ActivationHash)
+	require.NoError(t, err)
 	require.NotNil(t, found)
 	require.Equal(t, user.ID, found.ID)
 
-	notFound := repo.GetByActivationHash("nonexistent")
-	require.Nil(t, notFound)
+	_, err = repo.GetByActivationHash(context.Background(), "nonexistent")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+}
+
+func TestUsersRepositoryMem_GetByCalendarToken(t *testing.T) {
+	repo := NewUsersRepositoryMem()
+
+	user := &User{CalendarToken: "token123"}
+	_ = repo.Create(context.Background(), user)
+	_ = repo.Create(context.Background(), &User{})
+
+	found, err := repo.GetByCalendarToken(context.Background(), "token123")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	require.Equal(t, user.ID, found.ID)
+
+	_, err = repo.GetByCalendarToken(context.Background(), "")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	_, err = repo.GetByCalendarToken(context.Background(), "nonexistent")
+	require.ErrorIs(t, err, utils.ErrNotFound)
 }
 
 func TestUsersRepositoryMem_Update(t *testing.T) {
 	repo := NewUsersRepositoryMem()
 
 	user := &User{Email: "test@example.com"}
-	_ = repo.Create(user)
+	_ = repo.Create(context.Background(), user)
 
 	user.Email = "updated@example.com"
-	err := repo.Update(user)
+	err := repo.Update(context.Background(), user)
 	require.NoError(t, err)
 
-	updated := repo.GetByID(user.ID)
+	updated, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
 	require.Equal(t, "updated@example.com", updated.Email)
 
 	nonexistentUser := &User{ID: 999}
-	err = repo.Update(nonexistentUser)
+	err = repo.Update(context.Background(), nonexistentUser)
 	require.Error(t, err)
 }
 
@@ -82,14 +114,84 @@ func TestUsersRepositoryMem_Delete(t *testing.T) {
 	repo := NewUsersRepositoryMem()
 
 	user := &User{Email: "test@example.com"}
-	_ = repo.Create(user)
+	_ = repo.Create(context.Background(), user)
 
-	err := repo.Delete(user.ID)
+	err := repo.Delete(context.Background(), user.ID)
 	require.NoError(t, err)
 
-	deleted := repo.GetByID(user.ID)
-	require.Nil(t, deleted)
+	_, err = repo.GetByID(context.Background(), user.ID)
+	require.ErrorIs(t, err, utils.ErrNotFound)
 
-	err = repo.Delete(999)
+	err = repo.Delete(context.Background(), 999)
 	require.Error(t, err)
 }
+
+func TestUsersRepositoryMem_Identity(t *testing.T) {
+	repo := NewUsersRepositoryMem()
+
+	user := &User{Email: "test@example.com"}
+	_ = repo.Create(context.Background(), user)
+
+	_, err := repo.GetByIdentity(context.Background(), "google", "subject")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+
+	err = repo.CreateIdentity(context.Background(), &Identity{UserID: user.ID, Provider: "google", Subject: "subject"})
+	require.NoError(t, err)
+
+	found, err := repo.GetByIdentity(context.Background(), "google", "subject")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	require.Equal(t, user.ID, found.ID)
+	_, err = repo.GetByIdentity(context.Background(), "github", "subject")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+
+	err = repo.CreateIdentity(context.Background(), &Identity{UserID: user.ID, Provider: "google", Subject: "subject"})
+	require.Error(t, err)
+
+	err = repo.CreateIdentity(context.Background(), &Identity{UserID: 999, Provider: "github", Subject: "subject"})
+	require.Error(t, err)
+
+	_ = repo.Delete(context.Background(), user.ID)
+	_, err = repo.GetByIdentity(context.Background(), "google", "subject")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+}
+
+func TestUsersRepositoryMem_DeleteRequestedBefore(t *testing.T) {
+	repo := NewUsersRepositoryMem()
+
+	requestedAt := time.Now().Add(-time.Hour)
+	expired := &User{Email: "expired@example.com", DeleteRequestedAt: &requestedAt}
+	active := &User{Email: "active@example.com"}
+	_ = repo.Create(context.Background(), expired)
+	_ = repo.Create(context.Background(), active)
+	_ = repo.CreateIdentity(context.Background(), &Identity{UserID: expired.ID, Provider: "google", Subject: "subject"})
+
+	deleted, err := repo.DeleteRequestedBefore(context.Background(), time.Now().Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 0, deleted)
+
+	deleted, err = repo.DeleteRequestedBefore(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	_, err = repo.GetByID(context.Background(), expired.ID)
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	_, err = repo.GetByID(context.Background(), active.ID)
+	require.NoError(t, err)
+	_, err = repo.GetByIdentity(context.Background(), "google", "subject")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+}
+
+func TestUsersRepositoryMem_Update_EmailExists(t *testing.T) {
+	repo := NewUsersRepositoryMem()
+
+	user := &User{Email: "user@example.com"}
+	other := &User{Email: "other@example.com"}
+	_ = repo.Create(context.Background(), user)
+	_ = repo.Create(context.Background(), other)
+
+	err := repo.Update(context.Background(), &User{ID: user.ID, Email: "other@example.com"})
+	require.ErrorIs(t, err, ErrEmailExists)
+
+	err = repo.Update(context.Background(), &User{ID: user.ID, Email: "user@example.com", Name: "Renamed"})
+	require.NoError(t, err)
+}
@@ -2,8 +2,9 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"time"
 	"time-tracker/internal/utils"
 
 	"github.com/jackc/pgx/v5"
@@ -17,55 +18,71 @@ type PgxPool interface {
 
 type UsersRepositoryPostgres struct {
 	// db *pgxpool.Pool
-	db PgxPool
+	db           PgxPool
+	queryTimeout time.Duration
 }
 
-func NewUsersRepositoryPostgres(db PgxPool) *UsersRepositoryPostgres {
-	return &UsersRepositoryPostgres{db: db}
+// queryTimeout limits every query, 0 means no limit
+func NewUsersRepositoryPostgres(db PgxPool, queryTimeout time.Duration) *UsersRepositoryPostgres {
+	return &UsersRepositoryPostgres{db: db, queryTimeout: queryTimeout}
 }
 
-func (r *UsersRepositoryPostgres) getByField(fieldName string, fieldValue interface{}) *User {
+const usersSelectFields = "SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users"
+
+func (r *UsersRepositoryPostgres) getByField(ctx context.Context, fieldName string, fieldValue interface{}) (*User, error) {
 	validFields := map[string]bool{
 		"id":              true,
 		"email":           true,
 		"activation_hash": true,
+		"calendar_token":  true,
 	}
 	if !validFields[fieldName] {
-		slog.Error("UsersRepositoryPostgres getByField validFields", "fieldName", fieldName)
-		return nil
+		return nil, fmt.Errorf("UsersRepositoryPostgres getByField %q: %w", fieldName, utils.ErrInvalidInput)
 	}
-	query := "SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE " + fieldName + " = $1"
-	rows, err := r.db.Query(context.Background(), query, fieldValue)
+	query := usersSelectFields + " WHERE " + fieldName + " = $1"
+	return r.getOne(ctx, query, fieldValue)
+}
+
+// The error is utils.ErrNotFound if there is no user
+func (r *UsersRepositoryPostgres) getOne(ctx context.Context, query string, args ...interface{}) (*User, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		slog.Error("UsersRepositoryPostgres getByField Query", "err", err)
-		return nil
+		return nil, fmt.Errorf("UsersRepositoryPostgres getOne Query: %w", err)
 	}
 	defer rows.Close()
 	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[User])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("user: %w", utils.ErrNotFound)
+	}
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil
-		}
-		slog.Error("UsersRepositoryPostgres getByField CollectOneRow", "fieldName", fieldName, "FieldValue", fieldValue, "err", err)
-		// It is not possible to return nil if an error occurs, since nil must ensure that the user does not exist.
-		panic(err)
+		return nil, fmt.Errorf("UsersRepositoryPostgres getOne CollectOneRow: %w", err)
 	}
-	return &user
+	return &user, nil
 }
 
-func (r *UsersRepositoryPostgres) GetByID(id int) *User {
-	return r.getByField("id", id)
+func (r *UsersRepositoryPostgres) GetByID(ctx context.Context, id int) (*User, error) {
+	return r.getByField(ctx, "id", id)
 }
 
-func (r *UsersRepositoryPostgres) GetByEmail(email string) *User {
-	return r.getByField("email", email)
+// Ignores the case, the providers of OAuth and the users may write the same email differently
+func (r *UsersRepositoryPostgres) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.getOne(ctx, usersSelectFields+" WHERE lower(email) = lower($1)", email)
 }
 
-func (r *UsersRepositoryPostgres) GetByActivationHash(activationHash string) *User {
-	return r.getByField("activation_hash", activationHash)
+func (r *UsersRepositoryPostgres) GetByActivationHash(ctx context.Context, activationHash string) (*User, error) {
+	return r.getByField(ctx, "activation_hash", activationHash)
+}
+
+func (r *UsersRepositoryPostgres) GetByCalendarToken(ctx context.Context, calendarToken string) (*User, error) {
+	if calendarToken == "" {
+		return nil, fmt.Errorf("user: %w", utils.ErrNotFound)
+	}
+	return r.getByField(ctx, "calendar_token", calendarToken)
 }
 
-func (r *UsersRepositoryPostgres) Create(user *User) error {
+func (r *UsersRepositoryPostgres) Create(ctx context.Context, user *User) error {
 	fields, placeholders, params := utils.BuildFieldsFromArr(utils.Arr{
 		{"name", user.Name},
 		{"password", user.Password},
@@ -76,17 +93,23 @@ func (r *UsersRepositoryPostgres) Create(user *User) error {
 		{"is_active", user.IsActive},
 		{"timezone", user.TimeZone},
 		{"is_week_start_monday", user.IsWeekStartMonday},
+		{"no_password", user.NoPassword},
 	})
 	query := "INSERT INTO users (" + fields + ") VALUES (" + placeholders + ")"
-	_, err := r.db.Exec(context.Background(), query, params...)
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.Exec(ctx, query, params...)
+	if isEmailUniqueViolation(err) {
+		return fmt.Errorf("failed to insert user: %w", ErrEmailExists)
+	}
 	if err != nil {
-		slog.Error("Failed to insert user", "err", err)
+		utils.Logger(ctx).Error("Failed to insert user", "err", err)
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
 	return nil
 }
 
-func (r *UsersRepositoryPostgres) Update(user *User) error {
+func (r *UsersRepositoryPostgres) Update(ctx context.Context, user *User) error {
 	builder := utils.NewBuilderFieldsValues()
 	set := builder.BuildFromArr(utils.Arr{
 		{"name", user.Name},
@@ -98,24 +121,79 @@ func (r *UsersRepositoryPostgres) Update(user *User) error {
 		{"is_active", user.IsActive},
 		{"timezone", user.TimeZone},
 		{"is_week_start_monday", user.IsWeekStartMonday},
+		{"delete_requested_at", user.DeleteRequestedAt},
+		{"new_email", user.NewEmail},
+		{"calendar_token", user.CalendarToken},
+		{"no_password", user.NoPassword},
 	})
 	where := builder.BuildFromArr(utils.Arr{{"id", user.ID}})
 	query := "UPDATE users SET " + set + " WHERE " + where
-	_, err := r.db.Exec(context.Background(), query, builder.Params()...)
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.Exec(ctx, query, builder.Params()...)
+	if isEmailUniqueViolation(err) {
+		return fmt.Errorf("failed to update user %d: %w", user.ID, ErrEmailExists)
+	}
 	if err != nil {
-		slog.Error("Failed to update user", "id", user.ID, "err", err)
+		utils.Logger(ctx).Error("Failed to update user", "id", user.ID, "err", err)
 		return fmt.Errorf("failed to update user %d: %w", user.ID, err)
 	}
 	return nil
 }
 
-func (r *UsersRepositoryPostgres) Delete(id int) error {
+func (r *UsersRepositoryPostgres) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	_, err := r.db.Exec(context.Background(), query, id)
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.Exec(ctx, query, id)
 	if err != nil {
-		slog.Error("Failed to delete user", "id", id, "err", err)
+		utils.Logger(ctx).Error("Failed to delete user", "id", id, "err", err)
 		return fmt.Errorf("failed to delete user %d: %w", id, err)
 	}
 	return nil
 }
+
+func (r *UsersRepositoryPostgres) GetByIdentity(ctx context.Context, provider, subject string) (*User, error) {
+	query := usersSelectFields + " WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)"
+	return r.getOne(ctx, query, provider, subject)
+}
+
+func (r *UsersRepositoryPostgres) CreateIdentity(ctx context.Context, identity *Identity) error {
+	fields, placeholders, params := utils.BuildFieldsFromArr(utils.Arr{
+		{"user_id", identity.UserID},
+		{"provider", identity.Provider},
+		{"subject", identity.Subject},
+		{"email", identity.Email},
+		{"date_add", identity.DateAdd},
+	})
+	query := "INSERT INTO user_identities (" + fields + ") VALUES (" + placeholders + ")"
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.Exec(ctx, query, params...)
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to insert user identity", "err", err)
+		return fmt.Errorf("failed to insert user identity: %w", err)
+	}
+	return nil
+}
+
+func (r *UsersRepositoryPostgres) DeleteRequestedBefore(ctx context.Context, date time.Time) (int, error) {
+	query := `DELETE FROM users WHERE delete_requested_at < $1`
+
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.Exec(ctx, query, date)
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to delete users requested deletion", "date", date, "err", err)
+		return 0, fmt.Errorf("failed to delete users requested deletion before %s: %w", date, err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// unique_violation of users.email or of its index in lower case
+func isEmailUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		(pgErr.ConstraintName == "users_email_key" || pgErr.ConstraintName == "idx_users_email_lower")
+}
@@ -4,10 +4,13 @@
 package users
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
+	"time-tracker/internal/utils"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/require"
 )
@@ -17,12 +20,13 @@ func TestUsersRepositoryPostgres_GetByID(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
-	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE id = \$1`).
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE id = \$1`).
 		WithArgs(1).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active"}).
-			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "hash123", time.Now(), true))
-	user := repo.GetByID(1)
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "hash123", time.Now(), true, nil, "", "", false))
+	user, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
 	require.NotNil(t, user)
 	require.Equal(t, 1, user.ID)
 	require.Equal(t, "John Doe", user.Name)
@@ -34,12 +38,13 @@ func TestUsersRepositoryPostgres_GetByEmail(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
-	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE email = \$1`).
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE lower\(email\) = lower\(\$1\)`).
 		WithArgs("test@example.com").
-		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active"}).
-			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "hash123", time.Now(), true))
-	user := repo.GetByEmail("test@example.com")
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "hash123", time.Now(), true, nil, "", "", false))
+	user, err := repo.GetByEmail(context.Background(), "test@example.com")
+	require.NoError(t, err)
 	require.NotNil(t, user)
 	require.Equal(t, 1, user.ID)
 	require.Equal(t, "john@example.com", user.Email)
@@ -51,28 +56,50 @@ func TestUsersRepositoryPostgres_GetByActivationHash(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
-	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE activation_hash = \$1`).
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE activation_hash = \$1`).
 		WithArgs("hash123").
-		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active"}).
-			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "hash123", time.Now(), true))
-	user := repo.GetByActivationHash("hash123")
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "hash123", time.Now(), true, nil, "", "", false))
+	user, err := repo.GetByActivationHash(context.Background(), "hash123")
+	require.NoError(t, err)
 	require.NotNil(t, user)
 	require.Equal(t, 1, user.ID)
 	require.Equal(t, "hash123", user.ActivationHash)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUsersRepositoryPostgres_GetByCalendarToken(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE calendar_token = \$1`).
+		WithArgs("token123").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "", time.Now(), true, nil, "", "token123", false))
+	user, err := repo.GetByCalendarToken(context.Background(), "token123")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	require.Equal(t, "token123", user.CalendarToken)
+
+	// No query for the users with the disabled feed
+	_, err = repo.GetByCalendarToken(context.Background(), "")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUsersRepositoryPostgres_Create(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 	mock.ExpectExec(`INSERT INTO users`).
-		WithArgs("John Doe", "hashed_password", "test@example.com", pgxmock.AnyArg(), "hash123", pgxmock.AnyArg(), false, "UTC", true).
+		WithArgs("John Doe", "hashed_password", "test@example.com", pgxmock.AnyArg(), "hash123", pgxmock.AnyArg(), false, "UTC", true, false).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
-	err = repo.Create(&User{
+	err = repo.Create(context.Background(), &User{
 		Name:               "John Doe",
 		Password:           "hashed_password",
 		Email:              "test@example.com",
@@ -92,13 +119,13 @@ func TestUsersRepositoryPostgres_Create_Error(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 
 	mock.ExpectExec(`INSERT INTO users`).
-		WithArgs("John Doe", "hashed_password", "test@example.com", pgxmock.AnyArg(), "hash123", pgxmock.AnyArg(), false, "UTC", true).
+		WithArgs("John Doe", "hashed_password", "test@example.com", pgxmock.AnyArg(), "hash123", pgxmock.AnyArg(), false, "UTC", true, false).
 		WillReturnError(fmt.Errorf("database insert error"))
 
-	err = repo.Create(&User{
+	err = repo.Create(context.Background(), &User{
 		Name:               "John Doe",
 		Password:           "hashed_password",
 		Email:              "test@example.com",
@@ -120,11 +147,11 @@ func TestUsersRepositoryPostgres_Update(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 	mock.ExpectExec(`UPDATE users SET`).
-		WithArgs("Jane Doe", "new_password", "jane@example.com", pgxmock.AnyArg(), "new_hash", pgxmock.AnyArg(), true, "PST", false, 1).
+		WithArgs("Jane Doe", "new_password", "jane@example.com", pgxmock.AnyArg(), "new_hash", pgxmock.AnyArg(), true, "PST", false, pgxmock.AnyArg(), "", "", false, 1).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
-	err = repo.Update(&User{
+	err = repo.Update(context.Background(), &User{
 		ID:                 1,
 		Name:               "Jane Doe",
 		Password:           "new_password",
@@ -145,11 +172,11 @@ func TestUsersRepositoryPostgres_Update_Error(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 	mock.ExpectExec(`UPDATE users SET`).
-		WithArgs("Jane Doe", "new_password", "jane@example.com", pgxmock.AnyArg(), "new_hash", pgxmock.AnyArg(), true, "PST", false, 1).
+		WithArgs("Jane Doe", "new_password", "jane@example.com", pgxmock.AnyArg(), "new_hash", pgxmock.AnyArg(), true, "PST", false, pgxmock.AnyArg(), "", "", false, 1).
 		WillReturnError(fmt.Errorf("database update error"))
-	err = repo.Update(&User{
+	err = repo.Update(context.Background(), &User{
 		ID:                 1,
 		Name:               "Jane Doe",
 		Password:           "new_password",
@@ -167,16 +194,47 @@ func TestUsersRepositoryPostgres_Update_Error(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUsersRepositoryPostgres_Update_EmailExists(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	mock.ExpectExec(`UPDATE users SET`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), "taken@example.com", pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), 1).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+	err = repo.Update(context.Background(), &User{ID: 1, Email: "taken@example.com"})
+
+	require.ErrorIs(t, err, ErrEmailExists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersRepositoryPostgres_Create_EmailExists(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	// The same email in another case, a signup after the check of GetByEmail
+	mock.ExpectExec(`INSERT INTO users`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), "taken@example.com", pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_lower"})
+	err = repo.Create(context.Background(), &User{Email: "taken@example.com"})
+
+	require.ErrorIs(t, err, ErrEmailExists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUsersRepositoryPostgres_Delete(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
 		WithArgs(1).
 		WillReturnResult(pgxmock.NewResult("DELETE", 1))
-	err = repo.Delete(1)
+	err = repo.Delete(context.Background(), 1)
 	require.NoError(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -186,11 +244,11 @@ func TestUsersRepositoryPostgres_Delete_Error(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
 		WithArgs(1).
 		WillReturnError(fmt.Errorf("database deletee error"))
-	err = repo.Delete(1)
+	err = repo.Delete(context.Background(), 1)
 
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "failed to delete user")
@@ -202,12 +260,12 @@ func TestUsersRepositoryPostgres_GetByField_NoRows(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE id = \$1`).
 		WithArgs(1).
 		WillReturnRows(pgxmock.NewRows([]string{"id"}))
-	repo := NewUsersRepositoryPostgres(mock)
-	user := repo.GetByID(1)
-	require.Nil(t, user)
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	_, err = repo.GetByID(context.Background(), 1)
+	require.ErrorIs(t, err, utils.ErrNotFound)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -216,15 +274,16 @@ func TestUsersRepositoryPostgres_GetByField_ErrorCollectingRow(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE id = \$1`).
 		WithArgs(1).
 		// Some fields were transferred, which causes an error in CollectOneRow
 		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "Test User", "test@example.com"))
 
-	repo := NewUsersRepositoryPostgres(mock)
-	require.Panics(t, func() {
-		repo.GetByID(1)
-	})
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	user, err := repo.GetByID(context.Background(), 1)
+	require.Nil(t, user)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, utils.ErrNotFound)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -233,43 +292,141 @@ func TestUsersRepositoryPostgres_getByField(t *testing.T) {
 	require.NoError(t, err)
 	defer mock.Close()
 
-	repo := NewUsersRepositoryPostgres(mock)
+	repo := NewUsersRepositoryPostgres(mock, 0)
 
 	t.Run("Valid field and value", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE email = \$1`).
+		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE email = \$1`).
 			WithArgs("test@example.com").
-			WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active"}).
-				AddRow(1, "John Doe", "password123", "UTC", true, "test@example.com", time.Now(), "hash123", time.Now(), true))
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+				AddRow(1, "John Doe", "password123", "UTC", true, "test@example.com", time.Now(), "hash123", time.Now(), true, nil, "", "", false))
 
-		user := repo.getByField("email", "test@example.com")
+		user, err := repo.getByField(context.Background(), "email", "test@example.com")
+		require.NoError(t, err)
 		require.NotNil(t, user)
 		require.Equal(t, "John Doe", user.Name)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Invalid field name", func(t *testing.T) {
-		user := repo.getByField("invalid_field", "value")
-		require.Nil(t, user)
+		_, err := repo.getByField(context.Background(), "invalid_field", "value")
+		require.ErrorIs(t, err, utils.ErrInvalidInput)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("No rows found", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE email = \$1`).
+		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE email = \$1`).
 			WithArgs("nonexistent@example.com").
 			WillReturnRows(pgxmock.NewRows([]string{"id"}))
 
-		user := repo.getByField("email", "nonexistent@example.com")
-		require.Nil(t, user)
+		_, err := repo.getByField(context.Background(), "email", "nonexistent@example.com")
+		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Query execution error", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active FROM users WHERE email = \$1`).
+		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE email = \$1`).
 			WithArgs("error@example.com").
 			WillReturnError(fmt.Errorf("query failed"))
 
-		user := repo.getByField("email", "error@example.com")
-		require.Nil(t, user)
+		_, err := repo.getByField(context.Background(), "email", "error@example.com")
+		require.ErrorContains(t, err, "query failed")
+		require.NotErrorIs(t, err, utils.ErrNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Timeout while reading the row", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+				AddRow(1, "John Doe", "password123", "UTC", true, "test@example.com", time.Now(), "hash123", time.Now(), true, nil, "", "", false).
+				RowError(0, fmt.Errorf("timeout: %w", context.DeadlineExceeded)))
+
+		// Not "no such user", e.g. PersonalTokenMiddleware must not answer 401 to a valid token
+		_, err := repo.GetByID(context.Background(), 1)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotErrorIs(t, err, utils.ErrNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Other errors while reading the row", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE id = \$1`).
+			WithArgs(1).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+				AddRow(1, "John Doe", "password123", "UTC", true, "test@example.com", time.Now(), "hash123", time.Now(), true, nil, "", "", false).
+				RowError(0, fmt.Errorf("connection reset")))
+
+		_, err := repo.GetByID(context.Background(), 1)
+		require.ErrorContains(t, err, "connection reset")
+		require.NotErrorIs(t, err, utils.ErrNotFound)
+	})
+}
+
+func TestUsersRepositoryPostgres_GetByIdentity(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	mock.ExpectQuery(`SELECT id, name, password, timezone, is_week_start_monday, email, date_add, activation_hash, activation_hash_date, is_active, delete_requested_at, new_email, calendar_token, no_password FROM users WHERE id = \(SELECT user_id FROM user_identities WHERE provider = \$1 AND subject = \$2\)`).
+		WithArgs("google", "subject").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "password", "timezone", "is_week_start_monday", "email", "date_add", "activation_hash", "activation_hash_date", "is_active", "delete_requested_at", "new_email", "calendar_token", "no_password"}).
+			AddRow(1, "John Doe", "password123", "UTC", true, "john@example.com", time.Now(), "", time.Now(), true, nil, "", "", false))
+	user, err := repo.GetByIdentity(context.Background(), "google", "subject")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	require.Equal(t, 1, user.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersRepositoryPostgres_CreateIdentity(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	identity := &Identity{UserID: 1, Provider: "google", Subject: "subject", Email: "john@example.com", DateAdd: time.Now()}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO user_identities`).
+			WithArgs(1, "google", "subject", "john@example.com", pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		require.NoError(t, repo.CreateIdentity(context.Background(), identity))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO user_identities`).
+			WithArgs(1, "google", "subject", "john@example.com", pgxmock.AnyArg()).
+			WillReturnError(fmt.Errorf("duplicate key"))
+		require.Error(t, repo.CreateIdentity(context.Background(), identity))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUsersRepositoryPostgres_DeleteRequestedBefore(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUsersRepositoryPostgres(mock, 0)
+	date := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM users WHERE delete_requested_at < \$1`).
+			WithArgs(date).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2))
+		deleted, err := repo.DeleteRequestedBefore(context.Background(), date)
+		require.NoError(t, err)
+		require.Equal(t, 2, deleted)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM users WHERE delete_requested_at < \$1`).
+			WithArgs(date).
+			WillReturnError(fmt.Errorf("database delete error"))
+		_, err := repo.DeleteRequestedBefore(context.Background(), date)
+		require.Error(t, err)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 }
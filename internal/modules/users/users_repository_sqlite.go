@@ -0,0 +1,191 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+)
+
+type UsersRepositorySQLite struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// db is opened by sqlite.Open, queryTimeout limits every query, 0 means no limit
+func NewUsersRepositorySQLite(db *sql.DB, queryTimeout time.Duration) *UsersRepositorySQLite {
+	return &UsersRepositorySQLite{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *UsersRepositorySQLite) getByField(ctx context.Context, fieldName string, fieldValue interface{}) (*User, error) {
+	validFields := map[string]bool{
+		"id":              true,
+		"email":           true,
+		"activation_hash": true,
+		"calendar_token":  true,
+	}
+	if !validFields[fieldName] {
+		return nil, fmt.Errorf("UsersRepositorySQLite getByField %q: %w", fieldName, utils.ErrInvalidInput)
+	}
+	query := usersSelectFields + " WHERE " + fieldName + " = $1"
+	return r.getOne(ctx, query, fieldValue)
+}
+
+// The error is utils.ErrNotFound if there is no user
+func (r *UsersRepositorySQLite) getOne(ctx context.Context, query string, args ...interface{}) (*User, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+
+	var user User
+	var activationHashDate sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&user.ID, &user.Name, &user.Password, &user.TimeZone, &user.IsWeekStartMonday, &user.Email, &user.DateAdd,
+		&user.ActivationHash, &activationHashDate, &user.IsActive, &user.DeleteRequestedAt, &user.NewEmail, &user.CalendarToken,
+		&user.NoPassword,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user: %w", utils.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("UsersRepositorySQLite getOne Scan: %w", err)
+	}
+	user.ActivationHashDate = activationHashDate.Time
+	return &user, nil
+}
+
+func (r *UsersRepositorySQLite) GetByID(ctx context.Context, id int) (*User, error) {
+	return r.getByField(ctx, "id", id)
+}
+
+// Ignores the case, the providers of OAuth and the users may write the same email differently
+func (r *UsersRepositorySQLite) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return r.getOne(ctx, usersSelectFields+" WHERE email = $1 COLLATE NOCASE", email)
+}
+
+func (r *UsersRepositorySQLite) GetByActivationHash(ctx context.Context, activationHash string) (*User, error) {
+	return r.getByField(ctx, "activation_hash", activationHash)
+}
+
+func (r *UsersRepositorySQLite) GetByCalendarToken(ctx context.Context, calendarToken string) (*User, error) {
+	if calendarToken == "" {
+		return nil, fmt.Errorf("user: %w", utils.ErrNotFound)
+	}
+	return r.getByField(ctx, "calendar_token", calendarToken)
+}
+
+func (r *UsersRepositorySQLite) Create(ctx context.Context, user *User) error {
+	fields, placeholders, params := utils.BuildFieldsFromArr(utils.Arr{
+		{"name", user.Name},
+		{"password", user.Password},
+		{"email", user.Email},
+		{"date_add", sqlite.FormatTime(user.DateAdd)},
+		{"activation_hash", user.ActivationHash},
+		{"activation_hash_date", sqlite.FormatTime(user.ActivationHashDate)},
+		{"is_active", user.IsActive},
+		{"timezone", user.TimeZone},
+		{"is_week_start_monday", user.IsWeekStartMonday},
+		{"no_password", user.NoPassword},
+	})
+	query := "INSERT INTO users (" + fields + ") VALUES (" + placeholders + ")"
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, query, params...)
+	if sqlite.IsConstraintError(err, "users.email") {
+		return fmt.Errorf("failed to insert user: %w", ErrEmailExists)
+	}
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to insert user", "err", err)
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	return nil
+}
+
+func (r *UsersRepositorySQLite) Update(ctx context.Context, user *User) error {
+	builder := utils.NewBuilderFieldsValues()
+	set := builder.BuildFromArr(utils.Arr{
+		{"name", user.Name},
+		{"password", user.Password},
+		{"email", user.Email},
+		{"date_add", sqlite.FormatTime(user.DateAdd)},
+		{"activation_hash", user.ActivationHash},
+		{"activation_hash_date", sqlite.FormatTime(user.ActivationHashDate)},
+		{"is_active", user.IsActive},
+		{"timezone", user.TimeZone},
+		{"is_week_start_monday", user.IsWeekStartMonday},
+		{"delete_requested_at", sqlite.FormatTimePtr(user.DeleteRequestedAt)},
+		{"new_email", user.NewEmail},
+		{"calendar_token", user.CalendarToken},
+		{"no_password", user.NoPassword},
+	})
+	where := builder.BuildFromArr(utils.Arr{{"id", user.ID}})
+	query := "UPDATE users SET " + set + " WHERE " + where
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, query, builder.Params()...)
+	if sqlite.IsConstraintError(err, "users.email") {
+		return fmt.Errorf("failed to update user %d: %w", user.ID, ErrEmailExists)
+	}
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to update user", "id", user.ID, "err", err)
+		return fmt.Errorf("failed to update user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+func (r *UsersRepositorySQLite) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM users WHERE id = $1`
+
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to delete user", "id", id, "err", err)
+		return fmt.Errorf("failed to delete user %d: %w", id, err)
+	}
+	return nil
+}
+
+func (r *UsersRepositorySQLite) GetByIdentity(ctx context.Context, provider, subject string) (*User, error) {
+	query := usersSelectFields + " WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)"
+	return r.getOne(ctx, query, provider, subject)
+}
+
+func (r *UsersRepositorySQLite) CreateIdentity(ctx context.Context, identity *Identity) error {
+	fields, placeholders, params := utils.BuildFieldsFromArr(utils.Arr{
+		{"user_id", identity.UserID},
+		{"provider", identity.Provider},
+		{"subject", identity.Subject},
+		{"email", identity.Email},
+		{"date_add", sqlite.FormatTime(identity.DateAdd)},
+	})
+	query := "INSERT INTO user_identities (" + fields + ") VALUES (" + placeholders + ")"
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, query, params...)
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to insert user identity", "err", err)
+		return fmt.Errorf("failed to insert user identity: %w", err)
+	}
+	return nil
+}
+
+// Tasks, records and identities are deleted by ON DELETE CASCADE, sqlite.Open enables foreign keys
+func (r *UsersRepositorySQLite) DeleteRequestedBefore(ctx context.Context, date time.Time) (int, error) {
+	query := `DELETE FROM users WHERE delete_requested_at < $1`
+
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, query, sqlite.FormatTime(date))
+	if err != nil {
+		utils.Logger(ctx).Error("Failed to delete users requested deletion", "date", date, "err", err)
+		return 0, fmt.Errorf("failed to delete users requested deletion before %s: %w", date, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete users requested deletion before %s: %w", date, err)
+	}
+	return int(deleted), nil
+}
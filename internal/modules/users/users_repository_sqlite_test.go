@@ -0,0 +1,162 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/users --tags=unit -cover -run TestUsersRepositorySQLite.*
+package users
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newUsersRepositorySQLite(t *testing.T) *UsersRepositorySQLite {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewUsersRepositorySQLite(db, time.Second)
+}
+
+func createUserSQLite(t *testing.T, repo *UsersRepositorySQLite, email string) *User {
+	err := repo.Create(context.Background(), &User{
+		Name:               "John Doe",
+		Email:              email,
+		Password:           "$2a$10$abcdefghijklmnopqrstuuGq9K0Q9wY7g6f9c0G5Vb3aF1h2h3h4h",
+		TimeZone:           "Europe/Berlin",
+		IsWeekStartMonday:  true,
+		DateAdd:            time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC),
+		ActivationHash:     "hash-" + email,
+		ActivationHashDate: time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	user, err := repo.GetByEmail(context.Background(), email)
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	return user
+}
+
+func TestUsersRepositorySQLite_Create(t *testing.T) {
+	repo := newUsersRepositorySQLite(t)
+	ctx := context.Background()
+
+	user := createUserSQLite(t, repo, "test@example.com")
+	require.NotZero(t, user.ID)
+	require.Equal(t, "John Doe", user.Name)
+	require.Equal(t, "Europe/Berlin", user.TimeZone)
+	require.True(t, user.IsWeekStartMonday)
+	require.False(t, user.IsActive)
+	require.True(t, time.Date(2024, 12, 2, 9, 0, 0, 0, time.UTC).Equal(user.DateAdd))
+	require.Nil(t, user.DeleteRequestedAt)
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user, found)
+	found, err = repo.GetByActivationHash(ctx, "hash-test@example.com")
+	require.NoError(t, err)
+	require.Equal(t, user, found)
+	_, err = repo.GetByID(ctx, 999)
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	_, err = repo.GetByEmail(ctx, "nonexistent@example.com")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	found, err = repo.GetByEmail(ctx, "Test@Example.com")
+	require.NoError(t, err)
+	require.Equal(t, user, found)
+
+	err = repo.Create(ctx, &User{Name: "Duplicate", Email: "test@example.com", Password: "password"})
+	require.ErrorIs(t, err, ErrEmailExists)
+	err = repo.Create(ctx, &User{Name: "Duplicate", Email: "Test@Example.com", Password: "password"})
+	require.ErrorIs(t, err, ErrEmailExists)
+
+	// E.g. the client has gone, the error is not "no such user"
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = repo.GetByID(cancelledCtx, user.ID)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotErrorIs(t, err, utils.ErrNotFound)
+}
+
+func TestUsersRepositorySQLite_Update(t *testing.T) {
+	repo := newUsersRepositorySQLite(t)
+	ctx := context.Background()
+	user := createUserSQLite(t, repo, "test@example.com")
+	createUserSQLite(t, repo, "other@example.com")
+
+	deleteRequestedAt := time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC)
+	user.Name = "Jane Doe"
+	user.IsActive = true
+	user.NewEmail = "new@example.com"
+	user.DeleteRequestedAt = &deleteRequestedAt
+	user.CalendarToken = "calendar-token"
+	require.NoError(t, repo.Update(ctx, user))
+
+	updated, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Jane Doe", updated.Name)
+	require.True(t, updated.IsActive)
+	require.Equal(t, "new@example.com", updated.NewEmail)
+	require.NotNil(t, updated.DeleteRequestedAt)
+	require.True(t, deleteRequestedAt.Equal(*updated.DeleteRequestedAt))
+	found, err := repo.GetByCalendarToken(ctx, "calendar-token")
+	require.NoError(t, err)
+	require.Equal(t, user.ID, found.ID)
+	// The users with the disabled feed
+	_, err = repo.GetByCalendarToken(ctx, "")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+
+	user.Email = "other@example.com"
+	err = repo.Update(ctx, user)
+	require.ErrorIs(t, err, ErrEmailExists)
+}
+
+func TestUsersRepositorySQLite_Identity(t *testing.T) {
+	repo := newUsersRepositorySQLite(t)
+	ctx := context.Background()
+	user := createUserSQLite(t, repo, "test@example.com")
+
+	identity := &Identity{UserID: user.ID, Provider: "google", Subject: "123", Email: "test@example.com", DateAdd: time.Now()}
+	require.NoError(t, repo.CreateIdentity(ctx, identity))
+	require.Error(t, repo.CreateIdentity(ctx, identity))
+
+	found, err := repo.GetByIdentity(ctx, "google", "123")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	require.Equal(t, user.ID, found.ID)
+	_, err = repo.GetByIdentity(ctx, "github", "123")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+
+	// ON DELETE CASCADE
+	require.NoError(t, repo.Delete(ctx, user.ID))
+	_, err = repo.GetByID(ctx, user.ID)
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	_, err = repo.GetByIdentity(ctx, "google", "123")
+	require.ErrorIs(t, err, utils.ErrNotFound)
+}
+
+func TestUsersRepositorySQLite_DeleteRequestedBefore(t *testing.T) {
+	repo := newUsersRepositorySQLite(t)
+	ctx := context.Background()
+	old := createUserSQLite(t, repo, "old@example.com")
+	recent := createUserSQLite(t, repo, "recent@example.com")
+	kept := createUserSQLite(t, repo, "kept@example.com")
+
+	oldDate := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	old.DeleteRequestedAt = &oldDate
+	require.NoError(t, repo.Update(ctx, old))
+	recentDate := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	recent.DeleteRequestedAt = &recentDate
+	require.NoError(t, repo.Update(ctx, recent))
+
+	deleted, err := repo.DeleteRequestedBefore(ctx, time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	_, err = repo.GetByID(ctx, old.ID)
+	require.ErrorIs(t, err, utils.ErrNotFound)
+	_, err = repo.GetByID(ctx, recent.ID)
+	require.NoError(t, err)
+	_, err = repo.GetByID(ctx, kept.ID)
+	require.NoError(t, err)
+}
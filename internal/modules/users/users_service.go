@@ -1,31 +1,40 @@
 package users
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/oauth"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UsersService struct {
-	usersRepo    UsersRepository
-	sessionsRepo SessionsRepository
-	mailService  MailService
-	siteUrl      string
+	usersRepo          UsersRepository
+	sessionsRepo       SessionsRepository
+	personalTokensRepo PersonalTokensRepository
+	mailService        MailService
+	siteUrl            string
+	// The emails sent in the background, see Wait
+	background sync.WaitGroup
 }
 
-func NewUsersService(usersRepo UsersRepository, sessionsRepo SessionsRepository, mailService MailService, siteUrl string) *UsersService {
+func NewUsersService(usersRepo UsersRepository, sessionsRepo SessionsRepository, personalTokensRepo PersonalTokensRepository, mailService MailService, siteUrl string) *UsersService {
 	return &UsersService{
-		usersRepo:    usersRepo,
-		sessionsRepo: sessionsRepo,
-		mailService:  mailService,
-		siteUrl:      siteUrl,
+		usersRepo:          usersRepo,
+		sessionsRepo:       sessionsRepo,
+		personalTokensRepo: personalTokensRepo,
+		mailService:        mailService,
+		siteUrl:            siteUrl,
 	}
 }
 
@@ -43,16 +52,32 @@ var ErrInvalidEmailOrPassword = errors.New("invalid email or password")
 var ErrUserNotFoundOrActivationHashIsInvalid = errors.New("user not found or activation hash is invalid")
 var ErrUserNotFound = errors.New("user not found")
 var ErrTimeUntilResend = errors.New("please wait before resending")
+var ErrOAuthEmailNotVerified = errors.New("oauth email is not verified")
+var ErrInvalidPassword = errors.New("invalid password")
+var ErrNoPassword = errors.New("the account has no password, set one in the settings")
+var ErrSameEmail = errors.New("new email is the same as the current one")
+
+// Time to change the mind after "delete my account"
+const AccountDeletionGracePeriod = 30 * 24 * time.Hour
 
 var randomBytesReader = rand.Read
 var bcryptGenerateFromPassword = bcrypt.GenerateFromPassword
 
-func (s *UsersService) RegisterUser(registerUserData RegisterUserData) error {
-	existingUser := s.usersRepo.GetByEmail(registerUserData.Email)
-	if existingUser != nil {
-		if !existingUser.IsActive {
-			return ErrAccountNotActivated
-		}
+// The emails are stored in lower case, the unique index of users.email ignores the case
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *UsersService) RegisterUser(ctx context.Context, registerUserData RegisterUserData) error {
+	registerUserData.Email = normalizeEmail(registerUserData.Email)
+	existingUser, err := s.usersRepo.GetByEmail(ctx, registerUserData.Email)
+	switch {
+	case errors.Is(err, utils.ErrNotFound):
+	case err != nil:
+		return err
+	case !existingUser.IsActive:
+		return ErrAccountNotActivated
+	default:
 		return ErrEmailExists
 	}
 
@@ -76,40 +101,54 @@ func (s *UsersService) RegisterUser(registerUserData RegisterUserData) error {
 		ActivationHash:     activationHash,
 		ActivationHashDate: date,
 	}
-	err = s.usersRepo.Create(user)
+	err = s.usersRepo.Create(ctx, user)
 	if err != nil {
 		return err
 	}
-	go func() {
-		err := s.mailService.SendActivationEmail(user.Email, user.Name, s.activationLink(user.ActivationHash))
+	s.goBackground(ctx, func(ctx context.Context) {
+		err := s.mailService.SendActivationEmail(ctx, user.Email, user.Name, s.activationLink(user.ActivationHash))
 		if err != nil {
-			slog.Error("Failed to send activation email.", "err", err)
+			utils.Logger(ctx).Error("Failed to send activation email.", "err", err)
 		}
-	}()
+	})
 	return nil
 }
 
-func (s *UsersService) ActivateUser(activationHash string) (*Session, error) {
-	user := s.usersRepo.GetByActivationHash(activationHash)
-	if user == nil || time.Since(user.ActivationHashDate).Minutes() > 15 {
+func (s *UsersService) ActivateUser(ctx context.Context, activationHash string) (*Session, error) {
+	user, err := s.usersRepo.GetByActivationHash(ctx, activationHash)
+	if errors.Is(err, utils.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The hash of a pending email change was sent to the new address and must not log in
+	if user.NewEmail != "" || time.Since(user.ActivationHashDate).Minutes() > 15 {
 		return nil, ErrUserNotFound
 	}
 
 	user.IsActive = true
 	user.ActivationHash = ""
 
-	err := s.usersRepo.Update(user)
+	err = s.usersRepo.Update(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("could not Update user: %w", err)
 	}
 
-	session, err := s.makeSession(user.ID)
+	session, err := s.makeSession(ctx, user.ID)
 	return session, err
 }
 
-func (s *UsersService) LoginWithToken(token string) (*Session, error) {
-	user := s.usersRepo.GetByActivationHash(token)
-	if user == nil || time.Since(user.ActivationHashDate).Minutes() > 15 {
+func (s *UsersService) LoginWithToken(ctx context.Context, token string) (*Session, error) {
+	user, err := s.usersRepo.GetByActivationHash(ctx, token)
+	if errors.Is(err, utils.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The hash of a pending email change was sent to the new address and must not log in
+	if user.NewEmail != "" || time.Since(user.ActivationHashDate).Minutes() > 15 {
 		return nil, ErrUserNotFound
 	}
 
@@ -119,36 +158,138 @@ func (s *UsersService) LoginWithToken(token string) (*Session, error) {
 
 	user.ActivationHash = ""
 
-	err := s.usersRepo.Update(user)
+	err = s.usersRepo.Update(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("could not Update user: %w", err)
 	}
 
-	session, err := s.makeSession(user.ID)
+	session, err := s.makeSession(ctx, user.ID)
 	return session, err
 }
 
-func (s *UsersService) LoginUser(email, password string) (*Session, error) {
-	user := s.usersRepo.GetByEmail(email)
-	if user == nil || !checkPasswordHash(password, user.Password) {
+func (s *UsersService) LoginUser(ctx context.Context, email, password string) (*Session, error) {
+	user, err := s.usersRepo.GetByEmail(ctx, email)
+	if errors.Is(err, utils.ErrNotFound) {
+		return nil, ErrInvalidEmailOrPassword
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !checkPasswordHash(password, user.Password) {
 		return nil, ErrInvalidEmailOrPassword
 	}
 	if !user.IsActive {
 		return nil, ErrAccountNotActivated
 	}
-	session, err := s.makeSession(user.ID)
+	session, err := s.makeSession(ctx, user.ID)
 	return session, err
 }
 
-func (s *UsersService) LogoutUser(sessionID string) error {
-	return s.sessionsRepo.Delete(sessionID)
+// Logs in the user linked to the provider account.
+// An account that is not linked yet is linked to the user with the same email, or a new user is created.
+// The email must be verified by the provider, it proves the ownership as the activation does.
+func (s *UsersService) LoginWithOAuth(ctx context.Context, provider string, userInfo *oauth.UserInfo) (*Session, error) {
+	user, err := s.usersRepo.GetByIdentity(ctx, provider, userInfo.Subject)
+	if err == nil {
+		return s.makeSession(ctx, user.ID)
+	}
+	if !errors.Is(err, utils.ErrNotFound) {
+		return nil, err
+	}
+
+	email := normalizeEmail(userInfo.Email)
+	if !userInfo.EmailVerified || email == "" {
+		return nil, ErrOAuthEmailNotVerified
+	}
+
+	user, err = s.usersRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, utils.ErrNotFound):
+		hashedPassword, err := s.randomPasswordHash()
+		if err != nil {
+			return nil, err
+		}
+		user = &User{
+			Name:              oauthUserName(userInfo),
+			Email:             email,
+			Password:          hashedPassword,
+			TimeZone:          "UTC",
+			IsWeekStartMonday: true,
+			IsActive:          true,
+			DateAdd:           time.Now().UTC(),
+			NoPassword:        true,
+		}
+		if err := s.usersRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+		// Create does not return the ID of the new user
+		user, err = s.usersRepo.GetByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.IsActive:
+		// Anyone could sign up with the email before its owner, the password of the signup is not trusted
+		hashedPassword, err := s.randomPasswordHash()
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hashedPassword
+		user.NoPassword = true
+		user.IsActive = true
+		user.ActivationHash = ""
+		if err := s.usersRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("could not Update user: %w", err)
+		}
+	}
+
+	err = s.usersRepo.CreateIdentity(ctx, &Identity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  userInfo.Subject,
+		Email:    email,
+		DateAdd:  time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.makeSession(ctx, user.ID)
 }
 
-func (s *UsersService) SendLinkToLogin(email string) (timeUntilResend int, err error) {
-	user := s.usersRepo.GetByEmail(email)
-	if user == nil {
+// The hash of a password unknown to anyone, it can be set in the settings after "login with token"
+func (s *UsersService) randomPasswordHash() (string, error) {
+	randomPassword, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	return s.HashPassword(randomPassword)
+}
+
+func oauthUserName(userInfo *oauth.UserInfo) string {
+	name := strings.TrimSpace(userInfo.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(userInfo.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > 40 {
+		name = string(runes[:40])
+	}
+	return name
+}
+
+func (s *UsersService) LogoutUser(ctx context.Context, sessionID string) error {
+	return s.sessionsRepo.Delete(ctx, sessionID)
+}
+
+func (s *UsersService) SendLinkToLogin(ctx context.Context, email string) (timeUntilResend int, err error) {
+	user, err := s.usersRepo.GetByEmail(ctx, email)
+	if errors.Is(err, utils.ErrNotFound) {
 		return 0, ErrUserNotFound
 	}
+	if err != nil {
+		return 0, err
+	}
 
 	if !user.IsActive {
 		return 0, ErrAccountNotActivated
@@ -165,21 +306,23 @@ func (s *UsersService) SendLinkToLogin(email string) (timeUntilResend int, err e
 	}
 	user.ActivationHash = activationHash
 	user.ActivationHashDate = time.Now().UTC()
-	err = s.usersRepo.Update(user)
+	// The new hash replaces the hash of a pending email change
+	user.NewEmail = ""
+	err = s.usersRepo.Update(ctx, user)
 	if err != nil {
 		return 0, err
 	}
-	go func() {
-		err := s.mailService.SendLoginWithTokenEmail(user.Email, user.Name, s.loginWithTokenLink(user.ActivationHash))
+	s.goBackground(ctx, func(ctx context.Context) {
+		err := s.mailService.SendLoginWithTokenEmail(ctx, user.Email, user.Name, s.loginWithTokenLink(user.ActivationHash))
 		if err != nil {
-			slog.Error("Failed to send activation email.", "err", err)
+			utils.Logger(ctx).Error("Failed to send activation email.", "err", err)
 		}
-	}()
+	})
 
 	return user.TimeUntilResend(), nil
 }
 
-func (s *UsersService) ReSendActivationEmail(user *User) error {
+func (s *UsersService) ReSendActivationEmail(ctx context.Context, user *User) error {
 
 	activationHash, err := generateActivationHash(user.Email)
 	if err != nil {
@@ -187,25 +330,198 @@ func (s *UsersService) ReSendActivationEmail(user *User) error {
 	}
 	user.ActivationHash = activationHash
 	user.ActivationHashDate = time.Now().UTC()
-	err = s.usersRepo.Update(user)
+	err = s.usersRepo.Update(ctx, user)
 	if err != nil {
 		return err
 	}
-	go func() {
-		err := s.mailService.SendActivationEmail(user.Email, user.Name, s.activationLink(user.ActivationHash))
+	s.goBackground(ctx, func(ctx context.Context) {
+		err := s.mailService.SendActivationEmail(ctx, user.Email, user.Name, s.activationLink(user.ActivationHash))
 		if err != nil {
-			slog.Error("Failed to send activation email.", "err", err)
+			utils.Logger(ctx).Error("Failed to send activation email.", "err", err)
 		}
-	}()
+	})
 	return nil
 }
 
-func (s *UsersService) UserGetByEmail(email string) *User {
-	return s.usersRepo.GetByEmail(email)
+func (s *UsersService) UserGetByEmail(ctx context.Context, email string) (*User, error) {
+	return s.usersRepo.GetByEmail(ctx, email)
 }
 
-func (s *UsersService) UserUpdate(user *User) error {
-	return s.usersRepo.Update(user)
+func (s *UsersService) UserUpdate(ctx context.Context, user *User) error {
+	return s.usersRepo.Update(ctx, user)
+}
+
+// Sends the confirmation link to the new email. The email is changed by ConfirmEmailChange.
+// The password is required, the session alone is not enough to take over the account.
+func (s *UsersService) RequestEmailChange(ctx context.Context, user *User, newEmail, password string) error {
+	if user.NoPassword {
+		return ErrNoPassword
+	}
+	if !checkPasswordHash(password, user.Password) {
+		return ErrInvalidPassword
+	}
+	newEmail = normalizeEmail(newEmail)
+	if newEmail == normalizeEmail(user.Email) {
+		return ErrSameEmail
+	}
+	_, err := s.usersRepo.GetByEmail(ctx, newEmail)
+	if err == nil {
+		return ErrEmailExists
+	}
+	if !errors.Is(err, utils.ErrNotFound) {
+		return err
+	}
+
+	activationHash, err := generateActivationHash(newEmail)
+	if err != nil {
+		return err
+	}
+	user.NewEmail = newEmail
+	user.ActivationHash = activationHash
+	user.ActivationHashDate = time.Now().UTC()
+	err = s.usersRepo.Update(ctx, user)
+	if err != nil {
+		return err
+	}
+	s.goBackground(ctx, func(ctx context.Context) {
+		err := s.mailService.SendEmailChangeEmail(ctx, user.NewEmail, user.Name, s.confirmEmailLink(user.ActivationHash))
+		if err != nil {
+			utils.Logger(ctx).Error("Failed to send email change email.", "err", err)
+		}
+	})
+	return nil
+}
+
+// Replaces the email with the confirmed new email and notifies the old address
+func (s *UsersService) ConfirmEmailChange(ctx context.Context, activationHash string) (*User, error) {
+	user, err := s.usersRepo.GetByActivationHash(ctx, activationHash)
+	if errors.Is(err, utils.ErrNotFound) {
+		return nil, ErrUserNotFoundOrActivationHashIsInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.NewEmail == "" || time.Since(user.ActivationHashDate).Minutes() > 15 {
+		return nil, ErrUserNotFoundOrActivationHashIsInvalid
+	}
+	// The email could have been taken after the request.
+	// The users.email unique constraint covers the race between this check and Update.
+	_, err = s.usersRepo.GetByEmail(ctx, user.NewEmail)
+	if err == nil {
+		return nil, ErrEmailExists
+	}
+	if !errors.Is(err, utils.ErrNotFound) {
+		return nil, err
+	}
+
+	oldEmail := user.Email
+	user.Email = user.NewEmail
+	user.NewEmail = ""
+	user.ActivationHash = ""
+	err = s.usersRepo.Update(ctx, user)
+	if err != nil {
+		if errors.Is(err, ErrEmailExists) {
+			return nil, ErrEmailExists
+		}
+		return nil, fmt.Errorf("could not Update user: %w", err)
+	}
+	s.goBackground(ctx, func(ctx context.Context) {
+		err := s.mailService.SendEmailChangedEmail(ctx, oldEmail, user.Name, user.Email)
+		if err != nil {
+			utils.Logger(ctx).Error("Failed to send email changed email.", "err", err)
+		}
+	})
+	return user, nil
+}
+
+// Schedules the account deletion after AccountDeletionGracePeriod.
+// The password is re-confirmed, since the session may have been left open on another device.
+// The user is logged out everywhere: the sessions and the API tokens are deleted.
+// Logging in again within the grace period allows to cancel the deletion.
+func (s *UsersService) RequestAccountDeletion(ctx context.Context, user *User, password string) error {
+	if user.NoPassword {
+		return ErrNoPassword
+	}
+	if !checkPasswordHash(password, user.Password) {
+		return ErrInvalidPassword
+	}
+	date := time.Now().UTC()
+	user.DeleteRequestedAt = &date
+	if err := s.usersRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if err := s.sessionsRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.personalTokensRepo.DeletePersonalTokens(ctx, user.ID)
+}
+
+func (s *UsersService) CancelAccountDeletion(ctx context.Context, user *User) error {
+	user.DeleteRequestedAt = nil
+	return s.usersRepo.Update(ctx, user)
+}
+
+// Enables the calendar feed or replaces its URL, the old URL stops working
+func (s *UsersService) RotateCalendarToken(ctx context.Context, user *User) error {
+	token, err := generateRandomToken()
+	if err != nil {
+		return err
+	}
+	user.CalendarToken = token
+	return s.usersRepo.Update(ctx, user)
+}
+
+func (s *UsersService) DisableCalendarFeed(ctx context.Context, user *User) error {
+	user.CalendarToken = ""
+	return s.usersRepo.Update(ctx, user)
+}
+
+// The URL of the calendar feed for the calendar apps, empty if the feed is disabled
+func (s *UsersService) CalendarFeedLink(user *User) string {
+	if user.CalendarToken == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/calendar.ics?token=%s", s.siteUrl, user.CalendarToken)
+}
+
+// Deletes accounts whose grace period has expired
+func (s *UsersService) DeleteExpiredAccounts(ctx context.Context) (int, error) {
+	return s.usersRepo.DeleteRequestedBefore(ctx, time.Now().UTC().Add(-AccountDeletionGracePeriod))
+}
+
+// goBackground runs f after the response, e.g. sending an email, so that Wait can finish it on shutdown.
+// ctx of f keeps the span and the logger of the request, but is not canceled when the response is sent.
+func (s *UsersService) goBackground(ctx context.Context, f func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		f(ctx)
+	}()
+}
+
+// Wait waits for the emails sent in the background
+func (s *UsersService) Wait() {
+	s.background.Wait()
+}
+
+// Calls DeleteExpiredAccounts every interval until ctx is done
+func (s *UsersService) RunAccountDeletionJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.DeleteExpiredAccounts(ctx)
+		if err != nil {
+			slog.Error("RunAccountDeletionJob DeleteExpiredAccounts", "err", err)
+		} else if deleted > 0 {
+			slog.Info("RunAccountDeletionJob accounts deleted", "deleted", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (s *UsersService) HashPassword(password string) (string, error) {
@@ -216,14 +532,19 @@ func (s *UsersService) HashPassword(password string) (string, error) {
 	return string(hashedBytes), nil
 }
 
-func (s *UsersService) makeSession(userId int) (*Session, error) {
+func (s *UsersService) makeSession(ctx context.Context, userId int) (*Session, error) {
 	sessionID := uuid.New().String()
+	csrfToken, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
 	session := &Session{
-		UserID: userId,
-		Expiry: time.Now().AddDate(1, 0, 0),
+		UserID:    userId,
+		Expiry:    time.Now().AddDate(1, 0, 0),
+		CSRFToken: csrfToken,
 	}
 	session.SessionID = sessionID
-	err := s.sessionsRepo.Create(sessionID, session)
+	err = s.sessionsRepo.Create(ctx, sessionID, session)
 	if err != nil {
 		return nil, fmt.Errorf("could not create session: %w", err)
 	}
@@ -236,12 +557,24 @@ func (s *UsersService) activationLink(hash string) (link string) {
 func (s *UsersService) loginWithTokenLink(token string) (link string) {
 	return fmt.Sprintf("%s/login-with-token?token=%s", s.siteUrl, token)
 }
+func (s *UsersService) confirmEmailLink(hash string) (link string) {
+	return fmt.Sprintf("%s/confirm-email?hash=%s", s.siteUrl, hash)
+}
 
 func checkPasswordHash(password, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
 
+func generateRandomToken() (string, error) {
+	randomBytes := make([]byte, 32)
+	_, err := randomBytesReader(randomBytes)
+	if err != nil {
+		return "", fmt.Errorf("could not generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
+
 func generateActivationHash(email string) (string, error) {
 	randomBytes := make([]byte, 16)
 	_, err := randomBytesReader(randomBytes)
@@ -4,10 +4,14 @@
 package users
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/oauth"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -19,11 +23,11 @@ func TestUsersService_RegisterUser(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	sessionsRepo := new(MockSessionsRepo)
 	mailService := new(MockMailService)
-	service := NewUsersService(usersRepo, sessionsRepo, mailService, "https://example.com")
+	service := NewUsersService(usersRepo, sessionsRepo, nil, mailService, "https://example.com")
 
 	t.Run("EmailExists", func(t *testing.T) {
 		existingUser := &User{Email: email, IsActive: true}
-		usersRepo.On("GetByEmail", email).Return(existingUser).Once()
+		usersRepo.On("GetByEmail", email).Return(existingUser, nil).Once()
 
 		data := RegisterUserData{
 			Name:              "Test User",
@@ -33,14 +37,14 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.ErrorIs(t, err, ErrEmailExists)
 		usersRepo.AssertExpectations(t)
 	})
 
 	t.Run("ErrAccountNotActivated", func(t *testing.T) {
 		existingUser := &User{Email: email, IsActive: false}
-		usersRepo.On("GetByEmail", email).Return(existingUser).Once()
+		usersRepo.On("GetByEmail", email).Return(existingUser, nil).Once()
 
 		data := RegisterUserData{
 			Name:              "Test User",
@@ -50,7 +54,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.ErrorIs(t, err, ErrAccountNotActivated)
 		usersRepo.AssertExpectations(t)
 	})
@@ -59,7 +63,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 		originalReader := RandomBytesReaderMock()
 		defer func() { randomBytesReader = originalReader }()
 
-		usersRepo.On("GetByEmail", email).Return(nil).Once()
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
 
 		data := RegisterUserData{
 			Name:              "Test User",
@@ -69,7 +73,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.Error(t, err)
 		usersRepo.AssertExpectations(t)
 	})
@@ -78,7 +82,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 		originalFunc := BcryptGenerateFromPasswordMock()
 		defer func() { bcryptGenerateFromPassword = originalFunc }()
 
-		usersRepo.On("GetByEmail", email).Return(nil).Once()
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
 
 		data := RegisterUserData{
 			Name:              "Test User",
@@ -88,13 +92,13 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.Error(t, err)
 		usersRepo.AssertExpectations(t)
 	})
 
 	t.Run("CreateError", func(t *testing.T) {
-		usersRepo.On("GetByEmail", email).Return(nil).Once()
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
 		usersRepo.On("Create", mock.Anything).Return(errors.New("create error")).Once()
 
 		data := RegisterUserData{
@@ -105,7 +109,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.Error(t, err)
 		usersRepo.AssertExpectations(t)
 	})
@@ -113,7 +117,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		done := make(chan struct{})
 
-		usersRepo.On("GetByEmail", email).Return(nil).Once()
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
 		usersRepo.On("Create", mock.Anything).Return(nil).Once()
 		mailService.On("SendActivationEmail", email, "Test User", mock.Anything).
 			Return(nil).
@@ -130,7 +134,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.NoError(t, err)
 
 		// Waiting for SendActivationEmail to complete
@@ -144,10 +148,32 @@ func TestUsersService_RegisterUser(t *testing.T) {
 		usersRepo.AssertExpectations(t)
 		mailService.AssertExpectations(t)
 	})
+	t.Run("EmailInLowerCase", func(t *testing.T) {
+		done := make(chan struct{})
+
+		usersRepo.On("GetByEmail", "new@example.com").Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("Create", mock.MatchedBy(func(user *User) bool { return user.Email == "new@example.com" })).Return(nil).Once()
+		mailService.On("SendActivationEmail", "new@example.com", "Test User", mock.Anything).
+			Return(nil).
+			Once().
+			Run(func(args mock.Arguments) {
+				close(done)
+			})
+
+		err := service.RegisterUser(context.Background(), RegisterUserData{Name: "Test User", Email: " New@Example.COM ", Password: "password123"})
+		require.NoError(t, err)
+		select {
+		case <-done:
+		case <-time.After(1 * time.Second):
+			t.Fatal("Test timed out waiting for goroutine to complete")
+		}
+		usersRepo.AssertExpectations(t)
+	})
+
 	t.Run("SuccessButSendActivationEmailError", func(t *testing.T) {
 		done := make(chan struct{})
 
-		usersRepo.On("GetByEmail", email).Return(nil).Once()
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
 		usersRepo.On("Create", mock.Anything).Return(nil).Once()
 		mailService.On("SendActivationEmail", email, "Test User", mock.Anything).
 			Return(errors.New("SendActivationEmail error")).
@@ -164,7 +190,7 @@ func TestUsersService_RegisterUser(t *testing.T) {
 			IsWeekStartMonday: true,
 		}
 
-		err := service.RegisterUser(data)
+		err := service.RegisterUser(context.Background(), data)
 		require.NoError(t, err)
 
 		// Waiting for SendActivationEmail to complete
@@ -178,12 +204,32 @@ func TestUsersService_RegisterUser(t *testing.T) {
 		usersRepo.AssertExpectations(t)
 		mailService.AssertExpectations(t)
 	})
+	// The shutdown waits for the email that is still being sent
+	t.Run("WaitForEmail", func(t *testing.T) {
+		sent := false
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("Create", mock.Anything).Return(nil).Once()
+		mailService.On("SendActivationEmail", email, "Test User", mock.Anything).
+			Return(nil).
+			Once().
+			Run(func(args mock.Arguments) {
+				time.Sleep(50 * time.Millisecond)
+				sent = true
+			})
+
+		err := service.RegisterUser(context.Background(), RegisterUserData{Name: "Test User", Email: email, Password: "password123"})
+		require.NoError(t, err)
+		service.Wait()
+
+		require.True(t, sent)
+		mailService.AssertExpectations(t)
+	})
 }
 
 func TestUsersService_ActivateUser(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	sessionsRepo := new(MockSessionsRepo)
-	service := NewUsersService(usersRepo, sessionsRepo, nil, "https://example.com")
+	service := NewUsersService(usersRepo, sessionsRepo, nil, nil, "https://example.com")
 
 	t.Run("Success", func(t *testing.T) {
 		user := &User{
@@ -192,11 +238,11 @@ func TestUsersService_ActivateUser(t *testing.T) {
 			ActivationHashDate: time.Now().UTC(),
 		}
 
-		usersRepo.On("GetByActivationHash", "validhash").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "validhash").Return(user, nil).Once()
 		usersRepo.On("Update", mock.Anything).Return(nil).Once()
 		sessionsRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
 
-		session, err := service.ActivateUser("validhash")
+		session, err := service.ActivateUser(context.Background(), "validhash")
 		require.NoError(t, err)
 		require.NotNil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -210,9 +256,9 @@ func TestUsersService_ActivateUser(t *testing.T) {
 			ActivationHashDate: time.Now().Add(-16 * time.Minute),
 		}
 
-		usersRepo.On("GetByActivationHash", "expiredhash").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "expiredhash").Return(user, nil).Once()
 
-		session, err := service.ActivateUser("expiredhash")
+		session, err := service.ActivateUser(context.Background(), "expiredhash")
 		require.ErrorIs(t, err, ErrUserNotFound)
 		require.Nil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -225,10 +271,10 @@ func TestUsersService_ActivateUser(t *testing.T) {
 			ActivationHashDate: time.Now().UTC(),
 		}
 
-		usersRepo.On("GetByActivationHash", "validhash").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "validhash").Return(user, nil).Once()
 		usersRepo.On("Update", mock.Anything).Return(errors.New("update error")).Once()
 
-		session, err := service.ActivateUser("validhash")
+		session, err := service.ActivateUser(context.Background(), "validhash")
 		require.Error(t, err)
 		require.Nil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -238,7 +284,7 @@ func TestUsersService_ActivateUser(t *testing.T) {
 func TestUsersService_LoginWithToken(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	sessionsRepo := new(MockSessionsRepo)
-	service := NewUsersService(usersRepo, sessionsRepo, nil, "https://example.com")
+	service := NewUsersService(usersRepo, sessionsRepo, nil, nil, "https://example.com")
 
 	t.Run("Success", func(t *testing.T) {
 		user := &User{
@@ -248,11 +294,11 @@ func TestUsersService_LoginWithToken(t *testing.T) {
 			ActivationHashDate: time.Now().UTC(),
 		}
 
-		usersRepo.On("GetByActivationHash", "validtoken").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "validtoken").Return(user, nil).Once()
 		usersRepo.On("Update", mock.Anything).Return(nil).Once()
 		sessionsRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
 
-		session, err := service.LoginWithToken("validtoken")
+		session, err := service.LoginWithToken(context.Background(), "validtoken")
 		require.NoError(t, err)
 		require.NotNil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -267,9 +313,9 @@ func TestUsersService_LoginWithToken(t *testing.T) {
 			ActivationHashDate: time.Now().UTC(),
 		}
 
-		usersRepo.On("GetByActivationHash", "validtoken").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "validtoken").Return(user, nil).Once()
 
-		session, err := service.LoginWithToken("validtoken")
+		session, err := service.LoginWithToken(context.Background(), "validtoken")
 		require.ErrorIs(t, err, ErrAccountNotActivated)
 		require.Nil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -283,9 +329,9 @@ func TestUsersService_LoginWithToken(t *testing.T) {
 			ActivationHashDate: time.Now().Add(-16 * time.Minute),
 		}
 
-		usersRepo.On("GetByActivationHash", "validtoken").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "validtoken").Return(user, nil).Once()
 
-		session, err := service.LoginWithToken("validtoken")
+		session, err := service.LoginWithToken(context.Background(), "validtoken")
 		require.ErrorIs(t, err, ErrUserNotFound)
 		require.Nil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -299,10 +345,10 @@ func TestUsersService_LoginWithToken(t *testing.T) {
 			ActivationHashDate: time.Now().UTC(),
 		}
 
-		usersRepo.On("GetByActivationHash", "validtoken").Return(user).Once()
+		usersRepo.On("GetByActivationHash", "validtoken").Return(user, nil).Once()
 		usersRepo.On("Update", mock.Anything).Return(errors.New("update error")).Once()
 
-		session, err := service.LoginWithToken("validtoken")
+		session, err := service.LoginWithToken(context.Background(), "validtoken")
 		require.Error(t, err)
 		require.Nil(t, session)
 		usersRepo.AssertExpectations(t)
@@ -312,7 +358,7 @@ func TestUsersService_LoginWithToken(t *testing.T) {
 func TestUsersService_LoginUser(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	sessionsRepo := new(MockSessionsRepo)
-	service := NewUsersService(usersRepo, sessionsRepo, nil, "https://example.com")
+	service := NewUsersService(usersRepo, sessionsRepo, nil, nil, "https://example.com")
 
 	t.Run("Success", func(t *testing.T) {
 		pas := "password123"
@@ -323,10 +369,10 @@ func TestUsersService_LoginUser(t *testing.T) {
 			IsActive: true,
 		}
 
-		usersRepo.On("GetByEmail", email).Return(user).Once()
+		usersRepo.On("GetByEmail", email).Return(user, nil).Once()
 		sessionsRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
 
-		session, err := service.LoginUser(email, pas)
+		session, err := service.LoginUser(context.Background(), email, pas)
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -341,9 +387,9 @@ func TestUsersService_LoginUser(t *testing.T) {
 			IsActive: true,
 		}
 
-		usersRepo.On("GetByEmail", email).Return(user).Once()
+		usersRepo.On("GetByEmail", email).Return(user, nil).Once()
 
-		session, err := service.LoginUser(email, "wrongpassword")
+		session, err := service.LoginUser(context.Background(), email, "wrongpassword")
 		require.ErrorIs(t, err, ErrInvalidEmailOrPassword)
 		require.Nil(t, session)
 
@@ -359,9 +405,9 @@ func TestUsersService_LoginUser(t *testing.T) {
 			IsActive: false,
 		}
 
-		usersRepo.On("GetByEmail", email).Return(user).Once()
+		usersRepo.On("GetByEmail", email).Return(user, nil).Once()
 
-		session, err := service.LoginUser(email, pas)
+		session, err := service.LoginUser(context.Background(), email, pas)
 		require.ErrorIs(t, err, ErrAccountNotActivated)
 		require.Nil(t, session)
 
@@ -373,12 +419,12 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	mailService := new(MockMailService)
 	sessionsRepo := new(MockSessionsRepo)
-	service := NewUsersService(usersRepo, sessionsRepo, mailService, "https://example.com")
+	service := NewUsersService(usersRepo, sessionsRepo, nil, mailService, "https://example.com")
 
 	t.Run("user not found", func(t *testing.T) {
-		usersRepo.On("GetByEmail", "nonexistent@example.com").Return(nil).Once()
+		usersRepo.On("GetByEmail", "nonexistent@example.com").Return(nil, utils.ErrNotFound).Once()
 
-		timeUntilResend, err := service.SendLinkToLogin("nonexistent@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "nonexistent@example.com")
 		require.ErrorIs(t, err, ErrUserNotFound)
 		require.Equal(t, 0, timeUntilResend)
 		usersRepo.AssertExpectations(t)
@@ -389,9 +435,9 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Email:    "inactive@example.com",
 			IsActive: false,
 		}
-		usersRepo.On("GetByEmail", "inactive@example.com").Return(user).Once()
+		usersRepo.On("GetByEmail", "inactive@example.com").Return(user, nil).Once()
 
-		timeUntilResend, err := service.SendLinkToLogin("inactive@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "inactive@example.com")
 		require.ErrorIs(t, err, ErrAccountNotActivated)
 		require.Equal(t, 0, timeUntilResend)
 		usersRepo.AssertExpectations(t)
@@ -403,9 +449,9 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			IsActive:           true,
 			ActivationHashDate: time.Now().Add(-55 * time.Second),
 		}
-		usersRepo.On("GetByEmail", "recent@example.com").Return(user).Once()
+		usersRepo.On("GetByEmail", "recent@example.com").Return(user, nil).Once()
 
-		timeUntilResend, err := service.SendLinkToLogin("recent@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "recent@example.com")
 		require.ErrorIs(t, err, ErrTimeUntilResend)
 		require.Equal(t, 5, timeUntilResend)
 		usersRepo.AssertExpectations(t)
@@ -416,12 +462,12 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Email:    "hashfail@example.com",
 			IsActive: true,
 		}
-		usersRepo.On("GetByEmail", "hashfail@example.com").Return(user).Once()
+		usersRepo.On("GetByEmail", "hashfail@example.com").Return(user, nil).Once()
 
 		originalReader := RandomBytesReaderMock()
 		defer func() { randomBytesReader = originalReader }()
 
-		timeUntilResend, err := service.SendLinkToLogin("hashfail@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "hashfail@example.com")
 		require.Error(t, err)
 		require.Equal(t, 0, timeUntilResend)
 		usersRepo.AssertExpectations(t)
@@ -432,12 +478,12 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Email:    "updatefail@example.com",
 			IsActive: true,
 		}
-		usersRepo.On("GetByEmail", "updatefail@example.com").Return(user).Once()
+		usersRepo.On("GetByEmail", "updatefail@example.com").Return(user, nil).Once()
 		usersRepo.On("Update", mock.MatchedBy(func(user *User) bool {
 			return user.Email == "updatefail@example.com"
 		})).Return(fmt.Errorf("mock update error"))
 
-		timeUntilResend, err := service.SendLinkToLogin("updatefail@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "updatefail@example.com")
 		require.Error(t, err)
 		require.EqualError(t, err, "mock update error")
 		require.Equal(t, 0, timeUntilResend)
@@ -452,7 +498,7 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Name:     "Test User",
 			IsActive: true,
 		}
-		usersRepo.On("GetByEmail", "success@example.com").Return(user).Once()
+		usersRepo.On("GetByEmail", "success@example.com").Return(user, nil).Once()
 		usersRepo.On("Update", mock.MatchedBy(func(user *User) bool {
 			return user.Email == "success@example.com"
 		})).Return(nil)
@@ -462,7 +508,7 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Run(func(args mock.Arguments) {
 				close(done)
 			})
-		timeUntilResend, err := service.SendLinkToLogin("success@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "success@example.com")
 		require.NoError(t, err)
 
 		// Waiting for SendLoginWithTokenEmail to complete
@@ -486,7 +532,7 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Name:     "Test User",
 			IsActive: true,
 		}
-		usersRepo.On("GetByEmail", "success@example.com").Return(user).Once()
+		usersRepo.On("GetByEmail", "success@example.com").Return(user, nil).Once()
 		usersRepo.On("Update", mock.MatchedBy(func(user *User) bool {
 			return user.Email == "success@example.com"
 		})).Return(nil)
@@ -496,7 +542,7 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 			Run(func(args mock.Arguments) {
 				close(done)
 			})
-		timeUntilResend, err := service.SendLinkToLogin("success@example.com")
+		timeUntilResend, err := service.SendLinkToLogin(context.Background(), "success@example.com")
 		require.NoError(t, err)
 
 		// Waiting for SendLoginWithTokenEmail to complete
@@ -515,18 +561,18 @@ func TestUsersService_SendLinkToLogin(t *testing.T) {
 
 func TestUsersService_LogoutUser(t *testing.T) {
 	sessionsRepo := new(MockSessionsRepo)
-	service := NewUsersService(nil, sessionsRepo, nil, "https://example.com")
+	service := NewUsersService(nil, sessionsRepo, nil, nil, "https://example.com")
 
 	t.Run("Success", func(t *testing.T) {
 		sessionsRepo.On("Delete", "session123").Return(nil).Once()
-		err := service.LogoutUser("session123")
+		err := service.LogoutUser(context.Background(), "session123")
 		require.NoError(t, err)
 		sessionsRepo.AssertExpectations(t)
 	})
 
 	t.Run("Failure", func(t *testing.T) {
 		sessionsRepo.On("Delete", "session123").Return(errors.New("delete error")).Once()
-		err := service.LogoutUser("session123")
+		err := service.LogoutUser(context.Background(), "session123")
 		require.Error(t, err)
 		require.EqualError(t, err, "delete error")
 		sessionsRepo.AssertExpectations(t)
@@ -536,7 +582,7 @@ func TestUsersService_LogoutUser(t *testing.T) {
 func TestUsersService_ReSendActivationEmail(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	mailService := new(MockMailService)
-	service := NewUsersService(usersRepo, nil, mailService, "https://example.com")
+	service := NewUsersService(usersRepo, nil, nil, mailService, "https://example.com")
 
 	user := &User{
 		Email:    email,
@@ -547,7 +593,7 @@ func TestUsersService_ReSendActivationEmail(t *testing.T) {
 	t.Run("UpdateError", func(t *testing.T) {
 		usersRepo.On("Update", mock.Anything).Return(errors.New("update error")).Once()
 
-		err := service.ReSendActivationEmail(user)
+		err := service.ReSendActivationEmail(context.Background(), user)
 		require.Error(t, err)
 		require.EqualError(t, err, "update error")
 		usersRepo.AssertExpectations(t)
@@ -557,7 +603,7 @@ func TestUsersService_ReSendActivationEmail(t *testing.T) {
 		originalReader := RandomBytesReaderMock()
 		defer func() { randomBytesReader = originalReader }()
 
-		err := service.ReSendActivationEmail(user)
+		err := service.ReSendActivationEmail(context.Background(), user)
 		require.Error(t, err)
 	})
 
@@ -572,7 +618,7 @@ func TestUsersService_ReSendActivationEmail(t *testing.T) {
 				close(done)
 			})
 
-		err := service.ReSendActivationEmail(user)
+		err := service.ReSendActivationEmail(context.Background(), user)
 		require.NoError(t, err)
 
 		// Waiting for SendActivationEmail to complete
@@ -598,7 +644,7 @@ func TestUsersService_ReSendActivationEmail(t *testing.T) {
 				close(done)
 			})
 
-		err := service.ReSendActivationEmail(user)
+		err := service.ReSendActivationEmail(context.Background(), user)
 		require.NoError(t, err)
 
 		// Waiting for SendActivationEmail to complete
@@ -616,29 +662,30 @@ func TestUsersService_ReSendActivationEmail(t *testing.T) {
 
 func TestUsersService_UserGetByEmail(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
-	service := NewUsersService(usersRepo, nil, nil, "https://example.com")
+	service := NewUsersService(usersRepo, nil, nil, nil, "https://example.com")
 	t.Run("Success", func(t *testing.T) {
 		user := &User{
 			Email: email,
 		}
-		usersRepo.On("GetByEmail", email).Return(user).Once()
-		result := service.UserGetByEmail(email)
+		usersRepo.On("GetByEmail", email).Return(user, nil).Once()
+		result, err := service.UserGetByEmail(context.Background(), email)
+		require.NoError(t, err)
 		require.NotNil(t, result)
 		require.Equal(t, email, result.Email)
 		usersRepo.AssertExpectations(t)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
-		usersRepo.On("GetByEmail", email).Return(nil).Once()
-		result := service.UserGetByEmail(email)
-		require.Nil(t, result)
+		usersRepo.On("GetByEmail", email).Return(nil, utils.ErrNotFound).Once()
+		_, err := service.UserGetByEmail(context.Background(), email)
+		require.ErrorIs(t, err, utils.ErrNotFound)
 		usersRepo.AssertExpectations(t)
 	})
 }
 
 func TestUsersService_UserUpdate(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
-	service := NewUsersService(usersRepo, nil, nil, "https://example.com")
+	service := NewUsersService(usersRepo, nil, nil, nil, "https://example.com")
 	user := &User{
 		ID:    1,
 		Email: email,
@@ -647,7 +694,7 @@ func TestUsersService_UserUpdate(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		usersRepo.On("Update", user).Return(nil).Once()
 
-		err := service.UserUpdate(user)
+		err := service.UserUpdate(context.Background(), user)
 
 		require.NoError(t, err)
 		usersRepo.AssertExpectations(t)
@@ -656,7 +703,7 @@ func TestUsersService_UserUpdate(t *testing.T) {
 	t.Run("Failure", func(t *testing.T) {
 		usersRepo.On("Update", user).Return(errors.New("update error")).Once()
 
-		err := service.UserUpdate(user)
+		err := service.UserUpdate(context.Background(), user)
 
 		require.Error(t, err)
 		require.EqualError(t, err, "update error")
@@ -665,7 +712,7 @@ func TestUsersService_UserUpdate(t *testing.T) {
 }
 
 func TestUsersService_LoginWithTokenLink(t *testing.T) {
-	service := NewUsersService(nil, nil, nil, "https://example.com")
+	service := NewUsersService(nil, nil, nil, nil, "https://example.com")
 
 	t.Run("Success", func(t *testing.T) {
 		token := "validToken123"
@@ -753,15 +800,16 @@ func TestUsersService_HashPassword(t *testing.T) {
 func TestUsersService_MakeSession(t *testing.T) {
 	usersRepo := new(MockUsersRepo)
 	sessionsRepo := new(MockSessionsRepo)
-	service := NewUsersService(usersRepo, sessionsRepo, nil, "https://example.com")
+	service := NewUsersService(usersRepo, sessionsRepo, nil, nil, "https://example.com")
 
 	t.Run("Success", func(t *testing.T) {
 		sessionsRepo.On("Create", mock.AnythingOfType("string"), mock.AnythingOfType("*users.Session")).Return(nil).Once()
 
-		session, err := service.makeSession(1)
+		session, err := service.makeSession(context.Background(), 1)
 		require.NoError(t, err)
 		require.NotNil(t, session)
 		require.Equal(t, 1, session.UserID)
+		require.Len(t, session.CSRFToken, 64)
 
 		sessionsRepo.AssertExpectations(t)
 	})
@@ -769,11 +817,415 @@ func TestUsersService_MakeSession(t *testing.T) {
 	t.Run("CreateError", func(t *testing.T) {
 		sessionsRepo.On("Create", mock.AnythingOfType("string"), mock.AnythingOfType("*users.Session")).Return(fmt.Errorf("mock create session error")).Once()
 
-		session, err := service.makeSession(1)
+		session, err := service.makeSession(context.Background(), 1)
 		require.Error(t, err)
 		require.Nil(t, session)
 		require.EqualError(t, err, "could not create session: mock create session error")
 
 		sessionsRepo.AssertExpectations(t)
 	})
+
+	t.Run("GenerateCSRFTokenError", func(t *testing.T) {
+		originalReader := RandomBytesReaderMock()
+		defer func() { randomBytesReader = originalReader }()
+
+		session, err := service.makeSession(context.Background(), 1)
+		require.Error(t, err)
+		require.Nil(t, session)
+	})
+}
+
+func TestUsersService_LoginWithOAuth(t *testing.T) {
+	newService := func() (*UsersService, *UsersRepositoryMem) {
+		usersRepo := NewUsersRepositoryMem()
+		return NewUsersService(usersRepo, NewSessionsRepositoryMem(), nil, nil, "https://example.com"), usersRepo
+	}
+
+	t.Run("new user is created and linked", func(t *testing.T) {
+		service, usersRepo := newService()
+		userInfo := &oauth.UserInfo{Subject: "sub", Email: "new@example.com", EmailVerified: true}
+
+		session, err := service.LoginWithOAuth(context.Background(), "google", userInfo)
+		require.NoError(t, err)
+
+		user, err := usersRepo.GetByEmail(context.Background(), "new@example.com")
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.Equal(t, session.UserID, user.ID)
+		require.Equal(t, "new", user.Name)
+		require.True(t, user.IsActive)
+		require.Len(t, user.Password, 60)
+		require.True(t, user.NoPassword)
+		found, err := usersRepo.GetByIdentity(context.Background(), "google", "sub")
+		require.NoError(t, err)
+		require.Equal(t, user, found)
+	})
+
+	t.Run("existing user is linked by email and activated", func(t *testing.T) {
+		service, usersRepo := newService()
+		user := &User{Email: "old@example.com", IsActive: false, ActivationHash: "hash"}
+		_ = usersRepo.Create(context.Background(), user)
+		userInfo := &oauth.UserInfo{Subject: "sub", Email: "old@example.com", EmailVerified: true}
+
+		session, err := service.LoginWithOAuth(context.Background(), "github", userInfo)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, session.UserID)
+		require.True(t, user.IsActive)
+		require.Empty(t, user.ActivationHash)
+		found, err := usersRepo.GetByIdentity(context.Background(), "github", "sub")
+		require.NoError(t, err)
+		require.Equal(t, user, found)
+	})
+
+	t.Run("password of the signup is replaced on activation", func(t *testing.T) {
+		service, usersRepo := newService()
+		// Someone else signed up with the email and knows the password
+		hashedPassword, err := service.HashPassword("attacker-password")
+		require.NoError(t, err)
+		user := &User{Email: "victim@example.com", Password: hashedPassword, IsActive: false, ActivationHash: "hash"}
+		_ = usersRepo.Create(context.Background(), user)
+		userInfo := &oauth.UserInfo{Subject: "sub", Email: "Victim@Example.com", EmailVerified: true}
+
+		session, err := service.LoginWithOAuth(context.Background(), "google", userInfo)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, session.UserID)
+		require.True(t, user.IsActive)
+		require.Len(t, user.Password, 60)
+		require.True(t, user.NoPassword)
+		require.False(t, checkPasswordHash("attacker-password", user.Password))
+		_, err = service.LoginUser(context.Background(), "victim@example.com", "attacker-password")
+		require.ErrorIs(t, err, ErrInvalidEmailOrPassword)
+	})
+
+	t.Run("active user keeps the password", func(t *testing.T) {
+		service, usersRepo := newService()
+		hashedPassword, err := service.HashPassword("password123")
+		require.NoError(t, err)
+		user := &User{Email: "Old@Example.com", Password: hashedPassword, IsActive: true}
+		_ = usersRepo.Create(context.Background(), user)
+
+		session, err := service.LoginWithOAuth(context.Background(), "google", &oauth.UserInfo{Subject: "sub", Email: "old@example.com", EmailVerified: true})
+		require.NoError(t, err)
+		require.Equal(t, user.ID, session.UserID)
+		require.Equal(t, hashedPassword, user.Password)
+		require.False(t, user.NoPassword)
+		found, err := usersRepo.GetByIdentity(context.Background(), "google", "sub")
+		require.NoError(t, err)
+		require.Equal(t, user, found)
+	})
+
+	t.Run("linked identity logs in even if email changed", func(t *testing.T) {
+		service, usersRepo := newService()
+		user := &User{Email: "old@example.com", IsActive: true}
+		_ = usersRepo.Create(context.Background(), user)
+		_ = usersRepo.CreateIdentity(context.Background(), &Identity{UserID: user.ID, Provider: "google", Subject: "sub"})
+
+		session, err := service.LoginWithOAuth(context.Background(), "google", &oauth.UserInfo{Subject: "sub", Email: "other@example.com"})
+		require.NoError(t, err)
+		require.Equal(t, user.ID, session.UserID)
+	})
+
+	t.Run("email not verified", func(t *testing.T) {
+		service, usersRepo := newService()
+		user := &User{Email: "old@example.com", IsActive: true}
+		_ = usersRepo.Create(context.Background(), user)
+
+		session, err := service.LoginWithOAuth(context.Background(), "google", &oauth.UserInfo{Subject: "sub", Email: "old@example.com"})
+		require.ErrorIs(t, err, ErrOAuthEmailNotVerified)
+		require.Nil(t, session)
+		_, err = usersRepo.GetByIdentity(context.Background(), "google", "sub")
+		require.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("create user error", func(t *testing.T) {
+		usersRepo := new(MockUsersRepo)
+		service := NewUsersService(usersRepo, NewSessionsRepositoryMem(), nil, nil, "https://example.com")
+		usersRepo.On("GetByIdentity", "google", "sub").Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("GetByEmail", "new@example.com").Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("Create", mock.Anything).Return(errors.New("create error")).Once()
+
+		_, err := service.LoginWithOAuth(context.Background(), "google", &oauth.UserInfo{Subject: "sub", Email: "new@example.com", EmailVerified: true})
+		require.EqualError(t, err, "create error")
+		usersRepo.AssertExpectations(t)
+	})
+
+	t.Run("long name is truncated", func(t *testing.T) {
+		name := oauthUserName(&oauth.UserInfo{Name: " " + strings.Repeat("я", 50) + " "})
+		require.Equal(t, strings.Repeat("я", 40), name)
+	})
+}
+
+func TestUsersService_RequestAccountDeletion(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	sessionsRepo := NewSessionsRepositoryMem()
+	personalTokensRepo := NewPersonalTokensRepositoryMem()
+	service := NewUsersService(usersRepo, sessionsRepo, personalTokensRepo, new(MockMailService), "https://example.com")
+	hashedPassword, err := service.HashPassword("password123")
+	require.NoError(t, err)
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		user := &User{ID: 1, Password: hashedPassword}
+		err := service.RequestAccountDeletion(context.Background(), user, "wrong")
+		require.ErrorIs(t, err, ErrInvalidPassword)
+		require.Nil(t, user.DeleteRequestedAt)
+		usersRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("NoPassword", func(t *testing.T) {
+		// Created by OAuth, the random password is unknown to the user
+		user := &User{ID: 1, Password: hashedPassword, NoPassword: true}
+		err := service.RequestAccountDeletion(context.Background(), user, "password123")
+		require.ErrorIs(t, err, ErrNoPassword)
+		require.Nil(t, user.DeleteRequestedAt)
+		usersRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		ctx := context.Background()
+		user := &User{ID: 1, Password: hashedPassword}
+		for _, sessionID := range []string{"laptop", "phone"} {
+			require.NoError(t, sessionsRepo.Create(ctx, sessionID, &Session{SessionID: sessionID, UserID: 1, Expiry: time.Now().Add(time.Hour)}))
+		}
+		require.NoError(t, sessionsRepo.Create(ctx, "other", &Session{SessionID: "other", UserID: 2, Expiry: time.Now().Add(time.Hour)}))
+		_, err := personalTokensRepo.CreatePersonalToken(ctx, &PersonalToken{UserID: 1, Name: "CLI", TokenHash: hashPersonalToken("cli")})
+		require.NoError(t, err)
+		usersRepo.On("Update", user).Return(nil).Once()
+
+		err = service.RequestAccountDeletion(ctx, user, "password123")
+		require.NoError(t, err)
+		require.True(t, user.IsDeleteRequested())
+		require.WithinDuration(t, time.Now().Add(AccountDeletionGracePeriod), user.DeletionDate(), time.Minute)
+		usersRepo.AssertExpectations(t)
+
+		// Logged out everywhere
+		for _, sessionID := range []string{"laptop", "phone"} {
+			session, err := sessionsRepo.Get(ctx, sessionID)
+			require.NoError(t, err)
+			require.Nil(t, session)
+		}
+		session, err := sessionsRepo.Get(ctx, "other")
+		require.NoError(t, err)
+		require.NotNil(t, session)
+		tokens, err := personalTokensRepo.PersonalTokens(ctx, 1)
+		require.NoError(t, err)
+		require.Empty(t, tokens)
+	})
+}
+
+func TestUsersService_CancelAccountDeletion(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	service := NewUsersService(usersRepo, new(MockSessionsRepo), nil, new(MockMailService), "https://example.com")
+
+	requestedAt := time.Now()
+	user := &User{ID: 1, DeleteRequestedAt: &requestedAt}
+	usersRepo.On("Update", user).Return(nil).Once()
+	err := service.CancelAccountDeletion(context.Background(), user)
+	require.NoError(t, err)
+	require.False(t, user.IsDeleteRequested())
+	usersRepo.AssertExpectations(t)
+}
+
+func TestUsersService_CalendarFeed(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	service := NewUsersService(usersRepo, new(MockSessionsRepo), nil, new(MockMailService), "https://example.com")
+	user := &User{ID: 1}
+	require.Empty(t, service.CalendarFeedLink(user))
+
+	usersRepo.On("Update", user).Return(nil).Times(3)
+	require.NoError(t, service.RotateCalendarToken(context.Background(), user))
+	require.Len(t, user.CalendarToken, 64)
+	require.Equal(t, "https://example.com/calendar.ics?token="+user.CalendarToken, service.CalendarFeedLink(user))
+
+	previous := user.CalendarToken
+	require.NoError(t, service.RotateCalendarToken(context.Background(), user))
+	require.NotEqual(t, previous, user.CalendarToken)
+
+	require.NoError(t, service.DisableCalendarFeed(context.Background(), user))
+	require.Empty(t, user.CalendarToken)
+	require.Empty(t, service.CalendarFeedLink(user))
+	usersRepo.AssertExpectations(t)
+}
+
+func TestUsersService_DeleteExpiredAccounts(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	service := NewUsersService(usersRepo, new(MockSessionsRepo), nil, new(MockMailService), "https://example.com")
+
+	usersRepo.On("DeleteRequestedBefore", mock.MatchedBy(func(date time.Time) bool {
+		return time.Since(date) > AccountDeletionGracePeriod-time.Minute && time.Since(date) < AccountDeletionGracePeriod+time.Minute
+	})).Return(3, nil).Once()
+	deleted, err := service.DeleteExpiredAccounts(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, deleted)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestUsersService_RequestEmailChange(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	mailService := new(MockMailService)
+	service := NewUsersService(usersRepo, new(MockSessionsRepo), nil, mailService, "https://example.com")
+	hashedPassword, err := service.HashPassword("password123")
+	require.NoError(t, err)
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, Password: hashedPassword}
+		err := service.RequestEmailChange(context.Background(), user, "new@example.com", "wrong")
+		require.ErrorIs(t, err, ErrInvalidPassword)
+		require.Empty(t, user.NewEmail)
+		usersRepo.AssertNotCalled(t, "GetByEmail", "new@example.com")
+		usersRepo.AssertNotCalled(t, "Update", mock.Anything)
+		mailService.AssertNotCalled(t, "SendEmailChangeEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SameEmail", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, Password: hashedPassword}
+		err := service.RequestEmailChange(context.Background(), user, email, "password123")
+		require.ErrorIs(t, err, ErrSameEmail)
+	})
+
+	t.Run("NoPassword", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, Password: hashedPassword, NoPassword: true}
+		err := service.RequestEmailChange(context.Background(), user, "new@example.com", "password123")
+		require.ErrorIs(t, err, ErrNoPassword)
+		require.Empty(t, user.NewEmail)
+	})
+
+	t.Run("SameEmailInAnotherCase", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, Password: hashedPassword}
+		err := service.RequestEmailChange(context.Background(), user, " "+strings.ToUpper(email)+" ", "password123")
+		require.ErrorIs(t, err, ErrSameEmail)
+	})
+
+	t.Run("EmailExistsInAnotherCase", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, Password: hashedPassword}
+		usersRepo.On("GetByEmail", "taken@example.com").Return(&User{ID: 2}, nil).Once()
+		err := service.RequestEmailChange(context.Background(), user, " Taken@Example.com", "password123")
+		require.ErrorIs(t, err, ErrEmailExists)
+		require.Empty(t, user.NewEmail)
+	})
+
+	t.Run("EmailExists", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, Password: hashedPassword}
+		usersRepo.On("GetByEmail", "taken@example.com").Return(&User{ID: 2}, nil).Once()
+		err := service.RequestEmailChange(context.Background(), user, "taken@example.com", "password123")
+		require.ErrorIs(t, err, ErrEmailExists)
+		require.Empty(t, user.NewEmail)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		done := make(chan struct{})
+		user := &User{ID: 1, Name: "Test User", Email: email, Password: hashedPassword}
+		usersRepo.On("GetByEmail", "new@example.com").Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("Update", user).Return(nil).Once()
+		mailService.On("SendEmailChangeEmail", "new@example.com", "Test User", mock.MatchedBy(func(link string) bool {
+			return strings.HasPrefix(link, "https://example.com/confirm-email?hash=")
+		})).
+			Return(nil).
+			Once().
+			Run(func(args mock.Arguments) {
+				close(done)
+			})
+
+		// Stored and sent in lower case
+		err := service.RequestEmailChange(context.Background(), user, " New@Example.com ", "password123")
+		require.NoError(t, err)
+		require.Equal(t, email, user.Email)
+		require.Equal(t, "new@example.com", user.NewEmail)
+		require.NotEmpty(t, user.ActivationHash)
+
+		// Waiting for SendEmailChangeEmail to complete
+		select {
+		case <-done:
+		case <-time.After(1 * time.Second):
+			t.Fatal("Test timed out waiting for goroutine to complete")
+		}
+
+		usersRepo.AssertExpectations(t)
+		mailService.AssertExpectations(t)
+	})
+}
+
+func TestUsersService_ConfirmEmailChange(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	mailService := new(MockMailService)
+	service := NewUsersService(usersRepo, new(MockSessionsRepo), nil, mailService, "https://example.com")
+
+	t.Run("InvalidHash", func(t *testing.T) {
+		usersRepo.On("GetByActivationHash", "invalid").Return(nil, utils.ErrNotFound).Once()
+		_, err := service.ConfirmEmailChange(context.Background(), "invalid")
+		require.ErrorIs(t, err, ErrUserNotFoundOrActivationHashIsInvalid)
+	})
+
+	t.Run("NotEmailChangeHash", func(t *testing.T) {
+		usersRepo.On("GetByActivationHash", "login").Return(&User{ID: 1, ActivationHashDate: time.Now()}, nil).Once()
+		_, err := service.ConfirmEmailChange(context.Background(), "login")
+		require.ErrorIs(t, err, ErrUserNotFoundOrActivationHashIsInvalid)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		user := &User{ID: 1, NewEmail: "new@example.com", ActivationHashDate: time.Now().Add(-16 * time.Minute)}
+		usersRepo.On("GetByActivationHash", "expired").Return(user, nil).Once()
+		_, err := service.ConfirmEmailChange(context.Background(), "expired")
+		require.ErrorIs(t, err, ErrUserNotFoundOrActivationHashIsInvalid)
+	})
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, NewEmail: "new@example.com", ActivationHashDate: time.Now()}
+		usersRepo.On("GetByActivationHash", "taken").Return(user, nil).Once()
+		usersRepo.On("GetByEmail", "new@example.com").Return(&User{ID: 2}, nil).Once()
+		_, err := service.ConfirmEmailChange(context.Background(), "taken")
+		require.ErrorIs(t, err, ErrEmailExists)
+	})
+
+	t.Run("UniqueViolation", func(t *testing.T) {
+		user := &User{ID: 1, Email: email, NewEmail: "new@example.com", ActivationHashDate: time.Now()}
+		usersRepo.On("GetByActivationHash", "race").Return(user, nil).Once()
+		usersRepo.On("GetByEmail", "new@example.com").Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("Update", user).Return(fmt.Errorf("failed to update user 1: %w", ErrEmailExists)).Once()
+		_, err := service.ConfirmEmailChange(context.Background(), "race")
+		require.ErrorIs(t, err, ErrEmailExists)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		done := make(chan struct{})
+		user := &User{ID: 1, Name: "Test User", Email: email, NewEmail: "new@example.com", ActivationHash: "hash", ActivationHashDate: time.Now()}
+		usersRepo.On("GetByActivationHash", "hash").Return(user, nil).Once()
+		usersRepo.On("GetByEmail", "new@example.com").Return(nil, utils.ErrNotFound).Once()
+		usersRepo.On("Update", user).Return(nil).Once()
+		mailService.On("SendEmailChangedEmail", email, "Test User", "new@example.com").
+			Return(nil).
+			Once().
+			Run(func(args mock.Arguments) {
+				close(done)
+			})
+
+		confirmed, err := service.ConfirmEmailChange(context.Background(), "hash")
+		require.NoError(t, err)
+		require.Equal(t, "new@example.com", confirmed.Email)
+		require.Empty(t, confirmed.NewEmail)
+		require.Empty(t, confirmed.ActivationHash)
+
+		// Waiting for SendEmailChangedEmail to complete
+		select {
+		case <-done:
+		case <-time.After(1 * time.Second):
+			t.Fatal("Test timed out waiting for goroutine to complete")
+		}
+
+		usersRepo.AssertExpectations(t)
+		mailService.AssertExpectations(t)
+	})
+}
+
+func TestUsersService_EmailChangeHashDoesNotLogIn(t *testing.T) {
+	usersRepo := new(MockUsersRepo)
+	service := NewUsersService(usersRepo, new(MockSessionsRepo), nil, new(MockMailService), "https://example.com")
+
+	user := &User{ID: 1, IsActive: true, NewEmail: "new@example.com", ActivationHash: "hash", ActivationHashDate: time.Now()}
+	usersRepo.On("GetByActivationHash", "hash").Return(user, nil)
+
+	_, err := service.LoginWithToken(context.Background(), "hash")
+	require.ErrorIs(t, err, ErrUserNotFound)
+	_, err = service.ActivateUser(context.Background(), "hash")
+	require.ErrorIs(t, err, ErrUserNotFound)
+	usersRepo.AssertNotCalled(t, "Update", mock.Anything)
 }
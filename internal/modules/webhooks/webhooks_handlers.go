@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time-tracker/internal/modules/dashboard"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+)
+
+const (
+	maxSubscriptionsPerUser = 10
+	// The deliveries of the log in the settings
+	deliveryLogLimit = 50
+)
+
+type subscriptionForm struct {
+	URL        string `form:"url" validate:"required,max=2048" label:"URL"`
+	EventTypes []string
+}
+
+// For the checkboxes of the template
+func (f subscriptionForm) Selected(eventType string) bool {
+	return slices.Contains(f.EventTypes, eventType)
+}
+
+type WebhooksHandlers struct {
+	service *WebhooksService
+}
+
+func NewWebhooksHandlers(service *WebhooksService) *WebhooksHandlers {
+	return &WebhooksHandlers{service: service}
+}
+
+// GET /settings/webhooks
+func (h *WebhooksHandlers) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+	// All events by default
+	h.renderWebhooks(w, r, user, subscriptionForm{EventTypes: dashboard.EventTypes}, utils.FormErrors{})
+}
+
+// POST /settings/webhooks
+func (h *WebhooksHandlers) HandleWebhooksCreate(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+
+	var form subscriptionForm
+	if err := utils.ParseFormToStruct(r, &form); err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	// Checkboxes, ParseFormToStruct takes the first value only
+	form.EventTypes = r.Form["event_types"]
+
+	formErrors := utils.NewValidator(&form).Validate()
+	if !formErrors.HasErrorsField("URL") {
+		if err := ValidateURL(form.URL, h.service.allowPrivateNetworks); err != nil {
+			formErrors.Add("URL", err.Error())
+		}
+	}
+	if len(form.EventTypes) == 0 {
+		formErrors.Add("EventTypes", "Select at least one event")
+	}
+	for _, eventType := range form.EventTypes {
+		if !slices.Contains(dashboard.EventTypes, eventType) {
+			formErrors.Add("EventTypes", fmt.Sprintf("Unknown event %q", eventType))
+		}
+	}
+	subscriptions, err := h.service.Subscriptions(r.Context(), user.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	if len(subscriptions) >= maxSubscriptionsPerUser {
+		formErrors.Add("Common", fmt.Sprintf("You can have up to %d webhooks", maxSubscriptionsPerUser))
+	}
+	if formErrors.HasErrors() {
+		h.renderWebhooks(w, r, user, form, formErrors)
+		return
+	}
+
+	err = h.service.CreateSubscription(r.Context(), &Subscription{
+		UserID:     user.ID,
+		URL:        form.URL,
+		EventTypes: form.EventTypes,
+	})
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, "/settings/webhooks", http.StatusSeeOther)
+}
+
+// POST /settings/webhooks/{id}/delete
+func (h *WebhooksHandlers) HandleWebhooksDelete(w http.ResponseWriter, r *http.Request) {
+	user := users.GetUserFromRequest(r)
+	if user == nil {
+		utils.RedirectLogin(w, r)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.RenderError(w, r, utils.ErrInvalidInput)
+		return
+	}
+	if err := h.service.DeleteSubscription(r.Context(), id, user.ID); err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, "/settings/webhooks", http.StatusSeeOther)
+}
+
+func (h *WebhooksHandlers) renderWebhooks(w http.ResponseWriter, r *http.Request, user *users.User, form subscriptionForm, formErrors utils.FormErrors) {
+	subscriptions, err := h.service.Subscriptions(r.Context(), user.ID)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	deliveries, err := h.service.Deliveries(r.Context(), user.ID, deliveryLogLimit)
+	if err != nil {
+		utils.RenderError(w, r, err)
+		return
+	}
+	utils.RenderTemplate(w, "webhooks/webhooks", utils.TplData{
+		"Title":         "Webhooks",
+		"User":          user,
+		"Form":          form,
+		"Errors":        formErrors,
+		"EventTypes":    dashboard.EventTypes,
+		"Subscriptions": subscriptions,
+		"Deliveries":    deliveries,
+	})
+}
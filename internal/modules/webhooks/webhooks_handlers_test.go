@@ -0,0 +1,192 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/webhooks --tags=unit -cover -run TestHandleWebhooks.*
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/modules/dashboard"
+	"time-tracker/internal/modules/users"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebhooksRequest(method string, path string, form url.Values, user *users.User) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), users.ContextUserKey, user))
+	}
+	return req
+}
+
+func TestHandleWebhooks(t *testing.T) {
+	ctx := context.Background()
+	service, repo, user := newTestWebhooksService(t, false)
+	handlers := NewWebhooksHandlers(service)
+
+	t.Run("Empty", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandleWebhooks(w, newWebhooksRequest(http.MethodGet, "/settings/webhooks", nil, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "No webhooks yet.")
+		assert.Contains(t, body, "No deliveries yet.")
+		// All events are checked by default
+		assert.Equal(t, len(dashboard.EventTypes), strings.Count(body, `name="event_types"`))
+		assert.Equal(t, len(dashboard.EventTypes), strings.Count(body, "checked"))
+	})
+
+	t.Run("WithDeliveries", func(t *testing.T) {
+		subscription := &Subscription{UserID: user.ID, URL: "https://example.com/hook", EventTypes: []string{dashboard.EventTaskCreated}}
+		require.NoError(t, service.CreateSubscription(ctx, subscription))
+		_, err := repo.CreateDelivery(ctx, &Delivery{
+			SubscriptionID: subscription.ID,
+			EventType:      dashboard.EventTaskCreated,
+			Payload:        "{}",
+			NextAttemptAt:  time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC),
+			CreatedAt:      time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		defer repo.DeleteSubscription(ctx, subscription.ID, user.ID)
+
+		w := httptest.NewRecorder()
+		handlers.HandleWebhooks(w, newWebhooksRequest(http.MethodGet, "/settings/webhooks", nil, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "https://example.com/hook")
+		assert.Contains(t, body, subscription.Secret)
+		assert.Contains(t, body, fmt.Sprintf(`action="/settings/webhooks/%d/delete"`, subscription.ID))
+		assert.Contains(t, body, "2024-12-02 10:00:00")
+		assert.Contains(t, body, "pending")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandleWebhooks(w, newWebhooksRequest(http.MethodGet, "/settings/webhooks", nil, nil))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+}
+
+func TestHandleWebhooksCreate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		service, _, user := newTestWebhooksService(t, false)
+		form := url.Values{"url": {"https://example.com/hook"}, "event_types": {dashboard.EventRecordStarted, dashboard.EventRecordStopped}}
+
+		w := httptest.NewRecorder()
+		NewWebhooksHandlers(service).HandleWebhooksCreate(w, newWebhooksRequest(http.MethodPost, "/settings/webhooks", form, user))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/settings/webhooks", w.Header().Get("Location"))
+		subscriptions, err := service.Subscriptions(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		assert.Equal(t, "https://example.com/hook", subscriptions[0].URL)
+		assert.Equal(t, []string{dashboard.EventRecordStarted, dashboard.EventRecordStopped}, subscriptions[0].EventTypes)
+		assert.NotEmpty(t, subscriptions[0].Secret)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			form    url.Values
+			message string
+		}{
+			{"NoURL", url.Values{"event_types": {dashboard.EventTaskCreated}}, "URL is required"},
+			{"NotHTTP", url.Values{"url": {"ftp://example.com"}, "event_types": {dashboard.EventTaskCreated}}, "URL must be an http or https URL"},
+			{"PrivateNetwork", url.Values{"url": {"http://192.168.1.10/hook"}, "event_types": {dashboard.EventTaskCreated}}, "URL must not point to a local or private network address"},
+			{"NoEvents", url.Values{"url": {"https://example.com"}}, "Select at least one event"},
+			{"UnknownEvent", url.Values{"url": {"https://example.com"}, "event_types": {"task.archived"}}, "Unknown event &#34;task.archived&#34;"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				service, _, user := newTestWebhooksService(t, false)
+
+				w := httptest.NewRecorder()
+				NewWebhooksHandlers(service).HandleWebhooksCreate(w, newWebhooksRequest(http.MethodPost, "/settings/webhooks", tt.form, user))
+
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), tt.message)
+				subscriptions, err := service.Subscriptions(ctx, user.ID)
+				require.NoError(t, err)
+				assert.Empty(t, subscriptions)
+			})
+		}
+	})
+
+	t.Run("TooMany", func(t *testing.T) {
+		service, _, user := newTestWebhooksService(t, false)
+		for i := 0; i < maxSubscriptionsPerUser; i++ {
+			require.NoError(t, service.CreateSubscription(ctx, &Subscription{UserID: user.ID, URL: "https://example.com", EventTypes: dashboard.EventTypes}))
+		}
+		form := url.Values{"url": {"https://example.com/hook"}, "event_types": {dashboard.EventTaskCreated}}
+
+		w := httptest.NewRecorder()
+		NewWebhooksHandlers(service).HandleWebhooksCreate(w, newWebhooksRequest(http.MethodPost, "/settings/webhooks", form, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "You can have up to 10 webhooks")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		service, _, _ := newTestWebhooksService(t, false)
+
+		w := httptest.NewRecorder()
+		NewWebhooksHandlers(service).HandleWebhooksCreate(w, newWebhooksRequest(http.MethodPost, "/settings/webhooks", url.Values{}, nil))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+}
+
+func TestHandleWebhooksDelete(t *testing.T) {
+	ctx := context.Background()
+	service, _, user := newTestWebhooksService(t, false)
+	handlers := NewWebhooksHandlers(service)
+	subscription := &Subscription{UserID: user.ID, URL: "https://example.com", EventTypes: dashboard.EventTypes}
+	require.NoError(t, service.CreateSubscription(ctx, subscription))
+	deleteRequest := func(id string, user *users.User) *http.Request {
+		req := newWebhooksRequest(http.MethodPost, "/settings/webhooks/"+id+"/delete", url.Values{}, user)
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	t.Run("OtherUser", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandleWebhooksDelete(w, deleteRequest(fmt.Sprint(subscription.ID), &users.User{ID: user.ID + 1}))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandleWebhooksDelete(w, deleteRequest("abc", user))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.HandleWebhooksDelete(w, deleteRequest(fmt.Sprint(subscription.ID), user))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/settings/webhooks", w.Header().Get("Location"))
+		subscriptions, err := service.Subscriptions(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, subscriptions)
+	})
+}
@@ -0,0 +1,91 @@
+package webhooks
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// The statuses of Delivery
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Subscription is a webhook of the user: the events of EventTypes are sent to URL
+type Subscription struct {
+	ID     int
+	UserID int
+	URL    string
+	// The key of the HMAC signature of the deliveries, shown to the user
+	Secret string
+	// dashboard.EventTypes
+	EventTypes []string
+	CreatedAt  time.Time
+}
+
+func (s *Subscription) Subscribed(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event for one subscription: an item of the queue while pending, then an entry of the log.
+// The times are UTC.
+type Delivery struct {
+	ID             int
+	SubscriptionID int
+	EventType      string
+	// The JSON body of the request
+	Payload       string
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastAttemptAt *time.Time
+	// The HTTP status of the last attempt, 0 if there was no response
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+
+	// The URL of the subscription, set by ClaimDueDeliveries and Deliveries
+	URL string
+	// The secret of the subscription, set by ClaimDueDeliveries
+	Secret string
+}
+
+// Methods that change one subscription by ID return utils.ErrNotFound if it does not exist.
+// WebhooksRepositoryContract in the tests describes the behavior that every implementation must follow.
+type WebhooksRepository interface {
+	// Oldest first
+	Subscriptions(ctx context.Context, userID int) ([]*Subscription, error)
+	CreateSubscription(ctx context.Context, subscription *Subscription) (int, error)
+	// Deletes the subscription of the user with its deliveries
+	DeleteSubscription(ctx context.Context, id int, userID int) error
+
+	CreateDelivery(ctx context.Context, delivery *Delivery) (int, error)
+	// Returns up to limit pending deliveries due by now, the oldest first, and moves their NextAttemptAt to now+lease.
+	// So the other instances skip them until the attempt is saved, or until the lease ends if this instance stops.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Delivery, error)
+	// Saves Status, Attempts, NextAttemptAt, LastAttemptAt, ResponseStatus and LastError
+	SaveDeliveryAttempt(ctx context.Context, delivery *Delivery) error
+	// The last deliveries of the subscriptions of the user, the newest first
+	Deliveries(ctx context.Context, userID int, limit int) ([]*Delivery, error)
+	// Deletes the succeeded and the failed deliveries created before, the pending ones are kept
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// event_types of the storage
+func joinEventTypes(eventTypes []string) string {
+	return strings.Join(eventTypes, ",")
+}
+
+func splitEventTypes(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
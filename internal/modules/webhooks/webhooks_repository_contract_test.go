@@ -0,0 +1,211 @@
+// For all go:build
+// The contract of WebhooksRepository. Every implementation must pass it:
+// WebhooksRepositoryMem and WebhooksRepositorySQLite in the unit tests, WebhooksRepositoryPostgres in the integration tests.
+package webhooks
+
+import (
+	"context"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns an empty repository and two users
+type WebhooksRepositoryFactory func(t *testing.T) (repo WebhooksRepository, userID int, otherUserID int)
+
+func WebhooksRepositoryContract(t *testing.T, newRepo WebhooksRepositoryFactory) {
+	ctx := context.Background()
+	// The databases keep microseconds
+	now := time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC)
+
+	createSubscription := func(t *testing.T, repo WebhooksRepository, userID int, url string) *Subscription {
+		subscription := &Subscription{
+			UserID:     userID,
+			URL:        url,
+			Secret:     "secret-" + url,
+			EventTypes: []string{"task.created", "record.started"},
+			CreatedAt:  now,
+		}
+		id, err := repo.CreateSubscription(ctx, subscription)
+		require.NoError(t, err)
+		subscription.ID = id
+		return subscription
+	}
+	createDelivery := func(t *testing.T, repo WebhooksRepository, subscriptionID int, nextAttemptAt time.Time) int {
+		id, err := repo.CreateDelivery(ctx, &Delivery{
+			SubscriptionID: subscriptionID,
+			EventType:      "task.created",
+			Payload:        `{"type":"task.created"}`,
+			NextAttemptAt:  nextAttemptAt,
+			CreatedAt:      nextAttemptAt,
+		})
+		require.NoError(t, err)
+		return id
+	}
+	deliveryIDs := func(deliveries []*Delivery) (ids []int) {
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return
+	}
+
+	t.Run("Subscriptions", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+
+		first := createSubscription(t, repo, userID, "https://example.com/1")
+		second := createSubscription(t, repo, userID, "https://example.com/2")
+		createSubscription(t, repo, otherUserID, "https://example.com/other")
+
+		subscriptions, err := repo.Subscriptions(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+		assert.Equal(t, first.ID, subscriptions[0].ID)
+		assert.Equal(t, second.ID, subscriptions[1].ID)
+		assert.Equal(t, userID, subscriptions[0].UserID)
+		assert.Equal(t, "https://example.com/1", subscriptions[0].URL)
+		assert.Equal(t, "secret-https://example.com/1", subscriptions[0].Secret)
+		assert.Equal(t, []string{"task.created", "record.started"}, subscriptions[0].EventTypes)
+		assert.True(t, subscriptions[0].CreatedAt.Equal(now))
+	})
+
+	t.Run("DeleteSubscription", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		subscription := createSubscription(t, repo, userID, "https://example.com/1")
+		createDelivery(t, repo, subscription.ID, now)
+
+		err := repo.DeleteSubscription(ctx, subscription.ID, otherUserID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+
+		require.NoError(t, repo.DeleteSubscription(ctx, subscription.ID, userID))
+		subscriptions, err := repo.Subscriptions(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, subscriptions)
+		// With the deliveries
+		deliveries, err := repo.Deliveries(ctx, userID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+
+		err = repo.DeleteSubscription(ctx, subscription.ID, userID)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("ClaimDueDeliveries", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		subscription := createSubscription(t, repo, userID, "https://example.com/1")
+		later := createDelivery(t, repo, subscription.ID, now.Add(-time.Minute))
+		earlier := createDelivery(t, repo, subscription.ID, now.Add(-time.Hour))
+		createDelivery(t, repo, subscription.ID, now.Add(time.Minute))
+
+		claimed, err := repo.ClaimDueDeliveries(ctx, now, 5*time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int{earlier, later}, deliveryIDs(claimed))
+		assert.Equal(t, "https://example.com/1", claimed[0].URL)
+		assert.Equal(t, "secret-https://example.com/1", claimed[0].Secret)
+		assert.Equal(t, `{"type":"task.created"}`, claimed[0].Payload)
+		assert.Equal(t, DeliveryPending, claimed[0].Status)
+		assert.True(t, claimed[0].NextAttemptAt.Equal(now.Add(5*time.Minute)))
+
+		// Leased, the due one of the future is not due yet
+		claimed, err = repo.ClaimDueDeliveries(ctx, now.Add(2*time.Minute), 5*time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 1)
+
+		// The lease ended, the same time is ordered by ID
+		claimed, err = repo.ClaimDueDeliveries(ctx, now.Add(5*time.Minute), 5*time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int{later, earlier}, deliveryIDs(claimed))
+	})
+
+	t.Run("SaveDeliveryAttempt", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		subscription := createSubscription(t, repo, userID, "https://example.com/1")
+		failed := createDelivery(t, repo, subscription.ID, now)
+		succeeded := createDelivery(t, repo, subscription.ID, now)
+
+		claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		attemptAt := now.Add(time.Second)
+		claimed[0].Status = DeliveryPending
+		claimed[0].Attempts = 1
+		claimed[0].NextAttemptAt = now.Add(time.Hour)
+		claimed[0].LastAttemptAt = &attemptAt
+		claimed[0].ResponseStatus = 500
+		claimed[0].LastError = "response status 500 Internal Server Error"
+		require.NoError(t, repo.SaveDeliveryAttempt(ctx, claimed[0]))
+		claimed[1].Status = DeliverySucceeded
+		claimed[1].Attempts = 1
+		claimed[1].LastAttemptAt = &attemptAt
+		claimed[1].ResponseStatus = 204
+		require.NoError(t, repo.SaveDeliveryAttempt(ctx, claimed[1]))
+
+		deliveries, err := repo.Deliveries(ctx, userID, 10)
+		require.NoError(t, err)
+		require.Equal(t, []int{succeeded, failed}, deliveryIDs(deliveries))
+		assert.Equal(t, DeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 204, deliveries[0].ResponseStatus)
+		assert.Equal(t, DeliveryPending, deliveries[1].Status)
+		assert.Equal(t, 1, deliveries[1].Attempts)
+		assert.True(t, deliveries[1].NextAttemptAt.Equal(now.Add(time.Hour)))
+		require.NotNil(t, deliveries[1].LastAttemptAt)
+		assert.True(t, deliveries[1].LastAttemptAt.Equal(attemptAt))
+		assert.Equal(t, 500, deliveries[1].ResponseStatus)
+		assert.Equal(t, "response status 500 Internal Server Error", deliveries[1].LastError)
+		assert.Equal(t, "https://example.com/1", deliveries[1].URL)
+		assert.Empty(t, deliveries[1].Secret)
+
+		// Saved as not due
+		claimed, err = repo.ClaimDueDeliveries(ctx, now.Add(time.Minute), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		err = repo.SaveDeliveryAttempt(ctx, &Delivery{ID: succeeded + failed + 1000, Status: DeliveryFailed})
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		repo, userID, otherUserID := newRepo(t)
+		subscription := createSubscription(t, repo, userID, "https://example.com/1")
+		other := createSubscription(t, repo, otherUserID, "https://example.com/other")
+		first := createDelivery(t, repo, subscription.ID, now)
+		second := createDelivery(t, repo, subscription.ID, now)
+		third := createDelivery(t, repo, subscription.ID, now)
+		createDelivery(t, repo, other.ID, now)
+
+		deliveries, err := repo.Deliveries(ctx, userID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int{third, second, first}, deliveryIDs(deliveries))
+
+		deliveries, err = repo.Deliveries(ctx, userID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []int{third, second}, deliveryIDs(deliveries))
+	})
+
+	t.Run("DeleteDeliveriesBefore", func(t *testing.T) {
+		repo, userID, _ := newRepo(t)
+		subscription := createSubscription(t, repo, userID, "https://example.com/1")
+		old := createDelivery(t, repo, subscription.ID, now.Add(-48*time.Hour))
+		oldPending := createDelivery(t, repo, subscription.ID, now.Add(-48*time.Hour))
+		recent := createDelivery(t, repo, subscription.ID, now.Add(-time.Hour))
+
+		claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		for _, delivery := range claimed {
+			if delivery.ID != oldPending {
+				delivery.Status = DeliveryFailed
+				require.NoError(t, repo.SaveDeliveryAttempt(ctx, delivery))
+			}
+		}
+
+		deleted, err := repo.DeleteDeliveriesBefore(ctx, now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+		deliveries, err := repo.Deliveries(ctx, userID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int{recent, oldPending}, deliveryIDs(deliveries))
+		assert.NotContains(t, deliveryIDs(deliveries), old)
+	})
+}
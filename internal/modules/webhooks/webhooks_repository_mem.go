@@ -0,0 +1,181 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"time-tracker/internal/utils"
+)
+
+// WebhooksRepositoryMem has the same semantics as WebhooksRepositoryPostgres, for the tests.
+// Returned subscriptions and deliveries are copies, changing them does not change the repository.
+type WebhooksRepositoryMem struct {
+	mu             sync.Mutex
+	subscriptions  map[int]*Subscription
+	deliveries     map[int]*Delivery
+	nextID         int
+	nextDeliveryID int
+}
+
+func NewWebhooksRepositoryMem() *WebhooksRepositoryMem {
+	return &WebhooksRepositoryMem{
+		subscriptions:  make(map[int]*Subscription),
+		deliveries:     make(map[int]*Delivery),
+		nextID:         1,
+		nextDeliveryID: 1,
+	}
+}
+
+func (repo *WebhooksRepositoryMem) Subscriptions(_ context.Context, userID int) (subscriptions []*Subscription, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, subscription := range repo.subscriptions {
+		if subscription.UserID == userID {
+			subscriptions = append(subscriptions, copySubscription(subscription))
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions, nil
+}
+
+func (repo *WebhooksRepositoryMem) CreateSubscription(_ context.Context, subscription *Subscription) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	created := copySubscription(subscription)
+	created.ID = repo.nextID
+	repo.nextID++
+	repo.subscriptions[created.ID] = created
+	return created.ID, nil
+}
+
+func (repo *WebhooksRepositoryMem) DeleteSubscription(_ context.Context, id int, userID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	subscription, ok := repo.subscriptions[id]
+	if !ok || subscription.UserID != userID {
+		return fmt.Errorf("webhook subscription %d: %w", id, utils.ErrNotFound)
+	}
+	delete(repo.subscriptions, id)
+	// ON DELETE CASCADE
+	for deliveryID, delivery := range repo.deliveries {
+		if delivery.SubscriptionID == id {
+			delete(repo.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (repo *WebhooksRepositoryMem) CreateDelivery(_ context.Context, delivery *Delivery) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.subscriptions[delivery.SubscriptionID]; !ok {
+		return 0, fmt.Errorf("webhook subscription %d: foreign key violation", delivery.SubscriptionID)
+	}
+	created := *delivery
+	created.ID = repo.nextDeliveryID
+	created.Status = DeliveryPending
+	created.URL, created.Secret = "", ""
+	repo.nextDeliveryID++
+	repo.deliveries[created.ID] = &created
+	return created.ID, nil
+}
+
+func (repo *WebhooksRepositoryMem) ClaimDueDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*Delivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var due []*Delivery
+	for _, delivery := range repo.deliveries {
+		if delivery.Status == DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*Delivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = now.Add(lease)
+		claimedDelivery := repo.withSubscription(delivery)
+		claimed = append(claimed, claimedDelivery)
+	}
+	return claimed, nil
+}
+
+func (repo *WebhooksRepositoryMem) SaveDeliveryAttempt(_ context.Context, delivery *Delivery) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	saved, ok := repo.deliveries[delivery.ID]
+	if !ok {
+		return fmt.Errorf("webhook delivery %d: %w", delivery.ID, utils.ErrNotFound)
+	}
+	saved.Status = delivery.Status
+	saved.Attempts = delivery.Attempts
+	saved.NextAttemptAt = delivery.NextAttemptAt
+	saved.LastAttemptAt = nil
+	if delivery.LastAttemptAt != nil {
+		lastAttemptAt := *delivery.LastAttemptAt
+		saved.LastAttemptAt = &lastAttemptAt
+	}
+	saved.ResponseStatus = delivery.ResponseStatus
+	saved.LastError = delivery.LastError
+	return nil
+}
+
+func (repo *WebhooksRepositoryMem) Deliveries(_ context.Context, userID int, limit int) ([]*Delivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var deliveries []*Delivery
+	for _, delivery := range repo.deliveries {
+		if subscription := repo.subscriptions[delivery.SubscriptionID]; subscription != nil && subscription.UserID == userID {
+			d := repo.withSubscription(delivery)
+			d.Secret = ""
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (repo *WebhooksRepositoryMem) DeleteDeliveriesBefore(_ context.Context, before time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	deleted := 0
+	for id, delivery := range repo.deliveries {
+		if delivery.Status != DeliveryPending && delivery.CreatedAt.Before(before) {
+			delete(repo.deliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// A copy with URL and Secret of the subscription, the caller holds the lock
+func (repo *WebhooksRepositoryMem) withSubscription(delivery *Delivery) *Delivery {
+	d := *delivery
+	if delivery.LastAttemptAt != nil {
+		lastAttemptAt := *delivery.LastAttemptAt
+		d.LastAttemptAt = &lastAttemptAt
+	}
+	if subscription := repo.subscriptions[delivery.SubscriptionID]; subscription != nil {
+		d.URL, d.Secret = subscription.URL, subscription.Secret
+	}
+	return &d
+}
+
+func copySubscription(subscription *Subscription) *Subscription {
+	s := *subscription
+	s.EventTypes = append([]string(nil), subscription.EventTypes...)
+	return &s
+}
@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type PgxPool interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+type WebhooksRepositoryPostgres struct {
+	db           PgxPool
+	queryTimeout time.Duration
+}
+
+// queryTimeout limits every query, 0 means no limit
+func NewWebhooksRepositoryPostgres(db PgxPool, queryTimeout time.Duration) *WebhooksRepositoryPostgres {
+	return &WebhooksRepositoryPostgres{db: db, queryTimeout: queryTimeout}
+}
+
+const deliveryFieldsPostgres = `d.id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error, d.created_at, s.url`
+
+func (r *WebhooksRepositoryPostgres) Subscriptions(ctx context.Context, userID int) (subscriptions []*Subscription, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, url, secret, event_types, created_at
+		FROM webhook_subscriptions WHERE user_id = $1
+		ORDER BY id ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositoryPostgres Subscriptions Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("WebhooksRepositoryPostgres Subscriptions Scan: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositoryPostgres Subscriptions Rows: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhooksRepositoryPostgres) CreateSubscription(ctx context.Context, subscription *Subscription) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO webhook_subscriptions (user_id, url, secret, event_types, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, subscription.UserID, subscription.URL, subscription.Secret, joinEventTypes(subscription.EventTypes),
+		subscription.CreatedAt).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("WebhooksRepositoryPostgres CreateSubscription QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *WebhooksRepositoryPostgres) DeleteSubscription(ctx context.Context, id int, userID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("WebhooksRepositoryPostgres DeleteSubscription Exec: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("webhook subscription %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *WebhooksRepositoryPostgres) CreateDelivery(ctx context.Context, delivery *Delivery) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, delivery.SubscriptionID, delivery.EventType, delivery.Payload, DeliveryPending,
+		delivery.NextAttemptAt, delivery.CreatedAt).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("WebhooksRepositoryPostgres CreateDelivery QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+// SKIP LOCKED lets the instances claim different deliveries at the same time
+func (r *WebhooksRepositoryPostgres) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (deliveries []*Delivery, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, `
+		UPDATE webhook_deliveries d SET next_attempt_at = $2
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at ASC, id ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryFieldsPostgres+`, s.secret
+	`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositoryPostgres ClaimDueDeliveries Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("WebhooksRepositoryPostgres ClaimDueDeliveries Scan: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositoryPostgres ClaimDueDeliveries Rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhooksRepositoryPostgres) SaveDeliveryAttempt(ctx context.Context, delivery *Delivery) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	tag, err := r.db.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4, response_status = $5, last_error = $6
+		WHERE id = $7
+	`, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.ResponseStatus, delivery.LastError, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("WebhooksRepositoryPostgres SaveDeliveryAttempt Exec: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("webhook delivery %d: %w", delivery.ID, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *WebhooksRepositoryPostgres) Deliveries(ctx context.Context, userID int, limit int) (deliveries []*Delivery, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.Query(ctx, `
+		SELECT `+deliveryFieldsPostgres+`
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE s.user_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositoryPostgres Deliveries Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("WebhooksRepositoryPostgres Deliveries Scan: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositoryPostgres Deliveries Rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhooksRepositoryPostgres) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("WebhooksRepositoryPostgres DeleteDeliveriesBefore Exec: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// pgx.Row and *sql.Row
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (*Subscription, error) {
+	var subscription Subscription
+	var eventTypes string
+	err := row.Scan(&subscription.ID, &subscription.UserID, &subscription.URL, &subscription.Secret, &eventTypes, &subscription.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	subscription.EventTypes = splitEventTypes(eventTypes)
+	return &subscription, nil
+}
+
+// The fields of deliveryFieldsPostgres, and the secret if withSecret
+func scanDelivery(row rowScanner, withSecret bool) (*Delivery, error) {
+	var delivery Delivery
+	dest := []any{
+		&delivery.ID, &delivery.SubscriptionID, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &delivery.LastAttemptAt, &delivery.ResponseStatus, &delivery.LastError, &delivery.CreatedAt, &delivery.URL,
+	}
+	if withSecret {
+		dest = append(dest, &delivery.Secret)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
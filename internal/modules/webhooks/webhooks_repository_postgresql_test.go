@@ -0,0 +1,78 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/webhooks --tags=unit -cover -run TestWebhooksRepositoryPostgres_.*
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooksRepositoryPostgres_ClaimDueDeliveries(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewWebhooksRepositoryPostgres(mockPool, 0)
+	now := time.Date(2024, 12, 2, 10, 0, 0, 0, time.UTC)
+	columns := []string{
+		"id", "subscription_id", "event_type", "payload", "status", "attempts",
+		"next_attempt_at", "last_attempt_at", "response_status", "last_error", "created_at", "url", "secret",
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		leaseEnd := now.Add(5 * time.Minute)
+		rows := mockPool.NewRows(columns).
+			AddRow(7, 3, "task.created", `{}`, DeliveryPending, 1, leaseEnd, &now, 500, "response status 500", now, "https://example.com", "secret")
+		mockPool.ExpectQuery(`UPDATE webhook_deliveries d SET next_attempt_at = \$2[\s\S]+FOR UPDATE SKIP LOCKED[\s\S]+RETURNING`).
+			WithArgs(now, leaseEnd, 10).
+			WillReturnRows(rows)
+
+		deliveries, err := repo.ClaimDueDeliveries(context.Background(), now, 5*time.Minute, 10)
+
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 7, deliveries[0].ID)
+		assert.Equal(t, 3, deliveries[0].SubscriptionID)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, leaseEnd, deliveries[0].NextAttemptAt)
+		assert.Equal(t, now, *deliveries[0].LastAttemptAt)
+		assert.Equal(t, "https://example.com", deliveries[0].URL)
+		assert.Equal(t, "secret", deliveries[0].Secret)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockPool.ExpectQuery(`UPDATE webhook_deliveries d`).
+			WithArgs(now, now.Add(time.Minute), 10).
+			WillReturnError(errors.New("db error"))
+
+		_, err := repo.ClaimDueDeliveries(context.Background(), now, time.Minute, 10)
+
+		assert.ErrorContains(t, err, "WebhooksRepositoryPostgres ClaimDueDeliveries Query: db error")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestWebhooksRepositoryPostgres_DeleteSubscription(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	repo := NewWebhooksRepositoryPostgres(mockPool, 0)
+	mockPool.ExpectExec(`DELETE FROM webhook_subscriptions WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(5, 1).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err = repo.DeleteSubscription(context.Background(), 5, 1)
+
+	assert.ErrorIs(t, err, utils.ErrNotFound)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
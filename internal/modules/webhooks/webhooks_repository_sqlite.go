@@ -0,0 +1,202 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"time-tracker/internal/utils"
+	"time-tracker/internal/utils/sqlite"
+)
+
+// WebhooksRepositorySQLite keeps the webhooks in the tables of sqlite.Open.
+// One process uses the file, so the deliveries are claimed in a transaction without row locks.
+type WebhooksRepositorySQLite struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// queryTimeout limits every query, 0 means no limit
+func NewWebhooksRepositorySQLite(db *sql.DB, queryTimeout time.Duration) *WebhooksRepositorySQLite {
+	return &WebhooksRepositorySQLite{db: db, queryTimeout: queryTimeout}
+}
+
+const deliveriesQuerySQLite = `
+		SELECT d.id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts,
+			d.next_attempt_at, d.last_attempt_at, d.response_status, d.last_error, d.created_at, s.url, s.secret
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+	`
+
+func (r *WebhooksRepositorySQLite) Subscriptions(ctx context.Context, userID int) (subscriptions []*Subscription, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, url, secret, event_types, created_at
+		FROM webhook_subscriptions WHERE user_id = $1
+		ORDER BY id ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite Subscriptions Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("WebhooksRepositorySQLite Subscriptions Scan: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite Subscriptions Rows: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhooksRepositorySQLite) CreateSubscription(ctx context.Context, subscription *Subscription) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (user_id, url, secret, event_types, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, subscription.UserID, subscription.URL, subscription.Secret, joinEventTypes(subscription.EventTypes),
+		sqlite.FormatTime(subscription.CreatedAt)).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("WebhooksRepositorySQLite CreateSubscription QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *WebhooksRepositorySQLite) DeleteSubscription(ctx context.Context, id int, userID int) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("WebhooksRepositorySQLite DeleteSubscription Exec: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("webhook subscription %d: %w", id, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *WebhooksRepositorySQLite) CreateDelivery(ctx context.Context, delivery *Delivery) (newID int, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, delivery.SubscriptionID, delivery.EventType, delivery.Payload, DeliveryPending,
+		sqlite.FormatTime(delivery.NextAttemptAt), sqlite.FormatTime(delivery.CreatedAt)).Scan(&newID)
+	if err != nil {
+		return 0, fmt.Errorf("WebhooksRepositorySQLite CreateDelivery QueryRow: %w", err)
+	}
+	return newID, nil
+}
+
+func (r *WebhooksRepositorySQLite) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (deliveries []*Delivery, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite ClaimDueDeliveries BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, deliveriesQuerySQLite+`
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1
+		ORDER BY d.next_attempt_at ASC, d.id ASC
+		LIMIT $2
+	`, sqlite.FormatTime(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite ClaimDueDeliveries Query: %w", err)
+	}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, true)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("WebhooksRepositorySQLite ClaimDueDeliveries Scan: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite ClaimDueDeliveries Rows: %w", err)
+	}
+
+	nextAttemptAt := now.Add(lease)
+	for _, delivery := range deliveries {
+		_, err := tx.ExecContext(ctx, `UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id = $2`,
+			sqlite.FormatTime(nextAttemptAt), delivery.ID)
+		if err != nil {
+			return nil, fmt.Errorf("WebhooksRepositorySQLite ClaimDueDeliveries Exec: %w", err)
+		}
+		delivery.NextAttemptAt = nextAttemptAt
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite ClaimDueDeliveries Commit: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhooksRepositorySQLite) SaveDeliveryAttempt(ctx context.Context, delivery *Delivery) error {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4, response_status = $5, last_error = $6
+		WHERE id = $7
+	`, delivery.Status, delivery.Attempts, sqlite.FormatTime(delivery.NextAttemptAt), sqlite.FormatTimePtr(delivery.LastAttemptAt),
+		delivery.ResponseStatus, delivery.LastError, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("WebhooksRepositorySQLite SaveDeliveryAttempt Exec: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("webhook delivery %d: %w", delivery.ID, utils.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *WebhooksRepositorySQLite) Deliveries(ctx context.Context, userID int, limit int) (deliveries []*Delivery, err error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, deliveriesQuerySQLite+`
+		WHERE s.user_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite Deliveries Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("WebhooksRepositorySQLite Deliveries Scan: %w", err)
+		}
+		// Only the queue needs the secret
+		delivery.Secret = ""
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("WebhooksRepositorySQLite Deliveries Rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhooksRepositorySQLite) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+	defer cancel()
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1
+	`, sqlite.FormatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("WebhooksRepositorySQLite DeleteDeliveriesBefore Exec: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
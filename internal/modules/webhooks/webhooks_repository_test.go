@@ -0,0 +1,46 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/modules/webhooks --tags=unit -cover -run TestWebhooksRepository
+package webhooks
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/internal/utils/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooksRepositoryMem_Contract(t *testing.T) {
+	WebhooksRepositoryContract(t, func(t *testing.T) (WebhooksRepository, int, int) {
+		return NewWebhooksRepositoryMem(), 1, 2
+	})
+}
+
+func TestWebhooksRepositorySQLite_Contract(t *testing.T) {
+	WebhooksRepositoryContract(t, func(t *testing.T) (WebhooksRepository, int, int) {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		userIDs := []int{}
+		for i := 1; i <= 2; i++ {
+			var userID int
+			err := db.QueryRow(`
+				INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id
+			`, "Contract", fmt.Sprintf("contract-%d@example.com", i), "password").Scan(&userID)
+			require.NoError(t, err)
+			userIDs = append(userIDs, userID)
+		}
+		return NewWebhooksRepositorySQLite(db, time.Second), userIDs[0], userIDs[1]
+	})
+}
+
+func TestWebhooksRepository_EventTypes(t *testing.T) {
+	assert.Equal(t, "task.created,record.stopped", joinEventTypes([]string{"task.created", "record.stopped"}))
+	assert.Equal(t, []string{"task.created", "record.stopped"}, splitEventTypes("task.created,record.stopped"))
+	assert.Nil(t, splitEventTypes(""))
+}
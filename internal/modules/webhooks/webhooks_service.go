@@ -0,0 +1,395 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+	"time-tracker/internal/modules/dashboard"
+	"time-tracker/internal/modules/users"
+	"time-tracker/internal/utils"
+	"unicode/utf8"
+)
+
+// The headers of the deliveries
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	// A delivery is failed after this number of attempts, see retryDelay
+	maxDeliveryAttempts = 8
+	// The deliveries of one run of the job
+	deliveryBatchSize = 10
+	deliveryTimeout   = 10 * time.Second
+	// Longer than a batch of timed out deliveries, so another instance does not send them twice
+	deliveryLease = 5 * time.Minute
+	// The log of the finished deliveries
+	deliveryRetention = 30 * 24 * time.Hour
+	// The response bodies are read up to the limit and discarded, so the connections are reused
+	maxResponseBodyBytes = 64 << 10
+	lastErrorMaxLength   = 255
+)
+
+var errPrivateNetwork = errors.New("the address is in a private network")
+
+// WebhooksService queues the events of the dashboard for the subscriptions of their users and delivers them.
+// It is the dashboard.EventPublisher of dashboard.DashboardRepositoryEvents.
+type WebhooksService struct {
+	repo      WebhooksRepository
+	usersRepo users.UsersRepository
+	client    *http.Client
+	// See ValidateURL
+	allowPrivateNetworks bool
+	// Publish wakes RunDeliveryJob, so the deliveries do not wait for the interval
+	wake chan struct{}
+}
+
+// allowPrivateNetworks allows the URLs of the loopback and private addresses, e.g. for self-hosting.
+// Otherwise they are rejected, so the users cannot send requests to the internal services.
+func NewWebhooksService(repo WebhooksRepository, usersRepo users.UsersRepository, allowPrivateNetworks bool) *WebhooksService {
+	return &WebhooksService{
+		repo:                 repo,
+		usersRepo:            usersRepo,
+		client:               newDeliveryClient(allowPrivateNetworks),
+		allowPrivateNetworks: allowPrivateNetworks,
+		wake:                 make(chan struct{}, 1),
+	}
+}
+
+func (s *WebhooksService) Subscriptions(ctx context.Context, userID int) ([]*Subscription, error) {
+	return s.repo.Subscriptions(ctx, userID)
+}
+
+// CreateSubscription generates the secret of the subscription
+func (s *WebhooksService) CreateSubscription(ctx context.Context, subscription *Subscription) error {
+	secret, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	subscription.Secret = secret
+	subscription.CreatedAt = time.Now().UTC()
+	subscription.ID, err = s.repo.CreateSubscription(ctx, subscription)
+	return err
+}
+
+func (s *WebhooksService) DeleteSubscription(ctx context.Context, id int, userID int) error {
+	return s.repo.DeleteSubscription(ctx, id, userID)
+}
+
+func (s *WebhooksService) Deliveries(ctx context.Context, userID int, limit int) ([]*Delivery, error) {
+	return s.repo.Deliveries(ctx, userID, limit)
+}
+
+// Publish queues the event for the subscriptions of its user
+func (s *WebhooksService) Publish(ctx context.Context, event dashboard.Event) {
+	subscriptions, err := s.repo.Subscriptions(ctx, event.UserID)
+	if err != nil {
+		utils.Logger(ctx).Error("WebhooksService Publish Subscriptions", "type", event.Type, "err", err)
+		return
+	}
+	var subscribed []*Subscription
+	for _, subscription := range subscriptions {
+		if subscription.Subscribed(event.Type) {
+			subscribed = append(subscribed, subscription)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	user, err := s.usersRepo.GetByID(ctx, event.UserID)
+	if errors.Is(err, utils.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		utils.Logger(ctx).Error("WebhooksService Publish GetByID", "type", event.Type, "err", err)
+		return
+	}
+	payload, err := json.Marshal(newEventPayload(event, user.TimeZone))
+	if err != nil {
+		utils.Logger(ctx).Error("WebhooksService Publish Marshal", "type", event.Type, "err", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, subscription := range subscribed {
+		_, err := s.repo.CreateDelivery(ctx, &Delivery{
+			SubscriptionID: subscription.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			utils.Logger(ctx).Error("WebhooksService Publish CreateDelivery", "type", event.Type, "subscriptionID", subscription.ID, "err", err)
+		}
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// DeliverDue sends the deliveries due by now, one batch. A failed attempt is retried later, see retryDelay.
+func (s *WebhooksService) DeliverDue(ctx context.Context, now time.Time) (succeeded int, failed int, err error) {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, now, deliveryLease, deliveryBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	var errs []error
+	for _, delivery := range deliveries {
+		s.attempt(ctx, delivery, now)
+		if err := s.repo.SaveDeliveryAttempt(ctx, delivery); err != nil {
+			errs = append(errs, fmt.Errorf("webhook delivery %d: %w", delivery.ID, err))
+		}
+		if delivery.Status == DeliverySucceeded {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+	return succeeded, failed, errors.Join(errs...)
+}
+
+// Sends the delivery and sets the fields of the attempt
+func (s *WebhooksService) attempt(ctx context.Context, delivery *Delivery, now time.Time) {
+	delivery.Attempts++
+	attemptAt := now
+	delivery.LastAttemptAt = &attemptAt
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "TimeTracker-Webhooks/1.0")
+		req.Header.Set(HeaderEvent, delivery.EventType)
+		req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+		req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now().Unix(), body))
+		var resp *http.Response
+		resp, err = s.client.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodyBytes))
+			resp.Body.Close()
+			delivery.ResponseStatus = resp.StatusCode
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				err = fmt.Errorf("response status %s", resp.Status)
+			}
+		}
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = DeliverySucceeded
+	case delivery.Attempts >= maxDeliveryAttempts:
+		delivery.Status = DeliveryFailed
+	default:
+		delivery.Status = DeliveryPending
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+	}
+	if err != nil {
+		delivery.LastError = truncate(err.Error(), lastErrorMaxLength)
+	}
+}
+
+// Calls DeliverDue every interval, or right after Publish, until ctx is done.
+// The log of the finished deliveries is deleted after deliveryRetention.
+func (s *WebhooksService) RunDeliveryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+	for {
+		for {
+			succeeded, failed, err := s.DeliverDue(ctx, time.Now().UTC())
+			if err != nil {
+				slog.Error("RunDeliveryJob DeliverDue", "err", err)
+			}
+			if failed > 0 {
+				slog.Info("RunDeliveryJob deliveries failed", "succeeded", succeeded, "failed", failed)
+			}
+			// A full batch, there can be more
+			if err != nil || succeeded+failed < deliveryBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		if time.Since(lastCleanup) > time.Hour {
+			lastCleanup = time.Now()
+			if _, err := s.repo.DeleteDeliveriesBefore(ctx, time.Now().UTC().Add(-deliveryRetention)); err != nil {
+				slog.Error("RunDeliveryJob DeleteDeliveriesBefore", "err", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Sign returns the value of the X-Webhook-Signature header: "t=<unix time>,v1=<hex HMAC-SHA256>".
+// The HMAC is of "<unix time>.<body>" with the secret of the subscription.
+// The receivers compute it the same way and reject the old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// 1, 2, 4 ... 64 minutes after the attempts 1 to 7, about 2 hours in total
+func retryDelay(attempts int) time.Duration {
+	return time.Minute << (attempts - 1)
+}
+
+// ValidateURL checks the URL of a new subscription. The addresses of the host names are checked on every delivery.
+func ValidateURL(rawURL string, allowPrivateNetworks bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL must be an http or https URL")
+	}
+	if u.User != nil {
+		return errors.New("URL must not contain a user name or a password")
+	}
+	if allowPrivateNetworks {
+		return nil
+	}
+	ip := net.ParseIP(u.Hostname())
+	if u.Hostname() == "localhost" || (ip != nil && !isPublicIP(ip)) {
+		return errors.New("URL must not point to a local or private network address")
+	}
+	return nil
+}
+
+// The client does not follow the redirects, a redirect is a failed attempt.
+// Without allowPrivateNetworks the connections to the private addresses are refused after the DNS lookup.
+func newDeliveryClient(allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errPrivateNetwork
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: deliveryTimeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+func generateSecret() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("could not generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
+
+// Cuts s to at most max bytes without splitting a UTF-8 character
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// The JSON body of the deliveries
+type eventPayload struct {
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	Task      *taskPayload   `json:"task"`
+	Record    *recordPayload `json:"record,omitempty"`
+}
+
+type taskPayload struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	IsCompleted bool   `json:"is_completed"`
+}
+
+// The times with the offset of the time zone of the user, e.g. "2024-12-02T10:00:00+03:00"
+type recordPayload struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	TimeStart time.Time  `json:"time_start"`
+	TimeEnd   *time.Time `json:"time_end"`
+	Comment   string     `json:"comment"`
+}
+
+func newEventPayload(event dashboard.Event, timezone string) eventPayload {
+	payload := eventPayload{Type: event.Type, CreatedAt: event.OccurredAt}
+	if event.Task != nil {
+		payload.Task = &taskPayload{
+			ID:          event.Task.ID,
+			Title:       event.Task.Title,
+			Description: event.Task.Description,
+			Color:       event.Task.Color,
+			IsCompleted: event.Task.IsCompleted,
+		}
+	}
+	if event.Record != nil {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		// The records are in the wall clock time of the user
+		inZone := func(t time.Time) time.Time {
+			moment, _ := utils.FromWallClock(t, loc.String())
+			return moment.In(loc)
+		}
+		payload.Record = &recordPayload{
+			ID:        event.Record.ID,
+			TaskID:    event.Record.TaskID,
+			TimeStart: inZone(event.Record.TimeStart),
+			Comment:   event.Record.Comment,
+		}
+		if event.Record.TimeEnd != nil {
+			timeEnd := inZone(*event.Record.TimeEnd)
+			payload.Record.TimeEnd = &timeEnd
+		}
+	}
+	return payload
+}
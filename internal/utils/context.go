@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// Limits a database query by timeout. The query is also cancelled with ctx, e.g. when the HTTP request is cancelled.
+// timeout <= 0 means no own deadline.
+//
+//	ctx, cancel := utils.QueryContext(ctx, r.queryTimeout)
+//	defer cancel()
+func QueryContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,37 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils --tags=unit -run TestQueryContext
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryContext(t *testing.T) {
+	t.Run("Timeout", func(t *testing.T) {
+		ctx, cancel := QueryContext(context.Background(), time.Second)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("NoTimeout", func(t *testing.T) {
+		ctx, cancel := QueryContext(context.Background(), 0)
+		defer cancel()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+
+	t.Run("ParentCancelled", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel := QueryContext(parent, time.Minute)
+		defer cancel()
+		cancelParent()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Sentinel errors shared by the repositories, services and handlers of all modules.
+// Wrap them with details: fmt.Errorf("task %d: %w", id, utils.ErrNotFound)
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// ErrorStatus maps an error to the HTTP status and the text shown to the user.
+// Unknown errors are internal, their text is not shown because it can contain details of the database.
+func ErrorStatus(err error) (status int, title string, message string) {
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		return http.StatusBadRequest, "Bad Request", "The request is invalid. Please reload the page and try again."
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound, "Not Found", "The requested item was not found."
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, "Access denied", "You do not have access to this item."
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict, "Conflict", "The item was changed by another request. Please reload the page and try again."
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "Timeout", "The request took too long. Please try again later."
+	default:
+		return http.StatusInternalServerError, "Error", "Error. Please try again later."
+	}
+}
+
+// ErrorPageData returns the data of the error pages of RenderError, e.g. the User for the navigation of the layout.
+// The users module sets it, utils does not know the user.
+var ErrorPageData = func(r *http.Request) TplData {
+	return TplData{}
+}
+
+// RenderError renders the error page or the htmx error fragment for err, see ErrorStatus.
+// Internal errors are logged, their text is not shown to the user.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	status, title, message := ErrorStatus(err)
+	if status >= http.StatusInternalServerError {
+		Logger(r.Context()).Error("RenderError", "method", r.Method, "path", r.URL.Path, "status", status, "err", err)
+	}
+	RenderErrorPage(w, r, status, title, message, ErrorPageData(r))
+}
+
+// RenderErrorPage renders the error page with the layout, or only the error fragment for htmx requests.
+// htmx swaps 4xx and 5xx responses into the target, see htmx-config in layout.html.
+// data is passed to the templates, e.g. the User for the navigation of the layout.
+func RenderErrorPage(w http.ResponseWriter, r *http.Request, status int, title string, message string, data TplData) {
+	if data == nil {
+		data = TplData{}
+	}
+	data["Title"] = title
+	data["Message"] = message
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if IsHtmxRequest(r) {
+		RenderTemplateWithoutLayout(w, "components/error_block", "components/error_block", data)
+		return
+	}
+	RenderTemplate(w, "error", data)
+}
+
+func IsHtmxRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
@@ -0,0 +1,90 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils --tags=unit -cover -run TestErrors.*
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors_ErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"InvalidInput", ErrInvalidInput, http.StatusBadRequest},
+		{"NotFound", fmt.Errorf("task 1: %w", ErrNotFound), http.StatusNotFound},
+		{"Forbidden", fmt.Errorf("task 1: %w", ErrForbidden), http.StatusForbidden},
+		{"Conflict", fmt.Errorf("record overlaps: %w", ErrConflict), http.StatusConflict},
+		{"Timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"Internal", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, title, message := ErrorStatus(tt.err)
+
+			assert.Equal(t, tt.status, status)
+			assert.NotEmpty(t, title)
+			assert.NotEmpty(t, message)
+			assert.NotContains(t, message, tt.err.Error())
+		})
+	}
+}
+
+func TestErrors_RenderErrorPage(t *testing.T) {
+	SetAppDir()
+
+	t.Run("Page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+
+		RenderErrorPage(w, r, http.StatusNotFound, "Not Found", "The requested item was not found.", nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "<html")
+		assert.Contains(t, w.Body.String(), "<title>Not Found</title>")
+		assert.Contains(t, w.Body.String(), "The requested item was not found.")
+	})
+
+	t.Run("HtmxFragment", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		r.Header.Set("HX-Request", "true")
+
+		RenderErrorPage(w, r, http.StatusInternalServerError, "Error", "Error. Please try again later.", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "<html")
+		assert.Contains(t, w.Body.String(), `role="alert"`)
+		assert.Contains(t, w.Body.String(), "Error. Please try again later.")
+	})
+}
+
+func TestErrors_RenderError(t *testing.T) {
+	SetAppDir()
+	prev := ErrorPageData
+	t.Cleanup(func() { ErrorPageData = prev })
+	var called bool
+	ErrorPageData = func(r *http.Request) TplData {
+		called = true
+		return TplData{}
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+	r.Header.Set("HX-Request", "true")
+
+	RenderError(w, r, fmt.Errorf("task 1: %w", ErrNotFound))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "The requested item was not found.")
+	assert.True(t, called)
+}
@@ -0,0 +1,117 @@
+// Package ical writes the iCalendar (RFC 5545) files of the calendar feed and reads the files of the import.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The times of DTSTART, DTEND and DTSTAMP in UTC
+const timeLayout = "20060102T150405Z"
+
+// The content lines longer than 75 octets are folded
+const maxLineOctets = 75
+
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Categories  []string
+	// Set by Parse: DTSTART is a date, Start and End are midnights
+	AllDay bool
+	// Set by Parse: the value of RRULE, e.g. "FREQ=WEEKLY;BYDAY=MO". Start and End are the first occurrence.
+	RRule string
+	// Set by Parse: the occurrences of EXDATE excluded from RRULE
+	ExDates []time.Time
+	// Set by Parse: the occurrence of the recurring event with the same UID that this event replaces
+	RecurrenceID time.Time
+}
+
+type Calendar struct {
+	// X-WR-CALNAME, the name shown by the calendar apps
+	Name   string
+	Events []Event
+}
+
+// Write writes the calendar as a VCALENDAR of VEVENTs. now is DTSTAMP of the events.
+func Write(w io.Writer, calendar Calendar, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Time Tracker//Calendar Feed//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeText(calendar.Name),
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"X-PUBLISHED-TTL:PT1H",
+	}
+	dtstamp := now.UTC().Format(timeLayout)
+	for _, event := range calendar.Events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+escapeText(event.UID),
+			"DTSTAMP:"+dtstamp,
+			"DTSTART:"+event.Start.UTC().Format(timeLayout),
+			"DTEND:"+event.End.UTC().Format(timeLayout),
+			"SUMMARY:"+escapeText(event.Summary),
+		)
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := bw.WriteString(foldLine(line)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Escapes the TEXT value: backslash, semicolon, comma and newline
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// Splits the line into the lines of at most maxLineOctets, the next lines start with a space.
+// A UTF-8 character is not split. Returns the lines with CRLF.
+func foldLine(line string) string {
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The space of the continuation line is counted
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
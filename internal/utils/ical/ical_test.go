@@ -0,0 +1,90 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/ical --tags=unit -cover
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	calendar := Calendar{
+		Name: "Time Tracker",
+		Events: []Event{
+			{
+				UID:         "record-1@time-tracker",
+				Start:       time.Date(2024, 12, 2, 10, 0, 0, 0, moscow),
+				End:         time.Date(2024, 12, 2, 11, 30, 0, 0, moscow),
+				Summary:     "Meeting; team, weekly",
+				Description: "Line 1\nLine 2 \\ end",
+				Categories:  []string{"#FF5733"},
+			},
+			{
+				UID:     "record-2@time-tracker",
+				Start:   time.Date(2024, 12, 3, 9, 0, 0, 0, time.UTC),
+				End:     time.Date(2024, 12, 3, 10, 0, 0, 0, time.UTC),
+				Summary: "Coding",
+			},
+		},
+	}
+
+	var b strings.Builder
+	err = Write(&b, calendar, time.Date(2024, 12, 4, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Time Tracker//Calendar Feed//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Time Tracker",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT1H",
+		"X-PUBLISHED-TTL:PT1H",
+		"BEGIN:VEVENT",
+		"UID:record-1@time-tracker",
+		"DTSTAMP:20241204T120000Z",
+		"DTSTART:20241202T070000Z",
+		"DTEND:20241202T083000Z",
+		`SUMMARY:Meeting\; team\, weekly`,
+		`DESCRIPTION:Line 1\nLine 2 \\ end`,
+		"CATEGORIES:#FF5733",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:record-2@time-tracker",
+		"DTSTAMP:20241204T120000Z",
+		"DTSTART:20241203T090000Z",
+		"DTEND:20241203T100000Z",
+		"SUMMARY:Coding",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, expected, b.String())
+}
+
+func TestFoldLine(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short\r\n", foldLine("SUMMARY:short"))
+
+	line := "DESCRIPTION:" + strings.Repeat("a", 100)
+	folded := foldLine(line)
+	lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	require.Len(t, lines, 2)
+	assert.Len(t, lines[0], 75)
+	assert.Equal(t, " ", lines[1][:1])
+	assert.Equal(t, line, lines[0]+lines[1][1:])
+
+	// The 2-byte characters are not split
+	line = "SUMMARY:" + strings.Repeat("я", 100)
+	for _, l := range strings.Split(strings.TrimSuffix(foldLine(line), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(l), 75)
+		assert.True(t, strings.HasSuffix(l, "я"), l)
+	}
+}
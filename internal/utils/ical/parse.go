@@ -0,0 +1,253 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendar = errors.New("ical: invalid calendar")
+
+// The longest unfolded content line, e.g. a DESCRIPTION with an embedded image
+const maxParseLineBytes = 1 << 20
+
+// Parse reads the VEVENTs of the calendar. The times are moments: UTC for the "Z" times,
+// the location of TZID, or loc for the floating times and the dates.
+// A TZID that is not an IANA time zone, e.g. of Outlook, is read as loc.
+// The cancelled events are skipped. The recurring events are not expanded, see Event.RRule. An event without DTEND and DURATION ends when it starts,
+// or on the next day for a date.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("%w: no BEGIN:VCALENDAR", ErrInvalidCalendar)
+	}
+
+	var events []Event
+	var event *Event
+	var duration *time.Duration
+	var hasEnd, cancelled bool
+	// The components inside the VEVENT, e.g. VALARM
+	nested := 0
+	for i, line := range lines {
+		name, params, value := parseContentLine(line)
+		switch {
+		case name == "BEGIN" && event != nil:
+			nested++
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = &Event{}
+			duration, hasEnd, cancelled = nil, false, false
+		case name == "END" && event != nil && nested > 0:
+			nested--
+		case name == "END" && event != nil:
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("%w: line %d: VEVENT without DTSTART", ErrInvalidCalendar, i+1)
+			}
+			switch {
+			case duration != nil:
+				event.End = event.Start.Add(*duration)
+			case !hasEnd && event.AllDay:
+				event.End = event.Start.AddDate(0, 0, 1)
+			case !hasEnd:
+				event.End = event.Start
+			}
+			if !cancelled {
+				events = append(events, *event)
+			}
+			event = nil
+		case event == nil || nested > 0:
+			// The properties of VCALENDAR, VTIMEZONE and VALARM
+		case name == "UID":
+			event.UID = unescapeText(value)
+		case name == "SUMMARY":
+			event.Summary = unescapeText(value)
+		case name == "DESCRIPTION":
+			event.Description = unescapeText(value)
+		case name == "DTSTART":
+			event.Start, event.AllDay, err = parseDateTime(params, value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: DTSTART: %w", ErrInvalidCalendar, i+1, err)
+			}
+		case name == "DTEND":
+			event.End, _, err = parseDateTime(params, value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: DTEND: %w", ErrInvalidCalendar, i+1, err)
+			}
+			hasEnd = true
+		case name == "DURATION":
+			d, err := parseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: DURATION: %w", ErrInvalidCalendar, i+1, err)
+			}
+			duration = &d
+		case name == "RRULE":
+			event.RRule = value
+		case name == "EXDATE":
+			for _, exDateValue := range strings.Split(value, ",") {
+				exDate, _, err := parseDateTime(params, exDateValue, loc)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: EXDATE: %w", ErrInvalidCalendar, i+1, err)
+				}
+				event.ExDates = append(event.ExDates, exDate)
+			}
+		case name == "RECURRENCE-ID":
+			event.RecurrenceID, _, err = parseDateTime(params, value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: RECURRENCE-ID: %w", ErrInvalidCalendar, i+1, err)
+			}
+		case name == "STATUS":
+			cancelled = strings.EqualFold(value, "CANCELLED")
+		}
+	}
+	if event != nil {
+		return nil, fmt.Errorf("%w: no END:VEVENT", ErrInvalidCalendar)
+	}
+	return events, nil
+}
+
+// Joins the folded lines: a line starting with a space or a tab continues the previous one
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxParseLineBytes)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCalendar, err)
+	}
+	return lines, nil
+}
+
+// Splits "NAME;PARAM=value;PARAM2="quoted":value". The name and the parameter names are upper case.
+func parseContentLine(line string) (name string, params map[string]string, value string) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		key, paramValue, _ := strings.Cut(part, "=")
+		params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+	return name, params, line[colon+1:]
+}
+
+// Reads DATE-TIME and DATE values. allDay is true for a DATE.
+func parseDateTime(params map[string]string, value string, loc *time.Location) (t time.Time, allDay bool, err error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len("20060102") {
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(timeLayout, value)
+		return t, false, err
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		if tzLoc, err := time.LoadLocation(tzid); err == nil {
+			loc = tzLoc
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// Reads the DURATION value: [+-]P[nW] or [+-]P[nD][T[nH][nM][nS]]
+func parseDuration(value string) (time.Duration, error) {
+	s := value
+	sign := time.Duration(1)
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	number := ""
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'T' && !inTime && number == "":
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+		switch {
+		case c == 'W' && !inTime:
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			d += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * d, nil
+}
+
+// The inverse of escapeText
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
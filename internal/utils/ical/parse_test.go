@@ -0,0 +1,175 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/ical --tags=unit -cover -run TestParse.*
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	file := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//EN",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Berlin",
+		"BEGIN:STANDARD",
+		"DTSTART:19701025T030000",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:1@example.com",
+		"DTSTART:20241202T070000Z",
+		"DTEND:20241202T083000Z",
+		`SUMMARY:Meeting\; team\, weekly`,
+		"DESCRIPTION:Line 1\\nLine 2 \\\\ end. A long description that is folded a",
+		" t 75 octets",
+		"BEGIN:VALARM",
+		"DESCRIPTION:Reminder",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:2@example.com",
+		`DTSTART;TZID="Europe/Berlin":20241203T090000`,
+		"DURATION:PT1H30M",
+		"SUMMARY:Standup",
+		"RRULE:FREQ=DAILY",
+		"EXDATE;TZID=Europe/Berlin:20241204T090000,20241205T090000",
+		"EXDATE;TZID=Europe/Berlin:20241206T090000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:2@example.com",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20241207T090000",
+		`DTSTART;TZID="Europe/Berlin":20241207T110000`,
+		"DURATION:PT1H",
+		"SUMMARY:Standup moved",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:3@example.com",
+		"DTSTART;VALUE=DATE:20241204",
+		"SUMMARY:Holiday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:4@example.com",
+		"DTSTART:20241205T100000",
+		"DTEND;TZID=W. Europe Standard Time:20241205T110000",
+		"SUMMARY:Floating",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:5@example.com",
+		"DTSTART:20241206T100000Z",
+		"DTEND:20241206T110000Z",
+		"STATUS:CANCELLED",
+		"SUMMARY:Cancelled",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	events, err := Parse(strings.NewReader(file), moscow)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+
+	assert.Equal(t, "1@example.com", events[0].UID)
+	assert.True(t, events[0].Start.Equal(time.Date(2024, 12, 2, 7, 0, 0, 0, time.UTC)))
+	assert.True(t, events[0].End.Equal(time.Date(2024, 12, 2, 8, 30, 0, 0, time.UTC)))
+	assert.Equal(t, "Meeting; team, weekly", events[0].Summary)
+	assert.Equal(t, "Line 1\nLine 2 \\ end. A long description that is folded at 75 octets", events[0].Description)
+	assert.False(t, events[0].AllDay)
+	assert.Empty(t, events[0].RRule)
+	assert.True(t, events[0].RecurrenceID.IsZero())
+
+	assert.True(t, events[1].Start.Equal(time.Date(2024, 12, 3, 9, 0, 0, 0, berlin)))
+	assert.True(t, events[1].End.Equal(time.Date(2024, 12, 3, 10, 30, 0, 0, berlin)))
+	assert.Equal(t, "FREQ=DAILY", events[1].RRule)
+	require.Len(t, events[1].ExDates, 3)
+	assert.True(t, events[1].ExDates[0].Equal(time.Date(2024, 12, 4, 9, 0, 0, 0, berlin)))
+	assert.True(t, events[1].ExDates[2].Equal(time.Date(2024, 12, 6, 9, 0, 0, 0, berlin)))
+
+	assert.Equal(t, "2@example.com", events[2].UID)
+	assert.True(t, events[2].RecurrenceID.Equal(time.Date(2024, 12, 7, 9, 0, 0, 0, berlin)))
+	assert.True(t, events[2].Start.Equal(time.Date(2024, 12, 7, 11, 0, 0, 0, berlin)))
+	assert.Empty(t, events[2].RRule)
+
+	assert.True(t, events[3].AllDay)
+	assert.True(t, events[3].Start.Equal(time.Date(2024, 12, 4, 0, 0, 0, 0, moscow)))
+	assert.True(t, events[3].End.Equal(time.Date(2024, 12, 5, 0, 0, 0, 0, moscow)))
+
+	// The floating time and the unknown TZID are in the location of the user
+	assert.True(t, events[4].Start.Equal(time.Date(2024, 12, 5, 10, 0, 0, 0, moscow)))
+	assert.True(t, events[4].End.Equal(time.Date(2024, 12, 5, 11, 0, 0, 0, moscow)))
+}
+
+func TestParse_WrittenCalendar(t *testing.T) {
+	calendar := Calendar{
+		Name: "Time Tracker",
+		Events: []Event{{
+			UID:         "record-1@time-tracker",
+			Start:       time.Date(2024, 12, 2, 7, 0, 0, 0, time.UTC),
+			End:         time.Date(2024, 12, 2, 8, 30, 0, 0, time.UTC),
+			Summary:     "Meeting; team, weekly " + strings.Repeat("я", 50),
+			Description: "Line 1\nLine 2 \\ end",
+		}},
+	}
+	var b strings.Builder
+	require.NoError(t, Write(&b, calendar, time.Now()))
+
+	events, err := Parse(strings.NewReader(b.String()), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, calendar.Events[0].Summary, events[0].Summary)
+	assert.Equal(t, calendar.Events[0].Description, events[0].Description)
+	assert.True(t, calendar.Events[0].Start.Equal(events[0].Start))
+	assert.True(t, calendar.Events[0].End.Equal(events[0].End))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	testCases := map[string]string{
+		"NotCalendar":     "Subject,Start Date\nMeeting,2024-12-02",
+		"Empty":           "",
+		"NoDTSTART":       "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR",
+		"InvalidDTSTART":  "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2024-12-02\nEND:VEVENT\nEND:VCALENDAR",
+		"InvalidDuration": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20241202T070000Z\nDURATION:1H\nEND:VEVENT\nEND:VCALENDAR",
+		"NoEND":           "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20241202T070000Z",
+	}
+	for name, file := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(file), time.UTC)
+			assert.ErrorIs(t, err, ErrInvalidCalendar)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"PT1H30M":   90 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1DT2H":    26 * time.Hour,
+		"P2W":       14 * 24 * time.Hour,
+		"PT45S":     45 * time.Second,
+		"-PT15M":    -15 * time.Minute,
+		"+PT1H0M0S": time.Hour,
+	}
+	for value, expected := range testCases {
+		d, err := parseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, d, value)
+	}
+
+	for _, value := range []string{"", "P", "PT", "1H", "PT1", "PTH", "P1H", "PT1D"} {
+		_, err := parseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
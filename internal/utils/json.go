@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RenderJSON writes data as the JSON response of the API
+func RenderJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		Logger(nil).Warn("RenderJSON Encode", "err", err)
+	}
+}
+
+// RenderErrorJSON is RenderErrorPage of the API: {"error": "<message>"} with the status of ErrorStatus.
+// The errors of the storage are logged, their text is not shown.
+func RenderErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	status, _, message := ErrorStatus(err)
+	if status >= http.StatusInternalServerError {
+		Logger(r.Context()).Error("RenderErrorJSON", "method", r.Method, "path", r.URL.Path, "status", status, "err", err)
+	}
+	RenderJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,39 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils --tags=unit -cover -run TestJSON.*
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON_RenderJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	RenderJSON(w, http.StatusCreated, map[string]int{"id": 1})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"id":1}`, w.Body.String())
+}
+
+func TestJSON_RenderErrorJSON(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+
+	w := httptest.NewRecorder()
+	RenderErrorJSON(w, r, fmt.Errorf("task 1: %w", ErrNotFound))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"The requested item was not found."}`, w.Body.String())
+
+	// The text of the internal errors is not shown
+	w = httptest.NewRecorder()
+	RenderErrorJSON(w, r, errors.New("connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "connection refused")
+}
@@ -7,12 +7,17 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 )
 
 type LogHandlerDev struct {
 	slog.Handler
 	log *log.Logger
+	// Attributes of logger.With, e.g. request_id, route and user_id of the request logger
+	attrs []slog.Attr
+	// Prefix of the keys of logger.WithGroup
+	group string
 }
 
 var D = slog.Debug
@@ -29,6 +34,27 @@ func NewLogHandlerDev() *LogHandlerDev {
 	return h
 }
 
+// WithAttrs keeps the dev format for the loggers of logger.With, the embedded JSON handler would replace it
+func (h *LogHandlerDev) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.Handler = h.Handler.WithAttrs(attrs)
+	h2.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, slog.Attr{Key: h.group + a.Key, Value: a.Value})
+	}
+	return &h2
+}
+
+func (h *LogHandlerDev) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.Handler = h.Handler.WithGroup(name)
+	h2.group = h.group + name + "."
+	return &h2
+}
+
 func (h *LogHandlerDev) Handle(ctx context.Context, r slog.Record) error {
 	level := r.Level.String() + ":"
 
@@ -49,7 +75,7 @@ func (h *LogHandlerDev) Handle(ctx context.Context, r slog.Record) error {
 	if r.NumAttrs() == 1 {
 		r.Attrs(func(a slog.Attr) bool {
 			if a.Key == "!BADKEY" {
-				val := a.Value.Any()
+				val := attrValue(a.Value)
 				oneParams = fmt.Sprintf(" %s%T%s = %s%+v%s\n",
 					Colors.Blue, val, Colors.Reset, // Type
 					Colors.Yellow, val, Colors.Reset) // Value
@@ -60,7 +86,7 @@ func (h *LogHandlerDev) Handle(ctx context.Context, r slog.Record) error {
 
 	timeStr := r.Time.Format("[15:04:05.000]")
 	msg := highlightPanicAndApp(r.Message)
-	h.log.Println(timeStr, level, StrColor(msg, Colors.Green), oneParams)
+	h.log.Println(timeStr, level, StrColor(msg, Colors.Green)+h.formatAttrs(), oneParams)
 
 	if oneParams != "" {
 		return nil
@@ -69,8 +95,8 @@ func (h *LogHandlerDev) Handle(ctx context.Context, r slog.Record) error {
 	// For pairs of parameters. With json.MarshalIndent
 	// slog.Debug("DashboardHandler", "tasks", tasks)
 	r.Attrs(func(a slog.Attr) bool {
-		key := a.Key
-		val := a.Value.Any()
+		key := h.group + a.Key
+		val := attrValue(a.Value)
 
 		h.log.Printf("%s%v:%s %s%T%s = %s%+v%s\n",
 			Colors.Green, key, Colors.Reset, // Name
@@ -89,6 +115,28 @@ func (h *LogHandlerDev) Handle(ctx context.Context, r slog.Record) error {
 	return nil
 }
 
+// formatAttrs shows the attributes of logger.With on the line of the message, e.g. " request_id=3f2a route=GET /tasks"
+func (h *LogHandlerDev) formatAttrs() string {
+	var b strings.Builder
+	for _, a := range h.attrs {
+		fmt.Fprintf(&b, " %s%s=%s%v", Colors.Blue, a.Key, Colors.Reset, a.Value)
+	}
+	return b.String()
+}
+
+// attrValue resolves slog.LogValuer, e.g. the config with the redacted secrets, and shows a group as a map
+func attrValue(v slog.Value) any {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	group := make(map[string]any, len(v.Group()))
+	for _, a := range v.Group() {
+		group[a.Key] = attrValue(a.Value)
+	}
+	return group
+}
+
 func highlightPanicAndApp(logMessage string) string {
 	lines := strings.Split(logMessage, "\n")
 	var highlightedLines []string
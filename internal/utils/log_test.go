@@ -194,3 +194,41 @@ func TestLogHighlightPanicAndApp(t *testing.T) {
 		})
 	}
 }
+
+func TestLogHandlerDevWithAttrs(t *testing.T) {
+	handler := NewLogHandlerDev()
+	var buf bytes.Buffer
+	handler.log = log.New(&buf, "", 0)
+
+	logger := slog.New(handler).With("request_id", "3f2a", "route", "GET /tasks").WithGroup("db").With("query", "tasks")
+	logger.Info("HTTP request")
+
+	firstLine, _, _ := strings.Cut(buf.String(), "\n")
+	for _, field := range []string{"HTTP request", "request_id=\x1b[0m3f2a", "route=\x1b[0mGET /tasks", "db.query=\x1b[0mtasks"} {
+		if !strings.Contains(firstLine, field) {
+			t.Errorf("Expected %q on the line of the message: %q", field, firstLine)
+		}
+	}
+	if _, ok := logger.Handler().(*LogHandlerDev); !ok {
+		t.Errorf("Expected *LogHandlerDev after With, got %T", logger.Handler())
+	}
+}
+
+type secretLogValue struct{}
+
+func (secretLogValue) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("DBHost", "postgres"), slog.String("DBPassword", "[REDACTED]"))
+}
+
+func TestLogHandlerDevLogValuer(t *testing.T) {
+	handler := NewLogHandlerDev()
+	var buf bytes.Buffer
+	handler.log = log.New(&buf, "", 0)
+
+	slog.New(handler).Info("Server start", "Config", secretLogValue{})
+
+	output := buf.String()
+	if !strings.Contains(output, `"DBPassword": "[REDACTED]"`) || !strings.Contains(output, `"DBHost": "postgres"`) {
+		t.Errorf("Expected the resolved group as JSON: %s", output)
+	}
+}
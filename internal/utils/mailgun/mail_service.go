@@ -3,15 +3,18 @@ package mailgun
 import (
 	"bytes"
 	"context"
-	"path/filepath"
-	"text/template"
+	"html/template"
 	"time"
+	"time-tracker/internal/utils"
 
 	"github.com/mailgun/mailgun-go/v4"
 )
 
-var activationTplPath = filepath.Join("web", "templates", "email", "activation.html")
-var loginWithTokenTplPath = filepath.Join("web", "templates", "email", "login-with-token.html")
+// Paths in utils.TemplatesFS
+var activationTplPath = "email/activation.html"
+var loginWithTokenTplPath = "email/login-with-token.html"
+var emailChangeTplPath = "email/email-change.html"
+var emailChangedTplPath = "email/email-changed.html"
 
 // For tests
 type MailgunClient interface {
@@ -36,8 +39,8 @@ func NewMailService(domain, apiKey, emailFrom string) *MailService {
 	}
 }
 
-func (ms *MailService) sendEmail(to string, subject string, body string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+func (ms *MailService) sendEmail(ctx context.Context, to string, subject string, body string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
 	message := mailgun.NewMessage(ms.emailFrom, subject, "", to)
@@ -55,8 +58,8 @@ func (ms *MailService) Ping() error {
 	return err
 }
 
-func (ms *MailService) SendActivationEmail(email, name, link string) error {
-	tmpl, err := template.ParseFiles(activationTplPath)
+func (ms *MailService) SendActivationEmail(ctx context.Context, email, name, link string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), activationTplPath)
 	if err != nil {
 		return err
 	}
@@ -75,14 +78,15 @@ func (ms *MailService) SendActivationEmail(email, name, link string) error {
 	}
 
 	return ms.sendEmail(
+		ctx,
 		email,
 		"Activating an account in Time Tracker",
 		bodyBuffer.String(),
 	)
 }
 
-func (ms *MailService) SendLoginWithTokenEmail(email, name, link string) error {
-	tmpl, err := template.ParseFiles(loginWithTokenTplPath)
+func (ms *MailService) SendLoginWithTokenEmail(ctx context.Context, email, name, link string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), loginWithTokenTplPath)
 	if err != nil {
 		return err
 	}
@@ -101,8 +105,63 @@ func (ms *MailService) SendLoginWithTokenEmail(email, name, link string) error {
 	}
 
 	return ms.sendEmail(
+		ctx,
 		email,
 		"Login to Your Time Tracker Account",
 		bodyBuffer.String(),
 	)
 }
+
+func (ms *MailService) SendEmailChangeEmail(ctx context.Context, email, name, link string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), emailChangeTplPath)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name        string
+		ConfirmLink string
+	}{
+		Name:        name,
+		ConfirmLink: link,
+	}
+
+	var bodyBuffer bytes.Buffer
+	if err := tmpl.Execute(&bodyBuffer, data); err != nil {
+		return err
+	}
+
+	return ms.sendEmail(
+		ctx,
+		email,
+		"Confirm Your New Email in Time Tracker",
+		bodyBuffer.String(),
+	)
+}
+
+func (ms *MailService) SendEmailChangedEmail(ctx context.Context, email, name, newEmail string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), emailChangedTplPath)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name     string
+		NewEmail string
+	}{
+		Name:     name,
+		NewEmail: newEmail,
+	}
+
+	var bodyBuffer bytes.Buffer
+	if err := tmpl.Execute(&bodyBuffer, data); err != nil {
+		return err
+	}
+
+	return ms.sendEmail(
+		ctx,
+		email,
+		"Your Email in Time Tracker Has Been Changed",
+		bodyBuffer.String(),
+	)
+}
@@ -6,8 +6,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"testing"
+	"time-tracker/internal/utils"
 
 	"github.com/mailgun/mailgun-go/v4"
 	"github.com/stretchr/testify/assert"
@@ -98,13 +98,15 @@ func TestMailService_SendEmail_Error(t *testing.T) {
 		}),
 	).Return("", "", fmt.Errorf("mock Mailgun send error"))
 
-	err := mailService.sendEmail("user@example.com", "Test Subject", "Test Body")
+	err := mailService.sendEmail(context.Background(), "user@example.com", "Test Subject", "Test Body")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "mock Mailgun send error")
 }
 
 func TestMailService_SendActivationEmail(t *testing.T) {
-	SetAppDir()
+	// The templates are embedded, the working directory does not matter
+	os.Chdir(t.TempDir())
+	defer SetAppDir()
 	mockClient := new(MockMailgunClient)
 	mailService := &MailService{
 		client:    mockClient,
@@ -120,7 +122,7 @@ func TestMailService_SendActivationEmail(t *testing.T) {
 		}),
 	).Return("id", "message", nil)
 
-	err := mailService.SendActivationEmail("user@example.com", "John Doe", "http://activation-link")
+	err := mailService.SendActivationEmail(context.Background(), "user@example.com", "John Doe", "http://activation-link")
 	assert.NoError(t, err)
 	mockClient.AssertExpectations(t)
 }
@@ -142,7 +144,51 @@ func TestMailService_SendLoginWithTokenEmail(t *testing.T) {
 		}),
 	).Return("id", "message", nil)
 
-	err := mailService.SendLoginWithTokenEmail("user@example.com", "John Doe", "http://login-link")
+	err := mailService.SendLoginWithTokenEmail(context.Background(), "user@example.com", "John Doe", "http://login-link")
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestMailService_SendEmailChangeEmail(t *testing.T) {
+	SetAppDir()
+	mockClient := new(MockMailgunClient)
+	mailService := &MailService{
+		client:    mockClient,
+		emailFrom: "noreply@example.com",
+		domain:    "example.com",
+	}
+
+	mockClient.On(
+		"Send",
+		mock.Anything,
+		mock.MatchedBy(func(m *mailgun.Message) bool {
+			return m != nil
+		}),
+	).Return("id", "message", nil)
+
+	err := mailService.SendEmailChangeEmail(context.Background(), "user@example.com", "John Doe", "http://confirm-link")
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestMailService_SendEmailChangedEmail(t *testing.T) {
+	SetAppDir()
+	mockClient := new(MockMailgunClient)
+	mailService := &MailService{
+		client:    mockClient,
+		emailFrom: "noreply@example.com",
+		domain:    "example.com",
+	}
+
+	mockClient.On(
+		"Send",
+		mock.Anything,
+		mock.MatchedBy(func(m *mailgun.Message) bool {
+			return m != nil
+		}),
+	).Return("id", "message", nil)
+
+	err := mailService.SendEmailChangedEmail(context.Background(), "user@example.com", "John Doe", "new@example.com")
 	assert.NoError(t, err)
 	mockClient.AssertExpectations(t)
 }
@@ -163,22 +209,28 @@ func TestMailService_TemplateError(t *testing.T) {
 		}),
 	).Return("id", "message", nil)
 
-	os.Chdir("/tmp")
-	err := mailService.SendActivationEmail("user@example.com", "John Doe", "http://link")
+	// No templates on the disk
+	utils.SetWebDir(t.TempDir())
+	defer utils.SetWebDir("")
+	err := mailService.SendActivationEmail(context.Background(), "user@example.com", "John Doe", "http://link")
+	assert.Error(t, err)
+	err = mailService.SendLoginWithTokenEmail(context.Background(), "user@example.com", "John Doe", "http://link")
 	assert.Error(t, err)
-	err = mailService.SendLoginWithTokenEmail("user@example.com", "John Doe", "http://link")
+	err = mailService.SendEmailChangeEmail(context.Background(), "user@example.com", "John Doe", "http://link")
+	assert.Error(t, err)
+	err = mailService.SendEmailChangedEmail(context.Background(), "user@example.com", "John Doe", "new@example.com")
 	assert.Error(t, err)
 
-	SetAppDir()
+	utils.SetWebDir("")
 	oldActivationTplPath := activationTplPath
-	activationTplPath = filepath.Join("web", "templates", "test", "missing_template.html")
-	err = mailService.SendActivationEmail("user@example.com", "John Doe", "http://link")
+	activationTplPath = "test/missing_template.html"
+	err = mailService.SendActivationEmail(context.Background(), "user@example.com", "John Doe", "http://link")
 	assert.Error(t, err)
 	activationTplPath = oldActivationTplPath
 
 	oldLoginWithTokenTplPath := loginWithTokenTplPath
-	loginWithTokenTplPath = filepath.Join("web", "templates", "test", "missing_template.html")
-	err = mailService.SendLoginWithTokenEmail("user@example.com", "John Doe", "http://link")
+	loginWithTokenTplPath = "test/missing_template.html"
+	err = mailService.SendLoginWithTokenEmail(context.Background(), "user@example.com", "John Doe", "http://link")
 	assert.Error(t, err)
 	loginWithTokenTplPath = oldLoginWithTokenTplPath
 }
@@ -0,0 +1,105 @@
+// Package metrics collects the Prometheus metrics of the server, served by Handler on /metrics
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"time-tracker/internal/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "time_tracker"
+
+// Registry has the metrics of this package and of the Go runtime.
+// Collectors of the storage are added by main, see NewPgxPoolCollector and NewRedisPoolCollector.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by the route pattern and the status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mailSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mail_sent_total",
+		Help:      "Emails sent by the type and the result, success or failure.",
+	}, []string{"type", "result"})
+
+	RecordsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "records_created_total",
+		Help:      "Records created by the users.",
+	})
+
+	TasksCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_created_total",
+		Help:      "Tasks created by the users.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		mailSent,
+		RecordsCreated,
+		TasksCreated,
+	)
+}
+
+// Handler serves the metrics of Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// MailSent counts the email of the type, e.g. "activation", as a failure if err is not nil
+func MailSent(mailType string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	mailSent.WithLabelValues(mailType, result).Inc()
+}
+
+// NewCountFunc is a gauge that calls count on every scrape, e.g. the records in progress in the database.
+// An error of count is logged and the gauge is NaN, so a failed query does not look like 0.
+func NewCountFunc(name, help string, timeout time.Duration, count func(ctx context.Context) (int, error)) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		n, err := count(ctx)
+		if err != nil {
+			slog.Error("metrics count", "name", name, "err", err)
+			return math.NaN()
+		}
+		return float64(n)
+	})
+}
+
+// Middleware observes the duration and the status of every request by the pattern of routes, e.g. "GET /records/{id}".
+// The pattern keeps the number of label values small, unlike the path with IDs.
+func Middleware(next http.Handler, routes utils.Routes) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := utils.RoutePattern(routes, r)
+		sw := utils.NewStatusWriter(w)
+		start := time.Now()
+		next.ServeHTTP(sw, r)
+		httpRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(sw.Status)).Observe(time.Since(start).Seconds())
+	})
+}
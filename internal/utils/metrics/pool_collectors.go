@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// poolMetric reads one value of the stats of the pool on every scrape
+type poolMetric[S any] struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(stats S) float64
+}
+
+type poolCollector[S any] struct {
+	stats   func() S
+	metrics []poolMetric[S]
+}
+
+func (c *poolCollector[S]) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+}
+
+func (c *poolCollector[S]) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	for _, m := range c.metrics {
+		ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, m.value(stats))
+	}
+}
+
+func newPoolMetric[S any](subsystem, name, help string, valueType prometheus.ValueType, value func(stats S) float64) poolMetric[S] {
+	return poolMetric[S]{
+		desc:      prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, nil, nil),
+		valueType: valueType,
+		value:     value,
+	}
+}
+
+// NewPgxPoolCollector exports pool.Stat() of the Postgres connections
+func NewPgxPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	const subsystem = "pgxpool"
+	gauge, counter := prometheus.GaugeValue, prometheus.CounterValue
+	return &poolCollector[*pgxpool.Stat]{
+		stats: pool.Stat,
+		metrics: []poolMetric[*pgxpool.Stat]{
+			newPoolMetric(subsystem, "acquired_conns", "Connections acquired by the queries.", gauge,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+			newPoolMetric(subsystem, "idle_conns", "Idle connections.", gauge,
+				func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+			newPoolMetric(subsystem, "total_conns", "All connections of the pool.", gauge,
+				func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+			newPoolMetric(subsystem, "max_conns", "Maximum size of the pool.", gauge,
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+			newPoolMetric(subsystem, "acquire_total", "Successful acquires of a connection.", counter,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+			newPoolMetric(subsystem, "acquire_duration_seconds_total", "Time spent waiting for a connection.", counter,
+				func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+			newPoolMetric(subsystem, "empty_acquire_total", "Acquires that waited because the pool was empty.", counter,
+				func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+			newPoolMetric(subsystem, "canceled_acquire_total", "Acquires canceled by the context.", counter,
+				func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+		},
+	}
+}
+
+// NewRedisPoolCollector exports client.PoolStats() of the Redis connections
+func NewRedisPoolCollector(client *redis.Client) prometheus.Collector {
+	const subsystem = "redis_pool"
+	gauge, counter := prometheus.GaugeValue, prometheus.CounterValue
+	return &poolCollector[*redis.PoolStats]{
+		stats: client.PoolStats,
+		metrics: []poolMetric[*redis.PoolStats]{
+			newPoolMetric(subsystem, "hits_total", "Free connections found in the pool.", counter,
+				func(s *redis.PoolStats) float64 { return float64(s.Hits) }),
+			newPoolMetric(subsystem, "misses_total", "Free connections not found in the pool.", counter,
+				func(s *redis.PoolStats) float64 { return float64(s.Misses) }),
+			newPoolMetric(subsystem, "timeouts_total", "Waits for a connection that timed out.", counter,
+				func(s *redis.PoolStats) float64 { return float64(s.Timeouts) }),
+			newPoolMetric(subsystem, "total_conns", "All connections of the pool.", gauge,
+				func(s *redis.PoolStats) float64 { return float64(s.TotalConns) }),
+			newPoolMetric(subsystem, "idle_conns", "Idle connections.", gauge,
+				func(s *redis.PoolStats) float64 { return float64(s.IdleConns) }),
+			newPoolMetric(subsystem, "stale_conns", "Stale connections removed from the pool.", counter,
+				func(s *redis.PoolStats) float64 { return float64(s.StaleConns) }),
+		},
+	}
+}
@@ -0,0 +1,132 @@
+// Package migrate runs the goose migrations embedded into the binary, without the goose CLI
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Commands of Run, the same as of the goose CLI
+const (
+	CommandUp     = "up"
+	CommandDown   = "down"
+	CommandStatus = "status"
+	CommandRedo   = "redo"
+)
+
+var ErrUnknownCommand = errors.New("unknown migrate command, expected up, down, status or redo")
+
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// For goose.DialectPostgres all commands hold a session advisory lock,
+// so instances started together during a rolling deploy apply the migrations once, the others wait.
+func New(db *sql.DB, dialect goose.Dialect, migrations fs.FS) (*Migrator, error) {
+	options := []goose.ProviderOption{}
+	if dialect == goose.DialectPostgres {
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, fmt.Errorf("migrate NewPostgresSessionLocker: %w", err)
+		}
+		options = append(options, goose.WithSessionLocker(locker))
+	}
+	provider, err := goose.NewProvider(dialect, db, migrations, options...)
+	if err != nil {
+		return nil, fmt.Errorf("migrate NewProvider: %w", err)
+	}
+	return &Migrator{provider: provider}, nil
+}
+
+// Run runs the command, status is written to out
+func (m *Migrator) Run(ctx context.Context, command string, out io.Writer) error {
+	switch command {
+	case CommandUp:
+		return m.Up(ctx)
+	case CommandDown:
+		return m.Down(ctx)
+	case CommandStatus:
+		return m.Status(ctx, out)
+	case CommandRedo:
+		return m.Redo(ctx)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, command)
+	}
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	for _, result := range results {
+		logResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	if len(results) == 0 {
+		slog.Info("Migrate: no pending migrations")
+	}
+	return nil
+}
+
+// Down rolls back the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	result, err := m.provider.Down(ctx)
+	if result != nil {
+		logResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+	return nil
+}
+
+// Redo rolls back the last applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) error {
+	if err := m.Down(ctx); err != nil {
+		return err
+	}
+	result, err := m.provider.UpByOne(ctx)
+	if result != nil {
+		logResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate redo: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context, out io.Writer) error {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate status: %w", err)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Applied At\tMigration")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", appliedAt, status.Source.Path)
+	}
+	return w.Flush()
+}
+
+func logResult(result *goose.MigrationResult) {
+	if result.Error != nil {
+		slog.Error("Migrate", "result", result.String(), "err", result.Error)
+		return
+	}
+	slog.Info("Migrate", "result", result.String())
+}
@@ -0,0 +1,98 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/migrate --tags=unit -cover
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time-tracker/db/migrations"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// The locker is only for Postgres, the commands are checked on SQLite
+func newTestMigrator(t *testing.T) (*Migrator, *sql.DB) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	testMigrations := fstest.MapFS{
+		"00001_create_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")},
+		"00002_create_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n")},
+	}
+	migrator, err := New(db, goose.DialectSQLite3, testMigrations)
+	require.NoError(t, err)
+	return migrator, db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1`, name).Scan(&count)
+	require.NoError(t, err)
+	return count == 1
+}
+
+func TestMigrator_Run(t *testing.T) {
+	ctx := context.Background()
+	migrator, db := newTestMigrator(t)
+	out := &bytes.Buffer{}
+
+	require.NoError(t, migrator.Run(ctx, CommandStatus, out))
+	assert.Regexp(t, `Pending +00001_create_a.sql`, out.String())
+
+	require.NoError(t, migrator.Run(ctx, CommandUp, out))
+	assert.True(t, tableExists(t, db, "a"))
+	assert.True(t, tableExists(t, db, "b"))
+	// Nothing to apply
+	require.NoError(t, migrator.Run(ctx, CommandUp, out))
+
+	require.NoError(t, migrator.Run(ctx, CommandDown, out))
+	assert.True(t, tableExists(t, db, "a"))
+	assert.False(t, tableExists(t, db, "b"))
+
+	require.NoError(t, migrator.Run(ctx, CommandRedo, out))
+	assert.True(t, tableExists(t, db, "a"))
+	assert.False(t, tableExists(t, db, "b"))
+
+	out.Reset()
+	require.NoError(t, migrator.Run(ctx, CommandStatus, out))
+	assert.NotRegexp(t, `Pending +00001_create_a.sql`, out.String())
+	assert.Regexp(t, `Pending +00002_create_b.sql`, out.String())
+
+	err := migrator.Run(ctx, "sideways", out)
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+}
+
+func TestMigrator_DownWithoutMigrations(t *testing.T) {
+	migrator, _ := newTestMigrator(t)
+
+	err := migrator.Run(context.Background(), CommandDown, &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	embedded, err := fs.Glob(migrations.FS, "*.sql")
+	require.NoError(t, err)
+	onDisk, err := filepath.Glob("../../../db/migrations/*.sql")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, embedded)
+	assert.Len(t, embedded, len(onDisk))
+	for _, path := range onDisk {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		embeddedData, err := fs.ReadFile(migrations.FS, filepath.Base(path))
+		require.NoError(t, err)
+		assert.Equal(t, data, embeddedData)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/oauth --tags=unit -cover -run TestGitHubProvider.*
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGitHubTestServer(emails string, userStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(userStatus)
+		w.Write([]byte(`{"id": 42, "login": "octocat", "name": ""}`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(emails))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestGitHubProvider(server *httptest.Server) *GitHubProvider {
+	provider := NewGitHubProvider("client-id", "secret", "http://localhost/oauth/github/callback")
+	provider.config.Endpoint.TokenURL = server.URL + "/login/oauth/access_token"
+	provider.apiURL = server.URL
+	return provider
+}
+
+func TestGitHubProvider_AuthCodeURL(t *testing.T) {
+	provider := NewGitHubProvider("client-id", "secret", "http://localhost/oauth/github/callback")
+	assert.Equal(t, "github", provider.Name())
+	assert.Equal(t, "GitHub", provider.Title())
+	assert.Contains(t, provider.AuthCodeURL("state-value", "nonce"), "state=state-value")
+}
+
+func TestGitHubProvider_Exchange(t *testing.T) {
+	t.Run("primary verified email", func(t *testing.T) {
+		server := newGitHubTestServer(`[
+			{"email": "other@example.com", "primary": false, "verified": true},
+			{"email": "octocat@example.com", "primary": true, "verified": true}
+		]`, http.StatusOK)
+		defer server.Close()
+
+		userInfo, err := newTestGitHubProvider(server).Exchange(context.Background(), "code", "")
+		require.NoError(t, err)
+		assert.Equal(t, &UserInfo{
+			Subject:       "42",
+			Email:         "octocat@example.com",
+			EmailVerified: true,
+			Name:          "octocat",
+		}, userInfo)
+	})
+
+	t.Run("primary email not verified", func(t *testing.T) {
+		server := newGitHubTestServer(`[{"email": "octocat@example.com", "primary": true, "verified": false}]`, http.StatusOK)
+		defer server.Close()
+
+		userInfo, err := newTestGitHubProvider(server).Exchange(context.Background(), "code", "")
+		require.NoError(t, err)
+		assert.False(t, userInfo.EmailVerified)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		server := newGitHubTestServer(`[]`, http.StatusInternalServerError)
+		defer server.Close()
+
+		_, err := newTestGitHubProvider(server).Exchange(context.Background(), "code", "")
+		assert.ErrorContains(t, err, "status 500")
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		server := newGitHubTestServer(`[]`, http.StatusOK)
+		defer server.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var user map[string]any
+		err := newTestGitHubProvider(server).getJSON(ctx, server.Client(), "/user", &user)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,77 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/oauth --tags=unit -cover -run TestOIDCProvider.*
+package oauth
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCProvider_NewOIDCProvider(t *testing.T) {
+	t.Run("discovery error", func(t *testing.T) {
+		_, err := NewOIDCProvider(context.Background(), "oidc", "OIDC", "http://127.0.0.1:1", "id", "secret", "http://localhost/callback")
+		assert.Error(t, err)
+	})
+}
+
+func TestOIDCProvider_AuthCodeURL(t *testing.T) {
+	issuer := NewMockIssuer("client-id")
+	defer issuer.Server.Close()
+
+	provider, err := NewOIDCProvider(context.Background(), "oidc", "OIDC", issuer.Server.URL, "client-id", "secret", "http://localhost/oauth/oidc/callback")
+	require.NoError(t, err)
+	assert.Equal(t, "oidc", provider.Name())
+	assert.Equal(t, "OIDC", provider.Title())
+
+	authURL, err := url.Parse(provider.AuthCodeURL("state-value", "nonce-value"))
+	require.NoError(t, err)
+	assert.Equal(t, issuer.Server.URL+"/auth", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	query := authURL.Query()
+	assert.Equal(t, "state-value", query.Get("state"))
+	assert.Equal(t, "nonce-value", query.Get("nonce"))
+	assert.Equal(t, "client-id", query.Get("client_id"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+}
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	issuer := NewMockIssuer("client-id")
+	defer issuer.Server.Close()
+
+	provider, err := NewOIDCProvider(context.Background(), "oidc", "OIDC", issuer.Server.URL, "client-id", "secret", "http://localhost/oauth/oidc/callback")
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		issuer.Claims = map[string]any{
+			"sub":            "subject-1",
+			"email":          "user@example.com",
+			"email_verified": true,
+			"name":           "User Name",
+		}
+		// The mock issuer puts the code into the nonce claim
+		userInfo, err := provider.Exchange(context.Background(), "nonce-value", "nonce-value")
+		require.NoError(t, err)
+		assert.Equal(t, &UserInfo{
+			Subject:       "subject-1",
+			Email:         "user@example.com",
+			EmailVerified: true,
+			Name:          "User Name",
+		}, userInfo)
+	})
+
+	t.Run("invalid nonce", func(t *testing.T) {
+		issuer.Claims = map[string]any{"sub": "subject-1"}
+		_, err := provider.Exchange(context.Background(), "other-nonce", "nonce-value")
+		assert.ErrorContains(t, err, "invalid nonce")
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		issuer.Claims = map[string]any{"sub": "subject-1", "aud": "other-client"}
+		_, err := provider.Exchange(context.Background(), "nonce-value", "nonce-value")
+		assert.ErrorContains(t, err, "oidc verify")
+	})
+}
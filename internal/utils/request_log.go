@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is propagated from the load balancer or the client and returned in the response
+const RequestIDHeader = "X-Request-ID"
+
+// An incoming ID is used only if it is short and safe for the logs, otherwise a new one is generated
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Routes finds the pattern of the request, *http.ServeMux implements it
+type Routes interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// RoutePattern is the pattern of routes for r, e.g. "GET /records/{id}", or "unmatched".
+// Unlike the path it has no IDs, so it fits the labels of the metrics and the logs.
+func RoutePattern(routes Routes, r *http.Request) string {
+	_, pattern := routes.Handler(r)
+	if pattern == "" {
+		return "unmatched"
+	}
+	return pattern
+}
+
+type requestLogKey struct{}
+
+// requestLog is shared by the middlewares and the handlers of one request,
+// the attributes added by an inner middleware, e.g. the user ID, are in the access log of the outer one.
+type requestLog struct {
+	mu     sync.Mutex
+	id     string
+	logger *slog.Logger
+}
+
+// Logger returns the logger of the request in ctx with the request ID, the route and the user ID,
+// or slog.Default() outside of a request.
+//
+//	utils.Logger(r.Context()).Error("HandleExport writeExportZip", "err", err)
+func Logger(ctx context.Context) *slog.Logger {
+	rl, ok := ctx.Value(requestLogKey{}).(*requestLog)
+	if !ok {
+		return slog.Default()
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.logger
+}
+
+// AddLogAttrs adds the attributes to the logger of the request in ctx and to its access log.
+// Outside of a request it does nothing.
+func AddLogAttrs(ctx context.Context, args ...any) {
+	rl, ok := ctx.Value(requestLogKey{}).(*requestLog)
+	if !ok {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.logger = rl.logger.With(args...)
+}
+
+// RequestID returns the ID of the request in ctx or "" outside of a request
+func RequestID(ctx context.Context) string {
+	rl, ok := ctx.Value(requestLogKey{}).(*requestLog)
+	if !ok {
+		return ""
+	}
+	return rl.id
+}
+
+// RequestLogMiddleware assigns X-Request-ID, stores the logger of the request in the context
+// and writes one access log line per request. 5xx responses are logged as errors.
+func RequestLogMiddleware(next http.Handler, routes Routes) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		logger := slog.Default().With("request_id", id, "route", RoutePattern(routes, r))
+		// The span of the tracing middleware, the logs of the request are found by the trace
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			logger = logger.With("trace_id", sc.TraceID().String())
+		}
+		rl := &requestLog{id: id, logger: logger}
+		r = r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl))
+
+		sw := NewStatusWriter(w)
+		start := time.Now()
+		next.ServeHTTP(sw, r)
+
+		level := slog.LevelInfo
+		if sw.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		Logger(r.Context()).Log(r.Context(), level, "HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.Status,
+			"bytes", sw.Bytes,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// StatusWriter remembers the status and the size of the response for the middlewares
+type StatusWriter struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int
+	wroteHeader bool
+}
+
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (w *StatusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.Status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.Bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach Flush and the deadlines of the original writer
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
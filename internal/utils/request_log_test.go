@@ -0,0 +1,162 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils --tags=unit -cover -run TestRequestLog.*
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureLogs sets a JSON logger to the buffer as slog.Default() until the end of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func newRequestLogMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		AddLogAttrs(r.Context(), "user_id", 7)
+		Logger(r.Context()).Info("HandleRecordsEdit")
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("POST /records", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "error", http.StatusInternalServerError)
+	})
+	return mux
+}
+
+func TestRequestLogMiddleware(t *testing.T) {
+	mux := newRequestLogMux()
+	handler := RequestLogMiddleware(mux, mux)
+
+	t.Run("NewRequestID", func(t *testing.T) {
+		buf := captureLogs(t)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/records/5", nil))
+
+		id := w.Header().Get(RequestIDHeader)
+		assert.Len(t, id, 32)
+		lines := logLines(t, buf)
+		require.Len(t, lines, 2)
+
+		handlerLog, accessLog := lines[0], lines[1]
+		assert.Equal(t, "HandleRecordsEdit", handlerLog["msg"])
+		assert.Equal(t, id, handlerLog["request_id"])
+		assert.Equal(t, "GET /records/{id}", handlerLog["route"])
+		assert.Equal(t, 7.0, handlerLog["user_id"])
+
+		assert.Equal(t, "HTTP request", accessLog["msg"])
+		assert.Equal(t, "INFO", accessLog["level"])
+		assert.Equal(t, id, accessLog["request_id"])
+		assert.Equal(t, "GET /records/{id}", accessLog["route"])
+		assert.Equal(t, 7.0, accessLog["user_id"], "added by the inner handler")
+		assert.Equal(t, "/records/5", accessLog["path"])
+		assert.Equal(t, 200.0, accessLog["status"])
+		assert.Equal(t, 2.0, accessLog["bytes"])
+	})
+
+	t.Run("PropagatedRequestID", func(t *testing.T) {
+		buf := captureLogs(t)
+		r := httptest.NewRequest(http.MethodGet, "/records/5", nil)
+		r.Header.Set(RequestIDHeader, "lb-1234:abc")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, "lb-1234:abc", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "lb-1234:abc", logLines(t, buf)[1]["request_id"])
+	})
+
+	t.Run("InvalidRequestID", func(t *testing.T) {
+		captureLogs(t)
+		for _, id := range []string{"with space", "new\nline", strings.Repeat("a", 129)} {
+			r := httptest.NewRequest(http.MethodGet, "/records/5", nil)
+			r.Header.Set(RequestIDHeader, id)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.NotEqual(t, id, w.Header().Get(RequestIDHeader))
+			assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+		}
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		buf := captureLogs(t)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/records", nil))
+
+		accessLog := logLines(t, buf)[0]
+		assert.Equal(t, "ERROR", accessLog["level"])
+		assert.Equal(t, 500.0, accessLog["status"])
+		assert.Equal(t, "POST /records", accessLog["route"])
+	})
+
+	t.Run("TraceID", func(t *testing.T) {
+		buf := captureLogs(t)
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  spanID,
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/records/5", nil).WithContext(ctx))
+
+		for _, line := range logLines(t, buf) {
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+		}
+	})
+
+	t.Run("Unmatched", func(t *testing.T) {
+		buf := captureLogs(t)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+		accessLog := logLines(t, buf)[0]
+		assert.Equal(t, "unmatched", accessLog["route"])
+		assert.Equal(t, 404.0, accessLog["status"])
+	})
+}
+
+func TestRequestLog_OutsideRequest(t *testing.T) {
+	ctx := context.Background()
+	AddLogAttrs(ctx, "user_id", 1)
+
+	assert.Same(t, slog.Default(), Logger(ctx))
+	assert.Equal(t, "", RequestID(ctx))
+}
+
+func TestRequestLog_StatusWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	sw := NewStatusWriter(w)
+
+	sw.Write([]byte("body"))
+	sw.WriteHeader(http.StatusInternalServerError)
+
+	assert.Equal(t, http.StatusOK, sw.Status, "the status is sent with the first Write")
+	assert.Equal(t, 4, sw.Bytes)
+	assert.Same(t, w, sw.Unwrap())
+}
@@ -3,8 +3,8 @@ package ses
 import (
 	"bytes"
 	"context"
-	"path/filepath"
-	"text/template"
+	"html/template"
+	"time-tracker/internal/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,8 +12,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ses/types"
 )
 
-var activationTplPath = filepath.Join("web", "templates", "email", "activation.html")
-var loginWithTokenTplPath = filepath.Join("web", "templates", "email", "login-with-token.html")
+// Paths in utils.TemplatesFS
+var activationTplPath = "email/activation.html"
+var loginWithTokenTplPath = "email/login-with-token.html"
+var emailChangeTplPath = "email/email-change.html"
+var emailChangedTplPath = "email/email-changed.html"
 
 // For tests. Instead of *ses.Client
 type SESClient interface {
@@ -40,7 +43,7 @@ func NewMailService(emailFrom string) (*MailService, error) {
 	}, nil
 }
 
-func (ms *MailService) sendEmail(to string, subject string, body string) error {
+func (ms *MailService) sendEmail(ctx context.Context, to string, subject string, body string) error {
 
 	input := &ses.SendEmailInput{
 		Destination: &types.Destination{
@@ -61,7 +64,7 @@ func (ms *MailService) sendEmail(to string, subject string, body string) error {
 		Source: aws.String(ms.emailFrom),
 	}
 
-	_, err := ms.client.SendEmail(context.Background(), input)
+	_, err := ms.client.SendEmail(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -78,8 +81,8 @@ func (ms *MailService) Ping() error {
 	return nil
 }
 
-func (ms *MailService) SendActivationEmail(email, name, link string) error {
-	tmpl, err := template.ParseFiles(activationTplPath)
+func (ms *MailService) SendActivationEmail(ctx context.Context, email, name, link string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), activationTplPath)
 	if err != nil {
 		return err
 	}
@@ -98,14 +101,15 @@ func (ms *MailService) SendActivationEmail(email, name, link string) error {
 	}
 
 	return ms.sendEmail(
+		ctx,
 		email,
 		"Activating an account in Time Tracker",
 		bodyBuffer.String(),
 	)
 }
 
-func (ms *MailService) SendLoginWithTokenEmail(email, name, link string) error {
-	tmpl, err := template.ParseFiles(loginWithTokenTplPath)
+func (ms *MailService) SendLoginWithTokenEmail(ctx context.Context, email, name, link string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), loginWithTokenTplPath)
 	if err != nil {
 		return err
 	}
@@ -124,8 +128,63 @@ func (ms *MailService) SendLoginWithTokenEmail(email, name, link string) error {
 	}
 
 	return ms.sendEmail(
+		ctx,
 		email,
 		"Login to Your Time Tracker Account",
 		bodyBuffer.String(),
 	)
 }
+
+func (ms *MailService) SendEmailChangeEmail(ctx context.Context, email, name, link string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), emailChangeTplPath)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name        string
+		ConfirmLink string
+	}{
+		Name:        name,
+		ConfirmLink: link,
+	}
+
+	var bodyBuffer bytes.Buffer
+	if err := tmpl.Execute(&bodyBuffer, data); err != nil {
+		return err
+	}
+
+	return ms.sendEmail(
+		ctx,
+		email,
+		"Confirm Your New Email in Time Tracker",
+		bodyBuffer.String(),
+	)
+}
+
+func (ms *MailService) SendEmailChangedEmail(ctx context.Context, email, name, newEmail string) error {
+	tmpl, err := template.ParseFS(utils.TemplatesFS(), emailChangedTplPath)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name     string
+		NewEmail string
+	}{
+		Name:     name,
+		NewEmail: newEmail,
+	}
+
+	var bodyBuffer bytes.Buffer
+	if err := tmpl.Execute(&bodyBuffer, data); err != nil {
+		return err
+	}
+
+	return ms.sendEmail(
+		ctx,
+		email,
+		"Your Email in Time Tracker Has Been Changed",
+		bodyBuffer.String(),
+	)
+}
@@ -19,7 +19,8 @@ const (
 )
 
 func NewMailServiceForTest(t *testing.T) *MailService {
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
 	if cfg.EmailFrom == "" {
 		t.Fatal("EMAIL_FROM is not set")
 	}
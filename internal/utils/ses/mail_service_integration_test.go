@@ -4,6 +4,7 @@
 package ses
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,7 +30,7 @@ func TestMailService_SendEmail(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
-			err := ms.sendEmail(test.email, test.subject, test.body)
+			err := ms.sendEmail(context.Background(), test.email, test.subject, test.body)
 			assert.NoError(t, err, "failed to send email for "+test.testName)
 		})
 	}
@@ -41,6 +42,7 @@ func TestMailService_SendActivationEmail_SimulatorSuccess(t *testing.T) {
 	SetAppDir()
 	ms := NewMailServiceForTest(t)
 	err := ms.SendActivationEmail(
+		context.Background(),
 		SimulatorSuccess,
 		"My Name",
 		TestActivationURL,
@@ -54,6 +56,7 @@ func TestMailService_SendLoginWithTokenEmail_SimulatorSuccess(t *testing.T) {
 	SetAppDir()
 	ms := NewMailServiceForTest(t)
 	err := ms.SendLoginWithTokenEmail(
+		context.Background(),
 		SimulatorSuccess,
 		"My Name",
 		TestTokenURL,
@@ -4,6 +4,7 @@
 package ses
 
 import (
+	"context"
 	"flag"
 	"testing"
 
@@ -28,7 +29,7 @@ func TestMailService_SendActivationEmail_Manual(t *testing.T) {
 	SetAppDir()
 	email, name := getEmailAndName(t)
 	ms := NewMailServiceForTest(t)
-	err := ms.SendActivationEmail(email, name, TestActivationURL)
+	err := ms.SendActivationEmail(context.Background(), email, name, TestActivationURL)
 	if err == nil {
 		t.Log("Mail was successfully sent to " + email)
 	}
@@ -40,7 +41,7 @@ func TestMailService_SendLoginWithTokenEmail_Manual(t *testing.T) {
 	SetAppDir()
 	email, name := getEmailAndName(t)
 	ms := NewMailServiceForTest(t)
-	err := ms.SendLoginWithTokenEmail(email, name, TestTokenURL)
+	err := ms.SendLoginWithTokenEmail(context.Background(), email, name, TestTokenURL)
 	if err == nil {
 		t.Log("Mail was successfully sent to " + email)
 	}
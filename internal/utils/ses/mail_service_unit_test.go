@@ -6,8 +6,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"testing"
+	"time-tracker/internal/utils"
 
 	"github.com/aws/aws-sdk-go-v2/service/ses"
 	"github.com/stretchr/testify/assert"
@@ -105,7 +105,7 @@ func TestMailService_SendEmail_Error(t *testing.T) {
 		mock.Anything,
 	).Return(&ses.SendEmailOutput{}, fmt.Errorf("mock SES error"))
 
-	err := mailService.sendEmail("user@example.com", "Test Subject", "Test Body")
+	err := mailService.sendEmail(context.Background(), "user@example.com", "Test Subject", "Test Body")
 	assert.Error(t, err, "Expected error when SES returns an error")
 	assert.Contains(t, err.Error(), "mock SES error", "Error message should match the mock error")
 
@@ -130,7 +130,7 @@ func TestMailService_SendActivationEmail(t *testing.T) {
 		mock.Anything,
 	).Return(&ses.SendEmailOutput{}, nil)
 
-	err := mailService.SendActivationEmail("user@example.com", "John Doe", "http://activation-link")
+	err := mailService.SendActivationEmail(context.Background(), "user@example.com", "John Doe", "http://activation-link")
 
 	assert.NoError(t, err)
 	mockSESClient.AssertExpectations(t)
@@ -154,7 +154,55 @@ func TestMailService_SendLoginWithTokenEmail(t *testing.T) {
 		mock.Anything,
 	).Return(&ses.SendEmailOutput{}, nil)
 
-	err := mailService.SendLoginWithTokenEmail("user@example.com", "John Doe", "http://login-link")
+	err := mailService.SendLoginWithTokenEmail(context.Background(), "user@example.com", "John Doe", "http://login-link")
+
+	assert.NoError(t, err)
+	mockSESClient.AssertExpectations(t)
+}
+
+func TestMailService_SendEmailChangeEmail(t *testing.T) {
+	SetAppDir()
+	mockSESClient := new(MockSESClient)
+	mailService := &MailService{
+		client:    mockSESClient,
+		emailFrom: "noreply@example.com",
+	}
+
+	mockSESClient.On(
+		"SendEmail",
+		mock.Anything,
+		mock.MatchedBy(func(input *ses.SendEmailInput) bool {
+			return input.Destination.ToAddresses[0] == "user@example.com" &&
+				input.Message.Subject.Data != nil
+		}),
+		mock.Anything,
+	).Return(&ses.SendEmailOutput{}, nil)
+
+	err := mailService.SendEmailChangeEmail(context.Background(), "user@example.com", "John Doe", "http://confirm-link")
+
+	assert.NoError(t, err)
+	mockSESClient.AssertExpectations(t)
+}
+
+func TestMailService_SendEmailChangedEmail(t *testing.T) {
+	SetAppDir()
+	mockSESClient := new(MockSESClient)
+	mailService := &MailService{
+		client:    mockSESClient,
+		emailFrom: "noreply@example.com",
+	}
+
+	mockSESClient.On(
+		"SendEmail",
+		mock.Anything,
+		mock.MatchedBy(func(input *ses.SendEmailInput) bool {
+			return input.Destination.ToAddresses[0] == "user@example.com" &&
+				input.Message.Subject.Data != nil
+		}),
+		mock.Anything,
+	).Return(&ses.SendEmailOutput{}, nil)
+
+	err := mailService.SendEmailChangedEmail(context.Background(), "user@example.com", "John Doe", "new@example.com")
 
 	assert.NoError(t, err)
 	mockSESClient.AssertExpectations(t)
@@ -166,22 +214,28 @@ func TestMailService_TemplateError(t *testing.T) {
 		client:    mockSESClient,
 		emailFrom: "noreply@example.com",
 	}
-	os.Chdir("/tmp")
-	err := mailService.SendActivationEmail("user@example.com", "John Doe", "http://link")
+	// No templates on the disk
+	utils.SetWebDir(t.TempDir())
+	defer utils.SetWebDir("")
+	err := mailService.SendActivationEmail(context.Background(), "user@example.com", "John Doe", "http://link")
+	assert.Error(t, err)
+	err = mailService.SendLoginWithTokenEmail(context.Background(), "user@example.com", "John Doe", "http://link")
 	assert.Error(t, err)
-	err = mailService.SendLoginWithTokenEmail("user@example.com", "John Doe", "http://link")
+	err = mailService.SendEmailChangeEmail(context.Background(), "user@example.com", "John Doe", "http://link")
+	assert.Error(t, err)
+	err = mailService.SendEmailChangedEmail(context.Background(), "user@example.com", "John Doe", "new@example.com")
 	assert.Error(t, err)
 
-	SetAppDir()
+	utils.SetWebDir("")
 	oldActivationTplPath := activationTplPath
-	activationTplPath = filepath.Join("web", "templates", "test", "missing_template.html")
-	err = mailService.SendActivationEmail("user@example.com", "John Doe", "http://link")
+	activationTplPath = "test/missing_template.html"
+	err = mailService.SendActivationEmail(context.Background(), "user@example.com", "John Doe", "http://link")
 	assert.Error(t, err)
 	activationTplPath = oldActivationTplPath
 
 	oldLoginWithTokenTplPath := loginWithTokenTplPath
-	loginWithTokenTplPath = filepath.Join("web", "templates", "test", "missing_template.html")
-	err = mailService.SendLoginWithTokenEmail("user@example.com", "John Doe", "http://link")
+	loginWithTokenTplPath = "test/missing_template.html"
+	err = mailService.SendLoginWithTokenEmail(context.Background(), "user@example.com", "John Doe", "http://link")
 	assert.Error(t, err)
 	loginWithTokenTplPath = oldLoginWithTokenTplPath
 }
@@ -0,0 +1,177 @@
+-- The schema of db/migrations for SQLite, applied by Open on every start.
+-- Change it together with the migrations, statements must be idempotent.
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(100) NOT NULL COLLATE NOCASE UNIQUE,
+    password CHAR(60) NOT NULL,
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    is_week_start_monday BOOLEAN NOT NULL DEFAULT TRUE,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    date_add TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activation_hash_date TIMESTAMP,
+    activation_hash VARCHAR(64) NOT NULL DEFAULT '',
+    delete_requested_at TIMESTAMP,
+    new_email VARCHAR(100) NOT NULL DEFAULT '',
+    calendar_token VARCHAR(64) NOT NULL DEFAULT '',
+    no_password BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS idx_users_activation_hash ON users (activation_hash);
+-- The files created before email COLLATE NOCASE: as in the migration, the duplicates in another case
+-- that were never activated are deleted, and Open fails on the active ones
+DELETE FROM users
+WHERE NOT is_active
+  AND EXISTS (
+    SELECT 1 FROM users o
+    WHERE o.email = users.email COLLATE NOCASE AND o.id <> users.id AND (o.is_active OR o.id < users.id)
+  );
+DROP INDEX IF EXISTS idx_users_email_nocase;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_nocase_unique ON users (email COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_users_delete_requested_at ON users (delete_requested_at) WHERE delete_requested_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_calendar_token_key ON users (calendar_token) WHERE calendar_token <> '';
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(100) NOT NULL,
+    date_add TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    color CHAR(7) NOT NULL DEFAULT '#FFFFFF',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_completed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_sort ON tasks (user_id, sort_order);
+
+-- records.user_id duplicates tasks.user_id so that the constraints can work per user, see add_records_overlap_constraints.
+-- Times are the wall clock time of the user in the fixed width format of sqlite.TimeLayout, so they are compared as strings.
+CREATE TABLE IF NOT EXISTS records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    time_start TIMESTAMP NOT NULL,
+    time_end TIMESTAMP CHECK (time_end IS NULL OR time_end >= time_start),
+    comment TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (task_id, user_id) REFERENCES tasks (id, user_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_records_task_id ON records (task_id);
+CREATE INDEX IF NOT EXISTS idx_records_user_time_start ON records (user_id, time_start);
+
+-- SQLite has no exclusion constraints, the triggers check the same ranges as records_no_overlap:
+-- [time_start, time_end) for a completed record, [time_start, time_start] for a record in progress.
+-- The trigger names are the messages of the errors, the repositories map them by the names.
+CREATE TRIGGER IF NOT EXISTS records_one_in_progress_per_user_insert BEFORE INSERT ON records
+WHEN NEW.time_end IS NULL
+BEGIN
+    SELECT RAISE(ABORT, 'records_one_in_progress_per_user')
+    WHERE EXISTS (SELECT 1 FROM records WHERE user_id = NEW.user_id AND time_end IS NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS records_one_in_progress_per_user_update BEFORE UPDATE ON records
+WHEN NEW.time_end IS NULL
+BEGIN
+    SELECT RAISE(ABORT, 'records_one_in_progress_per_user')
+    WHERE EXISTS (SELECT 1 FROM records WHERE user_id = NEW.user_id AND time_end IS NULL AND id != NEW.id);
+END;
+
+-- An empty range (time_end = time_start) does not overlap anything
+CREATE TRIGGER IF NOT EXISTS records_no_overlap_insert BEFORE INSERT ON records
+WHEN NEW.time_end IS NULL OR NEW.time_end > NEW.time_start
+BEGIN
+    SELECT RAISE(ABORT, 'records_no_overlap')
+    WHERE EXISTS (
+        SELECT 1 FROM records r
+        WHERE r.user_id = NEW.user_id
+            AND (r.time_end IS NULL OR r.time_end > r.time_start)
+            AND (NEW.time_start < COALESCE(r.time_end, r.time_start) OR (r.time_end IS NULL AND NEW.time_start = r.time_start))
+            AND (r.time_start < COALESCE(NEW.time_end, NEW.time_start) OR (NEW.time_end IS NULL AND r.time_start = NEW.time_start))
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS records_no_overlap_update BEFORE UPDATE ON records
+WHEN NEW.time_end IS NULL OR NEW.time_end > NEW.time_start
+BEGIN
+    SELECT RAISE(ABORT, 'records_no_overlap')
+    WHERE EXISTS (
+        SELECT 1 FROM records r
+        WHERE r.user_id = NEW.user_id
+            AND r.id != NEW.id
+            AND (r.time_end IS NULL OR r.time_end > r.time_start)
+            AND (NEW.time_start < COALESCE(r.time_end, r.time_start) OR (r.time_end IS NULL AND NEW.time_start = r.time_start))
+            AND (r.time_start < COALESCE(NEW.time_end, NEW.time_start) OR (NEW.time_end IS NULL AND r.time_start = NEW.time_start))
+    );
+END;
+
+-- Dates are stored in sqlite.TimeLayout at midnight
+CREATE TABLE IF NOT EXISTS recurring_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    start_minute INTEGER NOT NULL CHECK (start_minute >= 0 AND start_minute < 1440),
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    rule VARCHAR(255) NOT NULL,
+    date_start DATE NOT NULL,
+    materialized_until DATE,
+    comment TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (task_id, user_id) REFERENCES tasks (id, user_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_recurring_records_user_id ON recurring_records (user_id);
+
+-- Replaces Redis for the sessions
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    expiry TIMESTAMP NOT NULL,
+    csrf_token VARCHAR(64) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_expiry ON sessions (expiry);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+-- The times of the webhooks are UTC in sqlite.TimeLayout
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    event_types VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_user_id ON webhook_subscriptions (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
+
+-- Only the SHA-256 of a token is stored, the times are UTC in sqlite.TimeLayout
+CREATE TABLE IF NOT EXISTS personal_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_personal_tokens_user_id ON personal_tokens (user_id);
@@ -0,0 +1,108 @@
+// Package sqlite opens the SQLite database that replaces Postgres and Redis for single-user self-hosting.
+package sqlite
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed schema.sql
+var schema string
+
+// Times are stored as text in the wall clock time, like TIMESTAMP without time zone of Postgres.
+// The fixed width keeps the order of strings equal to the order of times.
+const TimeLayout = "2006-01-02 15:04:05.000000"
+
+// The columns added to the tables after their creation. schema.sql has them for the new files,
+// the files created before get them on Open, SQLite has no ADD COLUMN IF NOT EXISTS.
+var addedColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"users", "calendar_token", "VARCHAR(64) NOT NULL DEFAULT ''"},
+	{"users", "no_password", "BOOLEAN NOT NULL DEFAULT FALSE"},
+}
+
+// Open opens the database file, creates it and its directory if they do not exist, and applies schema.sql
+func Open(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("sqlite Open MkdirAll: %w", err)
+		}
+	}
+
+	// The pragmas are applied to every connection of the pool
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite Open: %w", err)
+	}
+	// Before the schema, its indexes can use the added columns
+	if err := addMissingColumns(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite Open schema: %w", err)
+	}
+	return db, nil
+}
+
+// Adds addedColumns to the existing tables that do not have them. The tables that do not exist yet are created by the schema.
+func addMissingColumns(db *sql.DB) error {
+	for _, added := range addedColumns {
+		var tableExists, columnExists bool
+		err := db.QueryRow(`
+            SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = $1),
+                   EXISTS (SELECT 1 FROM pragma_table_info($1) WHERE name = $2)
+        `, added.table, added.column).Scan(&tableExists, &columnExists)
+		if err != nil {
+			return fmt.Errorf("sqlite addMissingColumns %s.%s: %w", added.table, added.column, err)
+		}
+		if !tableExists || columnExists {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE " + added.table + " ADD COLUMN " + added.column + " " + added.definition); err != nil {
+			return fmt.Errorf("sqlite addMissingColumns %s.%s: %w", added.table, added.column, err)
+		}
+	}
+	return nil
+}
+
+func FormatTime(t time.Time) string {
+	return t.Format(TimeLayout)
+}
+
+// FormatTimePtr returns nil for NULL
+func FormatTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return FormatTime(*t)
+}
+
+// IsConstraintError checks that err is a violation of the constraint or of the trigger with the name.
+// SQLite does not return the name separately, it is a part of the message,
+// e.g. "UNIQUE constraint failed: users.email" or "records_no_overlap" of RAISE.
+func IsConstraintError(err error, name string) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code()&0xff == sqlite3.SQLITE_CONSTRAINT && strings.Contains(sqliteErr.Error(), name)
+}
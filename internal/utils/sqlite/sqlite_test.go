@@ -0,0 +1,96 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/sqlite --tags=unit -cover
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "test.db")
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users (name, email, password) VALUES ('John', 'john@example.com', 'password')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// The schema is applied again on the existing file, the data is kept
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	var foreignKeys int
+	require.NoError(t, db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys))
+	assert.Equal(t, 1, foreignKeys)
+}
+
+func TestOpen_AddsMissingColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	require.NoError(t, err)
+	// users before calendar_token
+	_, err = db.Exec(`CREATE TABLE users (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name VARCHAR(100) NOT NULL,
+        email VARCHAR(100) NOT NULL UNIQUE,
+        password CHAR(60) NOT NULL,
+        timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+        is_week_start_monday BOOLEAN NOT NULL DEFAULT TRUE,
+        is_active BOOLEAN NOT NULL DEFAULT FALSE,
+        date_add TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        activation_hash_date TIMESTAMP,
+        activation_hash VARCHAR(64) NOT NULL DEFAULT '',
+        delete_requested_at TIMESTAMP,
+        new_email VARCHAR(100) NOT NULL DEFAULT ''
+    )`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users (name, email, password, is_active) VALUES ('John', 'john@example.com', 'password', TRUE)`)
+	require.NoError(t, err)
+	// A signup in another case that was never activated
+	_, err = db.Exec(`INSERT INTO users (name, email, password) VALUES ('John', 'John@Example.com', 'password')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	var calendarToken, email string
+	require.NoError(t, db.QueryRow(`SELECT calendar_token, email FROM users`).Scan(&calendarToken, &email))
+	assert.Equal(t, "", calendarToken)
+	assert.Equal(t, "john@example.com", email)
+
+	// The email is unique ignoring the case in the file created before
+	_, err = db.Exec(`INSERT INTO users (name, email, password) VALUES ('John', 'JOHN@example.com', 'password')`)
+	assert.True(t, IsConstraintError(err, "users.email"))
+}
+
+func TestIsConstraintError(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT INTO users (name, email, password) VALUES ('John', 'john@example.com', 'password')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users (name, email, password) VALUES ('John', 'John@Example.com', 'password')`)
+	assert.True(t, IsConstraintError(err, "users.email"))
+	assert.False(t, IsConstraintError(err, "users.name"))
+	assert.False(t, IsConstraintError(nil, "users.email"))
+}
+
+func TestFormatTime(t *testing.T) {
+	tm := time.Date(2024, 12, 2, 9, 5, 0, 1500, time.UTC)
+	assert.Equal(t, "2024-12-02 09:05:00.000001", FormatTime(tm))
+	assert.Nil(t, FormatTimePtr(nil))
+	assert.Equal(t, "2024-12-02 09:05:00.000001", FormatTimePtr(&tm))
+}
@@ -1,20 +1,63 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"html/template"
+	"io/fs"
 	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
-	"text/template"
+	"slices"
+	"strings"
+	"sync"
+	"time-tracker/web"
 	//"time-tracker/internal/middleware"
 )
 
-var componentsPath = filepath.Join("web", "templates", "components", "*")
-var layoutPath = filepath.Join("web", "templates", "layout.html")
+// Paths in templatesFS
+var componentsPath = "components/*"
+var layoutPath = "layout.html"
+
+// Files of web/templates and web/public, embedded into the binary by default, see SetWebDir
+var (
+	templatesFS  = web.Templates("")
+	publicFS     = web.Public("")
+	webFromDisk  = false
+	fileVersions sync.Map
+)
+
+// SetWebDir reads the templates and the public files from dir on the disk instead of the embedded files.
+// It is for development: changed files are used without a rebuild, the templates are parsed on every render.
+// An empty dir keeps the embedded files.
+// Call it on start, before the server handles requests.
+func SetWebDir(dir string) {
+	templatesFS = web.Templates(dir)
+	publicFS = web.Public(dir)
+	webFromDisk = dir != ""
+	fileVersions = sync.Map{}
+	registryMu.Lock()
+	registry = nil
+	registryMu.Unlock()
+}
+
+// TemplatesFS is web/templates, e.g. for the templates of the emails
+func TemplatesFS() fs.FS {
+	return templatesFS
+}
+
+// PublicFS is web/public for the file server
+func PublicFS() fs.FS {
+	return publicFS
+}
 
 type TplData map[string]interface{}
 
+// Implemented by the ResponseWriter of users.CSRFMiddleware
+type csrfTokenWriter interface {
+	CSRFToken() string
+}
+
 // Function for registration in the template engine
 // Used to pass multiple variables from a template to a subtemplate
 // Example:
@@ -35,15 +78,24 @@ func dict(values ...interface{}) TplData {
 	return m
 }
 
+// The hash of the content, so the version changes only with the file, not with the build or the deploy.
+// Embedded files do not change, their versions are calculated once.
 // Example:
 // <link href="/css/output.css?v={{fileVersion "/css/output.css"}}" rel="stylesheet" />
 func fileVersion(relPath string) string {
-	absPath := filepath.Join("web/public", relPath)
-	fileInfo, err := os.Stat(absPath)
+	if version, ok := fileVersions.Load(relPath); ok {
+		return version.(string)
+	}
+	content, err := fs.ReadFile(publicFS, strings.TrimPrefix(relPath, "/"))
 	if err != nil {
 		return ""
 	}
-	return fmt.Sprintf("%d", fileInfo.ModTime().Unix())
+	sum := sha256.Sum256(content)
+	version := hex.EncodeToString(sum[:8])
+	if !webFromDisk {
+		fileVersions.Store(relPath, version)
+	}
+	return version
 }
 
 func add(a, b float64) float64 {
@@ -56,45 +108,174 @@ func sub(a, b float64) float64 {
 	return a - b
 }
 
-func includeRaw(path string) string {
-	content, err := os.ReadFile(path)
+// path in web/templates, the files are trusted and are not escaped
+func includeRaw(path string) template.HTML {
+	content, err := fs.ReadFile(templatesFS, path)
 	if err != nil {
 		return ""
 	}
-	return string(content)
+	return template.HTML(content)
 }
 
-func createTemplate(w http.ResponseWriter, tplPaths []string) (templates *template.Template) {
-	templates = template.New("").Funcs(template.FuncMap{
-		"dict":               dict,
-		"fileVersion":        fileVersion,
-		"formatTimeForInput": FormatTimeForInput,
-		"formatDuration":     FormatDuration,
-		"add":                add,
-		"addInt":             addInt,
-		"sub":                sub,
-		"includeRaw":         includeRaw,
-	})
+var templateFuncs = template.FuncMap{
+	"dict":               dict,
+	"fileVersion":        fileVersion,
+	"formatTimeForInput": FormatTimeForInput,
+	"formatDuration":     FormatDuration,
+	"add":                add,
+	"addInt":             addInt,
+	"sub":                sub,
+	"includeRaw":         includeRaw,
+}
+
+// The templates of web/templates parsed once, keyed by the path without ".html", e.g. "dashboard/dashboard".
+// A page defines "content" and is rendered into the layout, a fragment is a component or a part of a page for htmx.
+type templateRegistry struct {
+	pages     map[string]*template.Template
+	fragments map[string]*template.Template
+}
+
+// Directories of web/templates that are not rendered by RenderTemplate, the mail services parse the emails
+var templatesSkipDirs = map[string]bool{"email": true, "test": true}
+
+var (
+	registry   *templateRegistry
+	registryMu sync.Mutex
+)
 
-	templates, err := templates.ParseGlob(componentsPath)
+// LoadTemplates parses all templates into the registry.
+// Call it on start, so a broken template stops the server instead of failing the requests.
+func LoadTemplates() error {
+	reg, err := parseTemplates()
 	if err != nil {
-		slog.Error("RenderTemplate ParseGlob", "componentsPath", componentsPath, "err", err.Error())
-		http.Error(w, "Error loading template", http.StatusInternalServerError)
-		return
+		return err
+	}
+	registryMu.Lock()
+	registry = reg
+	registryMu.Unlock()
+	return nil
+}
+
+// templates returns the registry parsed once, or parses the templates on every call after SetWebDir,
+// so the changes of the files on the disk are visible without a restart
+func templates() (*templateRegistry, error) {
+	if webFromDisk {
+		return parseTemplates()
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registry == nil {
+		reg, err := parseTemplates()
+		if err != nil {
+			return nil, err
+		}
+		registry = reg
+	}
+	return registry, nil
+}
+
+// parseTemplates parses the components and the fragments into one set shared by all fragments.
+// Every page gets a clone of the set with the layout and the page.
+func parseTemplates() (*templateRegistry, error) {
+	fragments, err := template.New("").Funcs(templateFuncs).ParseFS(templatesFS, componentsPath)
+	if err != nil {
+		return nil, fmt.Errorf("parse components %s: %w", componentsPath, err)
+	}
+	componentPaths, err := fs.Glob(templatesFS, componentsPath)
+	if err != nil {
+		return nil, fmt.Errorf("glob components %s: %w", componentsPath, err)
+	}
+
+	var pagePaths, fragmentPaths []string
+	err = fs.WalkDir(templatesFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if templatesSkipDirs[path] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".html") || path == layoutPath || slices.Contains(componentPaths, path) {
+			return nil
+		}
+		isPage, err := definesContent(path)
+		if err != nil {
+			return err
+		}
+		if isPage {
+			pagePaths = append(pagePaths, path)
+		} else {
+			fragmentPaths = append(fragmentPaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk templates: %w", err)
+	}
+
+	reg := &templateRegistry{
+		pages:     make(map[string]*template.Template, len(pagePaths)),
+		fragments: make(map[string]*template.Template, len(componentPaths)+len(fragmentPaths)),
+	}
+	if len(fragmentPaths) > 0 {
+		if fragments, err = fragments.ParseFS(templatesFS, fragmentPaths...); err != nil {
+			return nil, fmt.Errorf("parse fragments: %w", err)
+		}
 	}
-	for _, tplPath := range tplPaths {
-		tplPath = filepath.Join("web", "templates", tplPath+".html")
-		templates, err = templates.ParseFiles(tplPath)
+	for _, path := range append(componentPaths, fragmentPaths...) {
+		reg.fragments[strings.TrimSuffix(path, ".html")] = fragments
+	}
+
+	for _, path := range pagePaths {
+		page, err := fragments.Clone()
 		if err != nil {
-			slog.Error("RenderTemplate ParseFiles", "tplPath", tplPath, "err", err.Error())
-			http.Error(w, "Error loading template", http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("clone fragments for %s: %w", path, err)
 		}
+		if page, err = page.ParseFS(templatesFS, layoutPath, path); err != nil {
+			return nil, fmt.Errorf("parse page %s: %w", path, err)
+		}
+		reg.pages[strings.TrimSuffix(path, ".html")] = page
+	}
+	return reg, nil
+}
+
+// definesContent checks that the file is a page, not a fragment
+func definesContent(path string) (bool, error) {
+	tpl, err := template.New("").Funcs(templateFuncs).ParseFS(templatesFS, path)
+	if err != nil {
+		return false, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return tpl.Lookup("content") != nil, nil
+}
+
+// lookupTemplate finds the page or, without the layout, also the fragment, and writes 500 if it is missing
+func lookupTemplate(w http.ResponseWriter, tplPath string, withLayout bool) *template.Template {
+	reg, err := templates()
+	if err != nil {
+		slog.Error("RenderTemplate templates", "err", err.Error())
+		http.Error(w, "Error loading template", http.StatusInternalServerError)
+		return nil
+	}
+	tpl, ok := reg.pages[tplPath]
+	if !ok && !withLayout {
+		tpl, ok = reg.fragments[tplPath]
+	}
+	if !ok {
+		slog.Error("RenderTemplate template not found", "tplPath", tplPath, "withLayout", withLayout)
+		http.Error(w, "Error loading template", http.StatusInternalServerError)
+		return nil
 	}
-	return
+	return tpl
 }
 
 func executeTemplate(templates *template.Template, w http.ResponseWriter, tplPath string, data TplData) {
+	if cw, ok := w.(csrfTokenWriter); ok && data != nil {
+		if _, exists := data["CSRFToken"]; !exists {
+			data["CSRFToken"] = cw.CSRFToken()
+		}
+	}
 	err := templates.ExecuteTemplate(w, tplPath, data)
 	if err != nil {
 		slog.Error("RenderTemplate ExecuteTemplate", "err", err.Error())
@@ -102,22 +283,22 @@ func executeTemplate(templates *template.Template, w http.ResponseWriter, tplPat
 	}
 }
 
-func RenderTemplate(w http.ResponseWriter, tplPaths []string, data TplData) {
-	templates := createTemplate(w, tplPaths)
+// RenderTemplate renders the page into the layout, e.g. "dashboard/dashboard"
+func RenderTemplate(w http.ResponseWriter, page string, data TplData) {
+	templates := lookupTemplate(w, page, true)
 	if templates == nil {
 		return
 	}
-	templates, err := templates.ParseFiles(layoutPath)
-	if err != nil {
-		slog.Error("RenderTemplate ParseFiles", "layout", layoutPath, "err", err.Error())
-		http.Error(w, "Error loading template", http.StatusInternalServerError)
-		return
-	}
 	executeTemplate(templates, w, "layout", data)
 }
 
-func RenderTemplateWithoutLayout(w http.ResponseWriter, tplPaths []string, tplName string, data TplData) {
-	templates := createTemplate(w, tplPaths)
+// RenderTemplateWithoutLayout renders tplName of the page or of the fragment tplPath, e.g. for htmx:
+// ("dashboard/reports", "content") or ("dashboard/task_list", "dashboard/task_list")
+func RenderTemplateWithoutLayout(w http.ResponseWriter, tplPath string, tplName string, data TplData) {
+	templates := lookupTemplate(w, tplPath, false)
+	if templates == nil {
+		return
+	}
 	executeTemplate(templates, w, tplName, data)
 }
 
@@ -3,20 +3,31 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
-	"text/template"
 )
 
 // docker exec -it tt-app-1 go test -v ./internal/utils --tags=unit -cover -run TestTemplate.*
 func TestTemplate_FileVersion(t *testing.T) {
-	SetAppDir()
-	version := fileVersion("/css/output.css")
-	if version == "" {
-		t.Errorf("expected a valid version string, got empty")
+	content, err := fs.ReadFile(PublicFS(), "js/htmx.2.0.3.min.js")
+	if err != nil {
+		t.Fatalf("failed to read the embedded file: %v", err)
+	}
+	sum := sha256.Sum256(content)
+	expected := hex.EncodeToString(sum[:8])
+
+	version := fileVersion("/js/htmx.2.0.3.min.js")
+	if version != expected {
+		t.Errorf("expected the hash of the content %q, got %q", expected, version)
 	}
 	version = fileVersion("/wrongFile")
 	if version != "" {
@@ -24,6 +35,31 @@ func TestTemplate_FileVersion(t *testing.T) {
 	}
 }
 
+func TestTemplate_SetWebDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755)
+	os.MkdirAll(filepath.Join(dir, "templates"), 0o755)
+	cssPath := filepath.Join(dir, "public", "css", "output.css")
+	os.WriteFile(cssPath, []byte("body {}"), 0o644)
+	os.WriteFile(filepath.Join(dir, "templates", "appVer.txt"), []byte("dev"), 0o644)
+
+	SetWebDir(dir)
+	defer SetWebDir("")
+
+	if includeRaw("appVer.txt") != "dev" {
+		t.Errorf("expected the template from the disk")
+	}
+	version := fileVersion("/css/output.css")
+	if version == "" {
+		t.Fatalf("expected a version of the file from the disk")
+	}
+	// The file is read again after a change, there is no cache for the disk
+	os.WriteFile(cssPath, []byte("body { color: red }"), 0o644)
+	if fileVersion("/css/output.css") == version {
+		t.Errorf("expected a new version after the change of the file")
+	}
+}
+
 func TestTemplate_Add(t *testing.T) {
 	if add(1.5, 2.5) != 4.0 {
 		t.Error("add(1.5, 2.5) != 4.0")
@@ -47,16 +83,16 @@ func TestTemplate_IncludeRaw(t *testing.T) {
 	tests := []struct {
 		name     string
 		path     string
-		expected string
+		expected template.HTML
 	}{
 		{
 			name:     "Existing file",
-			path:     "web/templates/test/appVer.txt",
+			path:     "test/appVer.txt",
 			expected: "v123",
 		},
 		{
 			name:     "Non-existing file",
-			path:     "web/templates/test/noFile",
+			path:     "test/noFile",
 			expected: "",
 		},
 	}
@@ -121,19 +157,96 @@ func TestTemplate_Dict(t *testing.T) {
 	}
 }
 
-func TestTemplate_CreateTemplate_BrokenComponents(t *testing.T) {
+func TestTemplate_ParseTemplates(t *testing.T) {
+	SetAppDir()
+	reg, err := parseTemplates()
+	if err != nil {
+		t.Fatalf("failed to parse the templates: %v", err)
+	}
+	for _, page := range []string{"index", "error", "login", "dashboard/dashboard", "dashboard/reports"} {
+		if reg.pages[page] == nil {
+			t.Errorf("expected the page %q in the registry", page)
+		}
+	}
+	for _, fragment := range []string{"components/error_block", "dashboard/task_list", "dashboard/record_list", "dashboard/record_form"} {
+		if reg.fragments[fragment] == nil {
+			t.Errorf("expected the fragment %q in the registry", fragment)
+		}
+	}
+	if reg.pages["dashboard/task_list"] != nil || reg.fragments["dashboard/dashboard"] != nil {
+		t.Errorf("expected pages and fragments to be separated")
+	}
+	if reg.pages["email/activation"] != nil || reg.fragments["email/activation"] != nil {
+		t.Errorf("expected the emails to be skipped")
+	}
+}
+
+func TestTemplate_ParseTemplates_BrokenComponents(t *testing.T) {
 	SetAppDir()
 	originalComponents := componentsPath
 	componentsPath = componentsPath + "BrokenComponents"
 	defer func() { componentsPath = originalComponents }()
+	reg, err := parseTemplates()
+	if err == nil {
+		t.Errorf("expected an error for missing components")
+	}
+	if reg != nil {
+		t.Errorf("expected the registry to be nil due to error, but got %v", reg)
+	}
+}
+
+func TestTemplate_ParseTemplates_BrokenLayout(t *testing.T) {
+	SetAppDir()
+	originalLayoutPath := layoutPath
+	layoutPath = layoutPath + "BrokenLayoutPath"
+	defer func() { layoutPath = originalLayoutPath }()
+	if _, err := parseTemplates(); err == nil {
+		t.Errorf("expected an error for missing layout")
+	}
+}
+
+func TestTemplate_LoadTemplates(t *testing.T) {
+	SetAppDir()
+	if err := LoadTemplates(); err != nil {
+		t.Fatalf("failed to load the templates: %v", err)
+	}
+	first, _ := templates()
+	second, _ := templates()
+	if first != second {
+		t.Errorf("expected the embedded templates to be parsed once")
+	}
+}
+
+func TestTemplate_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "components"), 0o755)
+	os.WriteFile(filepath.Join(dir, "templates", "components", "empty.html"), []byte(`{{ define "components/empty" }}{{ end }}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "templates", "layout.html"), []byte(`{{ define "layout" }}<main>{{ block "content" . }}{{ end }}</main>{{ end }}`), 0o644)
+	pagePath := filepath.Join(dir, "templates", "page.html")
+	os.WriteFile(pagePath, []byte(`{{ define "content" }}first{{ end }}`), 0o644)
+
+	SetWebDir(dir)
+	defer SetWebDir("")
+
 	w := httptest.NewRecorder()
-	templates := createTemplate(w, []string{"index"})
-	resp := w.Result()
-	if resp.StatusCode != http.StatusInternalServerError {
-		t.Errorf("Expected status 500 for missing components, got %d", resp.StatusCode)
+	RenderTemplate(w, "page", TplData{})
+	if w.Body.String() != "<main>first</main>" {
+		t.Fatalf("expected the page from the disk, got %q", w.Body.String())
 	}
-	if templates != nil {
-		t.Errorf("Expected templates to be nil due to error, but got %v", templates)
+
+	os.WriteFile(pagePath, []byte(`{{ define "content" }}second{{ end }}`), 0o644)
+	w = httptest.NewRecorder()
+	RenderTemplate(w, "page", TplData{})
+	if w.Body.String() != "<main>second</main>" {
+		t.Errorf("expected the changed page without a restart, got %q", w.Body.String())
+	}
+
+	// A broken template fails only the render, the next render picks up the fix
+	os.WriteFile(pagePath, []byte(`{{ define "content" }}{{ end`), 0o644)
+	w = httptest.NewRecorder()
+	RenderTemplate(w, "page", TplData{})
+	if w.Result().StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected status 500 for a broken template, got %d", w.Result().StatusCode)
 	}
 }
 
@@ -141,7 +254,7 @@ func TestTemplate_RenderTemplate_Success(t *testing.T) {
 	SetAppDir()
 	w := httptest.NewRecorder()
 	data := TplData{"Title": "Title test", "Message": "Hello from existing error.html"}
-	RenderTemplate(w, []string{"error"}, data)
+	RenderTemplate(w, "error", data)
 	resp := w.Result()
 	body := w.Body.String()
 	if resp.StatusCode != http.StatusOK {
@@ -161,23 +274,20 @@ func TestTemplate_RenderTemplate_Success(t *testing.T) {
 func TestTemplate_RenderTemplate_WrongTpl(t *testing.T) {
 	SetAppDir()
 	w := httptest.NewRecorder()
-	RenderTemplate(w, []string{"wrongTpl"}, TplData{})
+	RenderTemplate(w, "wrongTpl", TplData{})
 	resp := w.Result()
 	if resp.StatusCode != http.StatusInternalServerError {
 		t.Errorf("Expected status 500 for invalid template, got %d", resp.StatusCode)
 	}
 }
 
-func TestTemplate_RenderTemplate_BrokenLayout(t *testing.T) {
+func TestTemplate_RenderTemplate_FragmentWithLayout(t *testing.T) {
 	SetAppDir()
-	originalLayoutPath := layoutPath
-	layoutPath = layoutPath + "BrokenLayoutPath"
-	defer func() { layoutPath = originalLayoutPath }()
 	w := httptest.NewRecorder()
-	RenderTemplate(w, []string{"index"}, TplData{})
+	RenderTemplate(w, "dashboard/task_list", TplData{})
 	resp := w.Result()
 	if resp.StatusCode != http.StatusInternalServerError {
-		t.Errorf("Expected status 500 for invalid template, got %d", resp.StatusCode)
+		t.Errorf("Expected status 500 for a fragment rendered into the layout, got %d", resp.StatusCode)
 	}
 }
 
@@ -204,7 +314,7 @@ func TestTemplate_RenderTemplateWithoutLayout_Success(t *testing.T) {
 	SetAppDir()
 	w := httptest.NewRecorder()
 	data := TplData{"Title": "Title test", "Message": "Hello from existing error.html"}
-	RenderTemplateWithoutLayout(w, []string{"error"}, "content", data)
+	RenderTemplateWithoutLayout(w, "error", "content", data)
 	resp := w.Result()
 	body := w.Body.String()
 	if resp.StatusCode != http.StatusOK {
@@ -236,3 +346,30 @@ func TestTemplate_RenderBlockNeedLogin(t *testing.T) {
 		t.Errorf("expected message about login, got %s", body)
 	}
 }
+
+type csrfRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (w csrfRecorder) CSRFToken() string {
+	return "csrf-token-value"
+}
+
+func TestTemplate_RenderTemplate_CSRFToken(t *testing.T) {
+	SetAppDir()
+	w := csrfRecorder{httptest.NewRecorder()}
+	RenderTemplate(w, "login", TplData{"Errors": map[string][]string{}})
+	body := w.Body.String()
+	if !strings.Contains(body, `name="csrf_token" value="csrf-token-value"`) {
+		t.Errorf("expected rendered template to contain csrf_token field, got %s", body)
+	}
+	if !strings.Contains(body, `hx-headers='{"X-CSRF-Token": "csrf-token-value"}'`) {
+		t.Errorf("expected rendered template to contain hx-headers, got %s", body)
+	}
+
+	w = csrfRecorder{httptest.NewRecorder()}
+	RenderTemplate(w, "login", TplData{"Errors": map[string][]string{}, "CSRFToken": "own"})
+	if !strings.Contains(w.Body.String(), `value="own"`) {
+		t.Errorf("expected CSRFToken from data not to be overwritten")
+	}
+}
@@ -12,16 +12,32 @@ import (
 // and timezone = Europe/Moscow
 // will return 2000-01-01 04:00:00.000000000 +0000 UTC
 func NowWithTimezone(timezone string) (time.Time, error) {
+	return WallClock(time.Now(), timezone)
+}
+
+// WallClock is NowWithTimezone for the moment t, e.g. for the jobs that work with the times of many users
+func WallClock(t time.Time, timezone string) (time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+		slog.Warn("WallClock LoadLocation", "timezone", timezone, "err", err)
+	}
+	t = t.In(loc)
+	_, offset := t.Zone()
+	t = t.Add(time.Duration(offset) * time.Second).In(time.UTC)
+	return t, err
+}
 
+// FromWallClock is the inverse of WallClock: the moment of the wall clock time t of the timezone, in UTC.
+// For the times skipped by the daylight saving time the result is as of time.Date.
+func FromWallClock(t time.Time, timezone string) (time.Time, error) {
 	loc, err := time.LoadLocation(timezone)
 	if err != nil {
 		loc = time.UTC
-		slog.Warn("NowWithTimezone LoadLocation", "timezone", timezone, "err", err)
+		slog.Warn("FromWallClock LoadLocation", "timezone", timezone, "err", err)
 	}
-	now := time.Now().In(loc)
-	_, offset := now.Zone()
-	now = now.Add(time.Duration(offset) * time.Second).In(time.UTC)
-	return now, err
+	moment := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	return moment.UTC(), err
 }
 
 // Time can be nil, so *time.Time
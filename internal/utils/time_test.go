@@ -35,6 +35,42 @@ func TestTime_NowWithTimezone(t *testing.T) {
 	}
 }
 
+func TestTime_WallClock(t *testing.T) {
+	moment := time.Date(2000, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	wallClock, err := WallClock(moment, "Europe/Moscow")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2000, 1, 1, 4, 0, 0, 0, time.UTC), wallClock)
+
+	// DST of New York: UTC-4 in summer
+	wallClock, err = WallClock(time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), "America/New_York")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC), wallClock)
+
+	wallClock, err = WallClock(moment, "Invalid/Timezone")
+	assert.Error(t, err)
+	assert.Equal(t, moment, wallClock)
+}
+
+func TestTime_FromWallClock(t *testing.T) {
+	moment, err := FromWallClock(time.Date(2000, 1, 1, 4, 0, 0, 0, time.UTC), "Europe/Moscow")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2000, 1, 1, 1, 0, 0, 0, time.UTC), moment)
+
+	// DST of New York: UTC-4 in summer, UTC-5 in winter
+	moment, err = FromWallClock(time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC), "America/New_York")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), moment)
+	moment, err = FromWallClock(time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC), "America/New_York")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 12, 1, 13, 0, 0, 0, time.UTC), moment)
+
+	wallClock := time.Date(2000, 1, 1, 4, 0, 0, 0, time.UTC)
+	moment, err = FromWallClock(wallClock, "Invalid/Timezone")
+	assert.Error(t, err)
+	assert.Equal(t, wallClock, moment)
+}
+
 func TestTime_EffectiveTime(t *testing.T) {
 	timezone := "Europe/Moscow"
 
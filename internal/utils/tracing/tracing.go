@@ -0,0 +1,119 @@
+// Package tracing sends OpenTelemetry spans of the HTTP requests, the queries of Postgres and Redis and the emails.
+// Without Setup the global tracer provider of otel is a no-op, the instrumentation costs almost nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time-tracker/internal/utils"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Values of Config.Exporter
+const (
+	// Tracing is disabled
+	ExporterNone = ""
+	// OTLP over HTTP to Config.Endpoint, e.g. the OpenTelemetry Collector or Jaeger
+	ExporterOTLP = "otlp"
+	// Spans are printed as JSON, for local testing
+	ExporterStdout = "stdout"
+)
+
+const serviceName = "time-tracker"
+
+type Config struct {
+	Exporter string
+	// URL of the OTLP HTTP receiver, e.g. "http://otel-collector:4318".
+	// Empty uses OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318.
+	Endpoint string
+	// Part of the traces that are sampled, from 0 to 1
+	SampleRatio float64
+	// Writer of ExporterStdout, os.Stdout if nil
+	Stdout io.Writer
+}
+
+// Setup sets the global tracer provider and the propagation of the trace context.
+// shutdown sends the remaining spans, call it after the server stops.
+func Setup(ctx context.Context, cfg Config) (shutdown func(ctx context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		var opts []stdouttrace.Option
+		if cfg.Stdout != nil {
+			opts = append(opts, stdouttrace.WithWriter(cfg.Stdout))
+		}
+		exporter, err = stdouttrace.New(opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected %q or %q", cfg.Exporter, ExporterOTLP, ExporterStdout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing exporter %s: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// The sampling decision of the parent, e.g. of the load balancer, is kept
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of the code of this service, e.g. the sending of an email.
+// End the span and record the error with End.
+//
+//	ctx, span := tracing.Start(ctx, "mail.Send activation")
+//	err := send(ctx)
+//	tracing.End(span, err)
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(serviceName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End marks the span as failed if err is not nil and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware starts the span of every request, named by the pattern of routes, e.g. "GET /records/{id}".
+// The trace context of the incoming traceparent header is continued.
+func Middleware(next http.Handler, routes utils.Routes) http.Handler {
+	withRoute := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.route", utils.RoutePattern(routes, r)))
+		next.ServeHTTP(w, r)
+	})
+	return otelhttp.NewHandler(withRoute, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return utils.RoutePattern(routes, r)
+		}),
+	)
+}
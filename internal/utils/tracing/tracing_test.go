@@ -0,0 +1,129 @@
+//go:build unit
+
+// docker exec -it tt-app-1 go test -v ./internal/utils/tracing --tags=unit -cover -run TestTracing_.*
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans sets a tracer provider that records the spans until the end of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	prevProvider := otel.GetTracerProvider()
+	prevPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return spans
+}
+
+func TestTracing_Setup(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("UnknownExporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+
+		assert.ErrorContains(t, err, `unknown tracing exporter "zipkin"`)
+	})
+
+	t.Run("Stdout", func(t *testing.T) {
+		recordSpans(t)
+		var buf bytes.Buffer
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, SampleRatio: 1, Stdout: &buf})
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "mail.Send activation")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Contains(t, buf.String(), `"Name":"mail.Send activation"`)
+		assert.Contains(t, buf.String(), `"Value":"time-tracker"`)
+	})
+
+	t.Run("SampleRatioZero", func(t *testing.T) {
+		recordSpans(t)
+		var buf bytes.Buffer
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, SampleRatio: 0, Stdout: &buf})
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "mail.Send activation")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("OTLP", func(t *testing.T) {
+		recordSpans(t)
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterOTLP, Endpoint: "http://localhost:4318", SampleRatio: 1})
+
+		require.NoError(t, err, "the exporter connects on the first export")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		shutdown(ctx)
+	})
+}
+
+func TestTracing_End(t *testing.T) {
+	spans := recordSpans(t)
+
+	_, span := Start(context.Background(), "ok", attribute.String("mail.type", "activation"))
+	End(span, nil)
+	_, span = Start(context.Background(), "failed")
+	End(span, errors.New("mailgun: 500"))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, codes.Unset, ended[0].Status().Code)
+	assert.Contains(t, ended[0].Attributes(), attribute.String("mail.type", "activation"))
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	require.Len(t, ended[1].Events(), 1)
+	assert.Equal(t, "exception", ended[1].Events()[0].Name)
+}
+
+func TestTracing_Middleware(t *testing.T) {
+	spans := recordSpans(t)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	mux := http.NewServeMux()
+	var handlerSpan trace.SpanContext
+	mux.HandleFunc("GET /records/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+	})
+	handler := Middleware(mux, mux)
+
+	r := httptest.NewRequest(http.MethodGet, "/records/5", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, "GET /records/{id}", ended[0].Name())
+	assert.Contains(t, ended[0].Attributes(), attribute.String("http.route", "GET /records/{id}"))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ended[0].SpanContext().TraceID().String(), "continues the incoming trace")
+	assert.Equal(t, ended[0].SpanContext().SpanID(), handlerSpan.SpanID())
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"html/template"
 	"reflect"
 	"strings"
 
@@ -10,9 +11,15 @@ import (
 
 var validate = validator.New()
 
-type FormErrors map[string][]string
+// The messages are rendered by html/template as they are, so Add escapes the text
+type FormErrors map[string][]template.HTML
 
 func (fe *FormErrors) Add(field, message string) {
+	(*fe)[field] = append((*fe)[field], template.HTML(template.HTMLEscapeString(message)))
+}
+
+// AddHTML adds a trusted message, e.g. with a link. The user data in it must be escaped by the caller.
+func (fe *FormErrors) AddHTML(field string, message template.HTML) {
 	(*fe)[field] = append((*fe)[field], message)
 }
 func (fe FormErrors) HasErrorsField(field string) bool {
@@ -27,7 +34,11 @@ func (fe FormErrors) HasErrors() bool {
 func (fe FormErrors) Error() string {
 	var sb strings.Builder
 	for field, messages := range fe {
-		sb.WriteString(fmt.Sprintf("Errors for field '%s': %s\n", field, strings.Join(messages, ", ")))
+		texts := make([]string, len(messages))
+		for i, message := range messages {
+			texts[i] = string(message)
+		}
+		sb.WriteString(fmt.Sprintf("Errors for field '%s': %s\n", field, strings.Join(texts, ", ")))
 	}
 	return sb.String()
 }
@@ -3,6 +3,7 @@
 package utils
 
 import (
+	"html/template"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -100,3 +101,12 @@ func TestValidator_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidator_FormErrors_AddEscapes(t *testing.T) {
+	formErrors := FormErrors{}
+	formErrors.Add("Title", `<script>alert("xss")</script>`)
+	formErrors.AddHTML("Title", `<a href="/dashboard">link</a>`)
+
+	assert.Equal(t, template.HTML("&lt;script&gt;alert(&#34;xss&#34;)&lt;/script&gt;"), formErrors["Title"][0])
+	assert.Equal(t, template.HTML(`<a href="/dashboard">link</a>`), formErrors["Title"][1])
+}
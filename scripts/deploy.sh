@@ -58,6 +58,11 @@ UPDATED_TASK_DEFINITION=$(echo "$TASK_DEFINITION_TPL" | jq \
   'del(.taskDefinitionArn, .revision, .status, .requiresAttributes, .compatibilities, .registeredAt, .registeredBy) |
    .family = $family |
    .containerDefinitions[0].image = $image |
+   .containerDefinitions[0].healthCheck = {
+     "command": ["CMD-SHELL", "wget -q -O /dev/null http://localhost:8080/healthz || exit 1"],
+     "interval": 30, "timeout": 5, "retries": 3, "startPeriod": 10
+   } |
+   .containerDefinitions[0].stopTimeout = 30 |
    .containerDefinitions[0].portMappings[0].hostPort += $versionNumber')
 
 echo "Registering the updated Task Definition"
@@ -1,35 +1,18 @@
 #!/bin/sh
 
-# Run from Dockerfile.prod
+# Run from Dockerfile.dev
 # or
 # docker exec -it tt-app-1 scripts/migrate.sh up
-
-if [ -z "$DB_USER" ] || [ -z "$DB_PASSWORD" ] || [ -z "$DB_HOST" ] || [ -z "$DB_PORT" ] || [ -z "$DB_NAME" ]; then
-  echo "Not all required environment variables are set: DB_USER, DB_PASSWORD, DB_HOST, DB_PORT, DB_NAME"
-  exit 1
-fi
-
-DB_URL="postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}:${DB_PORT}/${DB_NAME}?sslmode=${DB_SSLMODE}"
+#
+# The migrations of db/migrations are embedded into the server binary,
+# in production run them with "./server migrate up" or DB_AUTO_MIGRATE=true.
 
 case "$1" in
-  up)
-    echo "Running migrations forward..."
-    goose -dir db/migrations postgres "$DB_URL" up
-    ;;
-  down)
-    echo "Rolling back migrations..."
-    goose -dir db/migrations postgres "$DB_URL" down
-    ;;
-  redo)
-    echo "Rolling back the last migration and reapplying it..."
-    goose -dir db/migrations postgres "$DB_URL" redo
-    ;;
-  status)
-    echo "Checking the status of migrations..."
-    goose -dir db/migrations postgres "$DB_URL" status
+  up|down|redo|status)
+    cd "$(dirname "$0")/.." && go run ./cmd/server migrate "$1"
     ;;
   *)
     echo "Usage: $0 {up|down|redo|status}"
     exit 1
     ;;
-esac
\ No newline at end of file
+esac
@@ -0,0 +1,129 @@
+// The sse extension of htmx 2, see https://htmx.org/extensions/sse/
+// The attributes of the extension used by the app: hx-ext="sse", sse-connect, sse-swap, sse-close
+// and hx-trigger="sse:<event>". The same events are triggered: htmx:sseOpen, htmx:sseError,
+// htmx:sseBeforeMessage, htmx:sseMessage and htmx:sseClose.
+(function () {
+  let api;
+
+  htmx.defineExtension("sse", {
+    init: (apiRef) => {
+      api = apiRef;
+    },
+
+    getSelectors: () => ["[sse-connect]", "[data-sse-connect]", "[sse-swap]", "[data-sse-swap]"],
+
+    onEvent: (name, event) => {
+      const elt = event.target || event.detail.elt;
+      switch (name) {
+        case "htmx:beforeCleanupElement":
+          if (api.getInternalData(elt).sseEventSource) {
+            closeEventSource(elt);
+          }
+          return;
+        case "htmx:afterProcessNode": {
+          const url = api.getAttributeValue(elt, "sse-connect");
+          if (url && !api.getInternalData(elt).sseEventSource) {
+            connect(elt, url, 0);
+          }
+          register(elt);
+        }
+      }
+    },
+  });
+
+  function connect(elt, url, retryCount) {
+    const source = new EventSource(url);
+    api.getInternalData(elt).sseEventSource = source;
+
+    source.onopen = () => {
+      retryCount = 0;
+      api.triggerEvent(elt, "htmx:sseOpen", { source: source });
+    };
+    source.onerror = (error) => {
+      api.triggerErrorEvent(elt, "htmx:sseError", { error: error, source: source });
+      // EventSource reconnects by itself after the network errors, but not after the error responses
+      if (source.readyState !== EventSource.CLOSED) {
+        return;
+      }
+      const delay = Math.min(1000 * 2 ** retryCount, 128000);
+      setTimeout(() => {
+        if (api.getInternalData(elt).sseEventSource === source && api.bodyContains(elt)) {
+          connect(elt, url, retryCount + 1);
+          register(elt);
+        }
+      }, delay);
+    };
+
+    const closeEvent = api.getAttributeValue(elt, "sse-close");
+    if (closeEvent) {
+      source.addEventListener(closeEvent, () => closeEventSource(elt));
+    }
+  }
+
+  function closeEventSource(elt) {
+    const internalData = api.getInternalData(elt);
+    internalData.sseEventSource.close();
+    delete internalData.sseEventSource;
+    api.triggerEvent(elt, "htmx:sseClose", { source: elt });
+  }
+
+  // Adds the listeners of elt and its children to the closest EventSource, once per EventSource
+  function register(elt) {
+    const sourceElt = api.getClosestMatch(elt, (e) => api.getInternalData(e).sseEventSource != null);
+    if (!sourceElt) {
+      return;
+    }
+    const source = api.getInternalData(sourceElt).sseEventSource;
+
+    for (const child of queryAttributeOnThisOrChildren(elt, "sse-swap")) {
+      const internalData = api.getInternalData(child);
+      if (internalData.sseSwapSource === source) {
+        continue;
+      }
+      internalData.sseSwapSource = source;
+      for (const eventName of api.getAttributeValue(child, "sse-swap").split(",")) {
+        listen(source, child, eventName.trim(), (event) => {
+          if (!api.triggerEvent(child, "htmx:sseBeforeMessage", event)) {
+            return;
+          }
+          api.swap(api.getTarget(child), event.data, api.getSwapSpecification(child));
+          api.triggerEvent(child, "htmx:sseMessage", event);
+        });
+      }
+    }
+
+    for (const child of queryAttributeOnThisOrChildren(elt, "hx-trigger")) {
+      const trigger = api.getAttributeValue(child, "hx-trigger");
+      const internalData = api.getInternalData(child);
+      if (!trigger || !trigger.startsWith("sse:") || internalData.sseTriggerSource === source) {
+        continue;
+      }
+      internalData.sseTriggerSource = source;
+      listen(source, child, trigger.slice(4), (event) => {
+        htmx.trigger(child, trigger, event);
+        api.triggerEvent(child, "htmx:sseMessage", event);
+      });
+    }
+  }
+
+  // The listener is removed when elt is not on the page anymore
+  function listen(source, elt, eventName, handler) {
+    const listener = (event) => {
+      if (!api.bodyContains(elt)) {
+        source.removeEventListener(eventName, listener);
+        return;
+      }
+      handler(event);
+    };
+    source.addEventListener(eventName, listener);
+  }
+
+  function queryAttributeOnThisOrChildren(elt, attributeName) {
+    const result = [];
+    if (api.hasAttribute(elt, attributeName)) {
+      result.push(elt);
+    }
+    result.push(...elt.querySelectorAll("[" + attributeName + "], [data-" + attributeName + "]"));
+    return result;
+  }
+})();
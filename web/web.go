@@ -0,0 +1,35 @@
+// Package web embeds the templates and the public files into the server binary.
+// public/css/output.css is built by npm, build it before the server, see Dockerfile.prod.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed public
+var public embed.FS
+
+// Templates returns web/templates. With dir, e.g. "web", the files are read from the disk on every access,
+// so changes are visible without a rebuild. Without dir the embedded files are returned.
+func Templates(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(filepath.Join(dir, "templates"))
+	}
+	sub, _ := fs.Sub(templates, "templates")
+	return sub
+}
+
+// Public returns web/public, see Templates for dir
+func Public(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(filepath.Join(dir, "public"))
+	}
+	sub, _ := fs.Sub(public, "public")
+	return sub
+}